@@ -9,11 +9,19 @@ import (
 	wbzlog "github.com/wb-go/wbf/zlog"
 	"go.uber.org/fx"
 	"salestracker/internal/app/analytics"
+	"salestracker/internal/app/anomalies"
+	"salestracker/internal/app/budgets"
+	"salestracker/internal/app/changes"
+	"salestracker/internal/app/imports"
+	"salestracker/internal/app/reconciliations"
+	"salestracker/internal/app/stream"
 	"salestracker/internal/app/transactions"
+	"salestracker/internal/app/webhooks"
 	"salestracker/internal/config"
 	"salestracker/internal/di"
 	"salestracker/internal/storage/postgres"
 	"salestracker/internal/web/handlers"
+	_ "time/tzdata"
 )
 
 func main() {
@@ -33,6 +41,44 @@ func main() {
 			},
 			transactions.NewTransactionService,
 
+			func(db *postgres.Postgres) budgets.BudgetStorageProvider {
+				return db
+			},
+			func(db *postgres.Postgres) budgets.ActualsProvider {
+				return db
+			},
+			budgets.NewBudgetService,
+
+			func(db *postgres.Postgres) webhooks.WebhookStorageProvider {
+				return db
+			},
+			webhooks.NewWebhookService,
+
+			func(db *postgres.Postgres) stream.StreamStorageProvider {
+				return db
+			},
+			stream.NewStreamService,
+
+			func(db *postgres.Postgres) changes.ChangeStorageProvider {
+				return db
+			},
+			changes.NewChangeService,
+
+			func(db *postgres.Postgres) imports.ImportStorageProvider {
+				return db
+			},
+			imports.NewImportService,
+
+			func(db *postgres.Postgres) reconciliations.ReconciliationStorageProvider {
+				return db
+			},
+			reconciliations.NewReconciliationService,
+
+			func(db *postgres.Postgres) anomalies.AnomalyStorageProvider {
+				return db
+			},
+			anomalies.NewAnomalyService,
+
 			func(service *analytics.AnalyticService) handlers.AnalyticsIFace {
 				return service
 			},
@@ -42,9 +88,46 @@ func main() {
 				return service
 			},
 			handlers.NewTransactionHandler,
+
+			func(service *budgets.BudgetService) handlers.BudgetIFace {
+				return service
+			},
+			handlers.NewBudgetHandler,
+
+			func(service *webhooks.WebhookService) handlers.WebhookIFace {
+				return service
+			},
+			handlers.NewWebhookHandler,
+
+			func(service *stream.StreamService) handlers.StreamIFace {
+				return service
+			},
+			handlers.NewStreamHandler,
+
+			func(service *changes.ChangeService) handlers.ChangeIFace {
+				return service
+			},
+			handlers.NewChangeHandler,
+
+			func(service *imports.ImportService) handlers.ImportIFace {
+				return service
+			},
+			handlers.NewImportHandler,
+
+			func(service *reconciliations.ReconciliationService) handlers.ReconciliationIFace {
+				return service
+			},
+			handlers.NewReconciliationHandler,
+
+			func(service *anomalies.AnomalyService) handlers.AnomalyIFace {
+				return service
+			},
+			handlers.NewAnomalyHandler,
 		),
 		fx.Invoke(
 			di.StartHTTPServer,
+			di.StartWebhookDispatcher,
+			di.StartEventStream,
 			di.ClosePostgresOnStop,
 		),
 	)
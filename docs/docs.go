@@ -28,21 +28,21 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Дата начала (YYYY-MM-DD)",
+                        "description": "Начало периода (YYYY-MM-DD или RFC 3339)",
                         "name": "from",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Дата конца (YYYY-MM-DD)",
+                        "description": "Конец периода включительно (YYYY-MM-DD или RFC 3339)",
                         "name": "to",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Группировка (day/week/month/category)",
+                        "description": "Группировка: hour (2025-02-12T13:00), day (2025-02-12), week (2025-W07, ISO), month (2025-02), quarter (2025-Q1), year (2025), category, type, counterparty, none (одна группа all); по умолчанию day. Список через запятую (month,category) — дерево групп с агрегатами на каждом уровне в Children",
                         "name": "groupby",
                         "in": "query"
                     },
@@ -54,7 +54,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Поле для сортировки",
+                        "description": "Метрика для сортировки групп (одна из metrics), иначе по ключу группы",
                         "name": "sortby",
                         "in": "query"
                     },
@@ -63,6 +63,112 @@ const docTemplate = `{
                         "description": "Направление сортировки (asc/desc)",
                         "name": "sortdir",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заполнение периодов без транзакций: zero, null или previous; группы идут по порядку от from до to",
+                        "name": "fill",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Метрики через запятую: sum, avg, count, min, max, stddev, variance, median, percentile90, pNN (p25, p99.9). С этим параметром в ответе только выбранные метрики под их именами",
+                        "name": "metrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только транзакции типа income или expense",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма от, включительно",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма до, включительно",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока описания без учета регистра",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ИНН контрагента",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сравнение: previous (предыдущий период), yoy (год назад) или custom; у групп появляются CompareKey и Compare с Current, Previous, Delta и Percent по каждой метрике",
+                        "name": "compare",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода сравнения для compare=custom (YYYY-MM-DD или RFC 3339)",
+                        "name": "compareFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода сравнения включительно для compare=custom",
+                        "name": "compareTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Нарастающие итоги: у каждого периода Running (Income, Expense, Net) от входящего остатка Opening; только для временных группировок, по умолчанию включает fill=zero",
+                        "name": "cumulative",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Скользящие окна через запятую: \u003cN\u003e\u003ch|d|w|m|q|y\u003e, например 7d,30d; у каждого периода Rolling с Sum, Avg и Count за окно, окно захватывает данные до from",
+                        "name": "rolling",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Только с splitby=category: N крупнейших категорий в каждой группе, остальные сворачиваются в Other; разбиения отдаются в Data.Splits",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Метрика ранжирования для top (по модулю значения), по умолчанию sum",
+                        "name": "topby",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Как считаются метрики All и группы по категориям: signed (расход со знаком минус, по умолчанию) или absolute (по модулю)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат и группировки, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -103,21 +209,21 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Дата начала (YYYY-MM-DD)",
+                        "description": "Начало периода (YYYY-MM-DD или RFC 3339)",
                         "name": "from",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Дата конца (YYYY-MM-DD)",
+                        "description": "Конец периода включительно (YYYY-MM-DD или RFC 3339)",
                         "name": "to",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Группировка (day/week/month/category)",
+                        "description": "Группировка: hour (2025-02-12T13:00), day (2025-02-12), week (2025-W07, ISO), month (2025-02), quarter (2025-Q1), year (2025), category, type, counterparty, none (одна группа all); по умолчанию day. Список через запятую (month,category) — дерево групп с агрегатами на каждом уровне в Children",
                         "name": "groupby",
                         "in": "query"
                     },
@@ -129,7 +235,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Поле для сортировки",
+                        "description": "Метрика для сортировки групп (одна из metrics), иначе по ключу группы",
                         "name": "sortby",
                         "in": "query"
                     },
@@ -138,6 +244,112 @@ const docTemplate = `{
                         "description": "Направление сортировки (asc/desc)",
                         "name": "sortdir",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заполнение периодов без транзакций: zero, null или previous; группы идут по порядку от from до to",
+                        "name": "fill",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Метрики через запятую: sum, avg, count, min, max, stddev, variance, median, percentile90, pNN (p25, p99.9). С этим параметром в ответе только выбранные метрики под их именами",
+                        "name": "metrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только транзакции типа income или expense",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма от, включительно",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма до, включительно",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока описания без учета регистра",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ИНН контрагента",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сравнение: previous (предыдущий период), yoy (год назад) или custom; у групп появляются CompareKey и Compare с Current, Previous, Delta и Percent по каждой метрике",
+                        "name": "compare",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода сравнения для compare=custom (YYYY-MM-DD или RFC 3339)",
+                        "name": "compareFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода сравнения включительно для compare=custom",
+                        "name": "compareTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Нарастающие итоги: у каждого периода Running (Income, Expense, Net) от входящего остатка Opening; только для временных группировок, по умолчанию включает fill=zero",
+                        "name": "cumulative",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Скользящие окна через запятую: \u003cN\u003e\u003ch|d|w|m|q|y\u003e, например 7d,30d; у каждого периода Rolling с Sum, Avg и Count за окно, окно захватывает данные до from",
+                        "name": "rolling",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Только с splitby=category: N крупнейших категорий в каждой группе, остальные сворачиваются в Other; разбиения отдаются в Data.Splits",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Метрика ранжирования для top (по модулю значения), по умолчанию sum",
+                        "name": "topby",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Как считаются метрики All и группы по категориям: signed (расход со знаком минус, по умолчанию) или absolute (по модулю)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат и группировки, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -168,109 +380,75 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/items": {
+        "/api/analytics/forecast": {
             "get": {
-                "description": "Возвращает список всех транзакций с фильтрами",
+                "description": "Прогнозирует доходы и расходы по категориям и их итоги по типам на horizon периодов вперед моделью Хольта — Винтерса (при короткой истории — трендом Хольта или средним); возвращает точечные прогнозы, доверительные интервалы и ошибки на отложенном хвосте истории",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Transactions"
+                    "Analytics"
                 ],
-                "summary": "Получить все транзакции",
+                "summary": "Прогноз доходов и расходов",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Дата от",
+                        "description": "Начало истории (YYYY-MM-DD или RFC 3339); по умолчанию 1 год для day, 2 года для week, 3 года для month",
                         "name": "from",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Дата до",
+                        "description": "Конец истории включительно; по умолчанию конец последнего завершенного периода",
                         "name": "to",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Тип транзакции (income/expense)",
-                        "name": "type",
+                        "description": "Период: day (сезон — неделя), week или month (сезон — год); по умолчанию month",
+                        "name": "groupby",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Категория",
-                        "name": "category",
+                        "type": "integer",
+                        "description": "Сколько периодов прогнозировать; по умолчанию квартал: 91 день, 13 недель или 3 месяца",
+                        "name": "horizon",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Поле сортировки",
-                        "name": "sortBy",
+                        "type": "number",
+                        "description": "Уровень доверия интервалов, по умолчанию 0.95",
+                        "name": "level",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Направление сортировки (asc/desc)",
-                        "name": "sortDir",
+                        "description": "Только income или expense",
+                        "name": "type",
                         "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/transaction.Transaction"
-                            }
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "Создает транзакцию с типом (income/expense), категорией, суммой, датой и описанием",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Transactions"
-                ],
-                "summary": "Создать новую транзакцию",
-                "parameters": [
                     {
-                        "description": "Данные транзакции",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.SaveTransactionReq"
-                        }
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат и периодов, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/transaction.Transaction"
+                            "$ref": "#/definitions/analytic.Forecast"
                         }
                     },
                     "400": {
@@ -294,56 +472,95 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/items/export": {
+        "/api/analytics/histogram": {
             "get": {
-                "description": "Экспортирует все транзакции за период в CSV-файл",
+                "description": "Гистограмма сумм транзакций за период: границы корзин, число и сумма транзакций в каждой; корзины считаются в базе через width_bucket. Пустые корзины тоже возвращаются, последняя включает максимум",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Transactions"
+                    "Analytics"
                 ],
-                "summary": "Экспорт транзакций в CSV",
+                "summary": "Распределение сумм транзакций",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Дата от",
+                        "description": "Начало периода (YYYY-MM-DD или RFC 3339)",
                         "name": "from",
-                        "in": "query"
+                        "in": "query",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Дата до",
+                        "description": "Конец периода включительно (YYYY-MM-DD или RFC 3339)",
                         "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Число корзин от 1 до 100, по умолчанию 10; для quantile при повторяющихся суммах корзин может быть меньше",
+                        "name": "buckets",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Тип транзакции (income/expense)",
-                        "name": "type",
+                        "description": "Деление: fixed (одинаковая ширина от минимума до максимума, по умолчанию), log (одинаковая ширина по логарифму, только положительные суммы) или quantile (поровну транзакций)",
+                        "name": "bucketing",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Категория",
+                        "description": "Только транзакции типа income или expense",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
                         "name": "category",
                         "in": "query"
                     },
+                    {
+                        "type": "number",
+                        "description": "Сумма от, включительно",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма до, включительно",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Поле сортировки",
-                        "name": "sortBy",
+                        "description": "Подстрока описания без учета регистра",
+                        "name": "search",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Направление сортировки (asc/desc)",
-                        "name": "sortDir",
+                        "description": "ИНН контрагента",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат, по умолчанию — рабочего пространства",
+                        "name": "tz",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "CSV файл",
+                        "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/analytic.Histogram"
                         }
                     },
                     "400": {
@@ -367,27 +584,89 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/items/{id}": {
+        "/api/analytics/pivot": {
             "get": {
-                "description": "Возвращает транзакцию по ID",
+                "description": "Сводная таблица по произвольным измерениям строк и колонок (period, category, type, counterparty) с выбранными метриками: ячейки, итоги строк и колонок и общий итог, все посчитаны по самим транзакциям",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Transactions"
+                    "Analytics"
                 ],
-                "summary": "Получить транзакцию",
+                "summary": "Сводная таблица",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID транзакции",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Начало периода (YYYY-MM-DD или RFC 3339)",
+                        "name": "from",
+                        "in": "query",
                         "required": true
-                    }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода включительно (YYYY-MM-DD или RFC 3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Измерения строк через запятую: period, category, type, counterparty (tag не поддерживается)",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Измерения колонок через запятую; вместе со строками не больше 4 и без повторов",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Шаг измерения period: hour, day, week, month, quarter, year; по умолчанию month",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Метрики через запятую: sum, avg, count, min, max, stddev, variance, median, percentile90, pNN; по умолчанию sum",
+                        "name": "metrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed (расход со знаком минус, по умолчанию) или absolute",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только транзакции типа income или expense",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат и периодов, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/transaction.Transaction"
+                            "$ref": "#/definitions/analytic.Pivot"
                         }
                     },
                     "400": {
@@ -399,8 +678,86 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/analytics/pivot/export": {
+            "get": {
+                "description": "Плоская выгрузка сводной таблицы: колонка Level (cell, row_total, column_total, grand_total), по колонке на измерение и на метрику",
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Экспорт сводной таблицы в CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало периода (YYYY-MM-DD или RFC 3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода включительно (YYYY-MM-DD или RFC 3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Измерения строк через запятую: period, category, type, counterparty",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Измерения колонок через запятую",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Шаг измерения period, по умолчанию month",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Метрики через запятую, по умолчанию sum",
+                        "name": "metrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed или absolute",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат и периодов, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV файл",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -418,42 +775,97 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет данные транзакции по ID",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/api/analytics/seasonality": {
+            "get": {
+                "description": "Число, сумма или средняя сумма транзакций по дням недели и часам либо по месяцам и дням недели, с итогами строк, колонок и общим итогом",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Transactions"
+                    "Analytics"
                 ],
-                "summary": "Обновить транзакцию",
+                "summary": "Матрица сезонности",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID транзакции",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Начало периода (YYYY-MM-DD или RFC 3339)",
+                        "name": "from",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Новые данные транзакции",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.SaveTransactionReq"
-                        }
+                        "type": "string",
+                        "description": "Конец периода включительно (YYYY-MM-DD или RFC 3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Оси: weekday_hour (дни недели с понедельника × часы 00–23, по умолчанию) или month_weekday (месяцы × дни недели)",
+                        "name": "layout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Мера ячеек: count (по умолчанию), sum или avg",
+                        "name": "measure",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только транзакции типа income или expense",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма от, включительно",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма до, включительно",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока описания без учета регистра",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ИНН контрагента",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA: в нем определяются час, день недели и месяц транзакции; по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/transaction.Transaction"
+                            "$ref": "#/definitions/analytic.Seasonality"
                         }
                     },
                     "400": {
@@ -475,25 +887,98 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет транзакцию по ID",
+            }
+        },
+        "/api/analytics/seasonality/export": {
+            "get": {
+                "description": "Матрица как есть: подписи строк, колонки матрицы и Total; последняя строка — итоги колонок",
                 "tags": [
-                    "Transactions"
+                    "Analytics"
                 ],
-                "summary": "Удалить транзакцию",
+                "summary": "Экспорт матрицы сезонности в CSV",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID транзакции",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Начало периода (YYYY-MM-DD или RFC 3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода включительно (YYYY-MM-DD или RFC 3339)",
+                        "name": "to",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Оси: weekday_hour (дни недели с понедельника × часы 00–23, по умолчанию) или month_weekday (месяцы × дни недели)",
+                        "name": "layout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Мера ячеек: count (по умолчанию), sum или avg",
+                        "name": "measure",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только транзакции типа income или expense",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма от, включительно",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма до, включительно",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока описания без учета регистра",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ИНН контрагента",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA: в нем определяются час, день недели и месяц транзакции; по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "CSV файл",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -512,65 +997,2953 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "analytic.Analytic": {
-            "type": "object",
-            "properties": {
-                "Avg": {
-                    "type": "number"
-                },
-                "Count": {
-                    "type": "integer"
-                },
-                "Median": {
-                    "type": "number"
-                },
-                "Percentile90": {
-                    "type": "number"
-                },
-                "Sum": {
-                    "type": "number"
-                }
-            }
         },
-        "analytic.AnalyticByType": {
+        "/api/anomalies": {
+            "get": {
+                "description": "Находит транзакции, сумма которых — выброс для их типа и категории, и дни, итог которых выходит из ожидаемого диапазона по 28 предыдущим дням. Выброс — когда модифицированный z-score (медиана и MAD) не меньше threshold и значение за внешней границей Тьюки (3·IQR). Подтвержденные аномалии по умолчанию скрыты",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Anomalies"
+                ],
+                "summary": "Аномалии транзакций и дневных итогов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало периода (YYYY-MM-DD или RFC 3339), по умолчанию 30 дней до to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода включительно, по умолчанию сейчас",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "transaction или day, по умолчанию обе",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Порог модифицированного z-score, по умолчанию 3.5",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть и подтвержденные аномалии",
+                        "name": "acknowledged",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только income или expense",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA границ дней, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/anomaly.Anomaly"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/anomalies/{id}/ack": {
+            "post": {
+                "description": "Помечает аномалию как просмотренную, необязательная заметка сохраняется вместе с подтверждением",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Anomalies"
+                ],
+                "summary": "Подтвердить аномалию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID аномалии: tx-\u003cID транзакции\u003e или day-\u003cincome|expense\u003e-\u003cYYYY-MM-DD\u003e",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Заметка",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AckAnomalyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/anomaly.Ack"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет подтверждение, аномалия снова возвращается в списке",
+                "tags": [
+                    "Anomalies"
+                ],
+                "summary": "Снять подтверждение аномалии",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID аномалии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/budgets": {
+            "get": {
+                "description": "Возвращает список бюджетов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Budgets"
+                ],
+                "summary": "Получить все бюджеты",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/budget.Budget"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает плановый расход по категории на период (month/quarter/year)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Budgets"
+                ],
+                "summary": "Создать бюджет",
+                "parameters": [
+                    {
+                        "description": "Данные бюджета",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SaveBudgetReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/budget.Budget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/budgets/report": {
+            "get": {
+                "description": "Для каждого бюджета возвращает план, факт, отклонение, процент исполнения и прогноз на конец периода по текущему темпу расходов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Budgets"
+                ],
+                "summary": "Отчет план/факт по бюджетам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата, определяющая отчетный период (YYYY-MM-DD), по умолчанию сегодня",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только бюджеты периода (month/quarter/year)",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA границ периода, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/budget.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/budgets/{id}": {
+            "put": {
+                "description": "Обновляет категорию, период и сумму бюджета по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Budgets"
+                ],
+                "summary": "Обновить бюджет",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID бюджета",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые данные бюджета",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SaveBudgetReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/budget.Budget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет бюджет по ID",
+                "tags": [
+                    "Budgets"
+                ],
+                "summary": "Удалить бюджет",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID бюджета",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/changes": {
+            "get": {
+                "description": "Возвращает созданные/измененные транзакции (upsert) и удаленные (delete, tombstone) в порядке коммитов. Для первой синхронизации since не передается; дальше в since передается NextCursor из предыдущего ответа. Курсор хранится в БД и не устаревает, строки, закоммиченные позже, но начатые раньше, не пропускаются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Changes"
+                ],
+                "summary": "Лента изменений транзакций",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Курсор из NextCursor предыдущего ответа",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 500, максимум 5000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/change.Feed"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/imports/receipts": {
+            "post": {
+                "description": "Принимает JSON-выгрузки кассовых чеков 54-ФЗ из приложения ФНС (один чек или массив чеков в файле). Каждый чек становится расходной транзакцией на totalSum (возврат прихода — доходной), ИНН продавца записывается в Counterparty, в описание попадают продавец и позиции чека. Повторно загруженные чеки пропускаются по фискальному признаку",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Импорт кассовых чеков",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "JSON-файлы чеков (можно несколько)",
+                        "name": "files",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория транзакций (по умолчанию uncategorized)",
+                        "name": "category",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/statement.FileSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/imports/statements": {
+            "post": {
+                "description": "Принимает один или несколько файлов выписок в форматах OFX 1.x/2.x, QIF и 1CClientBankExchange. Тип операции (income/expense) определяется по стороне дебета/кредита. Повторная загрузка той же выписки безопасна: уже импортированные операции пропускаются по внешнему ID. Возвращает сводку по каждому файлу: создано, пропущено, с ошибками",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Импорт банковских выписок",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Файлы выписок (можно несколько)",
+                        "name": "files",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Формат (ofx/qif/1c), по умолчанию определяется по содержимому",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория для операций без категории (по умолчанию uncategorized)",
+                        "name": "category",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/statement.FileSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/imports/wb": {
+            "post": {
+                "description": "Принимает еженедельные отчеты о реализации Wildberries (XLSX или CSV, как выгружает кабинет продавца или API). Каждая строка раскладывается на отдельные транзакции: выручка, комиссия, логистика, хранение, штрафы и прочие удержания, каждая в свою настраиваемую категорию. Возвраты учитываются как расход. Повторная загрузка того же отчета безопасна: строки идентифицируются по rrd_id (или номеру отчета и номеру строки)",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Импорт отчета о реализации Wildberries",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Файлы отчетов (можно несколько)",
+                        "name": "files",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Номер отчета, нужен если в файле нет колонки rrd_id",
+                        "name": "reportId",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/statement.FileSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/items": {
+            "get": {
+                "description": "Возвращает список всех транзакций с фильтрами",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Получить все транзакции",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата от (YYYY-MM-DD или RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата до включительно (YYYY-MM-DD или RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип транзакции (income/expense)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма от, включительно",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма до, включительно",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока описания без учета регистра",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ИНН контрагента",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поле сортировки",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Направление сортировки (asc/desc)",
+                        "name": "sortDir",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/transaction.Transaction"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает транзакцию с типом (income/expense), категорией, суммой, датой и описанием.\nЕсли транзакция похожа на уже существующую, в ответе возвращается список PossibleDuplicates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Создать новую транзакцию",
+                "parameters": [
+                    {
+                        "description": "Данные транзакции",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SaveTransactionReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateTransactionResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/items/duplicates": {
+            "get": {
+                "description": "Возвращает пары транзакций с одинаковым типом и суммой, оцененные по близости дат, категории и похожести описания",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Найти возможные дубли транзакций",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата от (YYYY-MM-DD или RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата до включительно (YYYY-MM-DD или RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальный score пары (0..1), по умолчанию 0.7",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/transaction.DuplicatePair"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/items/duplicates/resolve": {
+            "post": {
+                "description": "merge — оставляет keepId и удаляет dropId (сверенные транзакции удалить или дополнить нельзя — 409), dismiss — помечает пару как не дубль, чтобы она больше не предлагалась",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Разрешить пару дублей",
+                "parameters": [
+                    {
+                        "description": "Пара транзакций и действие",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ResolveDuplicateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/transaction.Transaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/items/export": {
+            "get": {
+                "description": "Экспортирует все транзакции за период в CSV-файл",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Экспорт транзакций в CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата от (YYYY-MM-DD или RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата до включительно (YYYY-MM-DD или RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип транзакции (income/expense)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма от, включительно",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма до, включительно",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока описания без учета регистра",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ИНН контрагента",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поле сортировки",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Направление сортировки (asc/desc)",
+                        "name": "sortDir",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV файл",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/items/stream": {
+            "get": {
+                "description": "Отдает события transaction.created/updated/deleted в формате text/event-stream. Принимает те же фильтры, что и список транзакций. При переподключении передайте заголовок Last-Event-ID (или параметр lastEventId): пропущенные события будут досланы из буфера, а если их там уже нет — придет событие reset, после которого данные нужно перечитать",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Поток изменений транзакций (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID последнего полученного события",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID последнего полученного события (если нельзя передать заголовок)",
+                        "name": "lastEventId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип транзакции (income/expense/all)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Категории: параметр повторяется или перечисляет их через запятую",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма от, включительно",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма до, включительно",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока описания без учета регистра",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ИНН контрагента",
+                        "name": "counterparty",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток событий",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/items/{id}": {
+            "get": {
+                "description": "Возвращает транзакцию по ID",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Получить транзакцию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID транзакции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/transaction.Transaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет данные транзакции по ID. Сверенную с выпиской транзакцию можно изменить только с override=true",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Обновить транзакцию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID транзакции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Разрешить изменение сверенной транзакции",
+                        "name": "override",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Новые данные транзакции",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SaveTransactionReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/transaction.Transaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет транзакцию по ID. Сверенную с выпиской транзакцию можно удалить только с override=true",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Удалить транзакцию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID транзакции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Разрешить удаление сверенной транзакции",
+                        "name": "override",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reconciliations": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliations"
+                ],
+                "summary": "Список сессий сверки",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/reconciliation.Session"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Загружает выписку (OFX, QIF, 1C), создает сессию сверки за ее период и автоматически сопоставляет строки с транзакциями по сумме, окну дат и похожести описания. Строки выписки в транзакции не импортируются",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliations"
+                ],
+                "summary": "Начать сверку выписки",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Файл выписки",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Формат (ofx/qif/1c), по умолчанию определяется по содержимому",
+                        "name": "format",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reconciliation.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reconciliations/{id}": {
+            "get": {
+                "description": "Возвращает сопоставленные пары и несопоставленные строки выписки и транзакции",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliations"
+                ],
+                "summary": "Состояние сессии сверки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reconciliation.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reconciliations/{id}/auto-match": {
+            "post": {
+                "description": "Сопоставляет оставшиеся строки (например, после ручного ввода недостающих транзакций); существующие пары не меняются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliations"
+                ],
+                "summary": "Повторить автосопоставление",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reconciliation.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reconciliations/{id}/close": {
+            "post": {
+                "description": "Закрывает сессию и помечает сопоставленные транзакции сверенными: после этого их можно изменить или удалить только с override=true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliations"
+                ],
+                "summary": "Закрыть сессию сверки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reconciliation.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reconciliations/{id}/match": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliations"
+                ],
+                "summary": "Сопоставить строку вручную",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Строка выписки и транзакция",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MatchLineReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reconciliation.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reconciliations/{id}/unmatch": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliations"
+                ],
+                "summary": "Снять сопоставление строки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Строка выписки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UnmatchLineReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reconciliation.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks": {
+            "get": {
+                "description": "Возвращает список подписок (без секретов)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Получить подписки на вебхуки",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook.Subscription"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Подписывает URL на события transaction.created/updated/deleted. Каждая доставка подписывается HMAC-SHA256 тела запроса секретом подписки (заголовок X-Webhook-Signature: sha256=...). Секрет возвращается только в ответе на создание",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Создать подписку на вебхуки",
+                "parameters": [
+                    {
+                        "description": "Данные подписки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SaveWebhookReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/{id}": {
+            "delete": {
+                "description": "Удаляет подписку и ее журнал доставок",
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Удалить подписку на вебхуки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Возвращает доставки подписки: статус (pending/succeeded/failed/dead), число попыток, время следующей попытки, последнюю ошибку и код ответа",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Журнал доставок вебхука",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по статусу (pending/succeeded/failed/dead)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимум записей (по умолчанию 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook.Delivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "analytic.Analytic": {
+            "type": "object",
+            "properties": {
+                "Avg": {
+                    "type": "number"
+                },
+                "Count": {
+                    "type": "integer"
+                },
+                "Median": {
+                    "type": "number"
+                },
+                "Percentile90": {
+                    "type": "number"
+                },
+                "Sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "analytic.AnalyticByType": {
+            "type": "object",
+            "properties": {
+                "All": {
+                    "$ref": "#/definitions/analytic.Analytic"
+                },
+                "Expense": {
+                    "$ref": "#/definitions/analytic.Analytic"
+                },
+                "Income": {
+                    "$ref": "#/definitions/analytic.Analytic"
+                },
+                "Splits": {
+                    "description": "Splits — разбиения группы по убыванию TopBy, последним — Other; заполняется только при Query.Top",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.AnalyticSplit"
+                    }
+                }
+            }
+        },
+        "analytic.AnalyticGroup": {
+            "type": "object",
+            "properties": {
+                "Children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.AnalyticGroup"
+                    }
+                },
+                "Compare": {
+                    "$ref": "#/definitions/analytic.ComparisonByType"
+                },
+                "CompareKey": {
+                    "description": "CompareKey и Compare — соответствующая группа периода сравнения и изменения метрик относительно нее",
+                    "type": "string"
+                },
+                "Data": {
+                    "$ref": "#/definitions/analytic.AnalyticByType"
+                },
+                "Dimension": {
+                    "description": "Dimension и Children — измерение уровня и подгруппы следующего уровня во вложенной группировке",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/analytic.GroupBy"
+                        }
+                    ]
+                },
+                "Filled": {
+                    "description": "Filled — группа добавлена заполнением пропусков, транзакций в периоде нет",
+                    "type": "boolean"
+                },
+                "GroupKey": {
+                    "type": "string"
+                },
+                "Rolling": {
+                    "description": "Rolling — скользящие агрегаты по каждому запрошенному окну (\"7d\", \"30d\")",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/analytic.RollingByType"
+                    }
+                },
+                "Running": {
+                    "description": "Running — нарастающие итоги на конец периода с учетом входящего остатка",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/analytic.RunningTotals"
+                        }
+                    ]
+                }
+            }
+        },
+        "analytic.AnalyticSplit": {
+            "type": "object",
+            "properties": {
+                "Data": {
+                    "$ref": "#/definitions/analytic.Analytic"
+                },
+                "Key": {
+                    "type": "string"
+                },
+                "Other": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "analytic.Analytics": {
+            "type": "object",
+            "properties": {
+                "Comparison": {
+                    "$ref": "#/definitions/analytic.Comparison"
+                },
+                "Groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.AnalyticGroup"
+                    }
+                },
+                "Opening": {
+                    "description": "Opening — входящий остаток: итоги всех транзакций до from (с тем же фильтром)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/analytic.RunningTotals"
+                        }
+                    ]
+                },
+                "Summary": {
+                    "$ref": "#/definitions/analytic.AnalyticByType"
+                }
+            }
+        },
+        "analytic.Bucketing": {
+            "type": "string",
+            "enum": [
+                "fixed",
+                "log",
+                "quantile"
+            ],
+            "x-enum-comments": {
+                "BucketFixed": "корзины одинаковой ширины от минимальной до максимальной суммы",
+                "BucketLog": "одинаковой ширины по логарифму суммы: каждая следующая шире в одно и то же число раз",
+                "BucketQuantile": "границы — квантили, в корзинах примерно поровну транзакций"
+            },
+            "x-enum-descriptions": [
+                "корзины одинаковой ширины от минимальной до максимальной суммы",
+                "одинаковой ширины по логарифму суммы: каждая следующая шире в одно и то же число раз",
+                "границы — квантили, в корзинах примерно поровну транзакций"
+            ],
+            "x-enum-varnames": [
+                "BucketFixed",
+                "BucketLog",
+                "BucketQuantile"
+            ]
+        },
+        "analytic.Change": {
+            "type": "object",
+            "properties": {
+                "Current": {
+                    "type": "number"
+                },
+                "Delta": {
+                    "type": "number"
+                },
+                "Percent": {
+                    "type": "number"
+                },
+                "Previous": {
+                    "type": "number"
+                }
+            }
+        },
+        "analytic.CompareMode": {
+            "type": "string",
+            "enum": [
+                "",
+                "previous",
+                "yoy",
+                "custom"
+            ],
+            "x-enum-comments": {
+                "CompareCustom": "период из CompareFrom и CompareTo",
+                "ComparePrevious": "предыдущий период той же длины; целые месяцы сдвигаются на столько же месяцев",
+                "CompareYoY": "тот же период годом ранее"
+            },
+            "x-enum-descriptions": [
+                "",
+                "предыдущий период той же длины; целые месяцы сдвигаются на столько же месяцев",
+                "тот же период годом ранее",
+                "период из CompareFrom и CompareTo"
+            ],
+            "x-enum-varnames": [
+                "CompareNone",
+                "ComparePrevious",
+                "CompareYoY",
+                "CompareCustom"
+            ]
+        },
+        "analytic.Comparison": {
+            "type": "object",
+            "properties": {
+                "From": {
+                    "type": "string"
+                },
+                "Mode": {
+                    "$ref": "#/definitions/analytic.CompareMode"
+                },
+                "Summary": {
+                    "$ref": "#/definitions/analytic.ComparisonByType"
+                },
+                "To": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytic.ComparisonByType": {
+            "type": "object",
+            "properties": {
+                "All": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/analytic.Change"
+                    }
+                },
+                "Expense": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/analytic.Change"
+                    }
+                },
+                "Income": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/analytic.Change"
+                    }
+                }
+            }
+        },
+        "analytic.Dimension": {
+            "type": "string",
+            "enum": [
+                "period",
+                "category",
+                "type",
+                "counterparty",
+                "tag"
+            ],
+            "x-enum-comments": {
+                "DimCategory": "категория",
+                "DimCounterparty": "ИНН контрагента, пусто — не указан",
+                "DimPeriod": "период группировки PivotQuery.Period",
+                "DimTag": "у транзакций нет тегов: измерение распознается, но не поддерживается",
+                "DimType": "income или expense"
+            },
+            "x-enum-descriptions": [
+                "период группировки PivotQuery.Period",
+                "категория",
+                "income или expense",
+                "ИНН контрагента, пусто — не указан",
+                "у транзакций нет тегов: измерение распознается, но не поддерживается"
+            ],
+            "x-enum-varnames": [
+                "DimPeriod",
+                "DimCategory",
+                "DimType",
+                "DimCounterparty",
+                "DimTag"
+            ]
+        },
+        "analytic.Forecast": {
+            "type": "object",
+            "properties": {
+                "From": {
+                    "type": "string"
+                },
+                "GroupBy": {
+                    "$ref": "#/definitions/analytic.GroupBy"
+                },
+                "Horizon": {
+                    "type": "integer"
+                },
+                "Level": {
+                    "type": "number"
+                },
+                "Series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.SeriesForecast"
+                    }
+                },
+                "To": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytic.ForecastError": {
+            "type": "object",
+            "properties": {
+                "MAE": {
+                    "type": "number"
+                },
+                "MAPE": {
+                    "type": "number"
+                },
+                "Periods": {
+                    "type": "integer"
+                },
+                "RMSE": {
+                    "type": "number"
+                }
+            }
+        },
+        "analytic.ForecastPoint": {
+            "type": "object",
+            "properties": {
+                "Lower": {
+                    "type": "number"
+                },
+                "Period": {
+                    "type": "string"
+                },
+                "Upper": {
+                    "type": "number"
+                },
+                "Value": {
+                    "type": "number"
+                }
+            }
+        },
+        "analytic.GroupBy": {
+            "type": "string",
+            "enum": [
+                "hour",
+                "day",
+                "week",
+                "month",
+                "quarter",
+                "year",
+                "category",
+                "type",
+                "counterparty",
+                "none"
+            ],
+            "x-enum-comments": {
+                "GroupCategory": "категория транзакции",
+                "GroupCounterparty": "ИНН контрагента, пусто — не указан",
+                "GroupDay": "2025-02-12",
+                "GroupHour": "2025-02-12T13:00",
+                "GroupMonth": "2025-02",
+                "GroupNone": "одна группа на весь период",
+                "GroupQuarter": "2025-Q1",
+                "GroupType": "income или expense",
+                "GroupWeek": "2025-W07, неделя и год по ISO 8601",
+                "GroupYear": "2025"
+            },
+            "x-enum-descriptions": [
+                "2025-02-12T13:00",
+                "2025-02-12",
+                "2025-W07, неделя и год по ISO 8601",
+                "2025-02",
+                "2025-Q1",
+                "2025",
+                "категория транзакции",
+                "income или expense",
+                "ИНН контрагента, пусто — не указан",
+                "одна группа на весь период"
+            ],
+            "x-enum-varnames": [
+                "GroupHour",
+                "GroupDay",
+                "GroupWeek",
+                "GroupMonth",
+                "GroupQuarter",
+                "GroupYear",
+                "GroupCategory",
+                "GroupType",
+                "GroupCounterparty",
+                "GroupNone"
+            ]
+        },
+        "analytic.Histogram": {
+            "type": "object",
+            "properties": {
+                "Bucketing": {
+                    "$ref": "#/definitions/analytic.Bucketing"
+                },
+                "Buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.HistogramBucket"
+                    }
+                },
+                "Count": {
+                    "type": "integer"
+                },
+                "Max": {
+                    "type": "number"
+                },
+                "Min": {
+                    "type": "number"
+                },
+                "Sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "analytic.HistogramBucket": {
+            "type": "object",
+            "properties": {
+                "Count": {
+                    "type": "integer"
+                },
+                "Lower": {
+                    "type": "number"
+                },
+                "Sum": {
+                    "type": "number"
+                },
+                "Upper": {
+                    "type": "number"
+                }
+            }
+        },
+        "analytic.Metric": {
+            "type": "string",
+            "enum": [
+                "sum",
+                "avg",
+                "count",
+                "min",
+                "max",
+                "stddev",
+                "variance",
+                "median",
+                "percentile90"
+            ],
+            "x-enum-comments": {
+                "MetricStddev": "выборочное стандартное отклонение, для одной транзакции 0",
+                "MetricVariance": "выборочная дисперсия, для одной транзакции 0"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "",
+                "",
+                "",
+                "выборочное стандартное отклонение, для одной транзакции 0",
+                "выборочная дисперсия, для одной транзакции 0",
+                "",
+                ""
+            ],
+            "x-enum-varnames": [
+                "MetricSum",
+                "MetricAvg",
+                "MetricCount",
+                "MetricMin",
+                "MetricMax",
+                "MetricStddev",
+                "MetricVariance",
+                "MetricMedian",
+                "MetricPercentile90"
+            ]
+        },
+        "analytic.Pivot": {
+            "type": "object",
+            "properties": {
+                "Cells": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.PivotCell"
+                    }
+                },
+                "ColumnKeys": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "ColumnTotals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.PivotCell"
+                    }
+                },
+                "Columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.Dimension"
+                    }
+                },
+                "GrandTotal": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "Metrics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.Metric"
+                    }
+                },
+                "RowKeys": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "RowTotals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.PivotCell"
+                    }
+                },
+                "Rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.Dimension"
+                    }
+                }
+            }
+        },
+        "analytic.PivotCell": {
+            "type": "object",
+            "properties": {
+                "Column": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "Row": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "Values": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                }
+            }
+        },
+        "analytic.RollingByType": {
+            "type": "object",
+            "properties": {
+                "All": {
+                    "$ref": "#/definitions/analytic.RollingValue"
+                },
+                "Expense": {
+                    "$ref": "#/definitions/analytic.RollingValue"
+                },
+                "Income": {
+                    "$ref": "#/definitions/analytic.RollingValue"
+                }
+            }
+        },
+        "analytic.RollingValue": {
+            "type": "object",
+            "properties": {
+                "Avg": {
+                    "type": "number"
+                },
+                "Count": {
+                    "type": "integer"
+                },
+                "Sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "analytic.RunningTotals": {
+            "type": "object",
+            "properties": {
+                "Expense": {
+                    "type": "number"
+                },
+                "Income": {
+                    "type": "number"
+                },
+                "Net": {
+                    "type": "number"
+                }
+            }
+        },
+        "analytic.Seasonality": {
+            "type": "object",
+            "properties": {
+                "ColumnTotals": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "Columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "Layout": {
+                    "$ref": "#/definitions/analytic.SeasonalityLayout"
+                },
+                "Measure": {
+                    "$ref": "#/definitions/analytic.Metric"
+                },
+                "RowTotals": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "Rows": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "Total": {
+                    "type": "number"
+                },
+                "Values": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number",
+                            "format": "float64"
+                        }
+                    }
+                }
+            }
+        },
+        "analytic.SeasonalityLayout": {
+            "type": "string",
+            "enum": [
+                "weekday_hour",
+                "month_weekday"
+            ],
+            "x-enum-comments": {
+                "LayoutMonthWeekday": "строки — месяцы года, колонки — дни недели",
+                "LayoutWeekdayHour": "строки — дни недели с понедельника, колонки — часы 00–23"
+            },
+            "x-enum-descriptions": [
+                "строки — дни недели с понедельника, колонки — часы 00–23",
+                "строки — месяцы года, колонки — дни недели"
+            ],
+            "x-enum-varnames": [
+                "LayoutWeekdayHour",
+                "LayoutMonthWeekday"
+            ]
+        },
+        "analytic.SeriesForecast": {
+            "type": "object",
+            "properties": {
+                "Backtest": {
+                    "$ref": "#/definitions/analytic.ForecastError"
+                },
+                "Category": {
+                    "type": "string"
+                },
+                "Model": {
+                    "type": "string"
+                },
+                "Points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytic.ForecastPoint"
+                    }
+                },
+                "Type": {
+                    "$ref": "#/definitions/transaction.TransactionType"
+                }
+            }
+        },
+        "anomaly.Ack": {
+            "type": "object",
+            "properties": {
+                "AcknowledgedAt": {
+                    "type": "string"
+                },
+                "ID": {
+                    "type": "string"
+                },
+                "Note": {
+                    "type": "string"
+                }
+            }
+        },
+        "anomaly.Anomaly": {
+            "type": "object",
+            "properties": {
+                "Acknowledged": {
+                    "type": "boolean"
+                },
+                "AcknowledgedAt": {
+                    "type": "string"
+                },
+                "Category": {
+                    "type": "string"
+                },
+                "Date": {
+                    "type": "string"
+                },
+                "Expected": {
+                    "type": "number"
+                },
+                "ID": {
+                    "type": "string"
+                },
+                "Kind": {
+                    "$ref": "#/definitions/anomaly.Kind"
+                },
+                "Lower": {
+                    "type": "number"
+                },
+                "Note": {
+                    "type": "string"
+                },
+                "Reason": {
+                    "type": "string"
+                },
+                "Score": {
+                    "type": "number"
+                },
+                "TransactionID": {
+                    "type": "string"
+                },
+                "Type": {
+                    "$ref": "#/definitions/transaction.TransactionType"
+                },
+                "Upper": {
+                    "type": "number"
+                },
+                "Value": {
+                    "type": "number"
+                }
+            }
+        },
+        "anomaly.Kind": {
+            "type": "string",
+            "enum": [
+                "",
+                "transaction",
+                "day"
+            ],
+            "x-enum-comments": {
+                "KindDay": "итог дня по типу выбивается из ожидаемого диапазона",
+                "KindTransaction": "сумма транзакции нетипична для ее типа и категории"
+            },
+            "x-enum-descriptions": [
+                "",
+                "сумма транзакции нетипична для ее типа и категории",
+                "итог дня по типу выбивается из ожидаемого диапазона"
+            ],
+            "x-enum-varnames": [
+                "KindAll",
+                "KindTransaction",
+                "KindDay"
+            ]
+        },
+        "budget.Budget": {
+            "type": "object",
+            "properties": {
+                "Amount": {
+                    "type": "number"
+                },
+                "Category": {
+                    "type": "string"
+                },
+                "ID": {
+                    "type": "string"
+                },
+                "Period": {
+                    "$ref": "#/definitions/budget.Period"
+                }
+            }
+        },
+        "budget.Period": {
+            "type": "string",
+            "enum": [
+                "month",
+                "quarter",
+                "year"
+            ],
+            "x-enum-varnames": [
+                "Month",
+                "Quarter",
+                "Year"
+            ]
+        },
+        "budget.Report": {
+            "type": "object",
+            "properties": {
+                "Date": {
+                    "type": "string"
+                },
+                "Items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/budget.ReportItem"
+                    }
+                }
+            }
+        },
+        "budget.ReportItem": {
+            "type": "object",
+            "properties": {
+                "Actual": {
+                    "type": "number"
+                },
+                "BudgetID": {
+                    "type": "string"
+                },
+                "Category": {
+                    "type": "string"
+                },
+                "PercentConsumed": {
+                    "type": "number"
+                },
+                "Period": {
+                    "$ref": "#/definitions/budget.Period"
+                },
+                "PeriodEnd": {
+                    "type": "string"
+                },
+                "PeriodStart": {
+                    "type": "string"
+                },
+                "Planned": {
+                    "type": "number"
+                },
+                "Projected": {
+                    "type": "number"
+                },
+                "ProjectedVariance": {
+                    "type": "number"
+                },
+                "Variance": {
+                    "type": "number"
+                }
+            }
+        },
+        "change.Change": {
+            "type": "object",
+            "properties": {
+                "ID": {
+                    "type": "string"
+                },
+                "Op": {
+                    "$ref": "#/definitions/change.Op"
+                },
+                "Seq": {
+                    "type": "integer"
+                },
+                "Transaction": {
+                    "$ref": "#/definitions/transaction.Transaction"
+                }
+            }
+        },
+        "change.Feed": {
+            "type": "object",
+            "properties": {
+                "Changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/change.Change"
+                    }
+                },
+                "HasMore": {
+                    "type": "boolean"
+                },
+                "NextCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "change.Op": {
+            "type": "string",
+            "enum": [
+                "upsert",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "Upsert",
+                "Delete"
+            ]
+        },
+        "dto.AckAnomalyReq": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateTransactionResp": {
+            "type": "object",
+            "properties": {
+                "Amount": {
+                    "type": "number"
+                },
+                "Category": {
+                    "type": "string"
+                },
+                "Counterparty": {
+                    "type": "string"
+                },
+                "Date": {
+                    "type": "string"
+                },
+                "Description": {
+                    "type": "string"
+                },
+                "ID": {
+                    "type": "string"
+                },
+                "PossibleDuplicates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transaction.DuplicatePair"
+                    }
+                },
+                "Reconciled": {
+                    "type": "boolean"
+                },
+                "Type": {
+                    "$ref": "#/definitions/transaction.TransactionType"
+                }
+            }
+        },
+        "dto.MatchLineReq": {
             "type": "object",
             "properties": {
-                "All": {
-                    "$ref": "#/definitions/analytic.Analytic"
-                },
-                "Expense": {
-                    "$ref": "#/definitions/analytic.Analytic"
+                "lineId": {
+                    "type": "integer"
                 },
-                "Income": {
-                    "$ref": "#/definitions/analytic.Analytic"
+                "transactionId": {
+                    "type": "string"
                 }
             }
         },
-        "analytic.AnalyticGroup": {
+        "dto.ResolveDuplicateReq": {
             "type": "object",
             "properties": {
-                "Data": {
-                    "$ref": "#/definitions/analytic.AnalyticByType"
+                "action": {
+                    "description": "merge|dismiss",
+                    "type": "string"
                 },
-                "GroupKey": {
+                "dropId": {
+                    "type": "string"
+                },
+                "keepId": {
                     "type": "string"
                 }
             }
         },
-        "analytic.Analytics": {
+        "dto.SaveBudgetReq": {
             "type": "object",
             "properties": {
-                "Groups": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/analytic.AnalyticGroup"
-                    }
+                "amount": {
+                    "type": "number"
                 },
-                "Summary": {
-                    "$ref": "#/definitions/analytic.AnalyticByType"
+                "category": {
+                    "type": "string"
+                },
+                "period": {
+                    "description": "month|quarter|year",
+                    "type": "string"
                 }
             }
         },
@@ -584,6 +3957,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "date": {
+                    "description": "YYYY-MM-DD или RFC 3339",
                     "type": "string"
                 },
                 "description": {
@@ -595,6 +3969,249 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.SaveWebhookReq": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "transaction.created|transaction.updated|transaction.deleted, пусто — все",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "если пусто — будет сгенерирован",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.UnmatchLineReq": {
+            "type": "object",
+            "properties": {
+                "lineId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "event.Type": {
+            "type": "string",
+            "enum": [
+                "transaction.created",
+                "transaction.updated",
+                "transaction.deleted"
+            ],
+            "x-enum-varnames": [
+                "TransactionCreated",
+                "TransactionUpdated",
+                "TransactionDeleted"
+            ]
+        },
+        "reconciliation.BankLine": {
+            "type": "object",
+            "properties": {
+                "Amount": {
+                    "type": "number"
+                },
+                "Date": {
+                    "type": "string"
+                },
+                "Description": {
+                    "type": "string"
+                },
+                "ExternalID": {
+                    "type": "string"
+                },
+                "ID": {
+                    "type": "integer"
+                },
+                "MatchType": {
+                    "$ref": "#/definitions/reconciliation.MatchType"
+                },
+                "Score": {
+                    "type": "number"
+                },
+                "SessionID": {
+                    "type": "string"
+                },
+                "TransactionID": {
+                    "type": "string"
+                },
+                "Type": {
+                    "$ref": "#/definitions/transaction.TransactionType"
+                }
+            }
+        },
+        "reconciliation.Match": {
+            "type": "object",
+            "properties": {
+                "Line": {
+                    "$ref": "#/definitions/reconciliation.BankLine"
+                },
+                "Transaction": {
+                    "$ref": "#/definitions/transaction.Transaction"
+                }
+            }
+        },
+        "reconciliation.MatchType": {
+            "type": "string",
+            "enum": [
+                "auto",
+                "manual"
+            ],
+            "x-enum-varnames": [
+                "AutoMatch",
+                "ManualMatch"
+            ]
+        },
+        "reconciliation.Report": {
+            "type": "object",
+            "properties": {
+                "Matched": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/reconciliation.Match"
+                    }
+                },
+                "ParseErrors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/statement.LineError"
+                    }
+                },
+                "Session": {
+                    "$ref": "#/definitions/reconciliation.Session"
+                },
+                "UnmatchedBank": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/reconciliation.BankLine"
+                    }
+                },
+                "UnmatchedLedger": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transaction.Transaction"
+                    }
+                }
+            }
+        },
+        "reconciliation.Session": {
+            "type": "object",
+            "properties": {
+                "ClosedAt": {
+                    "type": "string"
+                },
+                "CreatedAt": {
+                    "type": "string"
+                },
+                "Format": {
+                    "$ref": "#/definitions/statement.Format"
+                },
+                "From": {
+                    "type": "string"
+                },
+                "ID": {
+                    "type": "string"
+                },
+                "Name": {
+                    "type": "string"
+                },
+                "Status": {
+                    "$ref": "#/definitions/reconciliation.Status"
+                },
+                "To": {
+                    "type": "string"
+                }
+            }
+        },
+        "reconciliation.Status": {
+            "type": "string",
+            "enum": [
+                "open",
+                "closed"
+            ],
+            "x-enum-varnames": [
+                "Open",
+                "Closed"
+            ]
+        },
+        "statement.FileSummary": {
+            "type": "object",
+            "properties": {
+                "Created": {
+                    "type": "integer"
+                },
+                "Errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/statement.LineError"
+                    }
+                },
+                "Failed": {
+                    "type": "integer"
+                },
+                "File": {
+                    "type": "string"
+                },
+                "Format": {
+                    "$ref": "#/definitions/statement.Format"
+                },
+                "Skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "statement.Format": {
+            "type": "string",
+            "enum": [
+                "ofx",
+                "qif",
+                "1c",
+                "wb",
+                "fns"
+            ],
+            "x-enum-varnames": [
+                "OFX",
+                "QIF",
+                "OneC",
+                "WBReport",
+                "Receipt"
+            ]
+        },
+        "statement.LineError": {
+            "type": "object",
+            "properties": {
+                "Error": {
+                    "type": "string"
+                },
+                "Line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "transaction.DuplicatePair": {
+            "type": "object",
+            "properties": {
+                "First": {
+                    "$ref": "#/definitions/transaction.Transaction"
+                },
+                "Reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "Score": {
+                    "type": "number"
+                },
+                "Second": {
+                    "$ref": "#/definitions/transaction.Transaction"
+                }
+            }
+        },
         "transaction.Transaction": {
             "type": "object",
             "properties": {
@@ -604,6 +4221,9 @@ const docTemplate = `{
                 "Category": {
                     "type": "string"
                 },
+                "Counterparty": {
+                    "type": "string"
+                },
                 "Date": {
                     "type": "string"
                 },
@@ -613,6 +4233,9 @@ const docTemplate = `{
                 "ID": {
                     "type": "string"
                 },
+                "Reconciled": {
+                    "type": "boolean"
+                },
                 "Type": {
                     "$ref": "#/definitions/transaction.TransactionType"
                 }
@@ -628,6 +4251,85 @@ const docTemplate = `{
                 "Income",
                 "Expense"
             ]
+        },
+        "webhook.Delivery": {
+            "type": "object",
+            "properties": {
+                "Attempts": {
+                    "type": "integer"
+                },
+                "CreatedAt": {
+                    "type": "string"
+                },
+                "EventID": {
+                    "type": "integer"
+                },
+                "EventType": {
+                    "$ref": "#/definitions/event.Type"
+                },
+                "ID": {
+                    "type": "integer"
+                },
+                "LastError": {
+                    "type": "string"
+                },
+                "NextAttemptAt": {
+                    "type": "string"
+                },
+                "ResponseCode": {
+                    "type": "integer"
+                },
+                "Status": {
+                    "$ref": "#/definitions/webhook.DeliveryStatus"
+                },
+                "SubscriptionID": {
+                    "type": "string"
+                },
+                "UpdatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhook.DeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "failed",
+                "dead"
+            ],
+            "x-enum-varnames": [
+                "Pending",
+                "Succeeded",
+                "Failed",
+                "Dead"
+            ]
+        },
+        "webhook.Subscription": {
+            "type": "object",
+            "properties": {
+                "Active": {
+                    "type": "boolean"
+                },
+                "CreatedAt": {
+                    "type": "string"
+                },
+                "Events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/event.Type"
+                    }
+                },
+                "ID": {
+                    "type": "string"
+                },
+                "Secret": {
+                    "type": "string"
+                },
+                "URL": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -6,6 +6,9 @@ import (
 	wbzlog "github.com/wb-go/wbf/zlog"
 	"io"
 	"salestracker/internal/domain/analytic"
+	"salestracker/internal/domain/transaction"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -14,7 +17,10 @@ type AnalyticService struct {
 }
 
 type AnalyticStorageProvider interface {
-	GetAnalytics(from, to time.Time, groupBy, splitBy, sortBy, sortDir string) (*analytic.Analytics, error)
+	GetAnalytics(q analytic.Query) (*analytic.Analytics, error)
+	GetPivot(q analytic.PivotQuery) (*analytic.Pivot, error)
+	GetHistogram(q analytic.HistogramQuery) (*analytic.Histogram, error)
+	GetSeasonality(q analytic.SeasonalityQuery) (*analytic.Seasonality, error)
 }
 
 func NewAnalyticService(repo AnalyticStorageProvider) *AnalyticService {
@@ -23,31 +29,101 @@ func NewAnalyticService(repo AnalyticStorageProvider) *AnalyticService {
 	}
 }
 
-func (s *AnalyticService) GetAnalytics(from, to time.Time, groupBy, splitBy, sortBy, sortDir string) (*analytic.Analytics, error) {
-	if from.After(to) {
-		err := fmt.Errorf("'from' date cannot be after 'to'")
-		wbzlog.Logger.Warn().Err(err).Msg("invalid date range in analytics request")
+func (s *AnalyticService) GetAnalytics(q analytic.Query) (*analytic.Analytics, error) {
+	q, err := normalizeQuery(q)
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid analytics request")
 		return nil, err
 	}
 
-	if groupBy == "" {
-		groupBy = "day"
+	result, err := s.load(q)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("analytics repository error")
+		return nil, err
 	}
-	if splitBy == "" {
-		splitBy = "transtype"
+
+	return result, nil
+}
+
+// load читает аналитику и, если задан режим сравнения, дополняет ее периодом сравнения
+func (s *AnalyticService) load(q analytic.Query) (*analytic.Analytics, error) {
+	if q.Compare == analytic.CompareNone {
+		return s.repo.GetAnalytics(q)
+	}
+
+	// Временные группы сопоставляются по порядковому номеру периода (февраль с январем, 2025-W07 с 2024-W07),
+	// поэтому оба ряда запрашиваются полными; пропуски, которых не просили, потом убираются
+	cur, prev := q, q
+	cur.Compare, prev.Compare = analytic.CompareNone, analytic.CompareNone
+	byPosition := q.GroupBy.IsTime()
+	if byPosition {
+		if cur.Fill == analytic.FillNone {
+			cur.Fill = analytic.FillZero
+		}
+		prev.Fill = analytic.FillZero
 	}
+	prev.From, prev.To = q.ComparisonRange()
+	prev.Cumulative, prev.Rolling = false, nil
 
-	result, err := s.repo.GetAnalytics(from, to, groupBy, splitBy, sortBy, sortDir)
+	result, err := s.repo.GetAnalytics(cur)
+	if err != nil {
+		return nil, err
+	}
+	base, err := s.repo.GetAnalytics(prev)
 	if err != nil {
-		wbzlog.Logger.Error().Err(err).Msg("analytics repository error")
 		return nil, err
 	}
+	if result == nil {
+		result = &analytic.Analytics{}
+	}
+	if base == nil {
+		base = &analytic.Analytics{}
+	}
 
+	metrics := q.MetricList()
+	byKey := make(map[string]analytic.AnalyticGroup, len(base.Groups))
+	for _, g := range base.Groups {
+		byKey[g.GroupKey] = g
+	}
+	groups := result.Groups[:0]
+	for i, g := range result.Groups {
+		var match *analytic.AnalyticGroup
+		if byPosition {
+			if i < len(base.Groups) {
+				match = &base.Groups[i]
+			}
+		} else if m, ok := byKey[g.GroupKey]; ok {
+			match = &m
+		}
+		if byPosition && g.Filled && q.Fill == analytic.FillNone {
+			continue
+		}
+		if g.Data != nil {
+			var prevData *analytic.AnalyticByType
+			if match != nil {
+				g.CompareKey, prevData = match.GroupKey, match.Data
+			}
+			g.Compare = analytic.NewComparison(g.Data, prevData, metrics)
+		}
+		groups = append(groups, g)
+	}
+	result.Groups = groups
+	result.Comparison = &analytic.Comparison{
+		Mode:    q.Compare,
+		From:    prev.From,
+		To:      prev.To,
+		Summary: *analytic.NewComparison(&result.Summary, &base.Summary, metrics),
+	}
 	return result, nil
 }
 
-func (s *AnalyticService) GetCSV(from, to time.Time, groupBy, splitBy, sortBy, sortDir string, output io.Writer) error {
-	anals, err := s.repo.GetAnalytics(from, to, groupBy, splitBy, sortBy, sortDir)
+func (s *AnalyticService) GetCSV(q analytic.Query, output io.Writer) error {
+	q, err := normalizeQuery(q)
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid analytics request")
+		return err
+	}
+	anals, err := s.load(q)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("repo get analytics error")
 		return err
@@ -56,29 +132,111 @@ func (s *AnalyticService) GetCSV(from, to time.Time, groupBy, splitBy, sortBy, s
 	writer := csv.NewWriter(output)
 	defer writer.Flush()
 
-	headers := []string{"GroupKey", "Type", "Sum", "Avg", "Count", "Median", "Percentile90"}
+	if len(q.Nested) > 0 {
+		return writeNestedCSV(writer, q, anals)
+	}
+
+	// Без явного выбора метрик колонки прежние: Sum, Avg, Count, Median, Percentile90
+	// С режимом сравнения после каждой метрики идут ее значение в периоде сравнения, разница и изменение в процентах
+	metrics := q.MetricList()
+	compare := q.Compare != analytic.CompareNone
+	headers := []string{"GroupKey", "Type"}
+	// С top после строк Income, Expense и All идут строки разбиений (Type All): ключ разбиения — в колонке Split,
+	// у свернутого Other Split пустой, а в колонке Other — true
+	if q.Top > 0 {
+		headers = append(headers, "Split", "Other")
+	}
+	if compare {
+		headers = append(headers, "CompareKey")
+	}
+	for _, m := range metrics {
+		name := string(m)
+		if len(q.Metrics) == 0 {
+			name = strings.ToUpper(name[:1]) + name[1:]
+		}
+		headers = append(headers, name)
+		if compare {
+			headers = append(headers, name+"_compare", name+"_delta", name+"_pct")
+		}
+	}
+	// Нарастающий итог: для Income и Expense — накопленная сумма типа, для All — чистый остаток
+	if q.Cumulative {
+		headers = append(headers, "Running")
+	}
+	for _, w := range q.Rolling {
+		headers = append(headers, "Rolling"+w.String()+"_sum", "Rolling"+w.String()+"_avg", "Rolling"+w.String()+"_count")
+	}
 	if err := writer.Write(headers); err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("Error writing CSV headers")
 		return err
 	}
 
 	for _, group := range anals.Groups {
-		typesMap := map[string]analytic.Analytic{
-			"Income":  group.Data.Income,
-			"Expense": group.Data.Expense,
-			"All":     group.Data.All,
-		}
-
-		for typ, data := range typesMap {
-			row := []string{
-				group.GroupKey,
-				typ,
-				fmt.Sprintf("%.2f", data.Sum),
-				fmt.Sprintf("%.2f", data.Avg),
-				fmt.Sprintf("%d", data.Count),
-				fmt.Sprintf("%.2f", data.Median),
-				fmt.Sprintf("%.2f", data.Percentile90),
+		if group.Data == nil {
+			// Пустой период при fill=null: строки есть, значений нет
+			for _, typ := range []string{"Income", "Expense", "All"} {
+				row := append([]string{group.GroupKey, typ}, make([]string, len(headers)-2-rollingColumns(q))...)
+				if group.Running != nil {
+					row[len(row)-1] = fmt.Sprintf("%.2f", group.Running.ByType(typ))
+				}
+				row = append(row, rollingCells(q, group, typ)...)
+				if err := writer.Write(row); err != nil {
+					wbzlog.Logger.Error().Err(err).Msg("Error writing CSV row")
+					return err
+				}
+			}
+			continue
+		}
+		for _, typ := range []string{"Income", "Expense", "All"} {
+			data := group.Data.ByType(typ)
+			row := []string{group.GroupKey, typ}
+			if q.Top > 0 {
+				row = append(row, "", "")
 			}
+			if compare {
+				row = append(row, group.CompareKey)
+			}
+			for _, m := range metrics {
+				row = append(row, formatMetric(m, data.Value(m)))
+				if compare && group.Compare != nil {
+					c := group.Compare.ByType(typ)[m]
+					pct := ""
+					if c.Percent != nil {
+						pct = fmt.Sprintf("%.2f", *c.Percent)
+					}
+					row = append(row, formatMetric(m, c.Previous), formatMetric(m, c.Delta), pct)
+				}
+			}
+			if q.Cumulative {
+				running := ""
+				if group.Running != nil {
+					running = fmt.Sprintf("%.2f", group.Running.ByType(typ))
+				}
+				row = append(row, running)
+			}
+			row = append(row, rollingCells(q, group, typ)...)
+			if err := writer.Write(row); err != nil {
+				wbzlog.Logger.Error().Err(err).Msg("Error writing CSV row")
+				return err
+			}
+		}
+
+		for _, split := range group.Data.Splits {
+			other := ""
+			if split.Other {
+				other = "true"
+			}
+			row := []string{group.GroupKey, "All", split.Key, other}
+			if compare {
+				row = append(row, "")
+			}
+			for _, m := range metrics {
+				row = append(row, formatMetric(m, split.Data.Value(m)))
+				if compare {
+					row = append(row, "", "", "")
+				}
+			}
+			row = append(row, make([]string, len(headers)-len(row))...)
 			if err := writer.Write(row); err != nil {
 				wbzlog.Logger.Error().Err(err).Msg("Error writing CSV row")
 				return err
@@ -89,3 +247,311 @@ func (s *AnalyticService) GetCSV(from, to time.Time, groupBy, splitBy, sortBy, s
 	wbzlog.Logger.Info().Msg("CSV report generation completed")
 	return nil
 }
+
+// writeNestedCSV выгружает дерево групп построчно: по колонке на уровень группировки, затем Type и метрики.
+// Группа идет перед своими подгруппами, у итогов верхних уровней колонки нижних уровней пустые
+func writeNestedCSV(writer *csv.Writer, q analytic.Query, anals *analytic.Analytics) error {
+	levels := append([]analytic.GroupBy{q.GroupBy}, q.Nested...)
+	metrics := q.MetricList()
+	headers := make([]string, 0, len(levels)+1+len(metrics))
+	for _, g := range levels {
+		headers = append(headers, string(g))
+	}
+	headers = append(headers, "Type")
+	for _, m := range metrics {
+		name := string(m)
+		if len(q.Metrics) == 0 {
+			name = strings.ToUpper(name[:1]) + name[1:]
+		}
+		headers = append(headers, name)
+	}
+	if err := writer.Write(headers); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error writing CSV headers")
+		return err
+	}
+
+	var walk func(groups []analytic.AnalyticGroup, path []string) error
+	walk = func(groups []analytic.AnalyticGroup, path []string) error {
+		for _, g := range groups {
+			keys := append(append([]string{}, path...), g.GroupKey)
+			for _, typ := range []string{"Income", "Expense", "All"} {
+				row := append(append([]string{}, keys...), make([]string, len(levels)-len(keys))...)
+				row = append(row, typ)
+				for _, m := range metrics {
+					if g.Data == nil {
+						row = append(row, "")
+						continue
+					}
+					row = append(row, formatMetric(m, g.Data.ByType(typ).Value(m)))
+				}
+				if err := writer.Write(row); err != nil {
+					wbzlog.Logger.Error().Err(err).Msg("Error writing CSV row")
+					return err
+				}
+			}
+			if err := walk(g.Children, keys); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(anals.Groups, nil); err != nil {
+		return err
+	}
+
+	wbzlog.Logger.Info().Msg("CSV report generation completed")
+	return nil
+}
+
+// rollingColumns — сколько колонок CSV занимают скользящие окна
+func rollingColumns(q analytic.Query) int {
+	return 3 * len(q.Rolling)
+}
+
+// rollingCells — сумма, среднее и число транзакций по каждому окну; пусто, если у группы нет окна
+func rollingCells(q analytic.Query, group analytic.AnalyticGroup, typ string) []string {
+	cells := make([]string, 0, rollingColumns(q))
+	for _, w := range q.Rolling {
+		r, ok := group.Rolling[w.String()]
+		if !ok {
+			cells = append(cells, "", "", "")
+			continue
+		}
+		v := r.ByType(typ)
+		cells = append(cells, fmt.Sprintf("%.2f", v.Sum), fmt.Sprintf("%.2f", v.Avg), fmt.Sprintf("%d", v.Count))
+	}
+	return cells
+}
+
+// Forecast прогнозирует доходы и расходы по категориям и их итоги. История каждого ряда — суммы по периодам
+// из той же аналитики (split по категориям, пустые периоды — нули)
+func (s *AnalyticService) Forecast(q analytic.ForecastQuery) (*analytic.Forecast, error) {
+	q, err := q.Normalize(time.Now())
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid forecast request")
+		return nil, err
+	}
+
+	types := []transaction.TransactionType{transaction.Income, transaction.Expense}
+	if q.Filter.Type != "" {
+		types = []transaction.TransactionType{q.Filter.Type}
+	}
+	periods := q.FuturePeriods()
+	result := &analytic.Forecast{GroupBy: q.GroupBy, From: q.From, To: q.To, Horizon: q.Horizon, Level: q.Level, Series: []analytic.SeriesForecast{}}
+
+	for _, typ := range types {
+		filter := q.Filter
+		filter.Type = typ
+		hist, err := s.repo.GetAnalytics(analytic.Query{
+			From: q.From, To: q.To, GroupBy: q.GroupBy, SplitBy: "category", Fill: analytic.FillZero,
+			Mode: analytic.ModeAbsolute, Filter: filter, Location: q.Location,
+		})
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("forecast history error")
+			return nil, err
+		}
+		if hist == nil {
+			continue
+		}
+
+		total := make([]float64, len(hist.Groups))
+		byCategory := map[string][]float64{}
+		var categories []string
+		for i, g := range hist.Groups {
+			if g.Data == nil {
+				continue
+			}
+			for cat, a := range g.Data.AllMap {
+				series, ok := byCategory[cat]
+				if !ok {
+					series = make([]float64, len(hist.Groups))
+					categories = append(categories, cat)
+				}
+				series[i] = a.Sum
+				byCategory[cat] = series
+				total[i] += a.Sum
+			}
+		}
+		sort.Strings(categories)
+
+		model, points, bt := analytic.ForecastSeries(total, q.Season(), periods, q.Level)
+		result.Series = append(result.Series, analytic.SeriesForecast{Type: typ, Model: model, Points: points, Backtest: bt})
+		for _, cat := range categories {
+			model, points, bt := analytic.ForecastSeries(byCategory[cat], q.Season(), periods, q.Level)
+			result.Series = append(result.Series, analytic.SeriesForecast{Type: typ, Category: cat, Model: model, Points: points, Backtest: bt})
+		}
+	}
+	return result, nil
+}
+
+func (s *AnalyticService) GetPivot(q analytic.PivotQuery) (*analytic.Pivot, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid pivot request")
+		return nil, err
+	}
+	res, err := s.repo.GetPivot(q)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get pivot error")
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetPivotCSV выгружает сводную таблицу плоско: колонка Level (cell, row_total, column_total, grand_total),
+// по колонке на измерение строк и колонок (у свернутых измерений значение пустое) и по колонке на метрику
+func (s *AnalyticService) GetPivotCSV(q analytic.PivotQuery, output io.Writer) error {
+	p, err := s.GetPivot(q)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(output)
+	defer writer.Flush()
+
+	headers := []string{"Level"}
+	for _, d := range p.Rows {
+		headers = append(headers, string(d))
+	}
+	for _, d := range p.Columns {
+		headers = append(headers, string(d))
+	}
+	for _, m := range p.Metrics {
+		headers = append(headers, string(m))
+	}
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	write := func(level analytic.PivotLevel, c analytic.PivotCell) error {
+		row := []string{string(level)}
+		for i := range p.Rows {
+			if i < len(c.Row) {
+				row = append(row, c.Row[i])
+			} else {
+				row = append(row, "")
+			}
+		}
+		for i := range p.Columns {
+			if i < len(c.Column) {
+				row = append(row, c.Column[i])
+			} else {
+				row = append(row, "")
+			}
+		}
+		for _, m := range p.Metrics {
+			row = append(row, formatMetric(m, c.Values[m]))
+		}
+		return writer.Write(row)
+	}
+	for _, part := range []struct {
+		level analytic.PivotLevel
+		cells []analytic.PivotCell
+	}{
+		{analytic.LevelCell, p.Cells},
+		{analytic.LevelRowTotal, p.RowTotals},
+		{analytic.LevelColumnTotal, p.ColumnTotals},
+		{analytic.LevelGrandTotal, []analytic.PivotCell{{Values: p.GrandTotal}}},
+	} {
+		for _, c := range part.cells {
+			if err := write(part.level, c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *AnalyticService) GetHistogram(q analytic.HistogramQuery) (*analytic.Histogram, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid histogram request")
+		return nil, err
+	}
+	res, err := s.repo.GetHistogram(q)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get histogram error")
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *AnalyticService) GetSeasonality(q analytic.SeasonalityQuery) (*analytic.Seasonality, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid seasonality request")
+		return nil, err
+	}
+	res, err := s.repo.GetSeasonality(q)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get seasonality error")
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetSeasonalityCSV выгружает матрицу сезонности как есть: первая колонка — подписи строк, затем колонки матрицы
+// и Total; последняя строка Total — итоги колонок и общий итог
+func (s *AnalyticService) GetSeasonalityCSV(q analytic.SeasonalityQuery, output io.Writer) error {
+	m, err := s.GetSeasonality(q)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(output)
+	defer writer.Flush()
+
+	corner, _, _ := strings.Cut(string(m.Layout), "_")
+	headers := append(append([]string{corner}, m.Columns...), "Total")
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+	line := func(label string, values []float64, total float64) error {
+		row := []string{label}
+		for _, v := range values {
+			row = append(row, formatMetric(m.Measure, v))
+		}
+		return writer.Write(append(row, formatMetric(m.Measure, total)))
+	}
+	for i, label := range m.Rows {
+		if err := line(label, m.Values[i], m.RowTotals[i]); err != nil {
+			return err
+		}
+	}
+	return line("Total", m.ColumnTotals, m.Total)
+}
+
+// formatMetric форматирует значение метрики для CSV: count — целым числом, остальное — с двумя знаками
+func formatMetric(m analytic.Metric, v float64) string {
+	if m == analytic.MetricCount {
+		return fmt.Sprintf("%d", int(v))
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// normalizeQuery проверяет запрос и подставляет значения по умолчанию
+func normalizeQuery(q analytic.Query) (analytic.Query, error) {
+	if err := q.Validate(); err != nil {
+		return q, err
+	}
+	if q.GroupBy == "" {
+		q.GroupBy = analytic.GroupDay
+	}
+	if q.SplitBy == "" {
+		q.SplitBy = "transtype"
+	}
+	if q.Mode == "" {
+		q.Mode = analytic.ModeSigned
+	}
+	if q.Top > 0 && q.TopBy == "" {
+		q.TopBy = analytic.MetricSum
+	}
+	// Нарастающий итог и скользящие окна нужны в каждом периоде, в том числе без транзакций
+	if (q.Cumulative || len(q.Rolling) > 0) && q.Fill == analytic.FillNone {
+		q.Fill = analytic.FillZero
+	}
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	return q, nil
+}
@@ -3,7 +3,11 @@ package analytics
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"math"
 	"salestracker/internal/domain/analytic"
+	"salestracker/internal/domain/transaction"
+	"strings"
 	"testing"
 	"time"
 )
@@ -12,19 +16,51 @@ import (
 type mockRepo struct {
 	Analytics *analytic.Analytics
 	Err       error
+	Query     analytic.Query
+
+	Pivot      *analytic.Pivot
+	PivotQuery analytic.PivotQuery
+
+	Histogram      *analytic.Histogram
+	HistogramQuery analytic.HistogramQuery
+
+	SeasonalityQuery analytic.SeasonalityQuery
 }
 
-func (m *mockRepo) GetAnalytics(from, to time.Time, groupBy, splitBy, sortBy, sortDir string) (*analytic.Analytics, error) {
+func (m *mockRepo) GetAnalytics(q analytic.Query) (*analytic.Analytics, error) {
+	m.Query = q
 	return m.Analytics, m.Err
 }
 
+func (m *mockRepo) GetPivot(q analytic.PivotQuery) (*analytic.Pivot, error) {
+	m.PivotQuery = q
+	return m.Pivot, m.Err
+}
+
+func (m *mockRepo) GetHistogram(q analytic.HistogramQuery) (*analytic.Histogram, error) {
+	m.HistogramQuery = q
+	return m.Histogram, m.Err
+}
+
+// GetSeasonality заполняет одну ячейку: понедельник 02:00 (или январь, вторник), 2 транзакции на 150
+func (m *mockRepo) GetSeasonality(q analytic.SeasonalityQuery) (*analytic.Seasonality, error) {
+	m.SeasonalityQuery = q
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	res := analytic.NewSeasonality(q)
+	res.Add(1, 2, 2, 150)
+	res.Finish()
+	return res, nil
+}
+
 // --- Helpers ---
 func sampleAnalytics() *analytic.Analytics {
 	return &analytic.Analytics{
 		Groups: []analytic.AnalyticGroup{
 			{
 				GroupKey: "2025-11-27",
-				Data: analytic.AnalyticByType{
+				Data: &analytic.AnalyticByType{
 					Income: analytic.Analytic{
 						Sum: 100, Avg: 50, Count: 2, Median: 50, Percentile90: 90,
 					},
@@ -45,7 +81,7 @@ func TestGetAnalytics_InvalidDateRange(t *testing.T) {
 	from := time.Now()
 	to := from.Add(-time.Hour)
 
-	_, err := svc.GetAnalytics(from, to, "", "", "", "")
+	_, err := svc.GetAnalytics(analytic.Query{From: from, To: to})
 	if err == nil {
 		t.Fatal("expected error for invalid date range")
 	}
@@ -56,7 +92,7 @@ func TestGetAnalytics_RepoError(t *testing.T) {
 	from := time.Now()
 	to := from.Add(time.Hour)
 
-	_, err := svc.GetAnalytics(from, to, "", "", "", "")
+	_, err := svc.GetAnalytics(analytic.Query{From: from, To: to})
 	if err == nil || err.Error() != "repo failure" {
 		t.Fatal("expected repo error")
 	}
@@ -68,7 +104,7 @@ func TestGetAnalytics_Success(t *testing.T) {
 	from := time.Now()
 	to := from.Add(time.Hour)
 
-	result, err := svc.GetAnalytics(from, to, "", "", "", "")
+	result, err := svc.GetAnalytics(analytic.Query{From: from, To: to})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -83,7 +119,7 @@ func TestGetCSV_RepoError(t *testing.T) {
 	from := time.Now()
 	to := from.Add(time.Hour)
 
-	err := svc.GetCSV(from, to, "", "", "", "", &buf)
+	err := svc.GetCSV(analytic.Query{From: from, To: to}, &buf)
 	if err == nil || err.Error() != "repo fail" {
 		t.Fatal("expected repo error")
 	}
@@ -96,7 +132,7 @@ func TestGetCSV_Success(t *testing.T) {
 	from := time.Now()
 	to := from.Add(time.Hour)
 
-	err := svc.GetCSV(from, to, "", "", "", "", &buf)
+	err := svc.GetCSV(analytic.Query{From: from, To: to}, &buf)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -114,4 +150,418 @@ func TestGetCSV_Success(t *testing.T) {
 	if !bytes.Contains([]byte(out), []byte("All")) {
 		t.Fatal("CSV content missing All")
 	}
+	// Строки типов идут в фиксированном порядке при каждой выгрузке
+	for i := 0; i < 20; i++ {
+		buf.Reset()
+		if err := svc.GetCSV(analytic.Query{From: from, To: to}, &buf); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		var types []string
+		for _, line := range lines[1:4] {
+			types = append(types, strings.Split(line, ",")[1])
+		}
+		if strings.Join(types, ",") != "Income,Expense,All" {
+			t.Fatalf("unexpected row order %v", types)
+		}
+	}
+}
+
+func TestGetAnalytics_Defaults(t *testing.T) {
+	repo := &mockRepo{Analytics: sampleAnalytics()}
+	svc := NewAnalyticService(repo)
+	from := time.Now()
+
+	if _, err := svc.GetAnalytics(analytic.Query{From: from, To: from.Add(time.Hour)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Query.GroupBy != "day" || repo.Query.SplitBy != "transtype" || repo.Query.Mode != analytic.ModeSigned || repo.Query.Location != time.UTC {
+		t.Fatalf("unexpected defaults: %+v", repo.Query)
+	}
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	if _, err := svc.GetAnalytics(analytic.Query{From: from, To: from.Add(time.Hour), Location: moscow}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Query.Location != moscow {
+		t.Fatalf("location not passed to repo: %v", repo.Query.Location)
+	}
+}
+
+func TestGetAnalytics_InvalidGroupBy(t *testing.T) {
+	repo := &mockRepo{Analytics: sampleAnalytics()}
+	svc := NewAnalyticService(repo)
+	from := time.Now()
+
+	_, err := svc.GetAnalytics(analytic.Query{From: from, To: from.Add(time.Hour), GroupBy: "fortnight"})
+	if !errors.Is(err, analytic.ErrInvalidQuery) {
+		t.Fatalf("expected ErrInvalidQuery, got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := svc.GetCSV(analytic.Query{From: from.Add(time.Hour), To: from}, &buf); !errors.Is(err, analytic.ErrInvalidQuery) {
+		t.Fatalf("expected ErrInvalidQuery from CSV, got %v", err)
+	}
+}
+
+func TestGetCSV_NullFilledGroup(t *testing.T) {
+	data := sampleAnalytics()
+	data.Groups = append(data.Groups, analytic.AnalyticGroup{GroupKey: "2025-11-28", Filled: true})
+	svc := NewAnalyticService(&mockRepo{Analytics: data})
+	var buf bytes.Buffer
+	from := time.Now()
+
+	if err := svc.GetCSV(analytic.Query{From: from, To: from.Add(time.Hour), Fill: analytic.FillNull}, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("2025-11-28,All,,,,,")) {
+		t.Fatalf("expected empty row for null-filled group, got:\n%s", buf.String())
+	}
+}
+
+func TestGetCSV_Metrics(t *testing.T) {
+	metrics := []analytic.Metric{"p25", analytic.MetricCount, analytic.MetricStddev}
+	a := analytic.AnalyticFromValues(metrics, []float64{1.25, 4, 2}, true)
+	data := &analytic.Analytics{Groups: []analytic.AnalyticGroup{
+		{GroupKey: "2025-11-27", Data: &analytic.AnalyticByType{Income: a, Expense: a, All: a}},
+	}}
+	svc := NewAnalyticService(&mockRepo{Analytics: data})
+	var buf bytes.Buffer
+	from := time.Now()
+
+	if err := svc.GetCSV(analytic.Query{From: from, To: from.Add(time.Hour), Metrics: metrics}, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !bytes.Contains([]byte(out), []byte("GroupKey,Type,p25,count,stddev\n")) {
+		t.Fatalf("unexpected headers:\n%s", out)
+	}
+	if !bytes.Contains([]byte(out), []byte("2025-11-27,All,1.25,4,2.00\n")) {
+		t.Fatalf("unexpected row:\n%s", out)
+	}
+}
+
+// periodRepo отдает аналитику в зависимости от начала периода и запоминает все запросы
+type periodRepo struct {
+	mockRepo
+	byFrom  map[time.Time]*analytic.Analytics
+	queries []analytic.Query
+}
+
+func (r *periodRepo) GetAnalytics(q analytic.Query) (*analytic.Analytics, error) {
+	r.queries = append(r.queries, q)
+	return r.byFrom[q.From], nil
+}
+
+func monthGroups(keys []string, sums []float64, filled []bool) *analytic.Analytics {
+	res := &analytic.Analytics{}
+	for i, k := range keys {
+		data := &analytic.AnalyticByType{Income: analytic.Analytic{Sum: sums[i]}, All: analytic.Analytic{Sum: sums[i]}}
+		res.Groups = append(res.Groups, analytic.AnalyticGroup{GroupKey: k, Data: data, Filled: filled[i]})
+		res.Summary.Income.Sum += sums[i]
+		res.Summary.All.Sum += sums[i]
+	}
+	return res
+}
+
+func TestGetAnalytics_CompareYoY(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond)
+	prevFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &periodRepo{byFrom: map[time.Time]*analytic.Analytics{
+		from:     monthGroups([]string{"2025-01", "2025-02"}, []float64{120, 0}, []bool{false, true}),
+		prevFrom: monthGroups([]string{"2024-01", "2024-02"}, []float64{100, 50}, []bool{false, false}),
+	}}
+	svc := NewAnalyticService(repo)
+
+	res, err := svc.GetAnalytics(analytic.Query{From: from, To: to, GroupBy: analytic.GroupMonth, Compare: analytic.CompareYoY})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.queries) != 2 || repo.queries[0].Fill != analytic.FillZero || repo.queries[1].Compare != analytic.CompareNone {
+		t.Fatalf("unexpected repo queries %+v", repo.queries)
+	}
+	// Февраль 2025 пуст и fill не запрошен — в ответе только январь
+	if len(res.Groups) != 1 || res.Groups[0].CompareKey != "2024-01" {
+		t.Fatalf("unexpected groups %+v", res.Groups)
+	}
+	if c := res.Groups[0].Compare.Income[analytic.MetricSum]; c.Previous != 100 || c.Delta != 20 || *c.Percent != 20 {
+		t.Errorf("unexpected group change %+v", c)
+	}
+	cmp := res.Comparison
+	if cmp == nil || !cmp.From.Equal(prevFrom) || cmp.Summary.All[analytic.MetricSum].Previous != 150 {
+		t.Fatalf("unexpected comparison %+v", cmp)
+	}
+
+	var buf bytes.Buffer
+	if err := svc.GetCSV(analytic.Query{From: from, To: to, GroupBy: analytic.GroupMonth, Compare: analytic.CompareYoY}, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !bytes.Contains([]byte(out), []byte("GroupKey,Type,CompareKey,Sum,Sum_compare,Sum_delta,Sum_pct,Avg,")) ||
+		!bytes.Contains([]byte(out), []byte("2025-01,Income,2024-01,120.00,100.00,20.00,20.00,")) {
+		t.Fatalf("unexpected CSV:\n%s", out)
+	}
+}
+
+func TestGetCSV_Cumulative(t *testing.T) {
+	data := sampleAnalytics()
+	data.Groups[0].Running = &analytic.RunningTotals{Income: 1100, Expense: 340, Net: 760}
+	repo := &mockRepo{Analytics: data}
+	svc := NewAnalyticService(repo)
+	var buf bytes.Buffer
+	from := time.Now()
+
+	if err := svc.GetCSV(analytic.Query{From: from, To: from.Add(time.Hour), Cumulative: true}, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Query.Fill != analytic.FillZero {
+		t.Errorf("cumulative must fill empty periods by default, got fill %q", repo.Query.Fill)
+	}
+	out := buf.String()
+	if !bytes.Contains([]byte(out), []byte("Percentile90,Running\n")) || !bytes.Contains([]byte(out), []byte(",760.00\n")) ||
+		!bytes.Contains([]byte(out), []byte(",340.00\n")) {
+		t.Fatalf("unexpected CSV:\n%s", out)
+	}
+}
+
+func TestGetCSV_Rolling(t *testing.T) {
+	data := sampleAnalytics()
+	data.Groups[0].Rolling = map[string]*analytic.RollingByType{
+		"7d": {All: analytic.RollingValue{Sum: 70, Avg: 10, Count: 9}},
+	}
+	data.Groups = append(data.Groups, analytic.AnalyticGroup{GroupKey: "2025-11-28", Filled: true, Rolling: data.Groups[0].Rolling})
+	repo := &mockRepo{Analytics: data}
+	svc := NewAnalyticService(repo)
+	var buf bytes.Buffer
+	from := time.Now()
+
+	q := analytic.Query{From: from, To: from.Add(time.Hour), Rolling: []analytic.Window{{N: 7, Unit: 'd'}, {N: 30, Unit: 'd'}}}
+	if err := svc.GetCSV(q, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Query.Fill != analytic.FillZero {
+		t.Errorf("rolling must fill empty periods by default, got fill %q", repo.Query.Fill)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Percentile90,Rolling7d_sum,Rolling7d_avg,Rolling7d_count,Rolling30d_sum,Rolling30d_avg,Rolling30d_count\n",
+		"2025-11-27,All,60.00,30.00,4,35.00,62.50,70.00,10.00,9,,,\n",
+		"2025-11-28,All,,,,,,70.00,10.00,9,,,\n",
+	} {
+		if !bytes.Contains([]byte(out), []byte(want)) {
+			t.Fatalf("CSV misses %q:\n%s", want, out)
+		}
+	}
+}
+
+// typeRepo отдает историю по типу транзакций из фильтра запроса
+type typeRepo struct {
+	mockRepo
+	byType  map[transaction.TransactionType]*analytic.Analytics
+	queries []analytic.Query
+}
+
+func (r *typeRepo) GetAnalytics(q analytic.Query) (*analytic.Analytics, error) {
+	r.queries = append(r.queries, q)
+	return r.byType[q.Filter.Type], nil
+}
+
+func TestForecast_Series(t *testing.T) {
+	expenses := &analytic.Analytics{}
+	for i := 0; i < 6; i++ {
+		expenses.Groups = append(expenses.Groups, analytic.AnalyticGroup{
+			GroupKey: fmt.Sprintf("2025-%02d", i+1),
+			Data: &analytic.AnalyticByType{AllMap: map[string]analytic.Analytic{
+				"food": {Sum: 100 + 10*float64(i)},
+				"rent": {Sum: 500},
+			}},
+		})
+	}
+	repo := &typeRepo{byType: map[transaction.TransactionType]*analytic.Analytics{transaction.Expense: expenses}}
+	svc := NewAnalyticService(repo)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond)
+	res, err := svc.Forecast(analytic.ForecastQuery{From: from, To: to, Horizon: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.queries) != 2 || repo.queries[0].SplitBy != "category" || repo.queries[0].Mode != analytic.ModeAbsolute ||
+		repo.queries[0].Fill != analytic.FillZero || repo.queries[1].Filter.Type != transaction.Expense {
+		t.Fatalf("unexpected repo queries %+v", repo.queries)
+	}
+	// Доходов нет — только итог расходов и две категории по алфавиту
+	if len(res.Series) != 3 || res.Series[0].Category != "" || res.Series[1].Category != "food" || res.Series[2].Category != "rent" {
+		t.Fatalf("unexpected series %+v", res.Series)
+	}
+	total := res.Series[0]
+	if total.Model != analytic.ModelHolt || total.Points[0].Period != "2025-07" || math.Abs(total.Points[0].Value-660) > 1e-6 {
+		t.Errorf("unexpected total forecast %+v", total)
+	}
+	if p := res.Series[2].Points[1]; math.Abs(p.Value-500) > 1e-6 || p.Period != "2025-08" {
+		t.Errorf("unexpected rent forecast %+v", p)
+	}
+}
+
+func TestForecast_InvalidQuery(t *testing.T) {
+	svc := NewAnalyticService(&mockRepo{})
+	if _, err := svc.Forecast(analytic.ForecastQuery{GroupBy: analytic.GroupYear}); !errors.Is(err, analytic.ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestGetPivotCSV(t *testing.T) {
+	repo := &mockRepo{Pivot: &analytic.Pivot{
+		Rows: []analytic.Dimension{analytic.DimCategory}, Columns: []analytic.Dimension{analytic.DimPeriod},
+		Metrics: []analytic.Metric{analytic.MetricSum, analytic.MetricCount},
+		Cells: []analytic.PivotCell{
+			{Row: []string{"food"}, Column: []string{"2025-01"}, Values: map[analytic.Metric]float64{"sum": 40, "count": 2}},
+		},
+		RowTotals:    []analytic.PivotCell{{Row: []string{"food"}, Values: map[analytic.Metric]float64{"sum": 40, "count": 2}}},
+		ColumnTotals: []analytic.PivotCell{{Column: []string{"2025-01"}, Values: map[analytic.Metric]float64{"sum": 40, "count": 2}}},
+		GrandTotal:   map[analytic.Metric]float64{"sum": 40, "count": 2},
+	}}
+	svc := NewAnalyticService(repo)
+	var buf bytes.Buffer
+
+	q := analytic.PivotQuery{Rows: []analytic.Dimension{analytic.DimCategory}, Columns: []analytic.Dimension{analytic.DimPeriod}}
+	if err := svc.GetPivotCSV(q, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.PivotQuery.Period != analytic.GroupMonth || repo.PivotQuery.Mode != analytic.ModeSigned || len(repo.PivotQuery.Metrics) != 1 {
+		t.Errorf("unexpected pivot defaults %+v", repo.PivotQuery)
+	}
+	want := "Level,category,period,sum,count\n" +
+		"cell,food,2025-01,40.00,2\n" +
+		"row_total,food,,40.00,2\n" +
+		"column_total,,2025-01,40.00,2\n" +
+		"grand_total,,,40.00,2\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+
+	q.Columns = []analytic.Dimension{analytic.DimCategory}
+	if _, err := svc.GetPivot(q); !errors.Is(err, analytic.ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery for a repeated dimension, got %v", err)
+	}
+}
+
+func TestGetCSV_Nested(t *testing.T) {
+	child := analytic.AnalyticGroup{GroupKey: "food", Dimension: analytic.GroupCategory, Data: &analytic.AnalyticByType{
+		Expense: analytic.Analytic{Sum: 40, Count: 1}, All: analytic.Analytic{Sum: -40, Count: 1},
+	}}
+	repo := &mockRepo{Analytics: &analytic.Analytics{Groups: []analytic.AnalyticGroup{{
+		GroupKey: "2025-02", Dimension: analytic.GroupMonth, Children: []analytic.AnalyticGroup{child},
+		Data: &analytic.AnalyticByType{Expense: analytic.Analytic{Sum: 40, Count: 1}, All: analytic.Analytic{Sum: -40, Count: 1}},
+	}}}}
+	svc := NewAnalyticService(repo)
+	var buf bytes.Buffer
+	from := time.Now()
+
+	q := analytic.Query{From: from, To: from.Add(time.Hour), GroupBy: analytic.GroupMonth, Nested: []analytic.GroupBy{analytic.GroupCategory},
+		Metrics: []analytic.Metric{analytic.MetricSum, analytic.MetricCount}}
+	if err := svc.GetCSV(q, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "month,category,Type,sum,count\n" +
+		"2025-02,,Income,0.00,0\n" +
+		"2025-02,,Expense,40.00,1\n" +
+		"2025-02,,All,-40.00,1\n" +
+		"2025-02,food,Income,0.00,0\n" +
+		"2025-02,food,Expense,40.00,1\n" +
+		"2025-02,food,All,-40.00,1\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+	if len(repo.Query.Nested) != 1 {
+		t.Errorf("nested levels must reach the repository, got %+v", repo.Query)
+	}
+}
+
+func TestGetCSV_Top(t *testing.T) {
+	metrics := []analytic.Metric{analytic.MetricSum, analytic.MetricCount}
+	sales := analytic.AnalyticFromValues(metrics, []float64{160, 2}, true)
+	other := analytic.AnalyticFromValues(metrics, []float64{-55, 3}, true)
+	all := analytic.AnalyticFromValues(metrics, []float64{105, 5}, true)
+	repo := &mockRepo{Analytics: &analytic.Analytics{Groups: []analytic.AnalyticGroup{{
+		GroupKey: analytic.AllGroupKey,
+		Data: &analytic.AnalyticByType{All: all, Splits: []analytic.AnalyticSplit{
+			{Key: "sales", Data: sales},
+			{Key: "Other", Data: sales}, // настоящая категория с именем Other не путается со свернутым разбиением
+			{Key: analytic.OtherSplitKey, Other: true, Data: other},
+		}},
+	}}}}
+	svc := NewAnalyticService(repo)
+	var buf bytes.Buffer
+	from := time.Now()
+
+	q := analytic.Query{From: from, To: from.Add(time.Hour), GroupBy: analytic.GroupNone, SplitBy: "category", Top: 1, Metrics: metrics}
+	if err := svc.GetCSV(q, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, line := range []string{"GroupKey,Type,Split,Other,sum,count\n", "all,All,,,105.00,5\n", "all,All,sales,,160.00,2\nall,All,Other,,160.00,2\nall,All,,true,-55.00,3\n"} {
+		if !strings.Contains(out, line) {
+			t.Fatalf("expected %q in CSV:\n%s", line, out)
+		}
+	}
+	if repo.Query.TopBy != analytic.MetricSum {
+		t.Errorf("expected topBy to default to sum, got %q", repo.Query.TopBy)
+	}
+}
+
+func TestGetHistogram_Defaults(t *testing.T) {
+	repo := &mockRepo{Histogram: &analytic.Histogram{}}
+	svc := NewAnalyticService(repo)
+	from := time.Now()
+
+	if _, err := svc.GetHistogram(analytic.HistogramQuery{From: from, To: from.Add(time.Hour)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.HistogramQuery.Buckets != analytic.DefaultHistogramBuckets || repo.HistogramQuery.Bucketing != analytic.BucketFixed {
+		t.Errorf("expected defaults to reach the repository, got %+v", repo.HistogramQuery)
+	}
+
+	if _, err := svc.GetHistogram(analytic.HistogramQuery{From: from, To: from.Add(time.Hour), Bucketing: "sqrt"}); !errors.Is(err, analytic.ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestGetSeasonalityCSV(t *testing.T) {
+	repo := &mockRepo{}
+	svc := NewAnalyticService(repo)
+	var buf bytes.Buffer
+	from := time.Now()
+
+	q := analytic.SeasonalityQuery{From: from, To: from.Add(time.Hour), Layout: analytic.LayoutMonthWeekday, Measure: analytic.MetricAvg}
+	if err := svc.GetSeasonalityCSV(q, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 14 {
+		t.Fatalf("expected header, 12 months and totals, got:\n%s", buf.String())
+	}
+	if lines[0] != "month,Mon,Tue,Wed,Thu,Fri,Sat,Sun,Total" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if lines[1] != "Jan,0.00,75.00,0.00,0.00,0.00,0.00,0.00,75.00" || lines[13] != "Total,0.00,75.00,0.00,0.00,0.00,0.00,0.00,75.00" {
+		t.Errorf("unexpected rows %q %q", lines[1], lines[13])
+	}
+	if repo.SeasonalityQuery.Location != time.UTC {
+		t.Errorf("expected UTC by default, got %v", repo.SeasonalityQuery.Location)
+	}
+
+	repo.SeasonalityQuery = analytic.SeasonalityQuery{}
+	buf.Reset()
+	if err := svc.GetSeasonalityCSV(analytic.SeasonalityQuery{From: from, To: from.Add(time.Hour)}, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "weekday,00,01,02,03,04,05,06,07,08,09,10,11,12,13,14,15,16,17,18,19,20,21,22,23,Total" ||
+		lines[1] != "Mon,0,0,2"+strings.Repeat(",0", 21)+",2" || lines[8] != "Total,0,0,2"+strings.Repeat(",0", 21)+",2" {
+		t.Errorf("unexpected count rows:\n%s", buf.String())
+	}
 }
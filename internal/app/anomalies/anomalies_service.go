@@ -0,0 +1,222 @@
+package anomalies
+
+import (
+	"fmt"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"math"
+	"salestracker/internal/domain/anomaly"
+	"salestracker/internal/domain/transaction"
+	"sort"
+	"time"
+)
+
+type AnomalyService struct {
+	repo AnomalyStorageProvider
+}
+
+type AnomalyStorageProvider interface {
+	GetAllTransactions(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error)
+	GetAnomalyAcks() (map[string]*anomaly.Ack, error)
+	AcknowledgeAnomaly(a *anomaly.Ack) error
+	UnacknowledgeAnomaly(id string) error
+}
+
+func NewAnomalyService(repo AnomalyStorageProvider) *AnomalyService {
+	return &AnomalyService{
+		repo: repo,
+	}
+}
+
+// GetAnomalies ищет нетипичные транзакции и дни за период запроса. Статистика категорий строится по транзакциям
+// периода и 90 дням до него, ожидаемый диапазон дня — по 28 предыдущим дням
+func (s *AnomalyService) GetAnomalies(q anomaly.Query) ([]*anomaly.Anomaly, error) {
+	q, err := q.Normalize(time.Now())
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid anomalies request")
+		return nil, err
+	}
+
+	trs, err := s.repo.GetAllTransactions(q.From.Add(-anomaly.TransactionLookback), q.To, q.Filter, "date", "asc")
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get all transactions error")
+		return nil, err
+	}
+	acks, err := s.repo.GetAnomalyAcks()
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get anomaly acks error")
+		return nil, err
+	}
+
+	var found []*anomaly.Anomaly
+	if q.Kind != anomaly.KindDay {
+		found = append(found, transactionAnomalies(trs, q)...)
+	}
+	if q.Kind != anomaly.KindTransaction {
+		found = append(found, dayAnomalies(trs, q)...)
+	}
+
+	result := []*anomaly.Anomaly{}
+	for _, a := range found {
+		if ack, ok := acks[a.ID]; ok {
+			if !q.IncludeAcknowledged {
+				continue
+			}
+			at := ack.AcknowledgedAt
+			a.Acknowledged, a.AcknowledgedAt, a.Note = true, &at, ack.Note
+		}
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Date.After(result[j].Date)
+	})
+	return result, nil
+}
+
+func (s *AnomalyService) Acknowledge(id, note string) (*anomaly.Ack, error) {
+	if err := anomaly.ValidateID(id); err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid anomaly id")
+		return nil, err
+	}
+	ack := &anomaly.Ack{ID: id, Note: note, AcknowledgedAt: time.Now().UTC()}
+	if err := s.repo.AcknowledgeAnomaly(ack); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo acknowledge anomaly error")
+		return nil, err
+	}
+	return ack, nil
+}
+
+func (s *AnomalyService) Unacknowledge(id string) error {
+	if err := anomaly.ValidateID(id); err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid anomaly id")
+		return err
+	}
+	if err := s.repo.UnacknowledgeAnomaly(id); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo unacknowledge anomaly error")
+		return err
+	}
+	return nil
+}
+
+// transactionAnomalies сравнивает сумму каждой транзакции периода с суммами ее типа и категории
+func transactionAnomalies(trs []*transaction.Transaction, q anomaly.Query) []*anomaly.Anomaly {
+	amounts := map[string][]float64{}
+	for _, tr := range trs {
+		key := string(tr.Type) + ":" + tr.Category
+		amounts[key] = append(amounts[key], tr.Amount)
+	}
+	stats := make(map[string]anomaly.Stats, len(amounts))
+	for key, values := range amounts {
+		if len(values) >= anomaly.MinSamples {
+			stats[key] = anomaly.NewStats(values)
+		}
+	}
+
+	var result []*anomaly.Anomaly
+	for _, tr := range trs {
+		if tr.Date.Before(q.From) {
+			continue
+		}
+		st, ok := stats[string(tr.Type)+":"+tr.Category]
+		if !ok || !st.Outlier(tr.Amount, q.Threshold) {
+			continue
+		}
+		lower, upper := st.Range(q.Threshold)
+		id := tr.ID
+		result = append(result, &anomaly.Anomaly{
+			ID:            anomaly.TransactionAnomalyID(tr.ID),
+			Kind:          anomaly.KindTransaction,
+			Type:          tr.Type,
+			Category:      tr.Category,
+			TransactionID: &id,
+			Date:          tr.Date,
+			Value:         tr.Amount,
+			Expected:      round2(st.Median),
+			Lower:         round2(math.Max(lower, 0)),
+			Upper:         round2(upper),
+			Score:         round2(math.Abs(st.Score(tr.Amount))),
+			Reason:        fmt.Sprintf("%s amount %.2f %s for category %q (%d transactions)", tr.Type, tr.Amount, deviation(tr.Amount, st), tr.Category, st.N),
+		})
+	}
+	return result
+}
+
+// dayAnomalies сравнивает итог каждого дня периода по типу с итогами предыдущих DayBaseline дней, включая пустые.
+// День не проверяется, если транзакции были меньше чем в MinSamples днях базы
+func dayAnomalies(trs []*transaction.Transaction, q anomaly.Query) []*anomaly.Anomaly {
+	types := []transaction.TransactionType{transaction.Income, transaction.Expense}
+	if q.Filter.Type != "" {
+		types = []transaction.TransactionType{q.Filter.Type}
+	}
+	totals := map[transaction.TransactionType]map[time.Time]float64{}
+	for _, typ := range types {
+		totals[typ] = map[time.Time]float64{}
+	}
+	for _, tr := range trs {
+		if byDay, ok := totals[tr.Type]; ok {
+			byDay[dayOf(tr.Date, q.Location)] += tr.Amount
+		}
+	}
+
+	var result []*anomaly.Anomaly
+	last := dayOf(q.To, q.Location)
+	for day := dayOf(q.From, q.Location); !day.After(last); day = day.AddDate(0, 0, 1) {
+		for _, typ := range types {
+			baseline := make([]float64, 0, anomaly.DayBaseline)
+			active := 0
+			for i := anomaly.DayBaseline; i >= 1; i-- {
+				v := totals[typ][day.AddDate(0, 0, -i)]
+				baseline = append(baseline, v)
+				if v != 0 {
+					active++
+				}
+			}
+			if active < anomaly.MinSamples {
+				continue
+			}
+			total := totals[typ][day]
+			st := anomaly.NewStats(baseline)
+			if !st.Outlier(total, q.Threshold) {
+				continue
+			}
+			lower, upper := st.Range(q.Threshold)
+			result = append(result, &anomaly.Anomaly{
+				ID:       anomaly.DayAnomalyID(typ, day),
+				Kind:     anomaly.KindDay,
+				Type:     typ,
+				Date:     day,
+				Value:    round2(total),
+				Expected: round2(st.Median),
+				Lower:    round2(math.Max(lower, 0)),
+				Upper:    round2(upper),
+				Score:    round2(math.Abs(st.Score(total))),
+				Reason:   fmt.Sprintf("daily %s total %.2f %s over the previous %d days", typ, total, deviation(total, st), anomaly.DayBaseline),
+			})
+		}
+	}
+	return result
+}
+
+// deviation описывает отклонение от медианы: во сколько раз и на сколько робастных СКО
+func deviation(x float64, st anomaly.Stats) string {
+	dir := "above"
+	if x < st.Median {
+		dir = "below"
+	}
+	s := fmt.Sprintf("is %.1f robust SD %s the median %.2f", math.Abs(st.Score(x)), dir, st.Median)
+	if st.Median > 0 && x >= 2*st.Median {
+		s += fmt.Sprintf(", %.1fx the median", x/st.Median)
+	}
+	return s
+}
+
+func dayOf(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,110 @@
+package anomalies
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"salestracker/internal/domain/anomaly"
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+// --- Mock repository ---
+type mockRepo struct {
+	trs   []*transaction.Transaction
+	acks  map[string]*anomaly.Ack
+	from  time.Time
+	saved *anomaly.Ack
+}
+
+func (m *mockRepo) GetAllTransactions(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error) {
+	m.from = from
+	return m.trs, nil
+}
+
+func (m *mockRepo) GetAnomalyAcks() (map[string]*anomaly.Ack, error) {
+	return m.acks, nil
+}
+
+func (m *mockRepo) AcknowledgeAnomaly(a *anomaly.Ack) error {
+	m.saved = a
+	return nil
+}
+
+func (m *mockRepo) UnacknowledgeAnomaly(id string) error {
+	return nil
+}
+
+func tr(typ transaction.TransactionType, category string, amount float64, date time.Time) *transaction.Transaction {
+	return &transaction.Transaction{ID: uuid.New(), Type: typ, Category: category, Amount: amount, Date: date}
+}
+
+// history — расход на продукты каждый день января 2025 около 500, в последний день — лишний ноль
+func history() []*transaction.Transaction {
+	var trs []*transaction.Transaction
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		trs = append(trs, tr(transaction.Expense, "food", 480+float64(i%5)*10, start.AddDate(0, 0, i)))
+	}
+	return append(trs, tr(transaction.Expense, "food", 5000, start.AddDate(0, 0, 30)))
+}
+
+func TestGetAnomalies_FatFinger(t *testing.T) {
+	repo := &mockRepo{trs: history()}
+	svc := NewAnomalyService(repo)
+	from := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24*time.Hour - time.Microsecond)
+
+	res, err := svc.GetAnomalies(anomaly.Query{From: from, To: to})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !repo.from.Equal(from.Add(-anomaly.TransactionLookback)) {
+		t.Errorf("history must include lookback, got from %v", repo.from)
+	}
+	if len(res) != 2 {
+		t.Fatalf("expected transaction and day anomalies, got %+v", res)
+	}
+	kinds := map[anomaly.Kind]*anomaly.Anomaly{}
+	for _, a := range res {
+		kinds[a.Kind] = a
+	}
+	txa, day := kinds[anomaly.KindTransaction], kinds[anomaly.KindDay]
+	if txa == nil || txa.Value != 5000 || txa.Expected != 500 || txa.Score < anomaly.DefaultThreshold || txa.Reason == "" {
+		t.Errorf("unexpected transaction anomaly %+v", txa)
+	}
+	if day == nil || day.ID != "day-expense-2025-01-31" || day.Value != 5000 {
+		t.Errorf("unexpected day anomaly %+v", day)
+	}
+
+	// Подтвержденная аномалия скрыта, но возвращается с acknowledged
+	repo.acks = map[string]*anomaly.Ack{day.ID: {ID: day.ID, Note: "bulk purchase", AcknowledgedAt: time.Now()}}
+	if res, _ = svc.GetAnomalies(anomaly.Query{From: from, To: to}); len(res) != 1 || res[0].Kind != anomaly.KindTransaction {
+		t.Errorf("acknowledged anomaly must be hidden, got %+v", res)
+	}
+	res, _ = svc.GetAnomalies(anomaly.Query{From: from, To: to, Kind: anomaly.KindDay, IncludeAcknowledged: true})
+	if len(res) != 1 || !res[0].Acknowledged || res[0].Note != "bulk purchase" {
+		t.Errorf("unexpected acknowledged anomalies %+v", res)
+	}
+}
+
+func TestGetAnomalies_NotEnoughHistory(t *testing.T) {
+	trs := history()[25:]
+	svc := NewAnomalyService(&mockRepo{trs: trs})
+	res, err := svc.GetAnomalies(anomaly.Query{From: trs[0].Date, To: trs[len(trs)-1].Date})
+	if err != nil || len(res) != 0 {
+		t.Errorf("expected no anomalies on short history, got %+v %v", res, err)
+	}
+}
+
+func TestAcknowledge_InvalidID(t *testing.T) {
+	repo := &mockRepo{}
+	svc := NewAnomalyService(repo)
+	if _, err := svc.Acknowledge("42", ""); !errors.Is(err, anomaly.ErrInvalidID) || repo.saved != nil {
+		t.Errorf("expected ErrInvalidID, got %v", err)
+	}
+	ack, err := svc.Acknowledge("day-income-2025-02-12", "promo")
+	if err != nil || repo.saved != ack || ack.Note != "promo" {
+		t.Errorf("unexpected ack %+v %v", ack, err)
+	}
+}
@@ -0,0 +1,161 @@
+package budgets
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"math"
+	"salestracker/internal/domain/analytic"
+	"salestracker/internal/domain/budget"
+	"salestracker/internal/domain/transaction"
+	"time"
+)
+
+type BudgetService struct {
+	repo    BudgetStorageProvider
+	actuals ActualsProvider
+}
+
+type BudgetStorageProvider interface {
+	SaveBudget(b *budget.Budget) error
+	GetBudget(id string) (*budget.Budget, error)
+	GetBudgets() ([]*budget.Budget, error)
+	UpdateBudget(b *budget.Budget) error
+	DeleteBudget(id string) error
+}
+
+// ActualsProvider отдает фактические агрегаты по категориям из аналитического хранилища
+type ActualsProvider interface {
+	GetCategoryTotals(from, to time.Time, trType string) (map[string]analytic.Analytic, error)
+}
+
+func NewBudgetService(repo BudgetStorageProvider, actuals ActualsProvider) *BudgetService {
+	return &BudgetService{
+		repo:    repo,
+		actuals: actuals,
+	}
+}
+
+func (s *BudgetService) CreateBudget(category, period string, amount float64) (*budget.Budget, error) {
+	b, err := budget.NewBudget(category, budget.Period(period), amount)
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid data for new budget")
+		return nil, err
+	}
+	if err := s.repo.SaveBudget(b); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo save budget error")
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *BudgetService) GetBudgets() ([]*budget.Budget, error) {
+	bs, err := s.repo.GetBudgets()
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get budgets error")
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (s *BudgetService) PutBudget(id, category, period string, amount float64) (*budget.Budget, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
+		return nil, err
+	}
+	b, err := s.repo.GetBudget(id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get (for put) budget error")
+		return nil, err
+	}
+	if b == nil {
+		return nil, errors.New("budget not found")
+	}
+	if err := b.BudgetChange(category, budget.Period(period), amount); err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid data for budget change")
+		return nil, err
+	}
+	if err := s.repo.UpdateBudget(b); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo update budget error")
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *BudgetService) DeleteBudget(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
+		return err
+	}
+	if err := s.repo.DeleteBudget(id); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo delete budget error")
+		return err
+	}
+	return nil
+}
+
+// GetReport сравнивает план с фактом за периоды, в которые попадает date.
+// Прогноз на конец периода считается по текущему темпу расходов (run-rate).
+func (s *BudgetService) GetReport(date time.Time, period string) (*budget.Report, error) {
+	if date.IsZero() {
+		date = time.Now()
+	}
+	bs, err := s.repo.GetBudgets()
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get budgets error")
+		return nil, err
+	}
+
+	report := &budget.Report{Date: date, Items: []budget.ReportItem{}}
+	totals := map[budget.Period]map[string]analytic.Analytic{}
+
+	for _, b := range bs {
+		if period != "" && string(b.Period) != period {
+			continue
+		}
+		start, end := b.Period.Bounds(date)
+		if _, ok := totals[b.Period]; !ok {
+			t, err := s.actuals.GetCategoryTotals(start, end, string(transaction.Expense))
+			if err != nil {
+				wbzlog.Logger.Error().Err(err).Msg("category totals error")
+				return nil, err
+			}
+			totals[b.Period] = t
+		}
+
+		actual := totals[b.Period][b.Category].Sum
+		projected := projectRunRate(actual, start, end, date)
+		report.Items = append(report.Items, budget.ReportItem{
+			BudgetID:          b.ID,
+			Category:          b.Category,
+			Period:            b.Period,
+			PeriodStart:       start,
+			PeriodEnd:         end,
+			Planned:           b.Amount,
+			Actual:            actual,
+			Variance:          round2(b.Amount - actual),
+			PercentConsumed:   round2(actual / b.Amount * 100),
+			Projected:         round2(projected),
+			ProjectedVariance: round2(b.Amount - projected),
+		})
+	}
+
+	return report, nil
+}
+
+// projectRunRate экстраполирует факт на весь период по доле прошедших дней (включая день date)
+func projectRunRate(actual float64, start, end, date time.Time) float64 {
+	if !date.Before(end) {
+		return actual
+	}
+	totalDays := end.Sub(start).Hours() / 24
+	elapsedDays := math.Floor(date.Sub(start).Hours()/24) + 1
+	if elapsedDays <= 0 {
+		return actual
+	}
+	return actual / elapsedDays * totalDays
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
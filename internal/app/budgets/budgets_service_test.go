@@ -0,0 +1,149 @@
+package budgets
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"salestracker/internal/domain/analytic"
+	"salestracker/internal/domain/budget"
+	"testing"
+	"time"
+)
+
+// --- Mock repository ---
+type mockRepo struct {
+	GetB      *budget.Budget
+	Budgets   []*budget.Budget
+	Err       error
+	SavedB    *budget.Budget
+	UpdatedB  *budget.Budget
+	DeletedID string
+}
+
+func (m *mockRepo) SaveBudget(b *budget.Budget) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.SavedB = b
+	return nil
+}
+func (m *mockRepo) GetBudget(id string) (*budget.Budget, error) {
+	return m.GetB, m.Err
+}
+func (m *mockRepo) GetBudgets() ([]*budget.Budget, error) {
+	return m.Budgets, m.Err
+}
+func (m *mockRepo) UpdateBudget(b *budget.Budget) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.UpdatedB = b
+	return nil
+}
+func (m *mockRepo) DeleteBudget(id string) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.DeletedID = id
+	return nil
+}
+
+type mockActuals struct {
+	Totals   map[string]analytic.Analytic
+	Err      error
+	From, To time.Time
+	TrType   string
+}
+
+func (m *mockActuals) GetCategoryTotals(from, to time.Time, trType string) (map[string]analytic.Analytic, error) {
+	m.From, m.To, m.TrType = from, to, trType
+	return m.Totals, m.Err
+}
+
+func TestCreateBudget_Invalid(t *testing.T) {
+	svc := NewBudgetService(&mockRepo{}, &mockActuals{})
+	if _, err := svc.CreateBudget("marketing", "week", 100); err == nil {
+		t.Fatal("expected error for invalid period")
+	}
+}
+
+func TestCreateBudget_Success(t *testing.T) {
+	repo := &mockRepo{}
+	svc := NewBudgetService(repo, &mockActuals{})
+	b, err := svc.CreateBudget("marketing", "month", 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.SavedB != b {
+		t.Fatal("budget not saved")
+	}
+}
+
+func TestPutBudget_NotFound(t *testing.T) {
+	svc := NewBudgetService(&mockRepo{}, &mockActuals{})
+	if _, err := svc.PutBudget(uuid.New().String(), "marketing", "month", 100); err == nil {
+		t.Fatal("expected error for missing budget")
+	}
+}
+
+func TestDeleteBudget_InvalidUUID(t *testing.T) {
+	svc := NewBudgetService(&mockRepo{}, &mockActuals{})
+	if err := svc.DeleteBudget("bad-uuid"); err == nil {
+		t.Fatal("expected error for invalid UUID")
+	}
+}
+
+func TestGetReport_Success(t *testing.T) {
+	b, _ := budget.NewBudget("marketing", budget.Month, 3000)
+	actuals := &mockActuals{Totals: map[string]analytic.Analytic{"marketing": {Sum: 1000}}}
+	svc := NewBudgetService(&mockRepo{Budgets: []*budget.Budget{b}}, actuals)
+
+	// 10 из 30 дней ноября прошли — темп 100/день, прогноз 3000
+	date := time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC)
+	report, err := svc.GetReport(date, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actuals.TrType != "expense" || !actuals.From.Equal(time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("unexpected totals request")
+	}
+	if len(report.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(report.Items))
+	}
+	item := report.Items[0]
+	if item.Actual != 1000 || item.Variance != 2000 || item.PercentConsumed != 33.33 {
+		t.Fatalf("unexpected plan/fact values: %+v", item)
+	}
+	if item.Projected != 3000 || item.ProjectedVariance != 0 {
+		t.Fatalf("unexpected projection: %+v", item)
+	}
+}
+
+func TestGetReport_PeriodFilter(t *testing.T) {
+	m, _ := budget.NewBudget("marketing", budget.Month, 3000)
+	y, _ := budget.NewBudget("marketing", budget.Year, 30000)
+	svc := NewBudgetService(&mockRepo{Budgets: []*budget.Budget{m, y}}, &mockActuals{})
+
+	report, err := svc.GetReport(time.Now(), "year")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Items) != 1 || report.Items[0].Period != budget.Year {
+		t.Fatal("expected only yearly budget in report")
+	}
+}
+
+func TestGetReport_ActualsError(t *testing.T) {
+	b, _ := budget.NewBudget("marketing", budget.Month, 3000)
+	svc := NewBudgetService(&mockRepo{Budgets: []*budget.Budget{b}}, &mockActuals{Err: errors.New("fail")})
+	if _, err := svc.GetReport(time.Now(), ""); err == nil || err.Error() != "fail" {
+		t.Fatal("expected actuals error")
+	}
+}
+
+func TestProjectRunRate_PastPeriod(t *testing.T) {
+	start := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	if projectRunRate(500, start, end, end.AddDate(0, 0, 5)) != 500 {
+		t.Fatal("closed period must project to its actual")
+	}
+}
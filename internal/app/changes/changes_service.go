@@ -0,0 +1,56 @@
+package changes
+
+import (
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/change"
+)
+
+const (
+	DefaultPageSize = 500
+	MaxPageSize     = 5000
+)
+
+type ChangeService struct {
+	repo ChangeStorageProvider
+}
+
+type ChangeStorageProvider interface {
+	GetChanges(since int64, limit int) ([]change.Change, error)
+}
+
+func NewChangeService(repo ChangeStorageProvider) *ChangeService {
+	return &ChangeService{
+		repo: repo,
+	}
+}
+
+// GetChanges возвращает страницу ленты изменений после курсора since. Если изменений нет,
+// NextCursor совпадает с since, чтобы клиент мог просто повторить запрос позже
+func (s *ChangeService) GetChanges(since int64, limit int) (*change.Feed, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	// Берем на одну запись больше, чтобы без отдельного запроса понять, есть ли следующая страница
+	changes, err := s.repo.GetChanges(since, limit+1)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get changes error")
+		return nil, err
+	}
+
+	feed := &change.Feed{Changes: changes, NextCursor: change.FormatCursor(since)}
+	if len(changes) > limit {
+		feed.Changes = changes[:limit]
+		feed.HasMore = true
+	}
+	if feed.Changes == nil {
+		feed.Changes = []change.Change{}
+	}
+	if n := len(feed.Changes); n > 0 {
+		feed.NextCursor = change.FormatCursor(feed.Changes[n-1].Seq)
+	}
+	return feed, nil
+}
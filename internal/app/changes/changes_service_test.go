@@ -0,0 +1,100 @@
+package changes
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"salestracker/internal/domain/change"
+	"testing"
+)
+
+// --- Mock repository ---
+type mockRepo struct {
+	Changes  []change.Change
+	GotSince int64
+	GotLimit int
+	Err      error
+}
+
+func (m *mockRepo) GetChanges(since int64, limit int) ([]change.Change, error) {
+	m.GotSince, m.GotLimit = since, limit
+	var out []change.Change
+	for _, c := range m.Changes {
+		if c.Seq > since && len(out) < limit {
+			out = append(out, c)
+		}
+	}
+	return out, m.Err
+}
+
+func seqChanges(seqs ...int64) []change.Change {
+	out := make([]change.Change, 0, len(seqs))
+	for _, s := range seqs {
+		out = append(out, change.Change{Seq: s, Op: change.Upsert, ID: uuid.New()})
+	}
+	return out
+}
+
+// --- Tests ---
+
+func TestGetChanges_Paginates(t *testing.T) {
+	repo := &mockRepo{Changes: seqChanges(3, 5, 8, 9)}
+	s := NewChangeService(repo)
+
+	feed, err := s.GetChanges(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.GotLimit != 3 {
+		t.Errorf("expected repo limit 3, got %d", repo.GotLimit)
+	}
+	if len(feed.Changes) != 2 || !feed.HasMore || feed.NextCursor != "5" {
+		t.Fatalf("unexpected first page: %+v", feed)
+	}
+
+	next, _ := change.ParseCursor(feed.NextCursor)
+	feed, err = s.GetChanges(next, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.GotSince != 5 || len(feed.Changes) != 2 || feed.HasMore || feed.NextCursor != "9" {
+		t.Fatalf("unexpected second page: %+v", feed)
+	}
+}
+
+func TestGetChanges_EmptyKeepsCursor(t *testing.T) {
+	s := NewChangeService(&mockRepo{})
+	feed, err := s.GetChanges(42, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.NextCursor != "42" || feed.HasMore || feed.Changes == nil || len(feed.Changes) != 0 {
+		t.Fatalf("unexpected feed: %+v", feed)
+	}
+}
+
+func TestGetChanges_DefaultLimit(t *testing.T) {
+	repo := &mockRepo{}
+	if _, err := NewChangeService(repo).GetChanges(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if repo.GotLimit != DefaultPageSize+1 {
+		t.Errorf("expected default limit, got %d", repo.GotLimit)
+	}
+}
+
+func TestGetChanges_ClampsLimit(t *testing.T) {
+	repo := &mockRepo{}
+	if _, err := NewChangeService(repo).GetChanges(0, MaxPageSize*10); err != nil {
+		t.Fatal(err)
+	}
+	if repo.GotLimit != MaxPageSize+1 {
+		t.Errorf("expected clamped limit, got %d", repo.GotLimit)
+	}
+}
+
+func TestGetChanges_RepoError(t *testing.T) {
+	s := NewChangeService(&mockRepo{Err: errors.New("db down")})
+	if _, err := s.GetChanges(0, 10); err == nil {
+		t.Error("expected repo error")
+	}
+}
@@ -0,0 +1,284 @@
+package imports
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"golang.org/x/text/encoding/charmap"
+	"path/filepath"
+	"salestracker/internal/config"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultCategory — категория для операций, в выписке которых категории нет
+const DefaultCategory = "uncategorized"
+
+type ImportService struct {
+	repo         ImportStorageProvider
+	wbCategories config.WBCategories
+}
+
+type ImportStorageProvider interface {
+	// SaveImportedTransaction сохраняет транзакцию, если операции с таким externalID еще нет; false — уже импортирована
+	SaveImportedTransaction(tr *transaction.Transaction, externalID string) (bool, error)
+}
+
+func NewImportService(repo ImportStorageProvider, cfg *config.AppConfig) *ImportService {
+	cats := cfg.Wildberries.Categories
+	defaults := []struct {
+		field *string
+		value string
+	}{
+		{&cats.Sales, "wb_sales"},
+		{&cats.Returns, "wb_returns"},
+		{&cats.Commission, "wb_commission"},
+		{&cats.Logistics, "wb_logistics"},
+		{&cats.Storage, "wb_storage"},
+		{&cats.Penalties, "wb_penalties"},
+		{&cats.Other, "wb_other"},
+	}
+	for _, d := range defaults {
+		if *d.field == "" {
+			*d.field = d.value
+		}
+	}
+	return &ImportService{
+		repo:         repo,
+		wbCategories: cats,
+	}
+}
+
+// ImportStatements импортирует выписки. format пустой — определяется по содержимому каждого файла.
+// Даты без смещения считаются в часовом поясе loc (запроса или рабочего пространства), а не сервера.
+// Ошибка одного файла или строки не прерывает импорт остальных, все попадает в сводку
+func (s *ImportService) ImportStatements(files []statement.File, format, category string, loc *time.Location) ([]*statement.FileSummary, error) {
+	if format != "" && !statement.Format(format).Valid() {
+		return nil, fmt.Errorf("%w %q", statement.ErrUnknownFormat, format)
+	}
+	if category == "" {
+		category = DefaultCategory
+	}
+
+	result := make([]*statement.FileSummary, 0, len(files))
+	for _, f := range files {
+		result = append(result, s.importFile(f, statement.Format(format), category, loc))
+	}
+	return result, nil
+}
+
+func (s *ImportService) importFile(f statement.File, format statement.Format, category string, loc *time.Location) *statement.FileSummary {
+	summary := &statement.FileSummary{File: f.Name, Format: format, Errors: []statement.LineError{}}
+	var lines []statement.Line
+	var errs []statement.LineError
+	summary.Format, lines, errs = ParseStatement(f, format, loc)
+	summary.Errors = append(summary.Errors, errs...)
+
+	s.saveLines(summary, lines, category)
+	return summary
+}
+
+// ImportWBReports импортирует отчеты о реализации Wildberries (XLSX или CSV). reportID нужен только для выгрузок
+// без колонки rrd_id: тогда строки идентифицируются номером отчета и номером строки. Даты отчета — в часовом поясе loc
+func (s *ImportService) ImportWBReports(files []statement.File, reportID string, loc *time.Location) ([]*statement.FileSummary, error) {
+	result := make([]*statement.FileSummary, 0, len(files))
+	for _, f := range files {
+		summary := &statement.FileSummary{File: f.Name, Format: statement.WBReport, Errors: []statement.LineError{}}
+		table, err := readTable(f.Data)
+		if err != nil {
+			summary.Errors = append(summary.Errors, statement.LineError{Line: 0, Error: err.Error()})
+		} else {
+			lines, errs := parseWBReport(table, reportID, s.wbCategories, loc)
+			summary.Errors = append(summary.Errors, errs...)
+			s.saveLines(summary, lines, DefaultCategory)
+		}
+		summary.Failed = len(summary.Errors)
+		result = append(result, summary)
+	}
+	return result, nil
+}
+
+// ImportReceipts импортирует кассовые чеки из JSON-выгрузки ФНС: каждый чек — расходная транзакция
+// (возврат прихода — доходная), ИНН продавца — контрагент. Повторно загруженные чеки пропускаются по фискальному признаку.
+// Местное время кассы считается в часовом поясе loc
+func (s *ImportService) ImportReceipts(files []statement.File, category string, loc *time.Location) ([]*statement.FileSummary, error) {
+	if category == "" {
+		category = DefaultCategory
+	}
+	result := make([]*statement.FileSummary, 0, len(files))
+	for _, f := range files {
+		summary := &statement.FileSummary{File: f.Name, Format: statement.Receipt, Errors: []statement.LineError{}}
+		lines, errs := parseReceipts(f.Data, loc)
+		summary.Errors = append(summary.Errors, errs...)
+		s.saveLines(summary, lines, category)
+		result = append(result, summary)
+	}
+	return result, nil
+}
+
+// saveLines сохраняет разобранные строки и дописывает итоги в сводку
+func (s *ImportService) saveLines(summary *statement.FileSummary, lines []statement.Line, category string) {
+	for _, line := range lines {
+		lineCategory := line.Category
+		if lineCategory == "" {
+			lineCategory = category
+		}
+		tr, err := transaction.NewTransaction(line.Type, lineCategory, line.Amount, line.Description, line.Date)
+		if err != nil {
+			summary.Errors = append(summary.Errors, statement.LineError{Line: line.Number, Error: err.Error()})
+			continue
+		}
+		tr.Counterparty = line.Counterparty
+		created, err := s.repo.SaveImportedTransaction(tr, line.ExternalID)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Str("file", summary.File).Int("line", line.Number).Msg("repo save imported transaction error")
+			summary.Errors = append(summary.Errors, statement.LineError{Line: line.Number, Error: err.Error()})
+			continue
+		}
+		if created {
+			summary.Created++
+		} else {
+			summary.Skipped++
+		}
+	}
+	summary.Failed = len(summary.Errors)
+
+	wbzlog.Logger.Info().Str("file", summary.File).Str("format", string(summary.Format)).
+		Int("created", summary.Created).Int("skipped", summary.Skipped).Int("failed", summary.Failed).
+		Msg("statement imported")
+}
+
+// ParseStatement разбирает файл выписки; пустой format определяется по содержимому файла.
+// Даты без смещения считаются в часовом поясе loc
+func ParseStatement(f statement.File, format statement.Format, loc *time.Location) (statement.Format, []statement.Line, []statement.LineError) {
+	text := decodeText(f.Data)
+	if format == "" {
+		format = DetectFormat(f.Name, text)
+	}
+
+	switch format {
+	case statement.OFX:
+		lines, errs := parseOFX(text, loc)
+		return format, lines, errs
+	case statement.QIF:
+		lines, errs := parseQIF(text, loc)
+		return format, lines, errs
+	case statement.OneC:
+		lines, errs := parseOneC(text, loc)
+		return format, lines, errs
+	}
+	return format, nil, []statement.LineError{{Line: 0, Error: "cannot detect statement format"}}
+}
+
+// DetectFormat определяет формат выписки по содержимому, а если оно не узнаваемо — по расширению файла
+func DetectFormat(name, text string) statement.Format {
+	head := strings.TrimSpace(text)
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	upper := strings.ToUpper(head)
+	switch {
+	case strings.HasPrefix(head, oneCHeader):
+		return statement.OneC
+	case strings.Contains(upper, "OFXHEADER") || strings.Contains(upper, "<OFX>"):
+		return statement.OFX
+	case strings.HasPrefix(head, "!"):
+		return statement.QIF
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ofx", ".qfx":
+		return statement.OFX
+	case ".qif":
+		return statement.QIF
+	}
+	return ""
+}
+
+// decodeText убирает BOM и переводит Windows-1251 (кодировку по умолчанию у 1С и старых OFX) в UTF-8
+func decodeText(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	decoded, err := charmap.Windows1251.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// parseAmount понимает и "1,234.56", и "1 234,56"; пустая или нулевая сумма — ошибка
+func parseAmount(v string) (float64, error) {
+	if strings.TrimSpace(v) == "" {
+		return 0, errors.New("amount is empty")
+	}
+	amount, err := parseNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if amount == 0 {
+		return 0, errors.New("amount is zero")
+	}
+	return amount, nil
+}
+
+// parseNumber разбирает число в тех же форматах, что и parseAmount; пустая строка — ноль
+func parseNumber(v string) (float64, error) {
+	v = strings.NewReplacer(" ", "", "\u00a0", "", "+", "").Replace(strings.TrimSpace(v))
+	if v == "" {
+		return 0, nil
+	}
+	if strings.Contains(v, ",") {
+		if strings.Contains(v, ".") {
+			v = strings.ReplaceAll(v, ",", "")
+		} else {
+			v = strings.ReplaceAll(v, ",", ".")
+		}
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+// stableID строит внешний ID операции, одинаковый при повторной загрузке той же выписки
+func stableID(format statement.Format, parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x1f")))
+	return string(format) + ":" + hex.EncodeToString(sum[:16])
+}
+
+// occurrenceID — stableID для форматов без идентификатора операции. Порядковый номер одинаковых операций
+// в файле отличает две настоящие покупки на одну сумму в один день от повторной загрузки
+func occurrenceID(format statement.Format, seen map[string]int, parts ...string) string {
+	key := strings.Join(parts, "\x1f")
+	seen[key]++
+	return stableID(format, append(parts, strconv.Itoa(seen[key]))...)
+}
+
+func joinDescription(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if len(out) > 0 && out[len(out)-1] == p {
+			continue
+		}
+		out = append(out, p)
+	}
+	return strings.Join(out, " — ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package imports
+
+import (
+	"errors"
+	"golang.org/x/text/encoding/charmap"
+	"salestracker/internal/config"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+// msk — часовой пояс запроса в тестах разбора: даты без смещения должны браться в нем, а не в поясе сервера
+var msk = time.FixedZone("MSK", 3*60*60)
+
+// --- Mock repository ---
+type mockRepo struct {
+	Imported map[string]*transaction.Transaction
+	Err      error
+}
+
+func (m *mockRepo) SaveImportedTransaction(tr *transaction.Transaction, externalID string) (bool, error) {
+	if m.Err != nil {
+		return false, m.Err
+	}
+	if m.Imported == nil {
+		m.Imported = map[string]*transaction.Transaction{}
+	}
+	if _, ok := m.Imported[externalID]; ok {
+		return false, nil
+	}
+	m.Imported[externalID] = tr
+	return true, nil
+}
+
+// --- Tests ---
+
+func TestImportStatements_ReimportIsSkipped(t *testing.T) {
+	repo := &mockRepo{}
+	s := NewImportService(repo, &config.AppConfig{})
+	files := []statement.File{{Name: "jan.ofx", Data: []byte(ofxV1)}}
+
+	first, err := s.ImportStatements(files, "", "bank", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := first[0]; got.Format != statement.OFX || got.Created != 2 || got.Skipped != 0 || got.Failed != 1 {
+		t.Fatalf("unexpected first summary: %+v", got)
+	}
+
+	second, _ := s.ImportStatements(files, "", "bank", time.UTC)
+	if got := second[0]; got.Created != 0 || got.Skipped != 2 || got.Failed != 1 {
+		t.Fatalf("unexpected second summary: %+v", got)
+	}
+	for _, tr := range repo.Imported {
+		if tr.Category != "bank" {
+			t.Errorf("expected default category, got %q", tr.Category)
+		}
+	}
+}
+
+func TestImportStatements_Windows1251(t *testing.T) {
+	data, err := charmap.Windows1251.NewEncoder().Bytes([]byte(oneCStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &mockRepo{}
+	res, err := NewImportService(repo, &config.AppConfig{}).ImportStatements([]statement.File{{Name: "kl_to_1c.txt", Data: data}}, "", "", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res[0].Format != statement.OneC || res[0].Created != 2 {
+		t.Fatalf("unexpected summary: %+v", res[0])
+	}
+	for _, tr := range repo.Imported {
+		if tr.Category != DefaultCategory {
+			t.Errorf("expected %q category, got %q", DefaultCategory, tr.Category)
+		}
+	}
+}
+
+func TestImportStatements_PerFileErrors(t *testing.T) {
+	s := NewImportService(&mockRepo{Err: errors.New("db down")}, &config.AppConfig{})
+	res, err := s.ImportStatements([]statement.File{
+		{Name: "unknown.bin", Data: []byte("hello")},
+		{Name: "cash.qif", Data: []byte(qifBank)},
+	}, "", "", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res[0].Failed != 1 || res[0].Format != "" {
+		t.Errorf("unexpected summary for unknown file: %+v", res[0])
+	}
+	if res[1].Created != 0 || res[1].Failed != 4 {
+		t.Errorf("unexpected summary for qif with repo error: %+v", res[1])
+	}
+}
+
+func TestImportStatements_InvalidFormat(t *testing.T) {
+	if _, err := NewImportService(&mockRepo{}, &config.AppConfig{}).ImportStatements(nil, "csv", "", time.UTC); !errors.Is(err, statement.ErrUnknownFormat) {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := map[string]float64{
+		"1,234.56": 1234.56,
+		"1 234,56": 1234.56,
+		"-99.9":    -99.9,
+		"+15":      15,
+		"1 000,00": 1000,
+	}
+	for in, want := range tests {
+		got, err := parseAmount(in)
+		if err != nil || got != want {
+			t.Errorf("parseAmount(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0", "abc"} {
+		if _, err := parseAmount(in); err == nil {
+			t.Errorf("parseAmount(%q) expected error", in)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package imports
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ofxElement = regexp.MustCompile(`(?i)<([A-Z0-9.]+)>([^<\r\n]*)`)
+	ofxAccount = regexp.MustCompile(`(?i)<ACCTID>\s*([^<\r\n]+)`)
+	ofxDebit   = regexp.MustCompile(`(?i)<TRNAMT>\s*-`)
+	ofxEntity  = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'")
+)
+
+// ofxDebitTypes — TRNTYPE, которые означают списание. Смотрятся, только если в выписке нет ни одной суммы с минусом:
+// тогда банк выгрузил суммы без знака. Иначе знак TRNAMT главнее — положительный POS или CHECK это возврат
+var ofxDebitTypes = map[string]bool{
+	"DEBIT": true, "PAYMENT": true, "FEE": true, "SRVCHG": true, "ATM": true,
+	"POS": true, "CHECK": true, "DIRECTDEBIT": true, "CASH": true,
+}
+
+// parseOFX разбирает OFX 1.x (SGML, элементы без закрывающих тегов) и 2.x (XML).
+// Оба варианта читаются одинаково: агрегаты STMTTRN всегда закрываются, а значение элемента идет до следующего тега
+func parseOFX(text string, loc *time.Location) ([]statement.Line, []statement.LineError) {
+	upper := strings.ToUpper(text)
+	signed := ofxDebit.MatchString(text)
+	account := ""
+	if m := ofxAccount.FindStringSubmatch(text); m != nil {
+		account = strings.TrimSpace(m[1])
+	}
+
+	var (
+		lines  []statement.Line
+		errs   []statement.LineError
+		seen   = map[string]int{}
+		number = 0
+	)
+	for pos := 0; ; {
+		start := strings.Index(upper[pos:], "<STMTTRN>")
+		if start < 0 {
+			break
+		}
+		start += pos + len("<STMTTRN>")
+		end := strings.Index(upper[start:], "</STMTTRN>")
+		if end < 0 {
+			end = len(upper) - start
+		}
+		block := text[start : start+end]
+		pos = start + end
+		number++
+
+		fields := map[string]string{}
+		for _, m := range ofxElement.FindAllStringSubmatch(block, -1) {
+			fields[strings.ToUpper(m[1])] = ofxEntity.Replace(strings.TrimSpace(m[2]))
+		}
+		line, err := ofxLine(fields, account, signed, seen, loc)
+		if err != nil {
+			errs = append(errs, statement.LineError{Line: number, Error: err.Error()})
+			continue
+		}
+		line.Number = number
+		lines = append(lines, line)
+	}
+	return lines, errs
+}
+
+func ofxLine(fields map[string]string, account string, signed bool, seen map[string]int, loc *time.Location) (statement.Line, error) {
+	amount, err := parseAmount(fields["TRNAMT"])
+	if err != nil {
+		return statement.Line{}, fmt.Errorf("invalid TRNAMT: %w", err)
+	}
+	date, err := parseOFXDate(fields["DTPOSTED"], loc)
+	if err != nil {
+		return statement.Line{}, fmt.Errorf("invalid DTPOSTED: %w", err)
+	}
+
+	trType := transaction.Income
+	if amount < 0 || !signed && ofxDebitTypes[strings.ToUpper(fields["TRNTYPE"])] {
+		trType = transaction.Expense
+	}
+
+	// FITID уникален в рамках счета; без него собираем ID из полей операции
+	var externalID string
+	if fitID := fields["FITID"]; fitID != "" {
+		externalID = stableID(statement.OFX, account, fitID)
+	} else {
+		externalID = occurrenceID(statement.OFX, seen, account, fields["DTPOSTED"], fields["TRNAMT"], fields["NAME"], fields["MEMO"])
+	}
+
+	return statement.Line{
+		ExternalID:  externalID,
+		Type:        trType,
+		Amount:      math.Abs(amount),
+		Date:        date,
+		Description: joinDescription(fields["NAME"], fields["MEMO"]),
+	}, nil
+}
+
+// parseOFXDate разбирает YYYYMMDD[HHMMSS[.XXX]][gmt offset:tz name]. Смещение в скобках (-5:EST, +3, +5.5)
+// задает часовой пояс даты; без него дата считается в loc
+func parseOFXDate(v string, loc *time.Location) (time.Time, error) {
+	if i := strings.IndexByte(v, '['); i >= 0 {
+		if zone, ok := ofxZone(v[i:]); ok {
+			loc = zone
+		}
+		v = v[:i]
+	}
+	if i := strings.IndexByte(v, '.'); i >= 0 {
+		v = v[:i]
+	}
+	switch {
+	case len(v) >= 14:
+		return time.ParseInLocation("20060102150405", v[:14], loc)
+	case len(v) >= 8:
+		return time.ParseInLocation("20060102", v[:8], loc)
+	}
+	return time.Time{}, errors.New("date is too short")
+}
+
+// ofxZone разбирает "[gmt offset:tz name]": смещение в часах, возможно дробное
+func ofxZone(v string) (*time.Location, bool) {
+	v = strings.TrimSuffix(strings.TrimPrefix(v, "["), "]")
+	offset, name, _ := strings.Cut(v, ":")
+	hours, err := strconv.ParseFloat(strings.TrimSpace(offset), 64)
+	if err != nil || math.Abs(hours) > 14 {
+		return nil, false
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "GMT" + strings.TrimSpace(offset)
+	}
+	return time.FixedZone(name, int(math.Round(hours*3600))), true
+}
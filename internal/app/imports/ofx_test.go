@@ -0,0 +1,128 @@
+package imports
+
+import (
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+const ofxV1 = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+CHARSET:1252
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKACCTFROM><BANKID>044525225<ACCTID>40817810000000000001<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240115120000.000[+3:MSK]
+<TRNAMT>-1250.50
+<FITID>2024011501
+<NAME>Coffee &amp; Co
+<MEMO>Card purchase
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240116
+<TRNAMT>50000,00
+<FITID>2024011602
+<NAME>Salary
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>bad
+<TRNAMT>-1
+<FITID>2024011603
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+const ofxV2 = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="211"?>
+<OFX><CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+<CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>POS</TRNTYPE><DTPOSTED>20240201</DTPOSTED><TRNAMT>99.90</TRNAMT><FITID>A1</FITID><NAME>Shop</NAME></STMTTRN>
+</BANKTRANLIST>
+</CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1></OFX>`
+
+func TestParseOFX_V1(t *testing.T) {
+	lines, errs := parseOFX(ofxV1, time.UTC)
+	if len(lines) != 2 || len(errs) != 1 {
+		t.Fatalf("expected 2 lines and 1 error, got %d/%d: %v", len(lines), len(errs), errs)
+	}
+	if errs[0].Line != 3 {
+		t.Errorf("expected error on line 3, got %d", errs[0].Line)
+	}
+
+	first := lines[0]
+	if first.Type != transaction.Expense || first.Amount != 1250.50 {
+		t.Errorf("unexpected first line: %+v", first)
+	}
+	if first.Description != "Coffee & Co — Card purchase" {
+		t.Errorf("unexpected description %q", first.Description)
+	}
+	// смещение [+3:MSK] из файла важнее часового пояса запроса
+	if !first.Date.Equal(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", first.Date)
+	}
+	if lines[1].Type != transaction.Income || lines[1].Amount != 50000 {
+		t.Errorf("unexpected second line: %+v", lines[1])
+	}
+}
+
+func TestParseOFXDate(t *testing.T) {
+	cases := []struct {
+		in   string
+		want time.Time
+	}{
+		{"20240116", time.Date(2024, 1, 16, 0, 0, 0, 0, msk)},
+		{"20240116083000.000", time.Date(2024, 1, 16, 8, 30, 0, 0, msk)},
+		{"20240116083000[-5:EST]", time.Date(2024, 1, 16, 13, 30, 0, 0, time.UTC)},
+		{"20240116083000[+5.5:IST]", time.Date(2024, 1, 16, 3, 0, 0, 0, time.UTC)},
+		{"20240116083000[0]", time.Date(2024, 1, 16, 8, 30, 0, 0, time.UTC)},
+		{"20240116083000[:GMT]", time.Date(2024, 1, 16, 8, 30, 0, 0, msk)},
+	}
+	for _, c := range cases {
+		got, err := parseOFXDate(c.in, msk)
+		if err != nil || !got.Equal(c.want) {
+			t.Errorf("%s: got %v, %v; want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+func TestParseOFX_V2DebitTypeWithoutSign(t *testing.T) {
+	lines, errs := parseOFX(ofxV2, time.UTC)
+	if len(lines) != 1 || len(errs) != 0 {
+		t.Fatalf("unexpected result: %v %v", lines, errs)
+	}
+	if lines[0].Type != transaction.Expense || lines[0].Amount != 99.90 {
+		t.Errorf("unexpected line: %+v", lines[0])
+	}
+}
+
+func TestParseOFX_SignedAmountWinsOverDebitType(t *testing.T) {
+	// В выписке есть суммы со знаком, поэтому положительный POS — возврат на карту, а не покупка
+	text := `<OFX><BANKTRANLIST>
+<STMTTRN><TRNTYPE>POS</TRNTYPE><DTPOSTED>20240201</DTPOSTED><TRNAMT>-99.90</TRNAMT><FITID>A1</FITID><NAME>Shop</NAME></STMTTRN>
+<STMTTRN><TRNTYPE>POS</TRNTYPE><DTPOSTED>20240203</DTPOSTED><TRNAMT>99.90</TRNAMT><FITID>A2</FITID><NAME>Shop refund</NAME></STMTTRN>
+</BANKTRANLIST></OFX>`
+	lines, errs := parseOFX(text, time.UTC)
+	if len(lines) != 2 || len(errs) != 0 {
+		t.Fatalf("unexpected result: %v %v", lines, errs)
+	}
+	if lines[0].Type != transaction.Expense || lines[1].Type != transaction.Income || lines[1].Amount != 99.90 {
+		t.Errorf("unexpected lines: %+v", lines)
+	}
+}
+
+func TestParseOFX_StableExternalID(t *testing.T) {
+	a, _ := parseOFX(ofxV1, time.UTC)
+	b, _ := parseOFX(ofxV1, time.UTC)
+	if a[0].ExternalID != b[0].ExternalID || a[0].ExternalID == a[1].ExternalID {
+		t.Fatalf("external IDs must be stable and distinct: %q %q %q", a[0].ExternalID, b[0].ExternalID, a[1].ExternalID)
+	}
+}
@@ -0,0 +1,115 @@
+package imports
+
+import (
+	"bufio"
+	"errors"
+	"math"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"strings"
+	"time"
+)
+
+const oneCHeader = "1CClientBankExchange"
+
+// parseOneC разбирает текстовый формат обмена 1С:Предприятие с клиент-банком (1CClientBankExchange).
+// Сторона операции определяется по счету организации (РасчСчет в заголовке): поступление на свой счет — доход,
+// списание со своего — расход; если счета не указаны, используются ДатаПоступило/ДатаСписано
+func parseOneC(text string, loc *time.Location) ([]statement.Line, []statement.LineError) {
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		lines    []statement.Line
+		errs     []statement.LineError
+		seen     = map[string]int{}
+		own      = map[string]bool{}
+		doc      map[string]string
+		number   = 0
+		headerOK = false
+	)
+	for scanner.Scan() {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		if !headerOK {
+			if raw != oneCHeader {
+				return nil, []statement.LineError{{Line: 0, Error: "missing 1CClientBankExchange header"}}
+			}
+			headerOK = true
+			continue
+		}
+
+		key, value, _ := strings.Cut(raw, "=")
+		switch {
+		case key == "СекцияДокумент":
+			doc = map[string]string{}
+		case key == "КонецДокумента":
+			if doc == nil {
+				continue
+			}
+			number++
+			line, err := oneCLine(doc, own, seen, loc)
+			if err != nil {
+				errs = append(errs, statement.LineError{Line: number, Error: err.Error()})
+			} else {
+				line.Number = number
+				lines = append(lines, line)
+			}
+			doc = nil
+		case doc != nil:
+			doc[key] = strings.TrimSpace(value)
+		case key == "РасчСчет" && value != "":
+			own[strings.TrimSpace(value)] = true
+		}
+	}
+	if !headerOK {
+		return nil, []statement.LineError{{Line: 0, Error: "missing 1CClientBankExchange header"}}
+	}
+	return lines, errs
+}
+
+func oneCLine(doc map[string]string, own map[string]bool, seen map[string]int, loc *time.Location) (statement.Line, error) {
+	amount, err := parseAmount(doc["Сумма"])
+	if err != nil {
+		return statement.Line{}, errors.New("invalid Сумма: " + err.Error())
+	}
+
+	payerOwn, payeeOwn := own[doc["ПлательщикСчет"]], own[doc["ПолучательСчет"]]
+	// Контрагент — другая сторона платежа: его имя идет в описание, ИНН — в Counterparty
+	var (
+		trType          transaction.TransactionType
+		rawDate         string
+		counterparty    string
+		counterpartyINN string
+	)
+	switch {
+	case payerOwn && payeeOwn:
+		return statement.Line{}, errors.New("transfer between own accounts")
+	case payeeOwn, !payerOwn && doc["ДатаПоступило"] != "":
+		trType, rawDate = transaction.Income, doc["ДатаПоступило"]
+		counterparty, counterpartyINN = firstNonEmpty(doc["Плательщик1"], doc["Плательщик"]), doc["ПлательщикИНН"]
+	case payerOwn, doc["ДатаСписано"] != "":
+		trType, rawDate = transaction.Expense, doc["ДатаСписано"]
+		counterparty, counterpartyINN = firstNonEmpty(doc["Получатель1"], doc["Получатель"]), doc["ПолучательИНН"]
+	default:
+		return statement.Line{}, errors.New("cannot determine debit/credit side")
+	}
+	if rawDate == "" {
+		rawDate = doc["Дата"]
+	}
+	date, err := time.ParseInLocation("02.01.2006", rawDate, loc)
+	if err != nil {
+		return statement.Line{}, errors.New("invalid date: " + rawDate)
+	}
+
+	return statement.Line{
+		ExternalID:   occurrenceID(statement.OneC, seen, doc["Номер"], doc["Дата"], doc["Сумма"], doc["ПлательщикСчет"], doc["ПолучательСчет"]),
+		Type:         trType,
+		Amount:       math.Abs(amount),
+		Date:         date,
+		Description:  joinDescription(counterparty, doc["НазначениеПлатежа"]),
+		Counterparty: counterpartyINN,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package imports
+
+import (
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+const oneCStatement = `1CClientBankExchange
+ВерсияФормата=1.03
+Кодировка=Windows
+РасчСчет=40702810000000000001
+СекцияРасчСчет
+РасчСчет=40702810000000000001
+КонецРасчСчет
+СекцияДокумент=Платежное поручение
+Номер=15
+Дата=10.02.2024
+Сумма=15000.00
+ПлательщикСчет=40702810000000000001
+Плательщик1=ООО Продавец
+ПлательщикИНН=7700000001
+ПолучательСчет=40702810999999999999
+Получатель1=ООО Аренда
+ПолучательИНН=7700000002
+ДатаСписано=11.02.2024
+НазначениеПлатежа=Аренда за февраль
+КонецДокумента
+СекцияДокумент=Платежное поручение
+Номер=7
+Дата=12.02.2024
+Сумма=42000.50
+ПлательщикСчет=40702810888888888888
+Плательщик1=ООО Покупатель
+ПлательщикИНН=7700000003
+ПолучательСчет=40702810000000000001
+ПолучательИНН=7700000001
+ДатаПоступило=12.02.2024
+НазначениеПлатежа=Оплата по счету 7
+КонецДокумента
+СекцияДокумент=Платежное поручение
+Номер=8
+Дата=13.02.2024
+Сумма=1.00
+ПлательщикСчет=1
+ПолучательСчет=2
+КонецДокумента
+КонецФайла
+`
+
+func TestParseOneC(t *testing.T) {
+	lines, errs := parseOneC(oneCStatement, msk)
+	if len(lines) != 2 || len(errs) != 1 {
+		t.Fatalf("expected 2 lines and 1 error, got %d/%d: %v", len(lines), len(errs), errs)
+	}
+
+	rent := lines[0]
+	if rent.Type != transaction.Expense || rent.Amount != 15000 || rent.Description != "ООО Аренда — Аренда за февраль" {
+		t.Errorf("unexpected rent line: %+v", rent)
+	}
+	if rent.Counterparty != "7700000002" {
+		t.Errorf("expected payee INN as counterparty, got %q", rent.Counterparty)
+	}
+	if !rent.Date.Equal(time.Date(2024, 2, 11, 0, 0, 0, 0, msk)) {
+		t.Errorf("expected debit date, got %v", rent.Date)
+	}
+
+	payment := lines[1]
+	if payment.Type != transaction.Income || payment.Amount != 42000.50 {
+		t.Errorf("unexpected payment line: %+v", payment)
+	}
+	if payment.Counterparty != "7700000003" {
+		t.Errorf("expected payer INN as counterparty, got %q", payment.Counterparty)
+	}
+	if errs[0].Line != 3 {
+		t.Errorf("expected error on document 3, got %d", errs[0].Line)
+	}
+}
+
+func TestParseOneC_MissingHeader(t *testing.T) {
+	lines, errs := parseOneC("СекцияДокумент=Платежное поручение\nКонецДокумента\n", time.UTC)
+	if len(lines) != 0 || len(errs) != 1 {
+		t.Fatalf("expected header error, got %v %v", lines, errs)
+	}
+}
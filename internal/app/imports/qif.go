@@ -0,0 +1,131 @@
+package imports
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"strings"
+	"time"
+)
+
+// qifDateLayouts — Quicken пишет даты в американском порядке, российские банки — через точку
+var qifDateLayouts = []string{"1/2/2006", "1/2/06", "02.01.2006", "02.01.06", "2006-01-02"}
+
+// parseQIF разбирает банковские секции QIF (!Type:Bank, !Type:CCard, !Type:Cash …).
+// Записи в !Account и других служебных секциях пропускаются
+func parseQIF(text string, loc *time.Location) ([]statement.Line, []statement.LineError) {
+	var (
+		lines   []statement.Line
+		errs    []statement.LineError
+		seen    = map[string]int{}
+		fields  = map[byte]string{}
+		number  = 0
+		inTrans = false
+	)
+
+	flush := func() {
+		if len(fields) == 0 {
+			return
+		}
+		number++
+		line, err := qifLine(fields, seen, loc)
+		if err != nil {
+			errs = append(errs, statement.LineError{Line: number, Error: err.Error()})
+		} else {
+			line.Number = number
+			lines = append(lines, line)
+		}
+		fields = map[byte]string{}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		if raw[0] == '!' {
+			header := strings.ToLower(strings.TrimSpace(raw))
+			if strings.HasPrefix(header, "!option") || strings.HasPrefix(header, "!clear") {
+				continue
+			}
+			inTrans = strings.HasPrefix(header, "!type:") && !strings.HasPrefix(header, "!type:cat") &&
+				!strings.HasPrefix(header, "!type:class") && !strings.HasPrefix(header, "!type:memorized")
+			fields = map[byte]string{}
+			continue
+		}
+		if !inTrans {
+			continue
+		}
+		if raw[0] == '^' {
+			flush()
+			continue
+		}
+		code, value := raw[0], strings.TrimSpace(raw[1:])
+		// Сплиты (S/E/$) не разбираем: операция импортируется целиком, с общей суммой
+		if _, ok := fields[code]; !ok {
+			fields[code] = value
+		}
+	}
+	// Последняя запись может быть без завершающего ^
+	if inTrans {
+		flush()
+	}
+	return lines, errs
+}
+
+func qifLine(fields map[byte]string, seen map[string]int, loc *time.Location) (statement.Line, error) {
+	rawAmount := fields['T']
+	if rawAmount == "" {
+		rawAmount = fields['U']
+	}
+	amount, err := parseAmount(rawAmount)
+	if err != nil {
+		return statement.Line{}, fmt.Errorf("invalid amount: %w", err)
+	}
+	date, err := parseQIFDate(fields['D'], loc)
+	if err != nil {
+		return statement.Line{}, fmt.Errorf("invalid date: %w", err)
+	}
+
+	trType := transaction.Income
+	if amount < 0 {
+		trType = transaction.Expense
+	}
+
+	return statement.Line{
+		ExternalID:  occurrenceID(statement.QIF, seen, fields['D'], rawAmount, fields['N'], fields['P'], fields['M']),
+		Type:        trType,
+		Amount:      math.Abs(amount),
+		Date:        date,
+		Description: joinDescription(fields['P'], fields['M']),
+		Category:    qifCategory(fields['L']),
+	}, nil
+}
+
+func parseQIFDate(v string, loc *time.Location) (time.Time, error) {
+	// 1/2'06 и 1/2' 6 — вариант Quicken для дат после 2000 года
+	v = strings.ReplaceAll(strings.ReplaceAll(v, "' ", "/0"), "'", "/")
+	v = strings.ReplaceAll(v, " ", "")
+	for _, layout := range qifDateLayouts {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unsupported date format")
+}
+
+// qifCategory отбрасывает переводы между счетами ([Счет]) и класс после "/"
+func qifCategory(v string) string {
+	if strings.HasPrefix(v, "[") {
+		return ""
+	}
+	if i := strings.IndexByte(v, '/'); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v)
+}
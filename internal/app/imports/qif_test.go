@@ -0,0 +1,63 @@
+package imports
+
+import (
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+const qifBank = `!Type:Bank
+D01/15'24
+T-350.00
+PTaxi
+LTransport
+^
+D01/15'24
+T-350.00
+PTaxi
+LTransport
+^
+D16.01.2024
+T1,200.00
+PRefund
+L[Savings]
+^
+Dsomeday
+T10
+^
+`
+
+func TestParseQIF(t *testing.T) {
+	lines, errs := parseQIF(qifBank, msk)
+	if len(lines) != 3 || len(errs) != 1 {
+		t.Fatalf("expected 3 lines and 1 error, got %d/%d: %v", len(lines), len(errs), errs)
+	}
+	if errs[0].Line != 4 {
+		t.Errorf("expected error on record 4, got %d", errs[0].Line)
+	}
+
+	taxi := lines[0]
+	if taxi.Type != transaction.Expense || taxi.Amount != 350 || taxi.Category != "Transport" {
+		t.Errorf("unexpected taxi line: %+v", taxi)
+	}
+	if !taxi.Date.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, msk)) {
+		t.Errorf("unexpected date %v", taxi.Date)
+	}
+	// Две одинаковые поездки в один день — разные операции
+	if taxi.ExternalID == lines[1].ExternalID {
+		t.Error("identical records must get distinct external IDs")
+	}
+
+	refund := lines[2]
+	if refund.Type != transaction.Income || refund.Amount != 1200 || refund.Category != "" {
+		t.Errorf("unexpected refund line: %+v", refund)
+	}
+}
+
+func TestParseQIF_SkipsAccountSection(t *testing.T) {
+	text := "!Account\nNChecking\nTBank\n^\n!Type:Bank\nD1/2/2024\nT5\n^\n"
+	lines, errs := parseQIF(text, time.UTC)
+	if len(lines) != 1 || len(errs) != 0 {
+		t.Fatalf("unexpected result: %v %v", lines, errs)
+	}
+}
@@ -0,0 +1,203 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// receiptWrappers — ключи, под которыми выгрузки ФНС разных версий прячут сам чек:
+// {"ticket":{"document":{"receipt":{...}}}}, {"document":{"receipt":{...}}} или {"receipt":{...}}
+var receiptWrappers = []string{"ticket", "document", "receipt", "bso"}
+
+// receiptItemsInDescription — сколько позиций чека перечислять в описании транзакции
+const receiptItemsInDescription = 3
+
+type fnsReceipt struct {
+	DateTime      json.RawMessage `json:"dateTime"`
+	TotalSum      int64           `json:"totalSum"`
+	FiscalSign    json.RawMessage `json:"fiscalSign"`
+	UserInn       string          `json:"userInn"`
+	User          string          `json:"user"`
+	RetailPlace   string          `json:"retailPlace"`
+	OperationType int             `json:"operationType"`
+	Items         []fnsItem       `json:"items"`
+}
+
+type fnsItem struct {
+	Name     string  `json:"name"`
+	Price    int64   `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Sum      int64   `json:"sum"`
+}
+
+// parseReceipts разбирает JSON-выгрузку чеков ФНС: один чек или массив чеков, с обертками любой версии.
+// Суммы в чеке в копейках. Номер строки в ошибках — порядковый номер чека в файле
+func parseReceipts(data []byte, loc *time.Location) ([]statement.Line, []statement.LineError) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	var raws []json.RawMessage
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return nil, []statement.LineError{{Line: 0, Error: fmt.Sprintf("invalid receipt JSON: %v", err)}}
+		}
+	} else {
+		raws = []json.RawMessage{data}
+	}
+
+	var lines []statement.Line
+	var errs []statement.LineError
+	for i, raw := range raws {
+		line, err := parseReceipt(raw, loc)
+		if err != nil {
+			errs = append(errs, statement.LineError{Line: i + 1, Error: err.Error()})
+			continue
+		}
+		line.Number = i + 1
+		lines = append(lines, line)
+	}
+	return lines, errs
+}
+
+func parseReceipt(raw json.RawMessage, loc *time.Location) (statement.Line, error) {
+	raw, err := unwrapReceipt(raw)
+	if err != nil {
+		return statement.Line{}, err
+	}
+	var r fnsReceipt
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return statement.Line{}, fmt.Errorf("invalid receipt: %v", err)
+	}
+
+	sign := jsonScalar(r.FiscalSign)
+	if sign == "" {
+		return statement.Line{}, errors.New("fiscalSign is missing")
+	}
+	total := r.TotalSum
+	if total == 0 {
+		for _, it := range r.Items {
+			total += it.Sum
+		}
+	}
+	if total <= 0 {
+		return statement.Line{}, errors.New("totalSum is zero")
+	}
+	date, err := parseReceiptDate(r.DateTime, loc)
+	if err != nil {
+		return statement.Line{}, err
+	}
+	inn := strings.TrimSpace(r.UserInn)
+	if inn != "" && !validINN(inn) {
+		return statement.Line{}, fmt.Errorf("invalid seller INN %q", inn)
+	}
+
+	return statement.Line{
+		ExternalID:   string(statement.Receipt) + ":" + sign,
+		Type:         receiptType(r.OperationType),
+		Amount:       float64(total) / 100,
+		Date:         date,
+		Description:  joinDescription(firstNonEmpty(strings.TrimSpace(r.User), strings.TrimSpace(r.RetailPlace)), receiptItems(r.Items)),
+		Counterparty: inn,
+	}, nil
+}
+
+// unwrapReceipt снимает обертки выгрузки, пока не дойдет до объекта с totalSum
+func unwrapReceipt(raw json.RawMessage) (json.RawMessage, error) {
+	for depth := 0; depth <= len(receiptWrappers); depth++ {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, fmt.Errorf("invalid receipt: %v", err)
+		}
+		if _, ok := obj["totalSum"]; ok {
+			return raw, nil
+		}
+		found := false
+		for _, key := range receiptWrappers {
+			if v, ok := obj[key]; ok {
+				raw, found = v, true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return nil, errors.New("receipt not found in JSON")
+}
+
+// receiptType переводит признак расчета чека в тип транзакции с точки зрения покупателя:
+// приход (1) и возврат расхода (4) — мы платим, возврат прихода (2) и расход (3) — нам платят
+func receiptType(operationType int) transaction.TransactionType {
+	switch operationType {
+	case 2, 3:
+		return transaction.Income
+	}
+	return transaction.Expense
+}
+
+// parseReceiptDate понимает строку "2006-01-02T15:04[:05]" (местное время кассы) и unix-время старых выгрузок,
+// в которых местное время кассы записано как UTC; местное время кассы считается в loc
+func parseReceiptDate(raw json.RawMessage, loc *time.Location) (time.Time, error) {
+	v := jsonScalar(raw)
+	if v == "" {
+		return time.Time{}, errors.New("dateTime is missing")
+	}
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		t := time.Unix(sec, 0).UTC()
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid dateTime %q", v)
+}
+
+// receiptItems перечисляет первые позиции чека для описания транзакции
+func receiptItems(items []fnsItem) string {
+	var names []string
+	for _, it := range items {
+		if name := strings.TrimSpace(it.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) <= receiptItemsInDescription {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s и еще %d", strings.Join(names[:receiptItemsInDescription], ", "), len(names)-receiptItemsInDescription)
+}
+
+// jsonScalar возвращает число или строку из JSON как текст; фискальный признак встречается в обоих видах
+func jsonScalar(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+	return ""
+}
+
+// validINN проверяет длину ИНН: 10 цифр у организаций, 12 — у ИП
+func validINN(inn string) bool {
+	if len(inn) != 10 && len(inn) != 12 {
+		return false
+	}
+	for _, r := range inn {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package imports
+
+import (
+	"salestracker/internal/config"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+const fnsReceipts = `[
+  {"_id": "a1", "ticket": {"document": {"receipt": {
+    "dateTime": "2024-03-04T18:25:00", "totalSum": 123450, "fiscalSign": 3826458120,
+    "fiscalDocumentNumber": 51234, "operationType": 1, "userInn": "7703270067  ", "user": "ООО \"ЛЕНТА\"",
+    "items": [
+      {"name": "Молоко", "price": 8990, "quantity": 2, "sum": 17980},
+      {"name": "Хлеб", "price": 4500, "quantity": 1, "sum": 4500},
+      {"name": "Кофе", "price": 60000, "quantity": 1, "sum": 60000},
+      {"name": "Сыр", "price": 40970, "quantity": 1, "sum": 40970}
+    ]}}}},
+  {"document": {"receipt": {
+    "dateTime": 1709575500, "totalSum": 4500, "fiscalSign": "1234567890",
+    "operationType": 2, "userInn": "770327006712", "retailPlace": "Магазин у дома",
+    "items": [{"name": "Хлеб", "price": 4500, "quantity": 1, "sum": 4500}]}}},
+  {"receipt": {"dateTime": "2024-03-05T10:00", "totalSum": 100, "userInn": "77"}},
+  {"receipt": {"dateTime": "2024-03-05T10:00", "totalSum": 100, "fiscalSign": 1, "userInn": "ABC"}}
+]`
+
+func TestParseReceipts(t *testing.T) {
+	lines, errs := parseReceipts([]byte(fnsReceipts), msk)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 receipts, got %d: %v", len(lines), errs)
+	}
+	if len(errs) != 2 || errs[0].Line != 3 || errs[1].Line != 4 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	purchase := lines[0]
+	if purchase.ExternalID != "fns:3826458120" || purchase.Type != transaction.Expense || purchase.Amount != 1234.5 {
+		t.Errorf("unexpected purchase: %+v", purchase)
+	}
+	if purchase.Counterparty != "7703270067" {
+		t.Errorf("unexpected counterparty %q", purchase.Counterparty)
+	}
+	if purchase.Description != `ООО "ЛЕНТА" — Молоко, Хлеб, Кофе и еще 1` {
+		t.Errorf("unexpected description %q", purchase.Description)
+	}
+	if want := time.Date(2024, 3, 4, 18, 25, 0, 0, msk); !purchase.Date.Equal(want) {
+		t.Errorf("unexpected date %v", purchase.Date)
+	}
+
+	refund := lines[1]
+	if refund.ExternalID != "fns:1234567890" || refund.Type != transaction.Income || refund.Amount != 45 {
+		t.Errorf("unexpected refund: %+v", refund)
+	}
+	if want := time.Date(2024, 3, 4, 18, 5, 0, 0, msk); !refund.Date.Equal(want) {
+		t.Errorf("unix dateTime should keep the register's wall clock, got %v", refund.Date)
+	}
+}
+
+func TestParseReceipts_SingleObject(t *testing.T) {
+	lines, errs := parseReceipts([]byte("\xef\xbb\xbf"+`{"totalSum": 0, "fiscalSign": 7, "dateTime": "2024-03-05T10:00:00",
+		"items": [{"name": "Вода", "sum": 5000}, {"name": "Сок", "sum": 12000}]}`), time.UTC)
+	if len(errs) != 0 || len(lines) != 1 {
+		t.Fatalf("unexpected result: %v %v", lines, errs)
+	}
+	if lines[0].Amount != 170 || lines[0].Counterparty != "" {
+		t.Errorf("unexpected line: %+v", lines[0])
+	}
+}
+
+func TestParseReceipts_InvalidJSON(t *testing.T) {
+	if _, errs := parseReceipts([]byte(`[{"receipt": `), time.UTC); len(errs) != 1 || errs[0].Line != 0 {
+		t.Fatalf("expected file-level error, got %v", errs)
+	}
+	if _, errs := parseReceipts([]byte(`{"foo": 1}`), time.UTC); len(errs) != 1 {
+		t.Fatalf("expected receipt-not-found error, got %v", errs)
+	}
+}
+
+func TestImportReceipts_DedupByFiscalSign(t *testing.T) {
+	repo := &mockRepo{}
+	s := NewImportService(repo, &config.AppConfig{})
+	files := []statement.File{{Name: "receipts.json", Data: []byte(fnsReceipts)}}
+
+	first, err := s.ImportReceipts(files, "", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first[0].Format != statement.Receipt || first[0].Created != 2 || first[0].Failed != 2 {
+		t.Fatalf("unexpected first summary: %+v", first[0])
+	}
+	tr := repo.Imported["fns:3826458120"]
+	if tr.Category != DefaultCategory || tr.Counterparty != "7703270067" {
+		t.Errorf("unexpected saved transaction: %+v", tr)
+	}
+
+	second, _ := s.ImportReceipts(files, "groceries", time.UTC)
+	if second[0].Created != 0 || second[0].Skipped != 2 {
+		t.Fatalf("unexpected second summary: %+v", second[0])
+	}
+}
@@ -0,0 +1,234 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"salestracker/internal/config"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wbColumns — заголовки колонок отчета о реализации: как в XLSX из личного кабинета и как в API reportDetailByPeriod
+var wbColumns = map[string][]string{
+	"rowID":      {"rrd_id", "id строки"},
+	"rowNo":      {"№", "№ п/п"},
+	"reportID":   {"realizationreport_id", "номер отчета", "№ отчета"},
+	"docType":    {"doc_type_name", "тип документа"},
+	"operation":  {"supplier_oper_name", "обоснование для оплаты"},
+	"saleDate":   {"sale_dt", "дата продажи"},
+	"opDate":     {"rr_dt", "дата операции"},
+	"orderDate":  {"order_dt", "дата заказа покупателем"},
+	"retail":     {"retail_amount", "вайлдберриз реализовал товар (пр)"},
+	"forPay":     {"ppvz_for_pay", "к перечислению продавцу за реализованный товар"},
+	"delivery":   {"delivery_rub", "услуги по доставке товара покупателю"},
+	"penalty":    {"penalty", "общая сумма штрафов"},
+	"storage":    {"storage_fee", "хранение"},
+	"deduction":  {"deduction", "удержания"},
+	"acceptance": {"acceptance", "платная приемка", "операции на приемке"},
+	"article":    {"sa_name", "артикул поставщика"},
+	"subject":    {"subject_name", "предмет"},
+}
+
+var wbDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02", "02.01.2006 15:04:05", "02.01.2006"}
+
+// wbComponent — часть строки отчета, которая становится отдельной транзакцией.
+// Положительная сумма дает транзакцию типа kind, отрицательная — противоположного
+type wbComponent struct {
+	key      string
+	kind     transaction.TransactionType
+	category string
+	amount   float64
+	label    string
+}
+
+// readTable читает XLSX (zip) или CSV с разделителем ';', ',' или табуляцией
+func readTable(data []byte) ([][]string, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return readXLSX(data)
+	}
+	text := decodeText(data)
+	firstLine, _, _ := strings.Cut(text, "\n")
+	delim, best := ';', strings.Count(firstLine, ";")
+	for _, d := range []rune{',', '\t'} {
+		if n := strings.Count(firstLine, string(d)); n > best {
+			delim, best = d, n
+		}
+	}
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return r.ReadAll()
+}
+
+// parseWBReport раскладывает строки отчета о реализации на транзакции: продажа — доход, возврат — расход,
+// комиссия WB (разница между суммой реализации и суммой к перечислению), логистика, хранение, штрафы и прочие удержания — расходы.
+// Внешний ID — ID строки отчета (rrd_id) и вид составляющей, поэтому повторная загрузка того же отчета ничего не дублирует
+func parseWBReport(table [][]string, reportID string, cats config.WBCategories, loc *time.Location) ([]statement.Line, []statement.LineError) {
+	headerRow, cols := findWBHeader(table)
+	if headerRow < 0 {
+		return nil, []statement.LineError{{Line: 0, Error: "wb report header not found"}}
+	}
+	if cols["rowID"] < 0 && cols["rowNo"] < 0 {
+		return nil, []statement.LineError{{Line: headerRow + 1, Error: "wb report has no row ID column (rrd_id or №)"}}
+	}
+
+	var (
+		lines []statement.Line
+		errs  []statement.LineError
+	)
+	for i := headerRow + 1; i < len(table); i++ {
+		row := table[i]
+		get := func(key string) string {
+			if idx := cols[key]; idx >= 0 && idx < len(row) {
+				return strings.TrimSpace(row[idx])
+			}
+			return ""
+		}
+		if strings.Join(row, "") == "" {
+			continue
+		}
+		number := i + 1
+
+		rowKey, err := wbRowKey(get("rowID"), get("rowNo"), firstNonEmpty(get("reportID"), reportID))
+		if err != nil {
+			errs = append(errs, statement.LineError{Line: number, Error: err.Error()})
+			continue
+		}
+		date, err := parseWBDate(firstNonEmpty(get("saleDate"), get("opDate"), get("orderDate")), loc)
+		if err != nil {
+			errs = append(errs, statement.LineError{Line: number, Error: err.Error()})
+			continue
+		}
+
+		var numbers = map[string]float64{}
+		var numErr error
+		for _, key := range []string{"retail", "forPay", "delivery", "penalty", "storage", "deduction", "acceptance"} {
+			v, err := parseNumber(get(key))
+			if err != nil {
+				numErr = fmt.Errorf("invalid %s: %q", key, get(key))
+				break
+			}
+			numbers[key] = v
+		}
+		if numErr != nil {
+			errs = append(errs, statement.LineError{Line: number, Error: numErr.Error()})
+			continue
+		}
+
+		isReturn := strings.EqualFold(get("docType"), "Возврат")
+		sale := wbComponent{key: "sale", kind: transaction.Income, category: cats.Sales, amount: numbers["retail"], label: "продажа"}
+		commission := wbComponent{key: "commission", kind: transaction.Expense, category: cats.Commission, amount: numbers["retail"] - numbers["forPay"], label: "комиссия"}
+		if isReturn {
+			// При возврате деньги уходят покупателю, а комиссия WB возвращается продавцу
+			sale = wbComponent{key: "sale", kind: transaction.Expense, category: cats.Returns, amount: numbers["retail"], label: "возврат"}
+			commission.kind = transaction.Income
+		}
+		components := []wbComponent{
+			sale,
+			commission,
+			{key: "logistics", kind: transaction.Expense, category: cats.Logistics, amount: numbers["delivery"], label: "логистика"},
+			{key: "storage", kind: transaction.Expense, category: cats.Storage, amount: numbers["storage"], label: "хранение"},
+			{key: "penalty", kind: transaction.Expense, category: cats.Penalties, amount: numbers["penalty"], label: "штраф"},
+			{key: "deduction", kind: transaction.Expense, category: cats.Other, amount: numbers["deduction"], label: "удержание"},
+			{key: "acceptance", kind: transaction.Expense, category: cats.Other, amount: numbers["acceptance"], label: "платная приемка"},
+		}
+
+		details := joinDescription(get("operation"), get("article"), get("subject"))
+		for _, c := range components {
+			amount := math.Round(c.amount*100) / 100
+			if amount == 0 {
+				continue
+			}
+			kind := c.kind
+			if amount < 0 {
+				kind = oppositeType(kind)
+			}
+			lines = append(lines, statement.Line{
+				Number:      number,
+				ExternalID:  "wb:" + rowKey + ":" + c.key,
+				Type:        kind,
+				Amount:      math.Abs(amount),
+				Date:        date,
+				Description: joinDescription("WB "+c.label, details),
+				Category:    c.category,
+			})
+		}
+	}
+	return lines, errs
+}
+
+func findWBHeader(table [][]string) (int, map[string]int) {
+	for i := 0; i < len(table) && i < 20; i++ {
+		cols := map[string]int{}
+		for key := range wbColumns {
+			cols[key] = -1
+		}
+		for idx, cell := range table[i] {
+			name := strings.ToLower(strings.Join(strings.Fields(cell), " "))
+			for key, aliases := range wbColumns {
+				for _, alias := range aliases {
+					if name == alias && cols[key] < 0 {
+						cols[key] = idx
+					}
+				}
+			}
+		}
+		if cols["retail"] >= 0 || cols["forPay"] >= 0 {
+			return i, cols
+		}
+	}
+	return -1, nil
+}
+
+// wbRowKey — rrd_id уникален во всех отчетах WB; номер строки «№» уникален только внутри отчета, поэтому нужен номер отчета
+func wbRowKey(rowID, rowNo, reportID string) (string, error) {
+	if rowID != "" {
+		return normalizeID(rowID), nil
+	}
+	if rowNo == "" {
+		return "", errors.New("row ID is empty")
+	}
+	if reportID == "" {
+		return "", errors.New("report number is required when the report has no rrd_id column")
+	}
+	return normalizeID(reportID) + "-" + normalizeID(rowNo), nil
+}
+
+// normalizeID убирает хвост ".0", который появляется у числовых ID при сохранении через Excel
+func normalizeID(v string) string {
+	if f, err := strconv.ParseFloat(v, 64); err == nil && f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return v
+}
+
+func parseWBDate(v string, loc *time.Location) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, errors.New("date is empty")
+	}
+	for _, layout := range wbDateLayouts {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, nil
+		}
+	}
+	// Даты, сохраненные Excel как числа: дни от 30.12.1899
+	if serial, err := strconv.ParseFloat(v, 64); err == nil && serial > 0 {
+		base := time.Date(1899, 12, 30, 0, 0, 0, 0, loc)
+		return base.Add(time.Duration(serial * float64(24*time.Hour))).Round(time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", v)
+}
+
+func oppositeType(t transaction.TransactionType) transaction.TransactionType {
+	if t == transaction.Income {
+		return transaction.Expense
+	}
+	return transaction.Income
+}
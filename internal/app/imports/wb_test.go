@@ -0,0 +1,185 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"salestracker/internal/config"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"strings"
+	"testing"
+	"time"
+)
+
+const wbCSV = `rrd_id;doc_type_name;supplier_oper_name;sale_dt;retail_amount;ppvz_for_pay;delivery_rub;penalty;storage_fee;deduction;acceptance;sa_name
+1001;Продажа;Продажа;2024-03-04T00:00:00Z;1000;850,50;0;0;0;0;0;ABC-1
+1002;Возврат;Возврат;2024-03-05;500;425;0;0;0;0;0;ABC-1
+1003;;Логистика;2024-03-05;0;0;75;0;0;0;0;ABC-1
+1004;;Хранение;2024-03-06;0;0;0;0;12.3;0;0;
+1005;;Штраф;bad-date;0;0;0;300;0;0;0;
+`
+
+func testWBCategories() config.WBCategories {
+	return NewImportService(&mockRepo{}, &config.AppConfig{}).wbCategories
+}
+
+func linesByID(lines []statement.Line) map[string]statement.Line {
+	out := map[string]statement.Line{}
+	for _, l := range lines {
+		out[l.ExternalID] = l
+	}
+	return out
+}
+
+func TestParseWBReport_CSV(t *testing.T) {
+	table, err := readTable([]byte(wbCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cats := testWBCategories()
+	lines, errs := parseWBReport(table, "", cats, time.UTC)
+	if len(errs) != 1 || errs[0].Line != 6 {
+		t.Fatalf("expected one error on row 6, got %v", errs)
+	}
+	byID := linesByID(lines)
+	if len(byID) != 6 {
+		t.Fatalf("expected 6 lines, got %d: %v", len(byID), lines)
+	}
+
+	tests := []struct {
+		id       string
+		trType   transaction.TransactionType
+		category string
+		amount   float64
+	}{
+		{"wb:1001:sale", transaction.Income, cats.Sales, 1000},
+		{"wb:1001:commission", transaction.Expense, cats.Commission, 149.5},
+		{"wb:1002:sale", transaction.Expense, cats.Returns, 500},
+		{"wb:1002:commission", transaction.Income, cats.Commission, 75},
+		{"wb:1003:logistics", transaction.Expense, cats.Logistics, 75},
+		{"wb:1004:storage", transaction.Expense, cats.Storage, 12.3},
+	}
+	for _, tt := range tests {
+		l, ok := byID[tt.id]
+		if !ok {
+			t.Errorf("missing line %s", tt.id)
+			continue
+		}
+		if l.Type != tt.trType || l.Category != tt.category || l.Amount != tt.amount {
+			t.Errorf("%s: got %s/%s/%v, want %s/%s/%v", tt.id, l.Type, l.Category, l.Amount, tt.trType, tt.category, tt.amount)
+		}
+	}
+	if d := byID["wb:1001:sale"].Description; d != "WB продажа — Продажа — ABC-1" {
+		t.Errorf("unexpected description %q", d)
+	}
+}
+
+func TestParseWBReport_RowNumberNeedsReportID(t *testing.T) {
+	table := [][]string{
+		{"№", "Дата продажи", "Вайлдберриз реализовал Товар (Пр)", "К перечислению Продавцу за реализованный Товар"},
+		{"1", "04.03.2024", "100", "90"},
+	}
+	if _, errs := parseWBReport(table, "", testWBCategories(), time.UTC); len(errs) != 1 {
+		t.Fatalf("expected error without report number, got %v", errs)
+	}
+	lines, errs := parseWBReport(table, "555", testWBCategories(), time.UTC)
+	if len(errs) != 0 || len(lines) != 2 || lines[0].ExternalID != "wb:555-1:sale" {
+		t.Fatalf("unexpected result: %v %v", lines, errs)
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Отчет" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="worksheet" Target="worksheets/report.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>rrd_id</t></si><si><r><t>sale</t></r><r><t>_dt</t></r></si><si><t>retail_amount</t></si></sst>`,
+		"xl/worksheets/report.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="s"><v>2</v></c></row>
+			<row r="3"><c r="A3"><v>77.0</v></c><c r="B3"><v>45355</v></c><c r="D3" t="inlineStr"><is><t>250</t></is></c></row>
+		</sheetData></worksheet>`,
+	})
+
+	table, err := readTable(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table) != 3 || len(table[0]) != 4 || table[0][1] != "sale_dt" || table[0][2] != "" || table[2][3] != "250" {
+		t.Fatalf("unexpected table: %q", table)
+	}
+
+	lines, errs := parseWBReport(table, "", testWBCategories(), msk)
+	if len(errs) != 0 || len(lines) != 2 {
+		t.Fatalf("unexpected result: %v %v", lines, errs)
+	}
+	if lines[0].ExternalID != "wb:77:sale" {
+		t.Errorf("unexpected external ID %q", lines[0].ExternalID)
+	}
+	if want := time.Date(2024, 3, 4, 0, 0, 0, 0, msk); !lines[0].Date.Equal(want) {
+		t.Errorf("unexpected date %v, want %v", lines[0].Date, want)
+	}
+}
+
+func TestReadXLSX_OversizedRef(t *testing.T) {
+	cases := map[string]string{
+		"row":    `<row r="2000000"><c r="A2000000"><v>1</v></c></row>`,
+		"column": `<row r="1"><c r="XFE1"><v>1</v></c></row>`,
+		"long":   `<row r="1"><c r="ZZZZZZZZZZZZZZZZZZZZ1"><v>1</v></c></row>`,
+	}
+	for name, rows := range cases {
+		data := buildXLSX(t, map[string]string{
+			"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`,
+		})
+		if _, err := readXLSX(data); err == nil || !strings.Contains(err.Error(), "out of sheet bounds") {
+			t.Errorf("%s: expected out of bounds error, got %v", name, err)
+		}
+	}
+
+	data := buildXLSX(t, map[string]string{
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1"><c r="XFD1"><v>1</v></c></row></sheetData></worksheet>`,
+	})
+	table, err := readXLSX(data)
+	if err != nil || len(table[0]) != xlsxMaxColumns {
+		t.Fatalf("last column of the sheet must be read: %v", err)
+	}
+}
+
+func TestImportWBReports_Reimport(t *testing.T) {
+	repo := &mockRepo{}
+	s := NewImportService(repo, &config.AppConfig{Wildberries: config.WBConfig{Categories: config.WBCategories{Sales: "Выручка WB"}}})
+	files := []statement.File{{Name: "week.csv", Data: []byte(wbCSV)}}
+
+	first, err := s.ImportWBReports(files, "", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first[0].Format != statement.WBReport || first[0].Created != 6 || first[0].Failed != 1 {
+		t.Fatalf("unexpected first summary: %+v", first[0])
+	}
+	if repo.Imported["wb:1001:sale"].Category != "Выручка WB" {
+		t.Errorf("configured category not applied: %q", repo.Imported["wb:1001:sale"].Category)
+	}
+
+	second, _ := s.ImportWBReports(files, "", time.UTC)
+	if second[0].Created != 0 || second[0].Skipped != 6 {
+		t.Fatalf("unexpected second summary: %+v", second[0])
+	}
+}
+
+func buildXLSX(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
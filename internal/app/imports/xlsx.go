@@ -0,0 +1,209 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Пределы листа Excel: ссылки за ними — битый или подделанный файл, под который нельзя выделять память
+const (
+	xlsxMaxRows    = 1 << 20
+	xlsxMaxColumns = 1 << 14
+)
+
+// readXLSX читает первый лист книги XLSX в таблицу строк. Поддерживается ровно то, что нужно для выгрузок
+// маркетплейсов: общие строки, inline-строки, числа и булевы значения; стили и формулы игнорируются
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	sheet, ok := files[sheetPath]
+	if !ok {
+		return nil, errors.New("xlsx: worksheet not found")
+	}
+	return readSheet(sheet, shared)
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := decodeZipXML(f, &sst); err != nil {
+		return nil, err
+	}
+	out := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		out[i] = item.String()
+	}
+	return out, nil
+}
+
+// firstSheetPath находит файл первого листа через workbook.xml и его связи; если их нет — берет sheet1.xml
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wbFile, ok := files["xl/workbook.xml"]
+	relsFile, relsOK := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !relsOK {
+		return fallback, nil
+	}
+
+	var wb struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeZipXML(wbFile, &wb); err != nil {
+		return "", err
+	}
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(relsFile, &rels); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return fallback, nil
+	}
+	for _, r := range rels.Items {
+		if r.ID == wb.Sheets[0].RID {
+			if strings.HasPrefix(r.Target, "/") {
+				return strings.TrimPrefix(r.Target, "/"), nil
+			}
+			return path.Join("xl", r.Target), nil
+		}
+	}
+	return fallback, nil
+}
+
+func readSheet(f *zip.File, shared []string) ([][]string, error) {
+	var ws struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeZipXML(f, &ws); err != nil {
+		return nil, err
+	}
+
+	var table [][]string
+	for _, row := range ws.Rows {
+		if row.R > xlsxMaxRows {
+			return nil, fmt.Errorf("xlsx: row %d is out of sheet bounds", row.R)
+		}
+		// Пустые строки в sheetData пропускаются, номер строки сохраняем, чтобы ошибки указывали на строку в Excel
+		for row.R > len(table)+1 {
+			table = append(table, nil)
+		}
+		var cells []string
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			if col >= xlsxMaxColumns {
+				return nil, fmt.Errorf("xlsx: cell %q is out of sheet bounds", c.Ref)
+			}
+			for len(cells) < col {
+				cells = append(cells, "")
+			}
+			var v string
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(shared) {
+					return nil, errors.New("xlsx: invalid shared string index")
+				}
+				v = shared[idx]
+			case "inlineStr":
+				v = c.Inline.String()
+			default:
+				v = c.Value
+			}
+			if col < len(cells) {
+				cells[col] = v
+			} else {
+				cells = append(cells, v)
+			}
+		}
+		table = append(table, cells)
+	}
+	return table, nil
+}
+
+// columnIndex переводит ссылку на ячейку (AB12) в индекс колонки с нуля; слишком длинные ссылки
+// не переполняют int, а дают индекс за пределами листа
+func columnIndex(ref string) int {
+	idx := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		idx = idx*26 + int(r-'A'+1)
+		if idx > xlsxMaxColumns {
+			return xlsxMaxColumns
+		}
+	}
+	return idx - 1
+}
+
+func decodeZipXML(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	return xml.NewDecoder(io.LimitReader(rc, 256<<20)).Decode(v)
+}
@@ -0,0 +1,361 @@
+package reconciliations
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"math"
+	"salestracker/internal/app/imports"
+	"salestracker/internal/domain/reconciliation"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"sort"
+	"time"
+)
+
+const (
+	// matchWindowDays — насколько дата операции в банке может отличаться от даты в учете
+	matchWindowDays = 3
+	// minAutoMatchScore — ниже этого порога автосопоставление пару не предлагает
+	minAutoMatchScore = 0.3
+
+	dateWeight        = 0.6
+	descriptionWeight = 0.4
+)
+
+type ReconciliationService struct {
+	repo ReconciliationStorageProvider
+}
+
+type ReconciliationStorageProvider interface {
+	SaveSession(s *reconciliation.Session, lines []*reconciliation.BankLine) error
+	GetSession(id string) (*reconciliation.Session, error)
+	GetSessions() ([]*reconciliation.Session, error)
+	GetBankLines(sessionID uuid.UUID) ([]*reconciliation.BankLine, error)
+	GetMatchedElsewhere(sessionID uuid.UUID) (map[uuid.UUID]bool, error)
+	SaveMatches(lines []*reconciliation.BankLine) error
+	CloseSession(s *reconciliation.Session, transactionIDs []uuid.UUID) error
+	GetTransaction(id string) (*transaction.Transaction, error)
+	GetAllTransactions(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error)
+}
+
+func NewReconciliationService(repo ReconciliationStorageProvider) *ReconciliationService {
+	return &ReconciliationService{
+		repo: repo,
+	}
+}
+
+// CreateSession разбирает выписку (даты без смещения — в часовом поясе loc), создает сессию сверки
+// и сразу выполняет автосопоставление
+func (s *ReconciliationService) CreateSession(f statement.File, format string, loc *time.Location) (*reconciliation.Report, error) {
+	if format != "" && !statement.Format(format).Valid() {
+		return nil, fmt.Errorf("%w %q", statement.ErrUnknownFormat, format)
+	}
+	detected, lines, parseErrs := imports.ParseStatement(f, statement.Format(format), loc)
+	session, bankLines, err := reconciliation.NewSession(f.Name, detected, lines)
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Str("file", f.Name).Msg("invalid statement for reconciliation")
+		return nil, err
+	}
+	if err := s.repo.SaveSession(session, bankLines); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo save reconciliation session error")
+		return nil, err
+	}
+
+	report, err := s.AutoMatch(session.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	report.ParseErrors = parseErrs
+	return report, nil
+}
+
+func (s *ReconciliationService) GetSessions() ([]*reconciliation.Session, error) {
+	sessions, err := s.repo.GetSessions()
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get reconciliation sessions error")
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (s *ReconciliationService) GetReport(id string) (*reconciliation.Report, error) {
+	session, lines, ledger, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildReport(session, lines, ledger)
+}
+
+// AutoMatch сопоставляет несопоставленные строки выписки с транзакциями: тип и сумма должны совпадать точно,
+// дата — в пределах окна, а из нескольких кандидатов выбирается пара с лучшей близостью даты и описания.
+// Уже сделанные сопоставления (в том числе ручные) не трогаются, транзакции из других открытых сессий не берутся
+func (s *ReconciliationService) AutoMatch(id string) (*reconciliation.Report, error) {
+	session, lines, ledger, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != reconciliation.Open {
+		return nil, reconciliation.ErrSessionClosed
+	}
+
+	matched := matchedTransactions(lines)
+	elsewhere, err := s.repo.GetMatchedElsewhere(session.ID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get transactions matched in other sessions error")
+		return nil, err
+	}
+	for trID := range elsewhere {
+		matched[trID] = true
+	}
+	type candidate struct {
+		line  *reconciliation.BankLine
+		tr    *transaction.Transaction
+		score float64
+	}
+	var candidates []candidate
+	for _, line := range lines {
+		if line.TransactionID != nil {
+			continue
+		}
+		for _, tr := range ledger {
+			if tr.Reconciled || matched[tr.ID] {
+				continue
+			}
+			if score, ok := scoreMatch(line, tr); ok && score >= minAutoMatchScore {
+				candidates = append(candidates, candidate{line: line, tr: tr, score: score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	var changed []*reconciliation.BankLine
+	for _, c := range candidates {
+		if c.line.TransactionID != nil || matched[c.tr.ID] {
+			continue
+		}
+		trID := c.tr.ID
+		c.line.TransactionID = &trID
+		c.line.MatchType = reconciliation.AutoMatch
+		c.line.Score = c.score
+		matched[trID] = true
+		changed = append(changed, c.line)
+	}
+	if len(changed) > 0 {
+		if err := s.repo.SaveMatches(changed); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("repo save reconciliation matches error")
+			return nil, err
+		}
+	}
+	return s.buildReport(session, lines, ledger)
+}
+
+// Match вручную сопоставляет строку выписки с транзакцией; сумма и дата могут не совпадать — решение за бухгалтером
+func (s *ReconciliationService) Match(id string, lineID int64, transactionID string) (*reconciliation.Report, error) {
+	session, lines, ledger, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != reconciliation.Open {
+		return nil, reconciliation.ErrSessionClosed
+	}
+	line := findLine(lines, lineID)
+	if line == nil {
+		return nil, fmt.Errorf("%w: line %d", reconciliation.ErrLineNotFound, lineID)
+	}
+
+	if _, err := uuid.Parse(transactionID); err != nil {
+		wbzlog.Logger.Warn().Str("id", transactionID).Msg("invalid uuid")
+		return nil, fmt.Errorf("%w: transaction %q", reconciliation.ErrInvalidID, transactionID)
+	}
+	tr, err := s.repo.GetTransaction(transactionID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get (for match) transaction error")
+		return nil, err
+	}
+	if tr == nil {
+		return nil, transaction.ErrNotFound
+	}
+	if tr.Reconciled {
+		return nil, reconciliation.ErrTransactionReconciled
+	}
+	for _, other := range lines {
+		if other.ID != line.ID && other.TransactionID != nil && *other.TransactionID == tr.ID {
+			return nil, fmt.Errorf("%w to line %d", reconciliation.ErrAlreadyMatched, other.ID)
+		}
+	}
+	elsewhere, err := s.repo.GetMatchedElsewhere(session.ID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get transactions matched in other sessions error")
+		return nil, err
+	}
+	if elsewhere[tr.ID] {
+		return nil, fmt.Errorf("%w in another open session", reconciliation.ErrAlreadyMatched)
+	}
+
+	score, _ := scoreMatch(line, tr)
+	line.TransactionID = &tr.ID
+	line.MatchType = reconciliation.ManualMatch
+	line.Score = score
+	if err := s.repo.SaveMatches([]*reconciliation.BankLine{line}); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo save reconciliation matches error")
+		return nil, err
+	}
+	return s.buildReport(session, lines, ledger)
+}
+
+func (s *ReconciliationService) Unmatch(id string, lineID int64) (*reconciliation.Report, error) {
+	session, lines, ledger, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != reconciliation.Open {
+		return nil, reconciliation.ErrSessionClosed
+	}
+	line := findLine(lines, lineID)
+	if line == nil {
+		return nil, fmt.Errorf("%w: line %d", reconciliation.ErrLineNotFound, lineID)
+	}
+	if line.TransactionID == nil {
+		return s.buildReport(session, lines, ledger)
+	}
+
+	line.TransactionID = nil
+	line.MatchType = ""
+	line.Score = 0
+	if err := s.repo.SaveMatches([]*reconciliation.BankLine{line}); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo save reconciliation matches error")
+		return nil, err
+	}
+	return s.buildReport(session, lines, ledger)
+}
+
+// Close закрывает сессию и помечает сопоставленные транзакции как сверенные
+func (s *ReconciliationService) Close(id string) (*reconciliation.Report, error) {
+	session, lines, _, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != reconciliation.Open {
+		return nil, reconciliation.ErrSessionClosed
+	}
+
+	var ids []uuid.UUID
+	for _, line := range lines {
+		if line.TransactionID != nil {
+			ids = append(ids, *line.TransactionID)
+		}
+	}
+	if err := s.repo.CloseSession(session, ids); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo close reconciliation session error")
+		return nil, err
+	}
+	now := time.Now()
+	session.Status = reconciliation.Closed
+	session.ClosedAt = &now
+
+	wbzlog.Logger.Info().Str("session", id).Int("reconciled", len(ids)).Msg("reconciliation session closed")
+	return s.GetReport(id)
+}
+
+func (s *ReconciliationService) load(id string) (*reconciliation.Session, []*reconciliation.BankLine, []*transaction.Transaction, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
+		return nil, nil, nil, fmt.Errorf("%w: session %q", reconciliation.ErrInvalidID, id)
+	}
+	session, err := s.repo.GetSession(id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get reconciliation session error")
+		return nil, nil, nil, err
+	}
+	if session == nil {
+		return nil, nil, nil, reconciliation.ErrSessionNotFound
+	}
+	lines, err := s.repo.GetBankLines(session.ID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get reconciliation lines error")
+		return nil, nil, nil, err
+	}
+
+	from := session.From.AddDate(0, 0, -matchWindowDays)
+	to := session.To.AddDate(0, 0, matchWindowDays+1).Add(-time.Nanosecond)
+	ledger, err := s.repo.GetAllTransactions(from, to, transaction.Filter{}, "date", "asc")
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get all transactions error")
+		return nil, nil, nil, err
+	}
+	return session, lines, ledger, nil
+}
+
+func (s *ReconciliationService) buildReport(session *reconciliation.Session, lines []*reconciliation.BankLine, ledger []*transaction.Transaction) (*reconciliation.Report, error) {
+	byID := make(map[uuid.UUID]*transaction.Transaction, len(ledger))
+	for _, tr := range ledger {
+		byID[tr.ID] = tr
+	}
+
+	report := &reconciliation.Report{
+		Session:         session,
+		Matched:         []reconciliation.Match{},
+		UnmatchedBank:   []*reconciliation.BankLine{},
+		UnmatchedLedger: []*transaction.Transaction{},
+	}
+	matched := matchedTransactions(lines)
+	for _, line := range lines {
+		if line.TransactionID == nil {
+			report.UnmatchedBank = append(report.UnmatchedBank, line)
+			continue
+		}
+		tr, ok := byID[*line.TransactionID]
+		if !ok {
+			// Вручную сопоставленная транзакция может лежать вне окна дат сессии
+			var err error
+			tr, err = s.repo.GetTransaction(line.TransactionID.String())
+			if err != nil {
+				wbzlog.Logger.Error().Err(err).Msg("repo get (for report) transaction error")
+				return nil, err
+			}
+		}
+		report.Matched = append(report.Matched, reconciliation.Match{Line: line, Transaction: tr})
+	}
+	if session.Status == reconciliation.Open {
+		for _, tr := range ledger {
+			if !tr.Reconciled && !matched[tr.ID] {
+				report.UnmatchedLedger = append(report.UnmatchedLedger, tr)
+			}
+		}
+	}
+	return report, nil
+}
+
+// scoreMatch оценивает пару строка/транзакция от 0 до 1; false — тип, сумма или дата не подходят для автосопоставления
+func scoreMatch(line *reconciliation.BankLine, tr *transaction.Transaction) (float64, bool) {
+	if line.Type != tr.Type || transaction.Cents(line.Amount) != transaction.Cents(tr.Amount) {
+		return 0, false
+	}
+	days := transaction.DaysApart(line.Date, tr.Date)
+	if days > matchWindowDays {
+		return 0, false
+	}
+	score := dateWeight*transaction.DateCloseness(days, matchWindowDays) +
+		descriptionWeight*transaction.DescriptionSimilarity(line.Description, tr.Description)
+	return math.Round(score*1000) / 1000, true
+}
+
+func matchedTransactions(lines []*reconciliation.BankLine) map[uuid.UUID]bool {
+	matched := map[uuid.UUID]bool{}
+	for _, line := range lines {
+		if line.TransactionID != nil {
+			matched[*line.TransactionID] = true
+		}
+	}
+	return matched
+}
+
+func findLine(lines []*reconciliation.BankLine, id int64) *reconciliation.BankLine {
+	for _, line := range lines {
+		if line.ID == id {
+			return line
+		}
+	}
+	return nil
+}
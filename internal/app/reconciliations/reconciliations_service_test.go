@@ -0,0 +1,281 @@
+package reconciliations
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"salestracker/internal/domain/reconciliation"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+// --- Mock repository ---
+type mockRepo struct {
+	Session    *reconciliation.Session
+	Lines      []*reconciliation.BankLine
+	Ledger     []*transaction.Transaction
+	Saved      []*reconciliation.BankLine
+	ClosedIDs  []uuid.UUID
+	Elsewhere  map[uuid.UUID]bool
+	Closed     bool
+	Err        error
+	nextLineID int64
+}
+
+func (m *mockRepo) SaveSession(s *reconciliation.Session, lines []*reconciliation.BankLine) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	for _, l := range lines {
+		m.nextLineID++
+		l.ID = m.nextLineID
+	}
+	m.Session, m.Lines = s, lines
+	return nil
+}
+func (m *mockRepo) GetSession(id string) (*reconciliation.Session, error) {
+	if m.Session == nil || m.Session.ID.String() != id {
+		return nil, m.Err
+	}
+	return m.Session, m.Err
+}
+func (m *mockRepo) GetSessions() ([]*reconciliation.Session, error) {
+	return []*reconciliation.Session{m.Session}, m.Err
+}
+func (m *mockRepo) GetBankLines(sessionID uuid.UUID) ([]*reconciliation.BankLine, error) {
+	// Копии строк, как после чтения из БД
+	out := make([]*reconciliation.BankLine, 0, len(m.Lines))
+	for _, l := range m.Lines {
+		c := *l
+		out = append(out, &c)
+	}
+	return out, m.Err
+}
+func (m *mockRepo) GetMatchedElsewhere(sessionID uuid.UUID) (map[uuid.UUID]bool, error) {
+	return m.Elsewhere, m.Err
+}
+func (m *mockRepo) SaveMatches(lines []*reconciliation.BankLine) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Saved = append(m.Saved, lines...)
+	for _, l := range lines {
+		for i, stored := range m.Lines {
+			if stored.ID == l.ID {
+				c := *l
+				m.Lines[i] = &c
+			}
+		}
+	}
+	return nil
+}
+func (m *mockRepo) CloseSession(s *reconciliation.Session, transactionIDs []uuid.UUID) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Closed, m.ClosedIDs = true, transactionIDs
+	m.Session.Status = reconciliation.Closed
+	for _, tr := range m.Ledger {
+		for _, id := range transactionIDs {
+			if tr.ID == id {
+				tr.Reconciled = true
+			}
+		}
+	}
+	return nil
+}
+func (m *mockRepo) GetTransaction(id string) (*transaction.Transaction, error) {
+	for _, tr := range m.Ledger {
+		if tr.ID.String() == id {
+			return tr, nil
+		}
+	}
+	return nil, m.Err
+}
+func (m *mockRepo) GetAllTransactions(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error) {
+	return m.Ledger, m.Err
+}
+
+// --- Helpers ---
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+func ledgerTr(trType transaction.TransactionType, amount float64, date time.Time, descr string) *transaction.Transaction {
+	return &transaction.Transaction{ID: uuid.New(), Type: trType, Category: "cat", Amount: amount, Date: date, Description: descr}
+}
+
+const marchQIF = `!Type:Bank
+D03/05/2024
+T-1500.00
+PRent office
+^
+D03/06/2024
+T-200.00
+PCoffee beans
+^
+D03/10/2024
+T300.00
+PRefund
+^
+`
+
+// --- Tests ---
+
+func TestCreateSession_AutoMatches(t *testing.T) {
+	rent := ledgerTr(transaction.Expense, 1500, day(4), "office rent")
+	rentLookalike := ledgerTr(transaction.Expense, 1500, day(8), "something else")
+	coffee := ledgerTr(transaction.Expense, 200, day(6), "Coffee beans")
+	manual := ledgerTr(transaction.Income, 99, day(7), "not in bank")
+	repo := &mockRepo{Ledger: []*transaction.Transaction{rent, rentLookalike, coffee, manual}}
+	s := NewReconciliationService(repo)
+
+	report, err := s.CreateSession(statement.File{Name: "march.qif", Data: []byte(marchQIF)}, "", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Matched) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(report.Matched))
+	}
+	got := map[uuid.UUID]bool{}
+	for _, m := range report.Matched {
+		got[m.Transaction.ID] = true
+		if m.Line.MatchType != reconciliation.AutoMatch {
+			t.Errorf("expected auto match, got %q", m.Line.MatchType)
+		}
+	}
+	if !got[rent.ID] || !got[coffee.ID] {
+		t.Errorf("expected rent and coffee to match, got %v", got)
+	}
+	if len(report.UnmatchedBank) != 1 || report.UnmatchedBank[0].Amount != 300 {
+		t.Errorf("unexpected unmatched bank lines: %+v", report.UnmatchedBank)
+	}
+	if len(report.UnmatchedLedger) != 2 {
+		t.Errorf("expected lookalike and manual unmatched in ledger, got %d", len(report.UnmatchedLedger))
+	}
+}
+
+func TestMatchUnmatchAndClose(t *testing.T) {
+	refund := ledgerTr(transaction.Income, 310, day(20), "refund, corrected")
+	repo := &mockRepo{Ledger: []*transaction.Transaction{refund}}
+	s := NewReconciliationService(repo)
+
+	report, err := s.CreateSession(statement.File{Name: "march.qif", Data: []byte(marchQIF)}, "qif", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := report.Session.ID.String()
+	var refundLine int64
+	for _, l := range report.UnmatchedBank {
+		if l.Amount == 300 {
+			refundLine = l.ID
+		}
+	}
+
+	report, err = s.Match(id, refundLine, refund.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Matched) != 1 || report.Matched[0].Line.MatchType != reconciliation.ManualMatch {
+		t.Fatalf("expected manual match, got %+v", report.Matched)
+	}
+
+	report, err = s.Unmatch(id, refundLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Matched) != 0 || len(report.UnmatchedLedger) != 1 {
+		t.Fatalf("expected match to be removed, got %+v", report)
+	}
+
+	if _, err := s.Match(id, refundLine, refund.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Close(id); err != nil {
+		t.Fatal(err)
+	}
+	if !repo.Closed || len(repo.ClosedIDs) != 1 || repo.ClosedIDs[0] != refund.ID {
+		t.Fatalf("expected refund to be reconciled, got %v", repo.ClosedIDs)
+	}
+
+	if _, err := s.Unmatch(id, refundLine); !errors.Is(err, reconciliation.ErrSessionClosed) {
+		t.Fatalf("expected ErrSessionClosed, got %v", err)
+	}
+}
+
+func TestMatch_RejectsTakenTransaction(t *testing.T) {
+	coffee := ledgerTr(transaction.Expense, 200, day(6), "Coffee beans")
+	repo := &mockRepo{Ledger: []*transaction.Transaction{coffee}}
+	s := NewReconciliationService(repo)
+
+	report, err := s.CreateSession(statement.File{Name: "march.qif", Data: []byte(marchQIF)}, "", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := report.UnmatchedBank[0].ID
+	if _, err := s.Match(report.Session.ID.String(), other, coffee.ID.String()); !errors.Is(err, reconciliation.ErrAlreadyMatched) {
+		t.Fatalf("expected ErrAlreadyMatched, got %v", err)
+	}
+}
+
+func TestMatch_SkipsTransactionsOfOtherOpenSessions(t *testing.T) {
+	coffee := ledgerTr(transaction.Expense, 200, day(6), "Coffee beans")
+	repo := &mockRepo{Ledger: []*transaction.Transaction{coffee}, Elsewhere: map[uuid.UUID]bool{coffee.ID: true}}
+	s := NewReconciliationService(repo)
+
+	report, err := s.CreateSession(statement.File{Name: "march.qif", Data: []byte(marchQIF)}, "", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Matched) != 0 {
+		t.Fatalf("transaction matched in another open session must not be auto-matched, got %+v", report.Matched)
+	}
+	line := report.UnmatchedBank[1].ID
+	if _, err := s.Match(report.Session.ID.String(), line, coffee.ID.String()); !errors.Is(err, reconciliation.ErrAlreadyMatched) {
+		t.Fatalf("expected ErrAlreadyMatched, got %v", err)
+	}
+}
+
+func TestCreateSession_EmptyStatement(t *testing.T) {
+	s := NewReconciliationService(&mockRepo{})
+	if _, err := s.CreateSession(statement.File{Name: "x.bin", Data: []byte("garbage")}, "", time.UTC); !errors.Is(err, reconciliation.ErrInvalidStatement) {
+		t.Fatalf("expected ErrInvalidStatement, got %v", err)
+	}
+}
+
+func TestCreateSession_UnknownFormat(t *testing.T) {
+	s := NewReconciliationService(&mockRepo{})
+	if _, err := s.CreateSession(statement.File{Name: "march.qif", Data: []byte(marchQIF)}, "csv", time.UTC); !errors.Is(err, statement.ErrUnknownFormat) {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestGetReport_Errors(t *testing.T) {
+	s := NewReconciliationService(&mockRepo{})
+	if _, err := s.GetReport("not-a-uuid"); !errors.Is(err, reconciliation.ErrInvalidID) {
+		t.Fatalf("expected ErrInvalidID, got %v", err)
+	}
+	if _, err := s.GetReport(uuid.NewString()); !errors.Is(err, reconciliation.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestScoreMatch(t *testing.T) {
+	line := &reconciliation.BankLine{Type: transaction.Expense, Amount: 100, Date: day(10), Description: "Taxi"}
+	if _, ok := scoreMatch(line, ledgerTr(transaction.Income, 100, day(10), "Taxi")); ok {
+		t.Error("different type must not match")
+	}
+	if _, ok := scoreMatch(line, ledgerTr(transaction.Expense, 100.01, day(10), "Taxi")); ok {
+		t.Error("different amount must not match")
+	}
+	if _, ok := scoreMatch(line, ledgerTr(transaction.Expense, 100, day(14), "Taxi")); ok {
+		t.Error("date outside window must not match")
+	}
+	exact, _ := scoreMatch(line, ledgerTr(transaction.Expense, 100, day(10), "Taxi"))
+	later, _ := scoreMatch(line, ledgerTr(transaction.Expense, 100, day(12), "Taxi"))
+	if exact != 1 || later >= exact {
+		t.Errorf("unexpected scores: exact=%v later=%v", exact, later)
+	}
+}
@@ -0,0 +1,164 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/config"
+	"salestracker/internal/domain/event"
+	"salestracker/internal/domain/transaction"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	subscriberBuffer = 64
+	reconnectDelay   = 3 * time.Second
+)
+
+// StreamService раздает события outbox подписчикам SSE и хранит ограниченный буфер для догонки по Last-Event-ID
+type StreamService struct {
+	repo     StreamStorageProvider
+	capacity int
+
+	mu     sync.Mutex
+	buffer []event.Event
+	seen   map[int64]struct{}
+	subs   map[<-chan event.Event]*subscriber
+}
+
+type StreamStorageProvider interface {
+	GetRecentEvents(limit int) ([]event.Event, error)
+	ListenEvents(ctx context.Context) (<-chan event.Event, error)
+}
+
+type subscriber struct {
+	ch     chan event.Event
+	filter transaction.Filter
+}
+
+func NewStreamService(repo StreamStorageProvider, cfg *config.AppConfig) *StreamService {
+	capacity := cfg.Stream.ReplayBuffer
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &StreamService{
+		repo:     repo,
+		capacity: capacity,
+		seen:     map[int64]struct{}{},
+		subs:     map[<-chan event.Event]*subscriber{},
+	}
+}
+
+// Subscribe регистрирует подписчика. Если lastEventID есть в буфере — возвращает события после него;
+// если его там нет (буфер переполнен или события не существует) — выставляет Reset, и клиент должен перечитать данные.
+// Подписчик получает только события транзакций, подходящих под фильтр f
+func (s *StreamService) Subscribe(lastEventID string, f transaction.Filter) *event.Stream {
+	sub := &subscriber{
+		ch:     make(chan event.Event, subscriberBuffer),
+		filter: f,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream := &event.Stream{C: sub.ch}
+	if lastEventID != "" {
+		replay, ok := s.replayAfterLocked(lastEventID)
+		if !ok {
+			stream.Reset = true
+		}
+		for _, ev := range replay {
+			if ev.Matches(f) {
+				stream.Replay = append(stream.Replay, ev)
+			}
+		}
+	}
+	s.subs[sub.ch] = sub
+	return stream
+}
+
+func (s *StreamService) Unsubscribe(stream *event.Stream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subs[stream.C]; ok {
+		delete(s.subs, stream.C)
+		close(sub.ch)
+	}
+}
+
+// Run слушает события из хранилища до отмены ctx, переподключаясь при ошибках
+func (s *StreamService) Run(ctx context.Context) {
+	for {
+		if err := s.consume(ctx); err != nil && ctx.Err() == nil {
+			wbzlog.Logger.Error().Err(err).Msg("event stream listener error")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (s *StreamService) consume(ctx context.Context) error {
+	// Сначала подписываемся, потом догружаем историю, чтобы не потерять события между этими шагами
+	events, err := s.repo.ListenEvents(ctx)
+	if err != nil {
+		return err
+	}
+	recent, err := s.repo.GetRecentEvents(s.capacity)
+	if err != nil {
+		return err
+	}
+	for _, ev := range recent {
+		s.Publish(ev)
+	}
+	for ev := range events {
+		s.Publish(ev)
+	}
+	return errors.New("event listener closed")
+}
+
+// Publish кладет событие в буфер и рассылает подписчикам; подписчик, не успевающий читать, отключается
+// и при переподключении догонит пропущенное по Last-Event-ID
+func (s *StreamService) Publish(ev event.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[ev.ID]; ok {
+		return
+	}
+	s.buffer = append(s.buffer, ev)
+	s.seen[ev.ID] = struct{}{}
+	if len(s.buffer) > s.capacity {
+		delete(s.seen, s.buffer[0].ID)
+		s.buffer = append([]event.Event(nil), s.buffer[1:]...)
+	}
+
+	for key, sub := range s.subs {
+		if !ev.Matches(sub.filter) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			delete(s.subs, key)
+			close(sub.ch)
+		}
+	}
+}
+
+func (s *StreamService) replayAfterLocked(lastEventID string) ([]event.Event, bool) {
+	id, err := strconv.ParseInt(lastEventID, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	for i, ev := range s.buffer {
+		if ev.ID == id {
+			return append([]event.Event(nil), s.buffer[i+1:]...), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"salestracker/internal/config"
+	"salestracker/internal/domain/event"
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+// --- Mock repository ---
+type mockRepo struct {
+	Recent []event.Event
+	Live   chan event.Event
+	Err    error
+}
+
+func (m *mockRepo) GetRecentEvents(limit int) ([]event.Event, error) {
+	return m.Recent, m.Err
+}
+func (m *mockRepo) ListenEvents(ctx context.Context) (<-chan event.Event, error) {
+	return m.Live, m.Err
+}
+
+// --- Helpers ---
+func newTestService(capacity int) *StreamService {
+	return NewStreamService(&mockRepo{}, &config.AppConfig{Stream: config.StreamConfig{ReplayBuffer: capacity}})
+}
+
+func ev(id int64, trType, category string) event.Event {
+	data, _ := json.Marshal(map[string]string{"Type": trType, "Category": category})
+	return event.Event{ID: id, Type: event.TransactionCreated, Data: data}
+}
+
+func ids(evs []event.Event) []int64 {
+	out := make([]int64, 0, len(evs))
+	for _, e := range evs {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+// --- Tests ---
+
+func TestSubscribe_ReplaysAfterLastEventID(t *testing.T) {
+	s := newTestService(10)
+	for i := int64(1); i <= 4; i++ {
+		s.Publish(ev(i, "income", "sales"))
+	}
+
+	stream := s.Subscribe("2", transaction.Filter{})
+	if stream.Reset {
+		t.Fatal("unexpected reset")
+	}
+	if got := ids(stream.Replay); len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("unexpected replay %v", got)
+	}
+}
+
+func TestSubscribe_ResetWhenEvicted(t *testing.T) {
+	s := newTestService(2)
+	for i := int64(1); i <= 5; i++ {
+		s.Publish(ev(i, "income", "sales"))
+	}
+
+	stream := s.Subscribe("1", transaction.Filter{})
+	if !stream.Reset {
+		t.Fatal("expected reset for evicted event")
+	}
+	if len(stream.Replay) != 0 {
+		t.Errorf("expected empty replay, got %v", ids(stream.Replay))
+	}
+}
+
+func TestPublish_FiltersAndDeduplicates(t *testing.T) {
+	s := newTestService(10)
+	stream := s.Subscribe("", transaction.Filter{Type: transaction.Expense})
+
+	s.Publish(ev(1, "income", "sales"))
+	s.Publish(ev(2, "expense", "rent"))
+	s.Publish(ev(2, "expense", "rent"))
+
+	select {
+	case got := <-stream.C:
+		if got.ID != 2 {
+			t.Fatalf("expected event 2, got %d", got.ID)
+		}
+	default:
+		t.Fatal("expected matching event")
+	}
+	select {
+	case got := <-stream.C:
+		t.Fatalf("unexpected extra event %d", got.ID)
+	default:
+	}
+}
+
+func TestPublish_MultipleCategories(t *testing.T) {
+	s := newTestService(10)
+	stream := s.Subscribe("", transaction.Filter{Categories: []string{"rent", "food"}})
+
+	s.Publish(ev(1, "income", "sales"))
+	s.Publish(ev(2, "expense", "rent"))
+	s.Publish(ev(3, "expense", "food"))
+
+	var got []int64
+	for len(stream.C) > 0 {
+		got = append(got, (<-stream.C).ID)
+	}
+	if len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("expected events 2 and 3, got %v", got)
+	}
+}
+
+func TestPublish_DropsSlowSubscriber(t *testing.T) {
+	s := newTestService(1000)
+	stream := s.Subscribe("", transaction.Filter{})
+
+	for i := int64(1); i <= subscriberBuffer+1; i++ {
+		s.Publish(ev(i, "income", "sales"))
+	}
+
+	n := 0
+	for range stream.C {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("expected %d buffered events before drop, got %d", subscriberBuffer, n)
+	}
+	// Повторная отписка уже отключенного подписчика не должна паниковать
+	s.Unsubscribe(stream)
+}
+
+func TestRun_PreloadsAndStreams(t *testing.T) {
+	repo := &mockRepo{Recent: []event.Event{ev(1, "income", "sales")}, Live: make(chan event.Event, 1)}
+	s := NewStreamService(repo, &config.AppConfig{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo.Live <- ev(2, "income", "sales")
+	go s.Run(ctx)
+
+	deadline := time.After(time.Second)
+	for {
+		stream := s.Subscribe("1", transaction.Filter{})
+		replay := ids(stream.Replay)
+		s.Unsubscribe(stream)
+		if !stream.Reset && len(replay) == 1 && replay[0] == 2 {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("events not streamed, last replay %v", replay)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package transactions
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"math"
+	"salestracker/internal/domain/transaction"
+	"sort"
+	"time"
+)
+
+const (
+	// duplicateWindow — максимальная разница в датах, при которой пара считается кандидатом
+	duplicateWindow = 3 * 24 * time.Hour
+	// DefaultDuplicateThreshold — минимальный score, начиная с которого пара считается дублем
+	DefaultDuplicateThreshold = 0.7
+
+	amountWeight      = 0.2
+	dateWeight        = 0.3
+	categoryWeight    = 0.25
+	descriptionWeight = 0.25
+)
+
+func (s *TransactionService) FindDuplicates(from, to time.Time, threshold float64) ([]*transaction.DuplicatePair, error) {
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		err := fmt.Errorf("'from' date cannot be after 'to'")
+		wbzlog.Logger.Warn().Err(err).Msg("invalid date range in duplicates request")
+		return nil, err
+	}
+	if threshold <= 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+
+	trs, err := s.repo.GetAllTransactions(from, to, transaction.Filter{}, "date", "asc")
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get all transactions error")
+		return nil, err
+	}
+	dismissed, err := s.repo.GetDismissedDuplicates()
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get dismissed duplicates error")
+		return nil, err
+	}
+
+	// Кандидаты всегда совпадают по типу и точной сумме, поэтому сравниваем только внутри таких корзин
+	buckets := map[string][]*transaction.Transaction{}
+	for _, tr := range trs {
+		key := duplicateBucketKey(tr)
+		buckets[key] = append(buckets[key], tr)
+	}
+
+	pairs := []*transaction.DuplicatePair{}
+	for _, bucket := range buckets {
+		sort.Slice(bucket, func(i, j int) bool { return bucket[i].Date.Before(bucket[j].Date) })
+		for i := range bucket {
+			for j := i + 1; j < len(bucket); j++ {
+				if bucket[j].Date.Sub(bucket[i].Date) > duplicateWindow {
+					break
+				}
+				if dismissed[transaction.PairKey(bucket[i].ID, bucket[j].ID)] {
+					continue
+				}
+				if pair := scoreDuplicate(bucket[i], bucket[j]); pair != nil && pair.Score >= threshold {
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		return pairs[i].First.Date.After(pairs[j].First.Date)
+	})
+	return pairs, nil
+}
+
+func (s *TransactionService) FindLikelyDuplicates(tr *transaction.Transaction) ([]*transaction.DuplicatePair, error) {
+	trs, err := s.repo.GetAllTransactions(tr.Date.Add(-duplicateWindow), tr.Date.Add(duplicateWindow), transaction.Filter{Type: tr.Type}, "date", "asc")
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get all transactions error")
+		return nil, err
+	}
+
+	pairs := []*transaction.DuplicatePair{}
+	for _, other := range trs {
+		if other.ID == tr.ID {
+			continue
+		}
+		if pair := scoreDuplicate(other, tr); pair != nil && pair.Score >= DefaultDuplicateThreshold {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Score > pairs[j].Score })
+	return pairs, nil
+}
+
+func (s *TransactionService) ResolveDuplicate(keepID, dropID, action string) (*transaction.Transaction, error) {
+	keepUID, err := uuid.Parse(keepID)
+	if err != nil {
+		wbzlog.Logger.Warn().Str("id", keepID).Msg("invalid uuid")
+		return nil, fmt.Errorf("%w: keepId: %v", transaction.ErrInvalidDuplicatePair, err)
+	}
+	dropUID, err := uuid.Parse(dropID)
+	if err != nil {
+		wbzlog.Logger.Warn().Str("id", dropID).Msg("invalid uuid")
+		return nil, fmt.Errorf("%w: dropId: %v", transaction.ErrInvalidDuplicatePair, err)
+	}
+	if keepUID == dropUID {
+		return nil, fmt.Errorf("%w: pair must reference two different transactions", transaction.ErrInvalidDuplicatePair)
+	}
+
+	keep, err := s.repo.GetTransaction(keepID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get (for resolve) transaction error")
+		return nil, err
+	}
+	drop, err := s.repo.GetTransaction(dropID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get (for resolve) transaction error")
+		return nil, err
+	}
+	if keep == nil || drop == nil {
+		return nil, transaction.ErrNotFound
+	}
+	if transaction.DuplicateAction(action) == transaction.MergeDuplicate && drop.Reconciled {
+		return nil, transaction.ErrReconciled
+	}
+
+	switch transaction.DuplicateAction(action) {
+	case transaction.DismissDuplicate:
+		if err := s.repo.DismissDuplicate(keep.ID, drop.ID); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("repo dismiss duplicate error")
+			return nil, err
+		}
+		return keep, nil
+	case transaction.MergeDuplicate:
+		fillDescription := keep.Description == "" && drop.Description != ""
+		fillCounterparty := keep.Counterparty == "" && drop.Counterparty != ""
+		// сверенную транзакцию слияние может только оставить, но не переписать
+		if keep.Reconciled && (fillDescription || fillCounterparty) {
+			return nil, transaction.ErrReconciled
+		}
+		if fillDescription {
+			keep.Description = drop.Description
+		}
+		if fillCounterparty {
+			keep.Counterparty = drop.Counterparty
+		}
+		if err := s.repo.MergeDuplicate(keep, drop.ID); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("repo merge duplicate error")
+			return nil, err
+		}
+		return keep, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown resolve action %q", transaction.ErrInvalidDuplicatePair, action)
+	}
+}
+
+// scoreDuplicate оценивает пару транзакций; nil — пара не может быть дублем (разный тип или сумма)
+func scoreDuplicate(a, b *transaction.Transaction) *transaction.DuplicatePair {
+	if a.Type != b.Type || transaction.Cents(a.Amount) != transaction.Cents(b.Amount) {
+		return nil
+	}
+	days := transaction.DaysApart(a.Date, b.Date)
+	windowDays := duplicateWindow.Hours() / 24
+	if days > windowDays {
+		return nil
+	}
+
+	score := amountWeight
+	reasons := []string{"same amount"}
+
+	score += dateWeight * transaction.DateCloseness(days, windowDays)
+	if days == 0 {
+		reasons = append(reasons, "same day")
+	} else {
+		reasons = append(reasons, fmt.Sprintf("%.0f day(s) apart", days))
+	}
+
+	if a.Category == b.Category {
+		score += categoryWeight
+		reasons = append(reasons, "same category")
+	}
+
+	sim := transaction.DescriptionSimilarity(a.Description, b.Description)
+	score += descriptionWeight * sim
+	if sim >= 0.5 {
+		reasons = append(reasons, fmt.Sprintf("similar description (%.2f)", sim))
+	}
+
+	return &transaction.DuplicatePair{
+		First:   a,
+		Second:  b,
+		Score:   math.Round(score*1000) / 1000,
+		Reasons: reasons,
+	}
+}
+
+func duplicateBucketKey(tr *transaction.Transaction) string {
+	return fmt.Sprintf("%s:%d", tr.Type, transaction.Cents(tr.Amount))
+}
@@ -0,0 +1,177 @@
+package transactions
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+func mustTransaction(t *testing.T, trType transaction.TransactionType, category string, amount float64, descr string, date time.Time) *transaction.Transaction {
+	tr, err := transaction.NewTransaction(trType, category, amount, descr, date)
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+	return tr
+}
+
+func TestFindDuplicates_FindsSameDayPair(t *testing.T) {
+	day := time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)
+	a := mustTransaction(t, transaction.Expense, "office", 1500, "Paper A4", day)
+	b := mustTransaction(t, transaction.Expense, "office", 1500, "paper a4 x5", day)
+	c := mustTransaction(t, transaction.Expense, "office", 1499, "Paper A4", day)
+
+	svc := NewTransactionService(&mockRepo{GetAllTrs: []*transaction.Transaction{a, b, c}})
+	pairs, err := svc.FindDuplicates(time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pairs) != 1 {
+		t.Fatalf("expected 1 pair, got %d", len(pairs))
+	}
+	if pairs[0].Score < DefaultDuplicateThreshold {
+		t.Fatalf("score %v below threshold", pairs[0].Score)
+	}
+}
+
+func TestFindDuplicates_SkipsDismissedAndDistantPairs(t *testing.T) {
+	day := time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)
+	a := mustTransaction(t, transaction.Income, "sales", 300, "order 1", day)
+	b := mustTransaction(t, transaction.Income, "sales", 300, "order 1", day)
+	c := mustTransaction(t, transaction.Income, "sales", 700, "order 2", day)
+	d := mustTransaction(t, transaction.Income, "sales", 700, "order 2", day.AddDate(0, 0, 10))
+
+	repo := &mockRepo{
+		GetAllTrs: []*transaction.Transaction{a, b, c, d},
+		Dismissed: map[string]bool{transaction.PairKey(b.ID, a.ID): true},
+	}
+	pairs, err := NewTransactionService(repo).FindDuplicates(time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pairs) != 0 {
+		t.Fatalf("expected no pairs, got %d", len(pairs))
+	}
+}
+
+func TestFindLikelyDuplicates_ExcludesSelf(t *testing.T) {
+	day := time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)
+	existing := mustTransaction(t, transaction.Expense, "food", 42, "lunch", day)
+	created := mustTransaction(t, transaction.Expense, "food", 42, "Lunch!", day)
+
+	svc := NewTransactionService(&mockRepo{GetAllTrs: []*transaction.Transaction{existing, created}})
+	pairs, err := svc.FindLikelyDuplicates(created)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pairs) != 1 || pairs[0].First.ID != existing.ID || pairs[0].Second.ID != created.ID {
+		t.Fatal("expected the existing transaction to be reported as a duplicate")
+	}
+}
+
+func TestResolveDuplicate_Merge(t *testing.T) {
+	day := time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)
+	keep := mustTransaction(t, transaction.Expense, "food", 42, "", day)
+	drop := mustTransaction(t, transaction.Expense, "food", 42, "lunch", day)
+	repo := &mockRepo{GetByID: map[string]*transaction.Transaction{
+		keep.ID.String(): keep,
+		drop.ID.String(): drop,
+	}}
+
+	res, err := NewTransactionService(repo).ResolveDuplicate(keep.ID.String(), drop.ID.String(), "merge")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.MergedDropID != drop.ID || repo.MergedKeep != keep {
+		t.Fatal("merge not passed to repository")
+	}
+	if res.Description != "lunch" {
+		t.Fatal("expected empty description to be taken from the dropped transaction")
+	}
+}
+
+func TestResolveDuplicate_MergeIntoReconciledKeep(t *testing.T) {
+	day := time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)
+	keep := mustTransaction(t, transaction.Expense, "food", 42, "", day)
+	keep.Reconciled = true
+	drop := mustTransaction(t, transaction.Expense, "food", 42, "lunch", day)
+	same := mustTransaction(t, transaction.Expense, "food", 42, "", day)
+	repo := &mockRepo{GetByID: map[string]*transaction.Transaction{
+		keep.ID.String(): keep,
+		drop.ID.String(): drop,
+		same.ID.String(): same,
+	}}
+	svc := NewTransactionService(repo)
+
+	if _, err := svc.ResolveDuplicate(keep.ID.String(), drop.ID.String(), "merge"); !errors.Is(err, transaction.ErrReconciled) {
+		t.Fatalf("expected ErrReconciled when merge would rewrite a reconciled keep, got %v", err)
+	}
+	if keep.Description != "" || repo.MergedKeep != nil {
+		t.Fatal("reconciled keep must stay untouched")
+	}
+
+	if _, err := svc.ResolveDuplicate(keep.ID.String(), same.ID.String(), "merge"); err != nil {
+		t.Fatalf("merge that leaves a reconciled keep unchanged must pass, got %v", err)
+	}
+	if repo.MergedDropID != same.ID {
+		t.Fatal("merge not passed to repository")
+	}
+}
+
+func TestResolveDuplicate_Dismiss(t *testing.T) {
+	day := time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)
+	a := mustTransaction(t, transaction.Expense, "food", 42, "lunch", day)
+	b := mustTransaction(t, transaction.Expense, "food", 42, "lunch", day)
+	repo := &mockRepo{GetByID: map[string]*transaction.Transaction{
+		a.ID.String(): a,
+		b.ID.String(): b,
+	}}
+
+	if _, err := NewTransactionService(repo).ResolveDuplicate(a.ID.String(), b.ID.String(), "dismiss"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.DismissedPair != [2]uuid.UUID{a.ID, b.ID} {
+		t.Fatal("dismissed pair not passed to repository")
+	}
+}
+
+func TestResolveDuplicate_UnknownAction(t *testing.T) {
+	day := time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)
+	a := mustTransaction(t, transaction.Expense, "food", 42, "lunch", day)
+	b := mustTransaction(t, transaction.Expense, "food", 42, "lunch", day)
+	repo := &mockRepo{GetByID: map[string]*transaction.Transaction{
+		a.ID.String(): a,
+		b.ID.String(): b,
+	}}
+
+	if _, err := NewTransactionService(repo).ResolveDuplicate(a.ID.String(), b.ID.String(), "delete"); !errors.Is(err, transaction.ErrInvalidDuplicatePair) {
+		t.Fatalf("expected ErrInvalidDuplicatePair for unknown action, got %v", err)
+	}
+}
+
+func TestResolveDuplicate_TypedErrors(t *testing.T) {
+	day := time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)
+	a := mustTransaction(t, transaction.Expense, "food", 42, "lunch", day)
+	b := mustTransaction(t, transaction.Expense, "food", 42, "lunch", day)
+	b.Reconciled = true
+	svc := NewTransactionService(&mockRepo{GetByID: map[string]*transaction.Transaction{
+		a.ID.String(): a,
+		b.ID.String(): b,
+	}})
+
+	cases := []struct {
+		keep, drop string
+		want       error
+	}{
+		{"bad-uuid", b.ID.String(), transaction.ErrInvalidDuplicatePair},
+		{a.ID.String(), a.ID.String(), transaction.ErrInvalidDuplicatePair},
+		{a.ID.String(), uuid.NewString(), transaction.ErrNotFound},
+		{a.ID.String(), b.ID.String(), transaction.ErrReconciled},
+	}
+	for _, c := range cases {
+		if _, err := svc.ResolveDuplicate(c.keep, c.drop, "merge"); !errors.Is(err, c.want) {
+			t.Errorf("%s/%s: expected %v, got %v", c.keep, c.drop, c.want, err)
+		}
+	}
+}
@@ -17,9 +17,12 @@ type TransactionService struct {
 type TransactionStorageProvider interface {
 	DeleteTransaction(id string) error
 	GetTransaction(id string) (*transaction.Transaction, error)
-	GetAllTransactions(from, to time.Time, trtype, category, sortBy, sortDir string) ([]*transaction.Transaction, error)
+	GetAllTransactions(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error)
 	SaveTransaction(tr *transaction.Transaction) error
 	UpdateTransaction(tr *transaction.Transaction) error
+	GetDismissedDuplicates() (map[string]bool, error)
+	DismissDuplicate(firstID, secondID uuid.UUID) error
+	MergeDuplicate(keep *transaction.Transaction, dropID uuid.UUID) error
 }
 
 func NewTransactionService(repo TransactionStorageProvider) *TransactionService {
@@ -56,8 +59,8 @@ func (s *TransactionService) CreateTransaction(trType, category string, amount f
 	return tr, nil
 }
 
-func (s *TransactionService) GetAllTransactions(from, to time.Time, trtype, category, sortBy, sortDir string) ([]*transaction.Transaction, error) {
-	trs, err := s.repo.GetAllTransactions(from, to, trtype, category, sortBy, sortDir)
+func (s *TransactionService) GetAllTransactions(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error) {
+	trs, err := s.repo.GetAllTransactions(from, to, f, sortBy, sortDir)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("repo get all transactions error")
 		return nil, err
@@ -65,7 +68,7 @@ func (s *TransactionService) GetAllTransactions(from, to time.Time, trtype, cate
 	return trs, nil
 }
 
-func (s *TransactionService) PutTransaction(id string, trType string, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error) {
+func (s *TransactionService) PutTransaction(id string, trType string, category string, amount float64, date time.Time, descr string, override bool) (*transaction.Transaction, error) {
 	_, err := uuid.Parse(id)
 	if err != nil {
 		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
@@ -76,6 +79,14 @@ func (s *TransactionService) PutTransaction(id string, trType string, category s
 		wbzlog.Logger.Error().Err(err).Msg("repo get (for put) transaction error")
 		return nil, err
 	}
+	if tr == nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("attempt to change missing transaction")
+		return nil, transaction.ErrNotFound
+	}
+	if tr.Reconciled && !override {
+		wbzlog.Logger.Warn().Str("id", id).Msg("attempt to change reconciled transaction")
+		return nil, transaction.ErrReconciled
+	}
 	err = tr.TransactionChange(transaction.TransactionType(trType), category, amount, descr, date)
 	if err != nil {
 		wbzlog.Logger.Warn().Err(err).Msg("invalid data for transaction change")
@@ -89,12 +100,23 @@ func (s *TransactionService) PutTransaction(id string, trType string, category s
 	return tr, err
 }
 
-func (s *TransactionService) DeleteTransaction(id string) error {
+func (s *TransactionService) DeleteTransaction(id string, override bool) error {
 	_, err := uuid.Parse(id)
 	if err != nil {
 		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
 		return err
 	}
+	if !override {
+		tr, err := s.repo.GetTransaction(id)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("repo get (for delete) transaction error")
+			return err
+		}
+		if tr != nil && tr.Reconciled {
+			wbzlog.Logger.Warn().Str("id", id).Msg("attempt to delete reconciled transaction")
+			return transaction.ErrReconciled
+		}
+	}
 	err = s.repo.DeleteTransaction(id)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("repo delete transaction error")
@@ -103,8 +125,8 @@ func (s *TransactionService) DeleteTransaction(id string) error {
 	return nil
 }
 
-func (s *TransactionService) GetCSV(from, to time.Time, trtype, category, sortBy, sortDir string, output io.Writer) error {
-	trs, err := s.repo.GetAllTransactions(from, to, trtype, category, sortBy, sortDir)
+func (s *TransactionService) GetCSV(from, to time.Time, f transaction.Filter, sortBy, sortDir string, output io.Writer) error {
+	trs, err := s.repo.GetAllTransactions(from, to, f, sortBy, sortDir)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("repo get all transactions error")
 		return err
@@ -113,7 +135,7 @@ func (s *TransactionService) GetCSV(from, to time.Time, trtype, category, sortBy
 	writer := csv.NewWriter(output)
 	defer writer.Flush()
 
-	headers := []string{"ID", "Type", "Category", "Amount", "Date", "Description"}
+	headers := []string{"ID", "Type", "Category", "Amount", "Date", "Description", "Counterparty"}
 	if err := writer.Write(headers); err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("error writing CSV headers")
 		return err
@@ -127,6 +149,7 @@ func (s *TransactionService) GetCSV(from, to time.Time, trtype, category, sortBy
 			fmt.Sprintf("%.2f", tr.Amount),
 			tr.Date.Format(time.RFC3339),
 			tr.Description,
+			tr.Counterparty,
 		}
 		if err := writer.Write(row); err != nil {
 			wbzlog.Logger.Error().Err(err).Msg("error writing CSV row")
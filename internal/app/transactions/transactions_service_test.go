@@ -17,15 +17,24 @@ type mockRepo struct {
 	UpdatedTr *transaction.Transaction
 	DeletedID string
 	SavedTr   *transaction.Transaction
+
+	GetByID       map[string]*transaction.Transaction
+	Dismissed     map[string]bool
+	DismissedPair [2]uuid.UUID
+	MergedKeep    *transaction.Transaction
+	MergedDropID  uuid.UUID
 }
 
 func (m *mockRepo) GetTransaction(id string) (*transaction.Transaction, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
+	if m.GetByID != nil {
+		return m.GetByID[id], nil
+	}
 	return m.GetTr, nil
 }
-func (m *mockRepo) GetAllTransactions(from, to time.Time, trtype, category, sortBy, sortDir string) ([]*transaction.Transaction, error) {
+func (m *mockRepo) GetAllTransactions(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -52,6 +61,27 @@ func (m *mockRepo) DeleteTransaction(id string) error {
 	m.DeletedID = id
 	return nil
 }
+func (m *mockRepo) GetDismissedDuplicates() (map[string]bool, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.Dismissed, nil
+}
+func (m *mockRepo) DismissDuplicate(firstID, secondID uuid.UUID) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.DismissedPair = [2]uuid.UUID{firstID, secondID}
+	return nil
+}
+func (m *mockRepo) MergeDuplicate(keep *transaction.Transaction, dropID uuid.UUID) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.MergedKeep = keep
+	m.MergedDropID = dropID
+	return nil
+}
 
 // --- Helpers ---
 func sampleTransaction(t *testing.T) *transaction.Transaction {
@@ -112,7 +142,7 @@ func TestCreateTransaction_Success(t *testing.T) {
 
 func TestPutTransaction_InvalidUUID(t *testing.T) {
 	svc := NewTransactionService(&mockRepo{})
-	_, err := svc.PutTransaction("bad-uuid", "income", "cat", 10, time.Now(), "desc")
+	_, err := svc.PutTransaction("bad-uuid", "income", "cat", 10, time.Now(), "desc", false)
 	if err == nil {
 		t.Fatal("expected error for invalid UUID")
 	}
@@ -121,17 +151,25 @@ func TestPutTransaction_InvalidUUID(t *testing.T) {
 func TestPutTransaction_RepoGetError(t *testing.T) {
 	svc := NewTransactionService(&mockRepo{Err: errors.New("get fail")})
 	id := uuid.New().String()
-	_, err := svc.PutTransaction(id, "income", "cat", 10, time.Now(), "desc")
+	_, err := svc.PutTransaction(id, "income", "cat", 10, time.Now(), "desc", false)
 	if err == nil || err.Error() != "get fail" {
 		t.Fatal("expected repo get error")
 	}
 }
 
+func TestPutTransaction_NotFound(t *testing.T) {
+	svc := NewTransactionService(&mockRepo{})
+	_, err := svc.PutTransaction(uuid.New().String(), "income", "cat", 10, time.Now(), "desc", false)
+	if !errors.Is(err, transaction.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
 func TestPutTransaction_Success(t *testing.T) {
 	tr := sampleTransaction(t)
 	svc := NewTransactionService(&mockRepo{GetTr: tr})
 	newAmount := 200.0
-	res, err := svc.PutTransaction(tr.ID.String(), "income", "cat", newAmount, time.Now(), "updated")
+	res, err := svc.PutTransaction(tr.ID.String(), "income", "cat", newAmount, time.Now(), "updated", false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -142,7 +180,7 @@ func TestPutTransaction_Success(t *testing.T) {
 
 func TestDeleteTransaction_InvalidUUID(t *testing.T) {
 	svc := NewTransactionService(&mockRepo{})
-	err := svc.DeleteTransaction("bad-uuid")
+	err := svc.DeleteTransaction("bad-uuid", false)
 	if err == nil {
 		t.Fatal("expected error for invalid UUID")
 	}
@@ -151,7 +189,7 @@ func TestDeleteTransaction_InvalidUUID(t *testing.T) {
 func TestDeleteTransaction_RepoError(t *testing.T) {
 	id := uuid.New().String()
 	svc := NewTransactionService(&mockRepo{Err: errors.New("delete fail")})
-	err := svc.DeleteTransaction(id)
+	err := svc.DeleteTransaction(id, false)
 	if err == nil || err.Error() != "delete fail" {
 		t.Fatal("expected repo delete error")
 	}
@@ -160,7 +198,7 @@ func TestDeleteTransaction_RepoError(t *testing.T) {
 func TestDeleteTransaction_Success(t *testing.T) {
 	id := uuid.New().String()
 	svc := NewTransactionService(&mockRepo{})
-	err := svc.DeleteTransaction(id)
+	err := svc.DeleteTransaction(id, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -171,7 +209,7 @@ func TestDeleteTransaction_Success(t *testing.T) {
 
 func TestGetAllTransactions_RepoError(t *testing.T) {
 	svc := NewTransactionService(&mockRepo{Err: errors.New("fail")})
-	_, err := svc.GetAllTransactions(time.Now(), time.Now(), "", "", "", "")
+	_, err := svc.GetAllTransactions(time.Now(), time.Now(), transaction.Filter{}, "", "")
 	if err == nil || err.Error() != "fail" {
 		t.Fatal("expected repo error")
 	}
@@ -180,7 +218,7 @@ func TestGetAllTransactions_RepoError(t *testing.T) {
 func TestGetAllTransactions_Success(t *testing.T) {
 	trs := []*transaction.Transaction{sampleTransaction(nil)}
 	svc := NewTransactionService(&mockRepo{GetAllTrs: trs})
-	res, err := svc.GetAllTransactions(time.Now(), time.Now(), "", "", "", "")
+	res, err := svc.GetAllTransactions(time.Now(), time.Now(), transaction.Filter{}, "", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -193,7 +231,7 @@ func TestGetCSV_Success(t *testing.T) {
 	tr := sampleTransaction(nil)
 	svc := NewTransactionService(&mockRepo{GetAllTrs: []*transaction.Transaction{tr}})
 	var buf bytes.Buffer
-	err := svc.GetCSV(time.Now(), time.Now(), "", "", "", "", &buf)
+	err := svc.GetCSV(time.Now(), time.Now(), transaction.Filter{}, "", "", &buf)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -202,3 +240,42 @@ func TestGetCSV_Success(t *testing.T) {
 		t.Fatal("CSV output incorrect")
 	}
 }
+
+func TestPutTransaction_ReconciledRequiresOverride(t *testing.T) {
+	tr := sampleTransaction(t)
+	tr.Reconciled = true
+	repo := &mockRepo{GetTr: tr}
+	svc := NewTransactionService(repo)
+
+	if _, err := svc.PutTransaction(tr.ID.String(), "income", "cat", 200, time.Now(), "updated", false); !errors.Is(err, transaction.ErrReconciled) {
+		t.Fatalf("expected ErrReconciled, got %v", err)
+	}
+	if repo.UpdatedTr != nil {
+		t.Fatal("reconciled transaction must not be updated without override")
+	}
+
+	res, err := svc.PutTransaction(tr.ID.String(), "income", "cat", 200, time.Now(), "updated", true)
+	if err != nil {
+		t.Fatalf("unexpected error with override: %v", err)
+	}
+	if !res.Reconciled || repo.UpdatedTr == nil {
+		t.Fatal("override must update and keep the reconciled flag")
+	}
+}
+
+func TestDeleteTransaction_ReconciledRequiresOverride(t *testing.T) {
+	tr := sampleTransaction(t)
+	tr.Reconciled = true
+	repo := &mockRepo{GetTr: tr}
+	svc := NewTransactionService(repo)
+
+	if err := svc.DeleteTransaction(tr.ID.String(), false); !errors.Is(err, transaction.ErrReconciled) {
+		t.Fatalf("expected ErrReconciled, got %v", err)
+	}
+	if err := svc.DeleteTransaction(tr.ID.String(), true); err != nil {
+		t.Fatalf("unexpected error with override: %v", err)
+	}
+	if repo.DeletedID != tr.ID.String() {
+		t.Fatal("override must delete the transaction")
+	}
+}
@@ -0,0 +1,223 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"io"
+	"net/http"
+	"salestracker/internal/config"
+	"salestracker/internal/domain/event"
+	"salestracker/internal/domain/webhook"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	defaultDeliveriesLimit = 100
+	maxDeliveriesLimit     = 1000
+)
+
+type WebhookService struct {
+	repo   WebhookStorageProvider
+	client *http.Client
+	cfg    config.WebhooksConfig
+}
+
+type WebhookStorageProvider interface {
+	SaveSubscription(s *webhook.Subscription) error
+	GetSubscriptions() ([]*webhook.Subscription, error)
+	DeleteSubscription(id string) error
+	FanOutEvents(limit int) (int64, error)
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]*webhook.Job, error)
+	RecordDeliveryAttempt(d *webhook.Delivery) error
+	GetDeliveries(subscriptionID, status string, limit int) ([]*webhook.Delivery, error)
+}
+
+func NewWebhookService(repo WebhookStorageProvider, cfg *config.AppConfig) *WebhookService {
+	c := cfg.Webhooks
+	if c.PollInterval <= 0 {
+		c.PollInterval = 2 * time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 50
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 8
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = 10 * time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = time.Hour
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	return &WebhookService{
+		repo:   repo,
+		client: &http.Client{Timeout: c.Timeout},
+		cfg:    c,
+	}
+}
+
+func (s *WebhookService) CreateSubscription(url, secret string, events []string) (*webhook.Subscription, error) {
+	types := make([]event.Type, 0, len(events))
+	for _, e := range events {
+		types = append(types, event.Type(e))
+	}
+	sub, err := webhook.NewSubscription(url, secret, types)
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("invalid data for new webhook subscription")
+		return nil, err
+	}
+	if err := s.repo.SaveSubscription(sub); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo save webhook subscription error")
+		return nil, err
+	}
+	return sub, nil
+}
+
+// GetSubscriptions возвращает подписки без секретов — секрет показывается только при создании
+func (s *WebhookService) GetSubscriptions() ([]*webhook.Subscription, error) {
+	subs, err := s.repo.GetSubscriptions()
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get webhook subscriptions error")
+		return nil, err
+	}
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+	return subs, nil
+}
+
+func (s *WebhookService) DeleteSubscription(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
+		return err
+	}
+	if err := s.repo.DeleteSubscription(id); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo delete webhook subscription error")
+		return err
+	}
+	return nil
+}
+
+func (s *WebhookService) GetDeliveries(subscriptionID, status string, limit int) ([]*webhook.Delivery, error) {
+	if _, err := uuid.Parse(subscriptionID); err != nil {
+		wbzlog.Logger.Warn().Str("id", subscriptionID).Msg("invalid uuid")
+		return nil, err
+	}
+	switch webhook.DeliveryStatus(status) {
+	case "", webhook.Pending, webhook.Succeeded, webhook.Failed, webhook.Dead:
+	default:
+		return nil, fmt.Errorf("unknown delivery status %q", status)
+	}
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	if limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+	ds, err := s.repo.GetDeliveries(subscriptionID, status, limit)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("repo get webhook deliveries error")
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Run раз в PollInterval разносит новые события outbox по подпискам и отправляет доставки, время которых пришло
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Tick(ctx)
+		}
+	}
+}
+
+func (s *WebhookService) Tick(ctx context.Context) {
+	if _, err := s.repo.FanOutEvents(s.cfg.BatchSize); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("webhook fan-out error")
+	}
+
+	// lease с запасом перекрывает таймаут запроса, чтобы доставку не забрал другой экземпляр
+	jobs, err := s.repo.ClaimDueDeliveries(s.cfg.BatchSize, 2*s.cfg.Timeout)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("webhook claim deliveries error")
+		return
+	}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		s.deliver(ctx, job)
+	}
+}
+
+func (s *WebhookService) deliver(ctx context.Context, job *webhook.Job) {
+	d := job.Delivery
+	d.Attempts++
+
+	code, err := s.send(ctx, job)
+	d.ResponseCode = code
+	switch {
+	case err == nil:
+		d.Status = webhook.Succeeded
+		d.LastError = ""
+		d.NextAttemptAt = time.Now()
+	case d.Attempts >= s.cfg.MaxAttempts:
+		d.Status = webhook.Dead
+		d.LastError = err.Error()
+		d.NextAttemptAt = time.Now()
+		wbzlog.Logger.Warn().Err(err).Int64("delivery", d.ID).Msg("webhook delivery dead-lettered")
+	default:
+		d.Status = webhook.Failed
+		d.LastError = err.Error()
+		d.NextAttemptAt = time.Now().Add(webhook.Backoff(s.cfg.BaseBackoff, s.cfg.MaxBackoff, d.Attempts))
+	}
+
+	if err := s.repo.RecordDeliveryAttempt(&d); err != nil {
+		wbzlog.Logger.Error().Err(err).Int64("delivery", d.ID).Msg("repo record webhook delivery error")
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, job *webhook.Job) (int, error) {
+	body, err := json.Marshal(job.Event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(job.Event.Type))
+	req.Header.Set(DeliveryHeader, fmt.Sprintf("%d", job.Delivery.ID))
+	req.Header.Set(SignatureHeader, "sha256="+webhook.Sign(job.Secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,168 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"salestracker/internal/config"
+	"salestracker/internal/domain/event"
+	"salestracker/internal/domain/webhook"
+	"testing"
+	"time"
+)
+
+// --- Mock repository ---
+type mockRepo struct {
+	Subs       []*webhook.Subscription
+	SavedSub   *webhook.Subscription
+	Jobs       []*webhook.Job
+	Recorded   []webhook.Delivery
+	FannedOut  bool
+	Deliveries []*webhook.Delivery
+	Err        error
+}
+
+func (m *mockRepo) SaveSubscription(s *webhook.Subscription) error {
+	m.SavedSub = s
+	return m.Err
+}
+func (m *mockRepo) GetSubscriptions() ([]*webhook.Subscription, error) {
+	return m.Subs, m.Err
+}
+func (m *mockRepo) DeleteSubscription(id string) error {
+	return m.Err
+}
+func (m *mockRepo) FanOutEvents(limit int) (int64, error) {
+	m.FannedOut = true
+	return 0, m.Err
+}
+func (m *mockRepo) ClaimDueDeliveries(limit int, lease time.Duration) ([]*webhook.Job, error) {
+	jobs := m.Jobs
+	m.Jobs = nil
+	return jobs, m.Err
+}
+func (m *mockRepo) RecordDeliveryAttempt(d *webhook.Delivery) error {
+	m.Recorded = append(m.Recorded, *d)
+	return nil
+}
+func (m *mockRepo) GetDeliveries(subscriptionID, status string, limit int) ([]*webhook.Delivery, error) {
+	return m.Deliveries, m.Err
+}
+
+// --- Helpers ---
+func testConfig() *config.AppConfig {
+	return &config.AppConfig{Webhooks: config.WebhooksConfig{
+		MaxAttempts: 3,
+		BaseBackoff: time.Minute,
+		MaxBackoff:  time.Hour,
+		Timeout:     time.Second,
+	}}
+}
+
+func sampleJob(url string, attempts int) *webhook.Job {
+	return &webhook.Job{
+		Delivery: webhook.Delivery{ID: 7, Attempts: attempts, Status: webhook.Pending},
+		URL:      url,
+		Secret:   "secret",
+		Event: event.Event{
+			ID:            42,
+			Type:          event.TransactionCreated,
+			TransactionID: uuid.New(),
+			Data:          json.RawMessage(`{"Amount":10}`),
+		},
+	}
+}
+
+func TestCreateSubscription_Invalid(t *testing.T) {
+	svc := NewWebhookService(&mockRepo{}, testConfig())
+	if _, err := svc.CreateSubscription("https://erp.example.com", "", []string{"sale.created"}); err == nil {
+		t.Fatal("expected error for unknown event")
+	}
+}
+
+func TestGetSubscriptions_HidesSecret(t *testing.T) {
+	sub, _ := webhook.NewSubscription("https://erp.example.com", "s3cr3t", nil)
+	svc := NewWebhookService(&mockRepo{Subs: []*webhook.Subscription{sub}}, testConfig())
+	subs, err := svc.GetSubscriptions()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if subs[0].Secret != "" {
+		t.Fatal("secret must not be returned")
+	}
+}
+
+func TestGetDeliveries_InvalidStatus(t *testing.T) {
+	svc := NewWebhookService(&mockRepo{}, testConfig())
+	if _, err := svc.GetDeliveries(uuid.New().String(), "lost", 10); err == nil {
+		t.Fatal("expected error for unknown status")
+	}
+}
+
+func TestTick_DeliversSignedPayload(t *testing.T) {
+	var gotSig, gotEvent string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get(SignatureHeader)
+		gotEvent = r.Header.Get(EventHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	repo := &mockRepo{Jobs: []*webhook.Job{sampleJob(srv.URL, 0)}}
+	NewWebhookService(repo, testConfig()).Tick(context.Background())
+
+	if !repo.FannedOut {
+		t.Fatal("expected outbox fan-out")
+	}
+	if gotEvent != string(event.TransactionCreated) {
+		t.Fatalf("unexpected event header %q", gotEvent)
+	}
+	if gotSig != "sha256="+webhook.Sign("secret", gotBody) {
+		t.Fatal("signature does not match body")
+	}
+	if len(repo.Recorded) != 1 || repo.Recorded[0].Status != webhook.Succeeded || repo.Recorded[0].Attempts != 1 {
+		t.Fatalf("unexpected recorded delivery: %+v", repo.Recorded)
+	}
+}
+
+func TestTick_RetriesWithBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	repo := &mockRepo{Jobs: []*webhook.Job{sampleJob(srv.URL, 1)}}
+	before := time.Now()
+	NewWebhookService(repo, testConfig()).Tick(context.Background())
+
+	d := repo.Recorded[0]
+	if d.Status != webhook.Failed || d.ResponseCode != http.StatusBadGateway || d.LastError == "" {
+		t.Fatalf("unexpected recorded delivery: %+v", d)
+	}
+	if d.NextAttemptAt.Before(before.Add(2 * time.Minute)) {
+		t.Fatal("expected exponential backoff for the second attempt")
+	}
+}
+
+func TestTick_DeadLettersAfterMaxAttempts(t *testing.T) {
+	repo := &mockRepo{Jobs: []*webhook.Job{sampleJob("http://127.0.0.1:1", 2)}}
+	NewWebhookService(repo, testConfig()).Tick(context.Background())
+
+	if repo.Recorded[0].Status != webhook.Dead {
+		t.Fatalf("expected dead delivery, got %s", repo.Recorded[0].Status)
+	}
+}
+
+func TestTick_RepoError(t *testing.T) {
+	repo := &mockRepo{Err: errors.New("db down")}
+	NewWebhookService(repo, testConfig()).Tick(context.Background())
+	if len(repo.Recorded) != 0 {
+		t.Fatal("nothing should be delivered when claim fails")
+	}
+}
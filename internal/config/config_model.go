@@ -5,11 +5,15 @@ import (
 )
 
 type AppConfig struct {
-	ServerConfig ServerConfig `mapstructure:"server"`
-	LoggerConfig loggerConfig `mapstructure:"logger"`
-	DBConfig     dbConfig     `mapstructure:"db_config"`
-	RetrysConfig RetrysConfig `mapstructure:"retry_strategy"`
-	GinConfig    ginConfig    `mapstructure:"gin"`
+	ServerConfig ServerConfig   `mapstructure:"server"`
+	LoggerConfig loggerConfig   `mapstructure:"logger"`
+	DBConfig     dbConfig       `mapstructure:"db_config"`
+	RetrysConfig RetrysConfig   `mapstructure:"retry_strategy"`
+	GinConfig    ginConfig      `mapstructure:"gin"`
+	Webhooks     WebhooksConfig `mapstructure:"webhooks"`
+	Stream       StreamConfig   `mapstructure:"stream"`
+	Wildberries  WBConfig       `mapstructure:"wildberries"`
+	Timezone     string         `mapstructure:"timezone" default:"UTC"`
 }
 
 type RetrysConfig struct {
@@ -18,6 +22,34 @@ type RetrysConfig struct {
 	Backoffs float64       `mapstructure:"backoffs" default:"2"`
 }
 
+type WebhooksConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval" default:"2s"`
+	BatchSize    int           `mapstructure:"batch_size" default:"50"`
+	MaxAttempts  int           `mapstructure:"max_attempts" default:"8"`
+	BaseBackoff  time.Duration `mapstructure:"base_backoff" default:"10s"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff" default:"1h"`
+	Timeout      time.Duration `mapstructure:"timeout" default:"10s"`
+}
+
+type StreamConfig struct {
+	ReplayBuffer int `mapstructure:"replay_buffer" default:"1000"`
+}
+
+// WBConfig — категории, в которые раскладываются строки отчета о реализации Wildberries
+type WBConfig struct {
+	Categories WBCategories `mapstructure:"categories"`
+}
+
+type WBCategories struct {
+	Sales      string `mapstructure:"sales" default:"wb_sales"`
+	Returns    string `mapstructure:"returns" default:"wb_returns"`
+	Commission string `mapstructure:"commission" default:"wb_commission"`
+	Logistics  string `mapstructure:"logistics" default:"wb_logistics"`
+	Storage    string `mapstructure:"storage" default:"wb_storage"`
+	Penalties  string `mapstructure:"penalties" default:"wb_penalties"`
+	Other      string `mapstructure:"other" default:"wb_other"`
+}
+
 type ginConfig struct {
 	Mode string `mapstructure:"mode" default:"debug"`
 }
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	wbfconfig "github.com/wb-go/wbf/config"
 	"os"
+	"time"
 )
 
 func NewAppConfig() (*AppConfig, error) {
@@ -32,5 +34,24 @@ func NewAppConfig() (*AppConfig, error) {
 	appCfg.DBConfig.Master.DBName = os.Getenv("POSTGRES_DB")
 	appCfg.DBConfig.Master.User = os.Getenv("POSTGRES_USER")
 	appCfg.DBConfig.Master.Password = os.Getenv("POSTGRES_PASSWORD")
+
+	if appCfg.Timezone == "" {
+		appCfg.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(appCfg.Timezone); err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", appCfg.Timezone, err)
+	}
+	if appCfg.Timezone == "Local" {
+		return nil, errors.New(`invalid timezone "Local": use an IANA name that Postgres can resolve`)
+	}
 	return &appCfg, nil
 }
+
+// Location возвращает часовой пояс рабочего пространства; NewAppConfig уже проверил, что он существует
+func (c *AppConfig) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -7,28 +7,31 @@ import (
 	"go.uber.org/fx"
 	"log"
 	"net/http"
+	"salestracker/internal/app/stream"
+	"salestracker/internal/app/webhooks"
 	"salestracker/internal/config"
 	"salestracker/internal/storage/postgres"
 	"salestracker/internal/web"
 	"salestracker/internal/web/handlers"
 )
 
-func StartHTTPServer(lc fx.Lifecycle, transactionHandler *handlers.TransactionHandler, analyticsHandler *handlers.AnalyticsHandler, config *config.AppConfig) {
+func StartHTTPServer(lc fx.Lifecycle, transactionHandler *handlers.TransactionHandler, analyticsHandler *handlers.AnalyticsHandler, budgetHandler *handlers.BudgetHandler, webhookHandler *handlers.WebhookHandler, streamHandler *handlers.StreamHandler, changeHandler *handlers.ChangeHandler, importHandler *handlers.ImportHandler, reconciliationHandler *handlers.ReconciliationHandler, anomalyHandler *handlers.AnomalyHandler, config *config.AppConfig) {
 	router := wbgin.New(config.GinConfig.Mode)
 
 	router.Use(wbgin.Logger(), wbgin.Recovery())
 	router.Use(func(c *wbgin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+handlers.TimezoneHeader)
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
 		}
 		c.Next()
 	})
+	router.Use(handlers.Timezone(config.Location()))
 
-	web.RegisterRoutes(router, transactionHandler, analyticsHandler)
+	web.RegisterRoutes(router, transactionHandler, analyticsHandler, budgetHandler, webhookHandler, streamHandler, changeHandler, importHandler, reconciliationHandler, anomalyHandler)
 
 	addres := fmt.Sprintf("%s:%d", config.ServerConfig.Host, config.ServerConfig.Port)
 	server := &http.Server{
@@ -53,6 +56,56 @@ func StartHTTPServer(lc fx.Lifecycle, transactionHandler *handlers.TransactionHa
 	})
 }
 
+func StartWebhookDispatcher(lc fx.Lifecycle, service *webhooks.WebhookService) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			log.Printf("Webhook dispatcher started")
+			go func() {
+				defer close(done)
+				service.Run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			log.Printf("Stopping webhook dispatcher...")
+			cancel()
+			select {
+			case <-done:
+			case <-stopCtx.Done():
+			}
+			return nil
+		},
+	})
+}
+
+func StartEventStream(lc fx.Lifecycle, service *stream.StreamService) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			log.Printf("Event stream started")
+			go func() {
+				defer close(done)
+				service.Run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			log.Printf("Stopping event stream...")
+			cancel()
+			select {
+			case <-done:
+			case <-stopCtx.Done():
+			}
+			return nil
+		},
+	})
+}
+
 func ClosePostgresOnStop(lc fx.Lifecycle, postgres *postgres.Postgres) {
 	lc.Append(fx.Hook{
 		OnStop: func(ctx context.Context) error {
@@ -1,28 +1,171 @@
 package analytic
 
+import (
+	"errors"
+	"fmt"
+	"salestracker/internal/domain/transaction"
+	"strings"
+	"time"
+)
+
+// GroupBy — как транзакции собираются в группы аналитики
+type GroupBy string
+
+const (
+	GroupHour         GroupBy = "hour"         // 2025-02-12T13:00
+	GroupDay          GroupBy = "day"          // 2025-02-12
+	GroupWeek         GroupBy = "week"         // 2025-W07, неделя и год по ISO 8601
+	GroupMonth        GroupBy = "month"        // 2025-02
+	GroupQuarter      GroupBy = "quarter"      // 2025-Q1
+	GroupYear         GroupBy = "year"         // 2025
+	GroupCategory     GroupBy = "category"     // категория транзакции
+	GroupType         GroupBy = "type"         // income или expense
+	GroupCounterparty GroupBy = "counterparty" // ИНН контрагента, пусто — не указан
+	GroupNone         GroupBy = "none"         // одна группа на весь период
+)
+
+// MaxGroupLevels — сколько уровней может быть во вложенной группировке
+const MaxGroupLevels = 4
+
+// Fill — чем заполнять периоды без транзакций во временном ряду
+type Fill string
+
+const (
+	FillNone     Fill = ""         // пустых периодов в ответе нет
+	FillZero     Fill = "zero"     // нулевые агрегаты
+	FillNull     Fill = "null"     // Data = null
+	FillPrevious Fill = "previous" // агрегаты предыдущего непустого периода; до первого — нули
+)
+
+// Mode — как транзакции входят в комбинированные метрики All и в группы по категориям
+type Mode string
+
+const (
+	ModeSigned   Mode = "signed"   // доход со знаком плюс, расход — минус: Sum — сальдо
+	ModeAbsolute Mode = "absolute" // сумма как есть: Sum — оборот
+)
+
+// AllGroupKey — ключ единственной группы при GroupNone
+const AllGroupKey = "all"
+
+// OtherSplitKey — ключ разбиения, в которое сворачиваются разбиения за пределами top. Пустой ключ не совпадает
+// ни с одной категорией (категория транзакции не бывает пустой); само разбиение отмечено флагом Other
+const OtherSplitKey = ""
+
+// ErrInvalidQuery — ошибка в параметрах аналитического запроса (а не в данных или БД)
+var ErrInvalidQuery = errors.New("invalid analytics query")
+
+// Analytic — агрегаты по набору транзакций: Income и Expense — по суммам своего типа, All — по всем транзакциям
+// группы со значениями в режиме Mode. Avg — среднее, Median — percentile_cont(0.5), Percentile90 — percentile_cont(0.9)
+// с линейной интерполяцией между соседними значениями; все метрики считаются по самим транзакциям, а не по агрегатам
 type Analytic struct {
 	Sum          float64 `json:"Sum"`
 	Avg          float64 `json:"Avg"`
 	Count        int     `json:"Count"`
 	Median       float64 `json:"Median"`
 	Percentile90 float64 `json:"Percentile90"`
+	// Values — значения явно выбранных метрик; если заданы, JSON состоит только из них
+	Values map[Metric]float64 `json:"-"`
 }
 
 type AnalyticByType struct {
-	Income  Analytic            `json:"Income"`
-	Expense Analytic            `json:"Expense"`
-	All     Analytic            `json:"All"`
-	AllMap  map[string]Analytic `json:"-"`
+	Income  Analytic `json:"Income"`
+	Expense Analytic `json:"Expense"`
+	All     Analytic `json:"All"`
+	// AllMap — агрегаты по ключам разбиения; свернутое Other в него не попадает
+	AllMap map[string]Analytic `json:"-"`
+	// Splits — разбиения группы по убыванию TopBy, последним — Other; заполняется только при Query.Top
+	Splits []AnalyticSplit `json:"Splits,omitempty"`
+}
+
+// AnalyticSplit — агрегаты одного разбиения группы. У Other — все разбиения за пределами top вместе,
+// метрики посчитаны по их транзакциям, а не по агрегатам
+type AnalyticSplit struct {
+	Key   string   `json:"Key"`
+	Other bool     `json:"Other,omitempty"`
+	Data  Analytic `json:"Data"`
+}
+
+// ByType возвращает агрегаты для типа в именовании CSV: Income, Expense или All
+func (d *AnalyticByType) ByType(typ string) Analytic {
+	switch typ {
+	case "Income":
+		return d.Income
+	case "Expense":
+		return d.Expense
+	}
+	return d.All
 }
 
 type AnalyticGroup struct {
-	GroupKey string         `json:"GroupKey"`
-	Data     AnalyticByType `json:"Data"`
+	GroupKey string          `json:"GroupKey"`
+	Data     *AnalyticByType `json:"Data"`
+	// Filled — группа добавлена заполнением пропусков, транзакций в периоде нет
+	Filled bool `json:"Filled,omitempty"`
+	// CompareKey и Compare — соответствующая группа периода сравнения и изменения метрик относительно нее
+	CompareKey string            `json:"CompareKey,omitempty"`
+	Compare    *ComparisonByType `json:"Compare,omitempty"`
+	// Running — нарастающие итоги на конец периода с учетом входящего остатка
+	Running *RunningTotals `json:"Running,omitempty"`
+	// Rolling — скользящие агрегаты по каждому запрошенному окну ("7d", "30d")
+	Rolling map[string]*RollingByType `json:"Rolling,omitempty"`
+	// Dimension и Children — измерение уровня и подгруппы следующего уровня во вложенной группировке
+	Dimension GroupBy         `json:"Dimension,omitempty"`
+	Children  []AnalyticGroup `json:"Children,omitempty"`
+}
+
+// RunningTotals — накопленные доходы, расходы и чистый остаток (Income - Expense)
+type RunningTotals struct {
+	Income  float64 `json:"Income"`
+	Expense float64 `json:"Expense"`
+	Net     float64 `json:"Net"`
+}
+
+// ByType возвращает итог для типа в именовании CSV: Income, Expense или All (чистый остаток)
+func (r RunningTotals) ByType(typ string) float64 {
+	switch typ {
+	case "Income":
+		return r.Income
+	case "Expense":
+		return r.Expense
+	}
+	return r.Net
 }
 
 type Analytics struct {
-	Summary AnalyticByType  `json:"Summary"`
-	Groups  []AnalyticGroup `json:"Groups"`
+	Summary    AnalyticByType  `json:"Summary"`
+	Groups     []AnalyticGroup `json:"Groups"`
+	Comparison *Comparison     `json:"Comparison,omitempty"`
+	// Opening — входящий остаток: итоги всех транзакций до from (с тем же фильтром)
+	Opening *RunningTotals `json:"Opening,omitempty"`
+}
+
+// Query — параметры аналитического запроса. Location — часовой пояс, в котором считаются границы дней, недель и месяцев
+type Query struct {
+	From     time.Time
+	To       time.Time
+	GroupBy  GroupBy
+	Nested   []GroupBy // следующие уровни вложенной группировки: groupby=month,category дает GroupBy month и Nested [category]
+	SplitBy  string    // type|category
+	SortBy   string    // одна из MetricList()
+	SortDir  string    // asc|desc
+	Fill     Fill
+	Mode     Mode
+	Metrics  []Metric // пусто — DefaultMetrics в прежней форме ответа
+	Filter   transaction.Filter
+	Location *time.Location
+	// Cumulative — добавить к временным группам нарастающие итоги от входящего остатка
+	Cumulative bool
+	// Rolling — скользящие окна для временных групп
+	Rolling []Window
+	// Compare — режим сравнения; CompareFrom и CompareTo задают период только для CompareCustom
+	Compare     CompareMode
+	CompareFrom time.Time
+	CompareTo   time.Time
+	// Top — сколько крупнейших разбиений оставить в каждой группе, остальные сворачиваются в Other; 0 — все
+	Top int
+	// TopBy — метрика, по модулю которой разбиения ранжируются для Top, по умолчанию sum
+	TopBy Metric
 }
 
 func NewAnalytic(sum float64, avg float64, count int, mediana float64, procentil90 float64) *Analytic {
@@ -34,3 +177,204 @@ func NewAnalytic(sum float64, avg float64, count int, mediana float64, procentil
 		Percentile90: procentil90,
 	}
 }
+
+// Valid проверяет группировку
+func (g GroupBy) Valid() bool {
+	switch g {
+	case GroupHour, GroupDay, GroupWeek, GroupMonth, GroupQuarter, GroupYear, GroupCategory, GroupType, GroupCounterparty, GroupNone:
+		return true
+	}
+	return false
+}
+
+// ParseGroupBy разбирает groupBy — одно измерение или упорядоченный список через запятую для вложенной группировки
+func ParseGroupBy(s string) (GroupBy, []GroupBy) {
+	var levels []GroupBy
+	for _, part := range strings.Split(s, ",") {
+		if g := GroupBy(strings.ToLower(strings.TrimSpace(part))); g != "" {
+			levels = append(levels, g)
+		}
+	}
+	if len(levels) == 0 {
+		return "", nil
+	}
+	return levels[0], levels[1:]
+}
+
+// validateNested проверяет уровни вложенной группировки: без none и повторов, не глубже MaxGroupLevels.
+// Сравнение, нарастающие итоги и скользящие окна считаются по плоскому ряду и с деревом не сочетаются
+func (q Query) validateNested() error {
+	if len(q.Nested) == 0 {
+		return nil
+	}
+	levels := append([]GroupBy{q.GroupBy}, q.Nested...)
+	if len(levels) > MaxGroupLevels {
+		return fmt.Errorf("%w: at most %d groupBy levels are allowed", ErrInvalidQuery, MaxGroupLevels)
+	}
+	seen := map[GroupBy]bool{}
+	for _, g := range levels {
+		if g == "" || g == GroupNone || !g.Valid() {
+			return fmt.Errorf("%w: invalid nested groupBy level %q", ErrInvalidQuery, g)
+		}
+		if seen[g] {
+			return fmt.Errorf("%w: groupBy level %s is used twice", ErrInvalidQuery, g)
+		}
+		seen[g] = true
+	}
+	switch {
+	case q.SplitBy == "category":
+		return fmt.Errorf("%w: splitBy=category is not supported with nested groupBy, add category as a level", ErrInvalidQuery)
+	case q.Compare != CompareNone:
+		return fmt.Errorf("%w: compare is not supported with nested groupBy", ErrInvalidQuery)
+	case q.Cumulative:
+		return fmt.Errorf("%w: cumulative is not supported with nested groupBy", ErrInvalidQuery)
+	case len(q.Rolling) > 0:
+		return fmt.Errorf("%w: rolling is not supported with nested groupBy", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// Validate проверяет параметры запроса; ошибки оборачивают ErrInvalidQuery
+func (q Query) Validate() error {
+	if q.From.After(q.To) {
+		return fmt.Errorf("%w: 'from' date cannot be after 'to'", ErrInvalidQuery)
+	}
+	if q.GroupBy != "" && !q.GroupBy.Valid() {
+		return fmt.Errorf("%w: unknown groupBy %q", ErrInvalidQuery, q.GroupBy)
+	}
+	if err := q.validateNested(); err != nil {
+		return err
+	}
+	if err := q.Filter.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	if len(q.Metrics) > MaxMetrics {
+		return fmt.Errorf("%w: at most %d metrics are allowed", ErrInvalidQuery, MaxMetrics)
+	}
+	for _, m := range q.Metrics {
+		if !m.Valid() {
+			return fmt.Errorf("%w: unknown metric %q", ErrInvalidQuery, m)
+		}
+	}
+	switch q.Mode {
+	case "", ModeSigned, ModeAbsolute:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidQuery, q.Mode)
+	}
+	switch q.Compare {
+	case CompareNone, ComparePrevious, CompareYoY:
+		if !q.CompareFrom.IsZero() || !q.CompareTo.IsZero() {
+			return fmt.Errorf("%w: compareFrom and compareTo require compare=custom", ErrInvalidQuery)
+		}
+	case CompareCustom:
+		if q.CompareFrom.IsZero() || q.CompareTo.IsZero() {
+			return fmt.Errorf("%w: compare=custom requires compareFrom and compareTo", ErrInvalidQuery)
+		}
+		if q.CompareFrom.After(q.CompareTo) {
+			return fmt.Errorf("%w: 'compareFrom' date cannot be after 'compareTo'", ErrInvalidQuery)
+		}
+	default:
+		return fmt.Errorf("%w: unknown compare %q", ErrInvalidQuery, q.Compare)
+	}
+	switch q.Fill {
+	case FillNone, FillZero, FillNull, FillPrevious:
+	default:
+		return fmt.Errorf("%w: unknown fill %q", ErrInvalidQuery, q.Fill)
+	}
+	if q.Cumulative && !q.GroupBy.IsTime() {
+		return fmt.Errorf("%w: cumulative requires a time grouping, got %q", ErrInvalidQuery, q.GroupBy)
+	}
+	if len(q.Rolling) > 0 && !q.GroupBy.IsTime() {
+		return fmt.Errorf("%w: rolling requires a time grouping, got %q", ErrInvalidQuery, q.GroupBy)
+	}
+	if len(q.Rolling) > MaxRollingWindows {
+		return fmt.Errorf("%w: at most %d rolling windows are allowed", ErrInvalidQuery, MaxRollingWindows)
+	}
+	for _, w := range q.Rolling {
+		if _, err := w.FrameOffset(q.GroupBy); err != nil {
+			return err
+		}
+	}
+	if q.Fill != FillNone && !q.GroupBy.IsTime() {
+		return fmt.Errorf("%w: fill requires a time grouping, got %q", ErrInvalidQuery, q.GroupBy)
+	}
+	return q.validateTop()
+}
+
+// validateTop проверяет top и topBy. Top имеет смысл только для splitBy=category: у splitBy=type всего два
+// разбиения, и свернутый тип пропал бы из Income и Expense
+func (q Query) validateTop() error {
+	if q.Top < 0 {
+		return fmt.Errorf("%w: top must be positive", ErrInvalidQuery)
+	}
+	if q.Top == 0 {
+		if q.TopBy != "" {
+			return fmt.Errorf("%w: topBy requires top", ErrInvalidQuery)
+		}
+		return nil
+	}
+	if q.SplitBy != "category" {
+		return fmt.Errorf("%w: top requires splitBy=category", ErrInvalidQuery)
+	}
+	if q.TopBy != "" && !q.TopBy.Valid() {
+		return fmt.Errorf("%w: unknown topBy metric %q", ErrInvalidQuery, q.TopBy)
+	}
+	return nil
+}
+
+// IsTime сообщает, что группы — последовательные периоды времени; пустая группировка означает day
+func (g GroupBy) IsTime() bool {
+	switch g {
+	case "", GroupHour, GroupDay, GroupWeek, GroupMonth, GroupQuarter, GroupYear:
+		return true
+	}
+	return false
+}
+
+// FillGaps выстраивает группы по списку периодов keys в хронологическом порядке и заполняет периоды
+// без транзакций согласно fill. Группы, ключей которых нет в keys, отбрасываются. metrics — явно выбранные
+// метрики запроса: нулевые периоды получают их в той же форме, что и остальные
+func FillGaps(groups []AnalyticGroup, keys []string, fill Fill, metrics []Metric) []AnalyticGroup {
+	byKey := make(map[string]AnalyticGroup, len(groups))
+	for _, g := range groups {
+		byKey[g.GroupKey] = g
+	}
+
+	result := make([]AnalyticGroup, 0, len(keys))
+	zero := emptyAnalytic(metrics)
+	empty := func() *AnalyticByType {
+		return &AnalyticByType{Income: zero.clone(), Expense: zero.clone(), All: zero.clone()}
+	}
+	prev := empty()
+	for _, k := range keys {
+		if g, ok := byKey[k]; ok {
+			result = append(result, g)
+			prev = g.Data
+			continue
+		}
+		filled := AnalyticGroup{GroupKey: k, Filled: true}
+		switch fill {
+		case FillZero:
+			filled.Data = empty()
+		case FillPrevious:
+			filled.Data = prev.clone()
+		}
+		result = append(result, filled)
+	}
+	return result
+}
+
+// clone копирует агрегаты группы вместе со значениями метрик
+func (d *AnalyticByType) clone() *AnalyticByType {
+	c := &AnalyticByType{Income: d.Income.clone(), Expense: d.Expense.clone(), All: d.All.clone()}
+	for _, sp := range d.Splits {
+		c.Splits = append(c.Splits, AnalyticSplit{Key: sp.Key, Other: sp.Other, Data: sp.Data.clone()})
+	}
+	if d.AllMap != nil {
+		c.AllMap = make(map[string]Analytic, len(d.AllMap))
+		for k, v := range d.AllMap {
+			c.AllMap[k] = v.clone()
+		}
+	}
+	return c
+}
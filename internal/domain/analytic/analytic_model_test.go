@@ -0,0 +1,152 @@
+package analytic
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueryValidate(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	for _, g := range []GroupBy{"", GroupHour, GroupDay, GroupWeek, GroupMonth, GroupQuarter, GroupYear, GroupCategory, GroupNone} {
+		if err := (Query{From: from, To: to, GroupBy: g}).Validate(); err != nil {
+			t.Errorf("%q: unexpected error: %v", g, err)
+		}
+	}
+
+	cases := []Query{
+		{From: to, To: from},
+		{From: from, To: to, GroupBy: "fortnight"},
+	}
+	for _, q := range cases {
+		if err := q.Validate(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", q, err)
+		}
+	}
+}
+
+func TestQueryValidate_Fill(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	if err := (Query{From: from, To: to, Fill: FillPrevious}).Validate(); err != nil {
+		t.Fatalf("fill with default grouping: unexpected error %v", err)
+	}
+	for _, q := range []Query{
+		{From: from, To: to, Fill: "linear"},
+		{From: from, To: to, GroupBy: GroupCategory, Fill: FillZero},
+		{From: from, To: to, GroupBy: GroupNone, Fill: FillNull},
+		{From: from, To: to, GroupBy: GroupCategory, Cumulative: true},
+	} {
+		if err := q.Validate(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", q, err)
+		}
+	}
+}
+
+func TestQueryValidate_Mode(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	for _, m := range []Mode{"", ModeSigned, ModeAbsolute} {
+		if err := (Query{From: from, To: to, Mode: m}).Validate(); err != nil {
+			t.Errorf("mode %q: unexpected error %v", m, err)
+		}
+	}
+	if err := (Query{From: from, To: to, Mode: "net"}).Validate(); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery for unknown mode, got %v", err)
+	}
+}
+
+func TestQueryValidate_Metrics(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	ok := Query{From: from, To: to, Metrics: ParseMetrics("sum, P25,p99.9 ,min,max,stddev,variance,median,percentile90,count,avg,p25")}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ok.Metrics) != 11 {
+		t.Fatalf("expected duplicates to be dropped, got %v", ok.Metrics)
+	}
+	for _, m := range []Metric{"p0", "p100", "p", "pxx", "p1e1", "mode"} {
+		if err := (Query{From: from, To: to, Metrics: []Metric{m}}).Validate(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%q: expected ErrInvalidQuery, got %v", m, err)
+		}
+	}
+}
+
+func TestQueryValidate_Top(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	for _, q := range []Query{
+		{From: from, To: to, SplitBy: "category", Top: 5},
+		{From: from, To: to, SplitBy: "category", Top: 1, TopBy: "p90"},
+	} {
+		if err := q.Validate(); err != nil {
+			t.Errorf("%+v: unexpected error: %v", q, err)
+		}
+	}
+	for _, q := range []Query{
+		{From: from, To: to, SplitBy: "category", Top: -1},
+		{From: from, To: to, Top: 5},
+		{From: from, To: to, SplitBy: "type", Top: 1},
+		{From: from, To: to, SplitBy: "category", TopBy: MetricSum},
+		{From: from, To: to, SplitBy: "category", Top: 3, TopBy: "mode"},
+	} {
+		if err := q.Validate(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", q, err)
+		}
+	}
+}
+
+func TestFillGaps(t *testing.T) {
+	jan := &AnalyticByType{All: Analytic{Sum: 10, Count: 1}}
+	mar := &AnalyticByType{All: Analytic{Sum: 30, Count: 3}}
+	// Группы пришли отсортированными по сумме, а не по времени
+	groups := []AnalyticGroup{{GroupKey: "2025-03", Data: mar}, {GroupKey: "2025-01", Data: jan}}
+	keys := []string{"2024-12", "2025-01", "2025-02", "2025-03", "2025-04"}
+
+	cases := []struct {
+		fill Fill
+		want []*AnalyticByType
+	}{
+		{FillZero, []*AnalyticByType{{}, jan, {}, mar, {}}},
+		{FillNull, []*AnalyticByType{nil, jan, nil, mar, nil}},
+		{FillPrevious, []*AnalyticByType{{}, jan, jan, mar, mar}},
+	}
+	for _, c := range cases {
+		got := FillGaps(groups, keys, c.fill, nil)
+		if len(got) != len(keys) {
+			t.Fatalf("%s: got %d groups, want %d", c.fill, len(got), len(keys))
+		}
+		for i, g := range got {
+			if g.GroupKey != keys[i] {
+				t.Fatalf("%s: group %d is %s, want %s", c.fill, i, g.GroupKey, keys[i])
+			}
+			wantFilled := keys[i] != "2025-01" && keys[i] != "2025-03"
+			if g.Filled != wantFilled {
+				t.Errorf("%s: %s Filled=%v", c.fill, g.GroupKey, g.Filled)
+			}
+			switch {
+			case c.want[i] == nil:
+				if g.Data != nil {
+					t.Errorf("%s: %s expected null data, got %+v", c.fill, g.GroupKey, g.Data)
+				}
+			case g.Data == nil || !reflect.DeepEqual(g.Data.All, c.want[i].All):
+				t.Errorf("%s: %s got %+v, want %+v", c.fill, g.GroupKey, g.Data, c.want[i])
+			}
+		}
+	}
+
+	// Копия предыдущего периода не должна разделять память с оригиналом
+	filled := FillGaps(groups, keys, FillPrevious, nil)
+	filled[2].Data.All.Sum = 999
+	if jan.All.Sum != 10 {
+		t.Fatal("FillPrevious must copy the previous data")
+	}
+}
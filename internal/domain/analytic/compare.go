@@ -0,0 +1,124 @@
+package analytic
+
+import (
+	"math"
+	"time"
+)
+
+// CompareMode — с каким периодом сравнивается аналитика
+type CompareMode string
+
+const (
+	CompareNone     CompareMode = ""
+	ComparePrevious CompareMode = "previous" // предыдущий период той же длины; целые месяцы сдвигаются на столько же месяцев
+	CompareYoY      CompareMode = "yoy"      // тот же период годом ранее
+	CompareCustom   CompareMode = "custom"   // период из CompareFrom и CompareTo
+)
+
+// Change — значение метрики в текущем периоде и в периоде сравнения. Percent — изменение в процентах от модуля
+// значения периода сравнения, null, если оно нулевое
+type Change struct {
+	Current  float64  `json:"Current"`
+	Previous float64  `json:"Previous"`
+	Delta    float64  `json:"Delta"`
+	Percent  *float64 `json:"Percent"`
+}
+
+// ComparisonByType — изменения всех метрик запроса по типам, как в AnalyticByType
+type ComparisonByType struct {
+	Income  map[Metric]Change `json:"Income"`
+	Expense map[Metric]Change `json:"Expense"`
+	All     map[Metric]Change `json:"All"`
+}
+
+// Comparison — период сравнения и сводка по нему
+type Comparison struct {
+	Mode    CompareMode      `json:"Mode"`
+	From    time.Time        `json:"From"`
+	To      time.Time        `json:"To"`
+	Summary ComparisonByType `json:"Summary"`
+}
+
+// ComparisonRange вычисляет период сравнения для режима запроса. previous для диапазона из целых месяцев
+// (в часовом поясе запроса) берет столько же предыдущих месяцев, иначе — отрезок той же длины перед from.
+// Границы включительные, как у самого запроса
+func (q Query) ComparisonRange() (time.Time, time.Time) {
+	loc := q.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	from := q.From.In(loc)
+	end := q.To.In(loc).Add(time.Microsecond) // исключающая граница: to — последняя микросекунда периода
+
+	switch q.Compare {
+	case CompareCustom:
+		return q.CompareFrom, q.CompareTo
+	case CompareYoY:
+		return shiftMonths(from, -12), shiftMonths(end, -12).Add(-time.Microsecond)
+	}
+	if months, ok := wholeMonths(from, end); ok {
+		return shiftMonths(from, -months), from.Add(-time.Microsecond)
+	}
+	return from.Add(-end.Sub(from)), from.Add(-time.Microsecond)
+}
+
+// wholeMonths сообщает, что [from, end) — целое число календарных месяцев
+func wholeMonths(from, end time.Time) (int, bool) {
+	isMonthStart := func(t time.Time) bool {
+		return t.Day() == 1 && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+	}
+	if !isMonthStart(from) || !isMonthStart(end) {
+		return 0, false
+	}
+	months := (end.Year()-from.Year())*12 + int(end.Month()-from.Month())
+	return months, months > 0
+}
+
+// shiftMonths сдвигает время на n месяцев; день, которого нет в целевом месяце (31 число, 29 февраля),
+// становится последним днем месяца
+func shiftMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// NewComparison сравнивает агрегаты группы с агрегатами периода сравнения по всем метрикам; prev может быть nil
+func NewComparison(cur, prev *AnalyticByType, metrics []Metric) *ComparisonByType {
+	if prev == nil {
+		prev = &AnalyticByType{}
+	}
+	return &ComparisonByType{
+		Income:  changes(cur.Income, prev.Income, metrics),
+		Expense: changes(cur.Expense, prev.Expense, metrics),
+		All:     changes(cur.All, prev.All, metrics),
+	}
+}
+
+// ByType возвращает изменения для типа в именовании CSV: Income, Expense или All
+func (c *ComparisonByType) ByType(typ string) map[Metric]Change {
+	switch typ {
+	case "Income":
+		return c.Income
+	case "Expense":
+		return c.Expense
+	}
+	return c.All
+}
+
+func changes(cur, prev Analytic, metrics []Metric) map[Metric]Change {
+	out := make(map[Metric]Change, len(metrics))
+	for _, m := range metrics {
+		c := Change{Current: cur.Value(m), Previous: prev.Value(m)}
+		c.Delta = c.Current - c.Previous
+		if c.Previous != 0 {
+			pct := c.Delta / math.Abs(c.Previous) * 100
+			c.Percent = &pct
+		}
+		out[m] = c
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package analytic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestComparisonRange(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	endOf := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d+1, 0, 0, 0, 0, moscow).Add(-time.Microsecond)
+	}
+	cases := []struct {
+		name     string
+		q        Query
+		from, to time.Time
+	}{
+		{"previous month", Query{From: time.Date(2025, 3, 1, 0, 0, 0, 0, moscow), To: endOf(2025, 3, 31), Compare: ComparePrevious},
+			time.Date(2025, 2, 1, 0, 0, 0, 0, moscow), endOf(2025, 2, 28)},
+		{"previous quarter", Query{From: time.Date(2025, 4, 1, 0, 0, 0, 0, moscow), To: endOf(2025, 6, 30), Compare: ComparePrevious},
+			time.Date(2025, 1, 1, 0, 0, 0, 0, moscow), endOf(2025, 3, 31)},
+		{"previous days", Query{From: time.Date(2025, 3, 10, 0, 0, 0, 0, moscow), To: endOf(2025, 3, 16), Compare: ComparePrevious},
+			time.Date(2025, 3, 3, 0, 0, 0, 0, moscow), endOf(2025, 3, 9)},
+		{"yoy leap day", Query{From: time.Date(2024, 2, 1, 0, 0, 0, 0, moscow), To: endOf(2024, 2, 29), Compare: CompareYoY},
+			time.Date(2023, 2, 1, 0, 0, 0, 0, moscow), endOf(2023, 2, 28)},
+		{"custom", Query{From: time.Date(2025, 3, 1, 0, 0, 0, 0, moscow), To: endOf(2025, 3, 31), Compare: CompareCustom,
+			CompareFrom: time.Date(2024, 12, 1, 0, 0, 0, 0, moscow), CompareTo: endOf(2024, 12, 31)},
+			time.Date(2024, 12, 1, 0, 0, 0, 0, moscow), endOf(2024, 12, 31)},
+	}
+	for _, c := range cases {
+		c.q.Location = moscow
+		from, to := c.q.ComparisonRange()
+		if !from.Equal(c.from) || !to.Equal(c.to) {
+			t.Errorf("%s: got %v — %v, want %v — %v", c.name, from, to, c.from, c.to)
+		}
+	}
+}
+
+func TestQueryValidate_Compare(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	if err := (Query{From: from, To: to, Compare: CompareYoY}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, q := range []Query{
+		{From: from, To: to, Compare: "week"},
+		{From: from, To: to, Compare: CompareCustom},
+		{From: from, To: to, Compare: CompareCustom, CompareFrom: to, CompareTo: from},
+		{From: from, To: to, Compare: ComparePrevious, CompareFrom: from},
+	} {
+		if err := q.Validate(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", q, err)
+		}
+	}
+}
+
+func TestNewComparison(t *testing.T) {
+	cur := &AnalyticByType{Income: Analytic{Sum: 150, Count: 3}, All: Analytic{Sum: -20}}
+	prev := &AnalyticByType{Income: Analytic{Sum: 100, Count: 3}, All: Analytic{Sum: -40}}
+	c := NewComparison(cur, prev, DefaultMetrics)
+
+	inc := c.Income[MetricSum]
+	if inc.Current != 150 || inc.Previous != 100 || inc.Delta != 50 || inc.Percent == nil || *inc.Percent != 50 {
+		t.Errorf("unexpected income change %+v", inc)
+	}
+	// Отрицательное сальдо выросло с -40 до -20: рост на 50% от модуля базы
+	if all := c.All[MetricSum]; all.Delta != 20 || *all.Percent != 50 {
+		t.Errorf("unexpected all change %+v", all)
+	}
+	if exp := c.Expense[MetricSum]; exp.Percent != nil {
+		t.Errorf("percent must be null for zero base, got %v", *exp.Percent)
+	}
+	if none := NewComparison(cur, nil, DefaultMetrics); none.Income[MetricSum].Previous != 0 {
+		t.Errorf("missing comparison group must compare with zero")
+	}
+}
@@ -0,0 +1,348 @@
+package analytic
+
+import (
+	"fmt"
+	"math"
+	"salestracker/internal/domain/transaction"
+	"time"
+)
+
+// Модели прогноза в порядке убывания требований к длине истории
+const (
+	ModelHoltWinters = "holt-winters" // уровень, тренд и аддитивная сезонность; нужно не меньше двух сезонов
+	ModelHolt        = "holt"         // уровень и тренд; нужно не меньше трех периодов
+	ModelMean        = "mean"         // среднее истории
+)
+
+// MaxForecastHorizon — на сколько периодов вперед можно строить прогноз
+const MaxForecastHorizon = 400
+
+// forecastGrid — значения alpha, beta и gamma, из которых подбирается модель по сумме квадратов ошибок на истории
+var forecastGrid = []float64{0.05, 0.1, 0.2, 0.3, 0.5, 0.7, 0.9}
+
+// forecastGroupings — длина сезона, горизонт по умолчанию (квартал) и глубина истории по умолчанию
+var forecastGroupings = map[GroupBy]struct {
+	season, horizon int
+	history         func(time.Time) time.Time
+}{
+	GroupDay:   {7, 91, func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) }},
+	GroupWeek:  {52, 13, func(t time.Time) time.Time { return t.AddDate(0, 0, -2*52*7) }}, // целое число недель: начало остается понедельником
+	GroupMonth: {12, 3, func(t time.Time) time.Time { return t.AddDate(-3, 0, 0) }},
+}
+
+// ForecastQuery — параметры прогноза: история [From, To] по периодам GroupBy, Horizon периодов вперед,
+// Level — уровень доверия интервалов
+type ForecastQuery struct {
+	From     time.Time
+	To       time.Time
+	GroupBy  GroupBy
+	Horizon  int
+	Level    float64
+	Filter   transaction.Filter
+	Location *time.Location
+}
+
+// ForecastPoint — прогноз на период и доверительный интервал; значения не бывают отрицательными
+type ForecastPoint struct {
+	Period string  `json:"Period"`
+	Value  float64 `json:"Value"`
+	Lower  float64 `json:"Lower"`
+	Upper  float64 `json:"Upper"`
+}
+
+// ForecastError — ошибки модели на отложенном хвосте истории. MAPE не считается, если все фактические значения нулевые
+type ForecastError struct {
+	Periods int      `json:"Periods"`
+	MAE     float64  `json:"MAE"`
+	RMSE    float64  `json:"RMSE"`
+	MAPE    *float64 `json:"MAPE"`
+}
+
+// SeriesForecast — прогноз одного ряда: тип и категория; пустая категория — итог по всем категориям типа
+type SeriesForecast struct {
+	Type     transaction.TransactionType `json:"Type"`
+	Category string                      `json:"Category,omitempty"`
+	Model    string                      `json:"Model"`
+	Points   []ForecastPoint             `json:"Points"`
+	Backtest *ForecastError              `json:"Backtest,omitempty"`
+}
+
+// Forecast — прогнозы всех рядов
+type Forecast struct {
+	GroupBy GroupBy          `json:"GroupBy"`
+	From    time.Time        `json:"From"`
+	To      time.Time        `json:"To"`
+	Horizon int              `json:"Horizon"`
+	Level   float64          `json:"Level"`
+	Series  []SeriesForecast `json:"Series"`
+}
+
+// Normalize подставляет значения по умолчанию (month, квартал вперед, 95%, история до последнего завершенного
+// периода) и проверяет запрос
+func (q ForecastQuery) Normalize(now time.Time) (ForecastQuery, error) {
+	if q.GroupBy == "" {
+		q.GroupBy = GroupMonth
+	}
+	g, ok := forecastGroupings[q.GroupBy]
+	if !ok {
+		return q, fmt.Errorf("%w: forecast supports day, week and month, got %q", ErrInvalidQuery, q.GroupBy)
+	}
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	// По умолчанию история заканчивается последним завершенным периодом: текущий неполный занизил бы прогноз
+	if q.To.IsZero() {
+		q.To = periodStart(q.GroupBy, now.In(q.Location)).Add(-time.Microsecond)
+	}
+	// История начинается с границы периода: To — конец периода (начало следующего −1µs), и сдвиг самого To
+	// дал бы неполный первый период
+	if q.From.IsZero() {
+		q.From = g.history(periodStart(q.GroupBy, q.To.In(q.Location).Add(time.Microsecond)))
+	}
+	if q.Horizon == 0 {
+		q.Horizon = g.horizon
+	}
+	if q.Level == 0 {
+		q.Level = 0.95
+	}
+	if q.From.After(q.To) {
+		return q, fmt.Errorf("%w: 'from' date cannot be after 'to'", ErrInvalidQuery)
+	}
+	if q.Horizon < 1 || q.Horizon > MaxForecastHorizon {
+		return q, fmt.Errorf("%w: horizon must be between 1 and %d", ErrInvalidQuery, MaxForecastHorizon)
+	}
+	if q.Level <= 0 || q.Level >= 1 {
+		return q, fmt.Errorf("%w: level must be between 0 and 1", ErrInvalidQuery)
+	}
+	if err := q.Filter.Validate(); err != nil {
+		return q, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	return q, nil
+}
+
+// FuturePeriods — ключи Horizon периодов после периода, в который попадает To, в формате ключей группировки
+func (q ForecastQuery) FuturePeriods() []string {
+	start := periodStart(q.GroupBy, q.To.In(q.Location))
+	keys := make([]string, q.Horizon)
+	for i := range keys {
+		start = nextPeriod(q.GroupBy, start)
+		switch q.GroupBy {
+		case GroupDay:
+			keys[i] = start.Format("2006-01-02")
+		case GroupWeek:
+			y, w := start.ISOWeek()
+			keys[i] = fmt.Sprintf("%04d-W%02d", y, w)
+		default:
+			keys[i] = start.Format("2006-01")
+		}
+	}
+	return keys
+}
+
+// periodStart — начало дня, ISO-недели (понедельник) или месяца, в которые попадает t, в его часовом поясе
+func periodStart(g GroupBy, t time.Time) time.Time {
+	switch g {
+	case GroupDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case GroupWeek:
+		return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func nextPeriod(g GroupBy, t time.Time) time.Time {
+	switch g {
+	case GroupDay:
+		return t.AddDate(0, 0, 1)
+	case GroupWeek:
+		return t.AddDate(0, 0, 7)
+	}
+	return t.AddDate(0, 1, 0)
+}
+
+// Season — длина сезона для группировки прогноза: неделя для дней, год для недель и месяцев
+func (q ForecastQuery) Season() int {
+	return forecastGroupings[q.GroupBy].season
+}
+
+// ForecastSeries строит прогноз ряда history на len(periods) периодов вперед с интервалами уровня level
+// и оценивает модель на отложенном хвосте истории длиной до горизонта (но не больше четверти истории)
+func ForecastSeries(history []float64, season int, periods []string, level float64) (string, []ForecastPoint, *ForecastError) {
+	fit := fitModel(history, season)
+	z := math.Sqrt2 * math.Erfinv(level)
+
+	points := make([]ForecastPoint, len(periods))
+	for i := range points {
+		h := i + 1
+		v := fit.predict(h)
+		spread := z * fit.sigma * math.Sqrt(fit.variance(h))
+		points[i] = ForecastPoint{
+			Period: periods[i],
+			Value:  math.Max(v, 0),
+			Lower:  math.Max(v-spread, 0),
+			Upper:  math.Max(v+spread, 0),
+		}
+	}
+	return fit.model, points, backtest(history, season, len(periods))
+}
+
+func backtest(history []float64, season, horizon int) *ForecastError {
+	holdout := horizon
+	if q := len(history) / 4; holdout > q {
+		holdout = q
+	}
+	if holdout < 1 || len(history)-holdout < 3 {
+		return nil
+	}
+	train, test := history[:len(history)-holdout], history[len(history)-holdout:]
+	fit := fitModel(train, season)
+
+	e := &ForecastError{Periods: holdout}
+	var pctSum float64
+	var pctN int
+	for i, actual := range test {
+		diff := actual - math.Max(fit.predict(i+1), 0)
+		e.MAE += math.Abs(diff)
+		e.RMSE += diff * diff
+		if actual != 0 {
+			pctSum += math.Abs(diff / actual)
+			pctN++
+		}
+	}
+	e.MAE /= float64(holdout)
+	e.RMSE = math.Sqrt(e.RMSE / float64(holdout))
+	if pctN > 0 {
+		mape := pctSum / float64(pctN) * 100
+		e.MAPE = &mape
+	}
+	return e
+}
+
+// modelFit — подобранная модель: состояние на конец истории, параметры сглаживания и СКО ошибок на один шаг
+type modelFit struct {
+	model              string
+	level, trend       float64
+	seasonal           []float64 // последние season сезонных компонент, seasonal[0] — самая ранняя
+	alpha, beta, gamma float64
+	sigma              float64
+}
+
+// predict — прогноз на h периодов вперед
+func (f modelFit) predict(h int) float64 {
+	v := f.level + float64(h)*f.trend
+	if m := len(f.seasonal); m > 0 {
+		v += f.seasonal[(h-1)%m]
+	}
+	return v
+}
+
+// variance — множитель дисперсии ошибки прогноза на h шагов относительно ошибки на один шаг
+// (аналитическая формула для аддитивных моделей экспоненциального сглаживания)
+func (f modelFit) variance(h int) float64 {
+	v := 1.0
+	if f.model == ModelMean {
+		return v
+	}
+	m := len(f.seasonal)
+	for j := 1; j < h; j++ {
+		c := f.alpha * (1 + float64(j)*f.beta)
+		if m > 0 && j%m == 0 {
+			c += f.gamma
+		}
+		v += c * c
+	}
+	return v
+}
+
+// fitModel выбирает самую богатую модель, для которой хватает истории, и подбирает параметры перебором сетки
+func fitModel(y []float64, season int) modelFit {
+	switch {
+	case season > 1 && len(y) >= 2*season:
+		best := modelFit{sigma: math.Inf(1)}
+		for _, a := range forecastGrid {
+			for _, b := range forecastGrid {
+				for _, g := range forecastGrid {
+					if f := holtWinters(y, season, a, b, g); f.sigma < best.sigma {
+						best = f
+					}
+				}
+			}
+		}
+		return best
+	case len(y) >= 3:
+		best := modelFit{sigma: math.Inf(1)}
+		for _, a := range forecastGrid {
+			for _, b := range forecastGrid {
+				if f := holt(y, a, b); f.sigma < best.sigma {
+					best = f
+				}
+			}
+		}
+		return best
+	}
+
+	f := modelFit{model: ModelMean}
+	if len(y) == 0 {
+		return f
+	}
+	for _, v := range y {
+		f.level += v
+	}
+	f.level /= float64(len(y))
+	for _, v := range y {
+		f.sigma += (v - f.level) * (v - f.level)
+	}
+	f.sigma = math.Sqrt(f.sigma / float64(len(y)))
+	return f
+}
+
+// holtWinters — аддитивная модель Хольта — Винтерса. Начальные уровень и тренд — по средним двух первых сезонов,
+// сезонность — отклонения первого сезона от линии тренда
+func holtWinters(y []float64, m int, alpha, beta, gamma float64) modelFit {
+	var first, second float64
+	for i := 0; i < m; i++ {
+		first += y[i]
+		second += y[m+i]
+	}
+	first /= float64(m)
+	second /= float64(m)
+
+	// Среднее сезона относится к его середине: уровень переносится на конец первого сезона,
+	// а из сезонных отклонений вычитается тренд
+	trend := (second - first) / float64(m)
+	mid := float64(m-1) / 2
+	level := first + trend*mid
+	seasonal := make([]float64, len(y))
+	for i := 0; i < m; i++ {
+		seasonal[i] = y[i] - (first + trend*(float64(i)-mid))
+	}
+
+	var sse float64
+	for t := m; t < len(y); t++ {
+		s := seasonal[t-m]
+		e := y[t] - (level + trend + s)
+		sse += e * e
+		prevLevel := level
+		level = alpha*(y[t]-s) + (1-alpha)*(level+trend)
+		trend = beta*(level-prevLevel) + (1-beta)*trend
+		seasonal[t] = gamma*(y[t]-level) + (1-gamma)*s
+	}
+	return modelFit{
+		model: ModelHoltWinters, level: level, trend: trend, seasonal: seasonal[len(y)-m:],
+		alpha: alpha, beta: beta, gamma: gamma, sigma: math.Sqrt(sse / float64(len(y)-m)),
+	}
+}
+
+// holt — линейный тренд Хольта без сезонности
+func holt(y []float64, alpha, beta float64) modelFit {
+	level, trend := y[0], y[1]-y[0]
+	var sse float64
+	for t := 1; t < len(y); t++ {
+		e := y[t] - (level + trend)
+		sse += e * e
+		prevLevel := level
+		level = alpha*y[t] + (1-alpha)*(level+trend)
+		trend = beta*(level-prevLevel) + (1-beta)*trend
+	}
+	return modelFit{model: ModelHolt, level: level, trend: trend, alpha: alpha, beta: beta, sigma: math.Sqrt(sse / float64(len(y)-1))}
+}
@@ -0,0 +1,98 @@
+package analytic
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestForecastSeries_Seasonal(t *testing.T) {
+	// Три года помесячно: тренд +10 в месяц и годовая сезонность
+	pattern := []float64{-30, -20, 0, 10, 20, 40, 50, 30, 10, 0, -40, -70}
+	var history []float64
+	for i := 0; i < 36; i++ {
+		history = append(history, 1000+10*float64(i)+pattern[i%12])
+	}
+	periods := []string{"2025-01", "2025-02", "2025-03"}
+
+	model, points, bt := ForecastSeries(history, 12, periods, 0.95)
+	if model != ModelHoltWinters {
+		t.Fatalf("expected %s, got %s", ModelHoltWinters, model)
+	}
+	for i, p := range points {
+		want := 1000 + 10*float64(36+i) + pattern[i]
+		if math.Abs(p.Value-want) > 5 {
+			t.Errorf("%s: got %.2f, want about %.2f", p.Period, p.Value, want)
+		}
+		if p.Lower > p.Value || p.Upper < p.Value {
+			t.Errorf("%s: value %.2f outside interval [%.2f, %.2f]", p.Period, p.Value, p.Lower, p.Upper)
+		}
+	}
+	if bt == nil || bt.Periods != 3 || bt.MAE > 5 || bt.MAPE == nil || *bt.MAPE > 1 {
+		t.Errorf("unexpected backtest %+v", bt)
+	}
+}
+
+func TestForecastSeries_ShortHistory(t *testing.T) {
+	model, points, _ := ForecastSeries([]float64{10, 20, 30, 40}, 12, []string{"a", "b"}, 0.9)
+	if model != ModelHolt || math.Abs(points[0].Value-50) > 1e-6 || math.Abs(points[1].Value-60) > 1e-6 {
+		t.Errorf("expected linear trend, got %s %+v", model, points)
+	}
+
+	model, points, bt := ForecastSeries([]float64{10, 30}, 12, []string{"a"}, 0.9)
+	if model != ModelMean || points[0].Value != 20 || bt != nil {
+		t.Errorf("expected mean, got %s %+v %+v", model, points, bt)
+	}
+
+	// Убывающий ряд не уходит в минус
+	_, points, _ = ForecastSeries([]float64{30, 20, 10}, 12, []string{"a", "b"}, 0.9)
+	if points[1].Value != 0 || points[1].Lower != 0 {
+		t.Errorf("forecast must be clamped at zero, got %+v", points[1])
+	}
+}
+
+func TestForecastQuery_Normalize(t *testing.T) {
+	now := time.Date(2025, 2, 12, 15, 0, 0, 0, time.UTC)
+	q, err := ForecastQuery{}.Normalize(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantTo := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond)
+	wantFrom := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	if q.GroupBy != GroupMonth || q.Horizon != 3 || q.Level != 0.95 || !q.To.Equal(wantTo) || !q.From.Equal(wantFrom) {
+		t.Fatalf("unexpected defaults %+v", q)
+	}
+	if got := q.FuturePeriods(); len(got) != 3 || got[0] != "2025-02" || got[2] != "2025-04" {
+		t.Errorf("unexpected periods %v", got)
+	}
+
+	q = ForecastQuery{GroupBy: GroupWeek, To: time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC), Horizon: 2}
+	if q, err = q.Normalize(now); err != nil {
+		t.Fatal(err)
+	}
+	// 30.12.2024 — первая ISO-неделя 2025 года
+	if got := q.FuturePeriods(); got[0] != "2025-W01" || got[1] != "2025-W02" {
+		t.Errorf("unexpected weeks %v", got)
+	}
+
+	for _, c := range []struct {
+		groupBy GroupBy
+		loc     *time.Location
+	}{{GroupDay, time.UTC}, {GroupWeek, time.UTC}, {GroupMonth, time.UTC}, {GroupMonth, time.FixedZone("MSK", 3*60*60)}} {
+		q, err := ForecastQuery{GroupBy: c.groupBy, Location: c.loc}.Normalize(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// история по умолчанию начинается ровно с начала периода в поясе запроса
+		if from := q.From.In(c.loc); !from.Equal(periodStart(c.groupBy, from)) {
+			t.Errorf("%s/%s: default history starts mid-period at %v", c.groupBy, c.loc, from)
+		}
+	}
+
+	for _, bad := range []ForecastQuery{{GroupBy: GroupYear}, {Horizon: -1}, {Level: 1.5}, {From: now, To: now.AddDate(0, -1, 0)}} {
+		if _, err := bad.Normalize(now); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package analytic
+
+import (
+	"fmt"
+	"math"
+	"salestracker/internal/domain/transaction"
+	"time"
+)
+
+// Bucketing — как диапазон сумм делится на корзины гистограммы
+type Bucketing string
+
+const (
+	BucketFixed    Bucketing = "fixed"    // корзины одинаковой ширины от минимальной до максимальной суммы
+	BucketLog      Bucketing = "log"      // одинаковой ширины по логарифму суммы: каждая следующая шире в одно и то же число раз
+	BucketQuantile Bucketing = "quantile" // границы — квантили, в корзинах примерно поровну транзакций
+)
+
+const (
+	// DefaultHistogramBuckets — число корзин по умолчанию
+	DefaultHistogramBuckets = 10
+	// MaxHistogramBuckets — сколько корзин можно запросить
+	MaxHistogramBuckets = 100
+)
+
+// HistogramQuery — параметры гистограммы сумм транзакций за период [From, To]
+type HistogramQuery struct {
+	From      time.Time
+	To        time.Time
+	Buckets   int
+	Bucketing Bucketing
+	Filter    transaction.Filter
+}
+
+// HistogramBucket — корзина [Lower, Upper): число и сумма попавших в нее транзакций. Последняя корзина включает Upper
+type HistogramBucket struct {
+	Lower float64 `json:"Lower"`
+	Upper float64 `json:"Upper"`
+	Count int     `json:"Count"`
+	Sum   float64 `json:"Sum"`
+}
+
+// Histogram — распределение сумм транзакций: итоги по всем транзакциям и корзины по порядку, включая пустые
+type Histogram struct {
+	Bucketing Bucketing         `json:"Bucketing"`
+	Count     int               `json:"Count"`
+	Sum       float64           `json:"Sum"`
+	Min       float64           `json:"Min"`
+	Max       float64           `json:"Max"`
+	Buckets   []HistogramBucket `json:"Buckets"`
+}
+
+// Normalize проверяет запрос и подставляет значения по умолчанию: 10 корзин одинаковой ширины
+func (q HistogramQuery) Normalize() (HistogramQuery, error) {
+	if q.From.After(q.To) {
+		return q, fmt.Errorf("%w: 'from' date cannot be after 'to'", ErrInvalidQuery)
+	}
+	if q.Buckets < 0 || q.Buckets > MaxHistogramBuckets {
+		return q, fmt.Errorf("%w: buckets must be between 1 and %d", ErrInvalidQuery, MaxHistogramBuckets)
+	}
+	if q.Buckets == 0 {
+		q.Buckets = DefaultHistogramBuckets
+	}
+	switch q.Bucketing {
+	case "":
+		q.Bucketing = BucketFixed
+	case BucketFixed, BucketLog, BucketQuantile:
+	default:
+		return q, fmt.Errorf("%w: unknown bucketing %q, expected fixed, log or quantile", ErrInvalidQuery, q.Bucketing)
+	}
+	if err := q.Filter.Validate(); err != nil {
+		return q, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	return q, nil
+}
+
+// Quantiles — доли, квантили которых служат нижними границами корзин при BucketQuantile: 0, 1/n, ..., (n-1)/n
+func (q HistogramQuery) Quantiles() []float64 {
+	p := make([]float64, q.Buckets)
+	for i := range p {
+		p[i] = float64(i) / float64(q.Buckets)
+	}
+	return p
+}
+
+// HistogramEdges возвращает границы n корзин между lo и hi (n+1 значение) для fixed и log.
+// Если все суммы равны, корзина одна
+func HistogramEdges(b Bucketing, n int, lo, hi float64) []float64 {
+	if lo >= hi {
+		return []float64{lo, hi}
+	}
+	edges := make([]float64, n+1)
+	for i := range edges {
+		if b == BucketLog {
+			edges[i] = math.Exp(math.Log(lo) + float64(i)*(math.Log(hi)-math.Log(lo))/float64(n))
+		} else {
+			edges[i] = lo + float64(i)*(hi-lo)/float64(n)
+		}
+	}
+	// Крайние границы — ровно минимум и максимум, без ошибки округления
+	edges[0], edges[n] = lo, hi
+	return edges
+}
+
+// QuantileEdges строит границы корзин из квантилей-нижних границ и максимума. Совпадающие квантили (много одинаковых
+// сумм) схлопываются, поэтому корзин может оказаться меньше запрошенного
+func QuantileEdges(thresholds []float64, hi float64) []float64 {
+	var edges []float64
+	for _, t := range thresholds {
+		if len(edges) == 0 || t > edges[len(edges)-1] {
+			edges = append(edges, t)
+		}
+	}
+	return append(edges, hi)
+}
+
+// NewHistogramBuckets — пустые корзины между соседними границами
+func NewHistogramBuckets(edges []float64) []HistogramBucket {
+	buckets := make([]HistogramBucket, 0, len(edges)-1)
+	for i := 1; i < len(edges); i++ {
+		buckets = append(buckets, HistogramBucket{Lower: edges[i-1], Upper: edges[i]})
+	}
+	return buckets
+}
+
+// Add записывает число и сумму транзакций корзины с номером bucket в нумерации width_bucket (с 1).
+// Номера за пределами корзин прижимаются к крайним: максимум width_bucket относит к корзине n+1
+func (h *Histogram) Add(bucket, count int, sum float64) {
+	if len(h.Buckets) == 0 {
+		return
+	}
+	i := min(max(bucket, 1), len(h.Buckets)) - 1
+	h.Buckets[i].Count += count
+	h.Buckets[i].Sum += sum
+}
@@ -0,0 +1,61 @@
+package analytic
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistogramQueryNormalize(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	q, err := HistogramQuery{From: from, To: to}.Normalize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Buckets != DefaultHistogramBuckets || q.Bucketing != BucketFixed {
+		t.Errorf("unexpected defaults %+v", q)
+	}
+	for _, bad := range []HistogramQuery{
+		{From: to, To: from},
+		{From: from, To: to, Buckets: -1},
+		{From: from, To: to, Buckets: MaxHistogramBuckets + 1},
+		{From: from, To: to, Bucketing: "sqrt"},
+	} {
+		if _, err := bad.Normalize(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", bad, err)
+		}
+	}
+}
+
+func TestHistogramEdges(t *testing.T) {
+	if got := HistogramEdges(BucketFixed, 4, 10, 50); !reflect.DeepEqual(got, []float64{10, 20, 30, 40, 50}) {
+		t.Errorf("fixed edges: %v", got)
+	}
+	got := HistogramEdges(BucketLog, 3, 1, 1000)
+	for i, want := range []float64{1, 10, 100, 1000} {
+		if math.Abs(got[i]-want) > 1e-9 {
+			t.Fatalf("log edges: %v", got)
+		}
+	}
+	if got := HistogramEdges(BucketFixed, 10, 7, 7); !reflect.DeepEqual(got, []float64{7, 7}) {
+		t.Errorf("equal amounts must give one bucket, got %v", got)
+	}
+	if got := QuantileEdges([]float64{1, 1, 1, 5, 8}, 20); !reflect.DeepEqual(got, []float64{1, 5, 8, 20}) {
+		t.Errorf("quantile edges: %v", got)
+	}
+}
+
+func TestHistogramAdd(t *testing.T) {
+	h := &Histogram{Buckets: NewHistogramBuckets([]float64{0, 10, 20})}
+	h.Add(1, 2, 15)
+	h.Add(2, 1, 12)
+	h.Add(3, 1, 20) // максимум: width_bucket дает n+1
+	want := []HistogramBucket{{Lower: 0, Upper: 10, Count: 2, Sum: 15}, {Lower: 10, Upper: 20, Count: 2, Sum: 32}}
+	if !reflect.DeepEqual(h.Buckets, want) {
+		t.Errorf("unexpected buckets %+v", h.Buckets)
+	}
+}
@@ -0,0 +1,159 @@
+package analytic
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Metric — статистика, которую считает аналитика: sum, avg, count, min, max, stddev, variance, median,
+// percentile90 или произвольный перцентиль pNN (p25, p95, p99.9)
+type Metric string
+
+const (
+	MetricSum          Metric = "sum"
+	MetricAvg          Metric = "avg"
+	MetricCount        Metric = "count"
+	MetricMin          Metric = "min"
+	MetricMax          Metric = "max"
+	MetricStddev       Metric = "stddev"   // выборочное стандартное отклонение, для одной транзакции 0
+	MetricVariance     Metric = "variance" // выборочная дисперсия, для одной транзакции 0
+	MetricMedian       Metric = "median"
+	MetricPercentile90 Metric = "percentile90"
+)
+
+// MaxMetrics — сколько метрик можно запросить за раз
+const MaxMetrics = 20
+
+// DefaultMetrics — метрики без параметра metrics; ответ тогда сохраняет прежнюю форму Analytic
+var DefaultMetrics = []Metric{MetricSum, MetricAvg, MetricCount, MetricMedian, MetricPercentile90}
+
+// ParseMetrics разбирает список метрик через запятую: пробелы и регистр не важны, повторы отбрасываются
+func ParseMetrics(s string) []Metric {
+	var out []Metric
+	seen := map[Metric]bool{}
+	for _, part := range strings.Split(s, ",") {
+		m := Metric(strings.ToLower(strings.TrimSpace(part)))
+		if m == "" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		out = append(out, m)
+	}
+	return out
+}
+
+// Percentile возвращает долю для перцентильных метрик: median — 0.5, percentile90 — 0.9, pNN — NN/100
+func (m Metric) Percentile() (float64, bool) {
+	switch m {
+	case MetricMedian:
+		return 0.5, true
+	case MetricPercentile90:
+		return 0.9, true
+	}
+	s := string(m)
+	if len(s) < 2 || s[0] != 'p' || strings.Trim(s[1:], "0123456789.") != "" {
+		return 0, false
+	}
+	// e-2 вместо деления на 100: p99.9 дает ровно 0.999, а не 0.9990000000000001
+	v, err := strconv.ParseFloat(s[1:]+"e-2", 64)
+	if err != nil || v <= 0 || v >= 1 {
+		return 0, false
+	}
+	return v, true
+}
+
+// Valid проверяет метрику
+func (m Metric) Valid() bool {
+	switch m {
+	case MetricSum, MetricAvg, MetricCount, MetricMin, MetricMax, MetricStddev, MetricVariance:
+		return true
+	}
+	_, ok := m.Percentile()
+	return ok
+}
+
+// MetricList — метрики запроса, без явного выбора — DefaultMetrics
+func (q Query) MetricList() []Metric {
+	if len(q.Metrics) == 0 {
+		return DefaultMetrics
+	}
+	return q.Metrics
+}
+
+// AnalyticFromValues собирает Analytic из значений в порядке metrics. Values заполняется, только если метрики
+// выбраны явно (dynamic): тогда в JSON попадают только они
+func AnalyticFromValues(metrics []Metric, values []float64, dynamic bool) Analytic {
+	var a Analytic
+	if dynamic {
+		a.Values = make(map[Metric]float64, len(metrics))
+	}
+	for i, m := range metrics {
+		v := values[i]
+		switch m {
+		case MetricSum:
+			a.Sum = v
+		case MetricAvg:
+			a.Avg = v
+		case MetricCount:
+			a.Count = int(v)
+		case MetricMedian:
+			a.Median = v
+		case MetricPercentile90:
+			a.Percentile90 = v
+		}
+		if dynamic {
+			a.Values[m] = v
+		}
+	}
+	return a
+}
+
+// Value возвращает значение метрики: из Values, а без них — из полей прежней формы
+func (a Analytic) Value(m Metric) float64 {
+	if a.Values != nil {
+		return a.Values[m]
+	}
+	switch m {
+	case MetricSum:
+		return a.Sum
+	case MetricAvg:
+		return a.Avg
+	case MetricCount:
+		return float64(a.Count)
+	case MetricMedian:
+		return a.Median
+	case MetricPercentile90:
+		return a.Percentile90
+	}
+	return 0
+}
+
+// MarshalJSON отдает только запрошенные метрики, если они выбраны явно, иначе — прежнюю форму
+func (a Analytic) MarshalJSON() ([]byte, error) {
+	if a.Values != nil {
+		return json.Marshal(a.Values)
+	}
+	type plain Analytic
+	return json.Marshal(plain(a))
+}
+
+// emptyAnalytic — нулевые значения для набора метрик; без явного выбора — нулевая прежняя форма
+func emptyAnalytic(metrics []Metric) Analytic {
+	if len(metrics) == 0 {
+		return Analytic{}
+	}
+	return AnalyticFromValues(metrics, make([]float64, len(metrics)), true)
+}
+
+// clone копирует Analytic вместе с Values
+func (a Analytic) clone() Analytic {
+	if a.Values != nil {
+		values := make(map[Metric]float64, len(a.Values))
+		for k, v := range a.Values {
+			values[k] = v
+		}
+		a.Values = values
+	}
+	return a
+}
@@ -0,0 +1,65 @@
+package analytic
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMetricPercentile(t *testing.T) {
+	cases := map[Metric]float64{
+		MetricMedian:       0.5,
+		MetricPercentile90: 0.9,
+		"p25":              0.25,
+		"p99.9":            0.999,
+	}
+	for m, want := range cases {
+		got, ok := m.Percentile()
+		if !ok || got != want {
+			t.Errorf("%s: got %v %v, want %v", m, got, ok, want)
+		}
+	}
+	if _, ok := MetricMax.Percentile(); ok {
+		t.Error("max is not a percentile")
+	}
+}
+
+func TestAnalyticJSON(t *testing.T) {
+	legacy := AnalyticFromValues(DefaultMetrics, []float64{10, 5, 2, 5, 9}, false)
+	data, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"Sum":10,"Avg":5,"Count":2,"Median":5,"Percentile90":9}` {
+		t.Errorf("unexpected legacy JSON %s", data)
+	}
+
+	dynamic := AnalyticFromValues([]Metric{"p25", MetricMax, MetricCount}, []float64{1.5, 7, 3}, true)
+	data, err = json.Marshal(dynamic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"count":3,"max":7,"p25":1.5}` {
+		t.Errorf("unexpected dynamic JSON %s", data)
+	}
+	if dynamic.Count != 3 || dynamic.Value("p25") != 1.5 {
+		t.Errorf("unexpected values %+v", dynamic)
+	}
+}
+
+func TestFillGaps_Metrics(t *testing.T) {
+	metrics := []Metric{"p95", MetricMin}
+	groups := []AnalyticGroup{{GroupKey: "2025-02", Data: &AnalyticByType{
+		All: AnalyticFromValues(metrics, []float64{9, 1}, true),
+	}}}
+	keys := []string{"2025-01", "2025-02", "2025-03"}
+
+	zero := FillGaps(groups, keys, FillZero, metrics)
+	if v := zero[0].Data.All.Values; len(v) != 2 || v["p95"] != 0 {
+		t.Errorf("zero period must carry requested metrics, got %v", v)
+	}
+	prev := FillGaps(groups, keys, FillPrevious, metrics)
+	prev[2].Data.All.Values["p95"] = 100
+	if groups[0].Data.All.Values["p95"] != 9 {
+		t.Fatal("FillPrevious must copy metric values")
+	}
+}
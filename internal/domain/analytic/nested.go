@@ -0,0 +1,79 @@
+package analytic
+
+import (
+	"cmp"
+	"slices"
+)
+
+// GroupTree собирает вложенные группы из агрегатов отдельных уровней
+type GroupTree struct {
+	levels []GroupBy
+	root   *groupNode
+}
+
+type groupNode struct {
+	data     *AnalyticByType
+	children map[string]*groupNode
+}
+
+// NewGroupTree создает дерево для уровней группировки levels
+func NewGroupTree(levels []GroupBy) *GroupTree {
+	return &GroupTree{levels: levels, root: &groupNode{children: map[string]*groupNode{}}}
+}
+
+// Add кладет агрегаты группы с ключами path (по ключу на уровень, от верхнего); промежуточные узлы создаются
+// при необходимости, их агрегаты приходят отдельными вызовами
+func (t *GroupTree) Add(path []string, data *AnalyticByType) {
+	node := t.root
+	for _, key := range path {
+		child, ok := node.children[key]
+		if !ok {
+			child = &groupNode{children: map[string]*groupNode{}}
+			node.children[key] = child
+		}
+		node = child
+	}
+	node.data = data
+}
+
+// Groups возвращает группы верхнего уровня с подгруппами. Соседние группы сортируются по метрике sortBy
+// комбинированных агрегатов All, если она среди metrics, иначе по ключу; по убыванию, если не asc,
+// как и плоская аналитика
+func (t *GroupTree) Groups(metrics []Metric, sortBy string, asc bool) []AnalyticGroup {
+	var metric Metric
+	for _, m := range metrics {
+		if string(m) == sortBy {
+			metric = m
+		}
+	}
+	return t.build(t.root, 0, metric, asc)
+}
+
+func (t *GroupTree) build(node *groupNode, level int, metric Metric, asc bool) []AnalyticGroup {
+	if len(node.children) == 0 {
+		return nil
+	}
+	groups := make([]AnalyticGroup, 0, len(node.children))
+	for key, child := range node.children {
+		groups = append(groups, AnalyticGroup{
+			GroupKey:  key,
+			Data:      child.data,
+			Dimension: t.levels[level],
+			Children:  t.build(child, level+1, metric, asc),
+		})
+	}
+	slices.SortFunc(groups, func(a, b AnalyticGroup) int {
+		c := 0
+		if metric != "" && a.Data != nil && b.Data != nil {
+			c = cmp.Compare(a.Data.All.Value(metric), b.Data.All.Value(metric))
+		}
+		if c == 0 {
+			c = cmp.Compare(a.GroupKey, b.GroupKey)
+		}
+		if !asc {
+			c = -c
+		}
+		return c
+	})
+	return groups
+}
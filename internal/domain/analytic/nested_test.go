@@ -0,0 +1,71 @@
+package analytic
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	g, nested := ParseGroupBy("Week, type,category")
+	if g != GroupWeek || !reflect.DeepEqual(nested, []GroupBy{GroupType, GroupCategory}) {
+		t.Errorf("unexpected levels %q %v", g, nested)
+	}
+	if g, nested = ParseGroupBy("month"); g != GroupMonth || len(nested) != 0 {
+		t.Errorf("unexpected single level %q %v", g, nested)
+	}
+	if g, _ = ParseGroupBy(""); g != "" {
+		t.Errorf("expected empty groupBy, got %q", g)
+	}
+}
+
+func TestValidate_Nested(t *testing.T) {
+	now := time.Now()
+	ok := Query{From: now, To: now, GroupBy: GroupMonth, Nested: []GroupBy{GroupCategory}, Fill: FillZero}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, bad := range []Query{
+		{GroupBy: GroupMonth, Nested: []GroupBy{GroupMonth}},
+		{GroupBy: GroupMonth, Nested: []GroupBy{GroupNone}},
+		{GroupBy: GroupMonth, Nested: []GroupBy{"store"}},
+		{GroupBy: GroupYear, Nested: []GroupBy{GroupMonth, GroupDay, GroupType, GroupCategory}},
+		{GroupBy: GroupMonth, Nested: []GroupBy{GroupType}, SplitBy: "category"},
+		{GroupBy: GroupMonth, Nested: []GroupBy{GroupType}, Compare: CompareYoY},
+		{GroupBy: GroupMonth, Nested: []GroupBy{GroupType}, Cumulative: true},
+		{GroupBy: GroupMonth, Nested: []GroupBy{GroupType}, Rolling: []Window{{N: 3, Unit: 'm'}}},
+	} {
+		bad.From, bad.To = now, now
+		if err := bad.Validate(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", bad, err)
+		}
+	}
+}
+
+func TestGroupTree(t *testing.T) {
+	data := func(sum float64) *AnalyticByType {
+		return &AnalyticByType{All: Analytic{Sum: sum}}
+	}
+	tree := NewGroupTree([]GroupBy{GroupMonth, GroupCategory})
+	tree.Add([]string{"2025-02", "food"}, data(40))
+	tree.Add([]string{"2025-01"}, data(100))
+	tree.Add([]string{"2025-02", "sales"}, data(60))
+	tree.Add([]string{"2025-02"}, data(100))
+	tree.Add([]string{"2025-01", "sales"}, data(100))
+
+	groups := tree.Groups(DefaultMetrics, "", true)
+	if len(groups) != 2 || groups[0].GroupKey != "2025-01" || groups[0].Dimension != GroupMonth || groups[0].Data.All.Sum != 100 {
+		t.Fatalf("unexpected top level %+v", groups)
+	}
+	feb := groups[1].Children
+	if len(feb) != 2 || feb[0].GroupKey != "food" || feb[0].Dimension != GroupCategory || feb[1].Data.All.Sum != 60 || feb[0].Children != nil {
+		t.Errorf("unexpected children %+v", feb)
+	}
+
+	// Сортировка по метрике действует на каждом уровне
+	feb = tree.Groups(DefaultMetrics, "sum", false)[0].Children
+	if feb[0].GroupKey != "sales" {
+		t.Errorf("expected children sorted by sum desc, got %+v", feb)
+	}
+}
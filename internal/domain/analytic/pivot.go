@@ -0,0 +1,217 @@
+package analytic
+
+import (
+	"fmt"
+	"salestracker/internal/domain/transaction"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Dimension — измерение сводной таблицы
+type Dimension string
+
+const (
+	DimPeriod       Dimension = "period"       // период группировки PivotQuery.Period
+	DimCategory     Dimension = "category"     // категория
+	DimType         Dimension = "type"         // income или expense
+	DimCounterparty Dimension = "counterparty" // ИНН контрагента, пусто — не указан
+	DimTag          Dimension = "tag"          // у транзакций нет тегов: измерение распознается, но не поддерживается
+)
+
+// MaxPivotDimensions — сколько измерений строк и колонок можно запросить вместе
+const MaxPivotDimensions = 4
+
+// PivotLevel — уровень агрегации строки сводной таблицы
+type PivotLevel string
+
+const (
+	LevelCell        PivotLevel = "cell"         // пересечение строки и колонки
+	LevelRowTotal    PivotLevel = "row_total"    // итог строки по всем колонкам
+	LevelColumnTotal PivotLevel = "column_total" // итог колонки по всем строкам
+	LevelGrandTotal  PivotLevel = "grand_total"  // общий итог
+)
+
+// PivotQuery — параметры сводной таблицы за период [From, To]
+type PivotQuery struct {
+	From     time.Time
+	To       time.Time
+	Rows     []Dimension
+	Columns  []Dimension
+	Period   GroupBy // шаг измерения period, по умолчанию month
+	Metrics  []Metric
+	Mode     Mode // значение для метрик: signed (по умолчанию) или absolute
+	Filter   transaction.Filter
+	Location *time.Location
+}
+
+// PivotCell — значения метрик на пересечении ключей строки и колонки. У итогов строк нет Column,
+// у итогов колонок — Row
+type PivotCell struct {
+	Row    []string           `json:"Row,omitempty"`
+	Column []string           `json:"Column,omitempty"`
+	Values map[Metric]float64 `json:"Values"`
+}
+
+// Pivot — сводная таблица: ключи строк и колонок по порядку, непустые ячейки, итоги строк и колонок
+// и общий итог. Итоги считаются по самим транзакциям, поэтому медианы и перцентили в них корректны
+type Pivot struct {
+	Rows         []Dimension        `json:"Rows"`
+	Columns      []Dimension        `json:"Columns"`
+	Metrics      []Metric           `json:"Metrics"`
+	RowKeys      [][]string         `json:"RowKeys"`
+	ColumnKeys   [][]string         `json:"ColumnKeys"`
+	Cells        []PivotCell        `json:"Cells"`
+	RowTotals    []PivotCell        `json:"RowTotals"`
+	ColumnTotals []PivotCell        `json:"ColumnTotals"`
+	GrandTotal   map[Metric]float64 `json:"GrandTotal"`
+}
+
+// ParseDimensions разбирает список измерений через запятую
+func ParseDimensions(s string) ([]Dimension, error) {
+	var out []Dimension
+	for _, part := range strings.Split(s, ",") {
+		d := Dimension(strings.ToLower(strings.TrimSpace(part)))
+		if d == "" {
+			continue
+		}
+		switch d {
+		case DimPeriod, DimCategory, DimType, DimCounterparty:
+		case DimTag:
+			return nil, fmt.Errorf("%w: dimension tag is not supported: transactions have no tags", ErrInvalidQuery)
+		default:
+			return nil, fmt.Errorf("%w: unknown dimension %q, expected period, category, type or counterparty", ErrInvalidQuery, d)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// Normalize проверяет запрос и подставляет значения по умолчанию: шаг month, метрика sum, режим signed
+func (q PivotQuery) Normalize() (PivotQuery, error) {
+	if q.From.After(q.To) {
+		return q, fmt.Errorf("%w: 'from' date cannot be after 'to'", ErrInvalidQuery)
+	}
+	dims := append(slices.Clone(q.Rows), q.Columns...)
+	if len(dims) == 0 {
+		return q, fmt.Errorf("%w: pivot requires at least one row or column dimension", ErrInvalidQuery)
+	}
+	if len(dims) > MaxPivotDimensions {
+		return q, fmt.Errorf("%w: at most %d pivot dimensions are allowed", ErrInvalidQuery, MaxPivotDimensions)
+	}
+	seen := map[Dimension]bool{}
+	for _, d := range dims {
+		if seen[d] {
+			return q, fmt.Errorf("%w: dimension %s is used twice", ErrInvalidQuery, d)
+		}
+		seen[d] = true
+	}
+	if q.Period == "" {
+		q.Period = GroupMonth
+	}
+	if _, ok := groupingHours[q.Period]; !ok {
+		return q, fmt.Errorf("%w: period must be a time grouping, got %q", ErrInvalidQuery, q.Period)
+	}
+	if len(q.Metrics) > MaxMetrics {
+		return q, fmt.Errorf("%w: at most %d metrics are allowed", ErrInvalidQuery, MaxMetrics)
+	}
+	for _, m := range q.Metrics {
+		if !m.Valid() {
+			return q, fmt.Errorf("%w: unknown metric %q", ErrInvalidQuery, m)
+		}
+	}
+	if len(q.Metrics) == 0 {
+		q.Metrics = []Metric{MetricSum}
+	}
+	switch q.Mode {
+	case "":
+		q.Mode = ModeSigned
+	case ModeSigned, ModeAbsolute:
+	default:
+		return q, fmt.Errorf("%w: unknown mode %q", ErrInvalidQuery, q.Mode)
+	}
+	if err := q.Filter.Validate(); err != nil {
+		return q, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	return q, nil
+}
+
+// Dimensions — все измерения запроса: сначала строки, затем колонки
+func (q PivotQuery) Dimensions() []Dimension {
+	return append(slices.Clone(q.Rows), q.Columns...)
+}
+
+// Levels — уровни, к которым относится строка результата со свернутыми измерениями aggregated (по флагу на измерение
+// запроса, как GROUPING в SQL). Без измерений строк ячейка одновременно итог своей колонки, а общий итог — итог
+// единственной строки; так же без измерений колонок
+func (p *Pivot) Levels(aggregated []bool) []PivotLevel {
+	rowsAggregated, colsAggregated := true, true
+	rowsGrouped, colsGrouped := true, true
+	for i, a := range aggregated {
+		if i < len(p.Rows) {
+			rowsAggregated = rowsAggregated && a
+			rowsGrouped = rowsGrouped && !a
+		} else {
+			colsAggregated = colsAggregated && a
+			colsGrouped = colsGrouped && !a
+		}
+	}
+	var levels []PivotLevel
+	if rowsGrouped && colsGrouped {
+		levels = append(levels, LevelCell)
+	}
+	if rowsGrouped && colsAggregated {
+		levels = append(levels, LevelRowTotal)
+	}
+	if rowsAggregated && colsGrouped {
+		levels = append(levels, LevelColumnTotal)
+	}
+	if rowsAggregated && colsAggregated {
+		levels = append(levels, LevelGrandTotal)
+	}
+	return levels
+}
+
+// Add добавляет строку результата на уровень level; keys — значения всех измерений запроса
+// (строки, затем колонки), значения свернутых измерений не используются
+func (p *Pivot) Add(level PivotLevel, keys []string, values map[Metric]float64) {
+	row, col := keys[:len(p.Rows):len(p.Rows)], keys[len(p.Rows):]
+	switch level {
+	case LevelCell:
+		p.Cells = append(p.Cells, PivotCell{Row: row, Column: col, Values: values})
+	case LevelRowTotal:
+		p.RowTotals = append(p.RowTotals, PivotCell{Row: row, Values: values})
+	case LevelColumnTotal:
+		p.ColumnTotals = append(p.ColumnTotals, PivotCell{Column: col, Values: values})
+	case LevelGrandTotal:
+		p.GrandTotal = values
+	}
+}
+
+// Sort собирает ключи строк и колонок из ячеек и упорядочивает ключи, ячейки и итоги по значениям
+// измерений: ключи периодов сортируются как строки, то есть хронологически
+func (p *Pivot) Sort() {
+	cmpCells := func(a, b PivotCell) int {
+		if c := slices.Compare(a.Row, b.Row); c != 0 {
+			return c
+		}
+		return slices.Compare(a.Column, b.Column)
+	}
+	slices.SortFunc(p.Cells, cmpCells)
+	slices.SortFunc(p.RowTotals, cmpCells)
+	slices.SortFunc(p.ColumnTotals, cmpCells)
+
+	p.RowKeys, p.ColumnKeys = [][]string{}, [][]string{}
+	for _, c := range p.Cells {
+		if n := len(p.RowKeys); len(p.Rows) > 0 && (n == 0 || !slices.Equal(p.RowKeys[n-1], c.Row)) {
+			p.RowKeys = append(p.RowKeys, c.Row)
+		}
+		if len(p.Columns) > 0 && !slices.ContainsFunc(p.ColumnKeys, func(k []string) bool { return slices.Equal(k, c.Column) }) {
+			p.ColumnKeys = append(p.ColumnKeys, c.Column)
+		}
+	}
+	slices.SortFunc(p.ColumnKeys, slices.Compare[[]string])
+}
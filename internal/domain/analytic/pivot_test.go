@@ -0,0 +1,81 @@
+package analytic
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDimensions(t *testing.T) {
+	dims, err := ParseDimensions("Category, period,,counterparty")
+	if err != nil || !reflect.DeepEqual(dims, []Dimension{DimCategory, DimPeriod, DimCounterparty}) {
+		t.Fatalf("unexpected dimensions %v %v", dims, err)
+	}
+	for _, s := range []string{"tag", "category,store"} {
+		if _, err := ParseDimensions(s); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%q: expected ErrInvalidQuery, got %v", s, err)
+		}
+	}
+}
+
+func TestPivotQuery_Normalize(t *testing.T) {
+	now := time.Now()
+	q, err := PivotQuery{From: now, To: now, Rows: []Dimension{DimType}}.Normalize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Period != GroupMonth || q.Mode != ModeSigned || !reflect.DeepEqual(q.Metrics, []Metric{MetricSum}) || q.Location != time.UTC {
+		t.Errorf("unexpected defaults %+v", q)
+	}
+	for _, bad := range []PivotQuery{
+		{},
+		{Rows: []Dimension{DimType}, Columns: []Dimension{DimType}},
+		{Rows: []Dimension{DimType}, Period: GroupCategory},
+		{Rows: []Dimension{DimType}, Metrics: []Metric{"p101"}},
+		{Rows: []Dimension{DimPeriod, DimCategory, DimType}, Columns: []Dimension{DimCounterparty, DimTag}},
+	} {
+		if _, err := bad.Normalize(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", bad, err)
+		}
+	}
+}
+
+func TestPivot_Levels(t *testing.T) {
+	p := &Pivot{Rows: []Dimension{DimCategory}, Columns: []Dimension{DimPeriod}}
+	cases := map[[2]bool][]PivotLevel{
+		{false, false}: {LevelCell},
+		{false, true}:  {LevelRowTotal},
+		{true, false}:  {LevelColumnTotal},
+		{true, true}:   {LevelGrandTotal},
+	}
+	for agg, want := range cases {
+		if got := p.Levels(agg[:]); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: got %v, want %v", agg, got, want)
+		}
+	}
+
+	// Без измерений строк ячейка — это и итог колонки, а общий итог — итог единственной строки
+	p = &Pivot{Columns: []Dimension{DimPeriod}}
+	if got := p.Levels([]bool{false}); !reflect.DeepEqual(got, []PivotLevel{LevelCell, LevelColumnTotal}) {
+		t.Errorf("unexpected levels %v", got)
+	}
+	if got := p.Levels([]bool{true}); !reflect.DeepEqual(got, []PivotLevel{LevelRowTotal, LevelGrandTotal}) {
+		t.Errorf("unexpected levels %v", got)
+	}
+}
+
+func TestPivot_Sort(t *testing.T) {
+	p := &Pivot{Rows: []Dimension{DimCategory}, Columns: []Dimension{DimPeriod}}
+	for _, k := range [][]string{{"sales", "2025-01"}, {"food", "2025-02"}, {"food", "2025-01"}, {"sales", "2024-12"}} {
+		p.Add(LevelCell, k, map[Metric]float64{MetricSum: 1})
+	}
+	p.Sort()
+	if !reflect.DeepEqual(p.RowKeys, [][]string{{"food"}, {"sales"}}) ||
+		!reflect.DeepEqual(p.ColumnKeys, [][]string{{"2024-12"}, {"2025-01"}, {"2025-02"}}) {
+		t.Errorf("unexpected keys %v %v", p.RowKeys, p.ColumnKeys)
+	}
+	if p.Cells[0].Row[0] != "food" || p.Cells[0].Column[0] != "2025-01" || p.Cells[3].Column[0] != "2025-01" {
+		t.Errorf("unexpected order %+v", p.Cells)
+	}
+}
@@ -0,0 +1,157 @@
+package analytic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxRollingWindows — сколько скользящих окон можно запросить за раз
+const MaxRollingWindows = 4
+
+// Window — длина скользящего окна: N единиц h, d, w, m (месяц), q или y. Записывается как "7d", "30d", "3m"
+type Window struct {
+	N    int
+	Unit byte
+}
+
+// windowUnits — интервал Postgres и примерная длина в часах для каждой единицы окна
+var windowUnits = map[byte]struct {
+	interval string
+	hours    int
+}{
+	'h': {"hours", 1},
+	'd': {"days", 24},
+	'w': {"weeks", 24 * 7},
+	'm': {"months", 24 * 30},
+	'q': {"months", 24 * 91},
+	'y': {"years", 24 * 365},
+}
+
+// groupingHours — примерная длина периода временной группировки в часах
+var groupingHours = map[GroupBy]int{
+	GroupHour:    1,
+	GroupDay:     24,
+	GroupWeek:    24 * 7,
+	GroupMonth:   24 * 30,
+	GroupQuarter: 24 * 91,
+	GroupYear:    24 * 365,
+}
+
+// ParseWindows разбирает список окон через запятую ("7d,30d"); повторы отбрасываются
+func ParseWindows(s string) ([]Window, error) {
+	var out []Window
+	seen := map[Window]bool{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		w, err := ParseWindow(part)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+// ParseWindow разбирает окно вида <N><unit>
+func ParseWindow(s string) (Window, error) {
+	if len(s) < 2 {
+		return Window{}, fmt.Errorf("%w: invalid rolling window %q", ErrInvalidQuery, s)
+	}
+	unit := s[len(s)-1]
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if _, ok := windowUnits[unit]; !ok || err != nil || n < 1 || n > 1000 {
+		return Window{}, fmt.Errorf("%w: invalid rolling window %q, expected <N><h|d|w|m|q|y>", ErrInvalidQuery, s)
+	}
+	return Window{N: n, Unit: unit}, nil
+}
+
+func (w Window) String() string {
+	return strconv.Itoa(w.N) + string(w.Unit)
+}
+
+// Interval — окно как интервал Postgres: "7 days", "6 months" для 2q
+func (w Window) Interval() string {
+	n := w.N
+	if w.Unit == 'q' {
+		n *= 3
+	}
+	return fmt.Sprintf("%d %s", n, windowUnits[w.Unit].interval)
+}
+
+// groupingMonths — длина календарных группировок в месяцах
+var groupingMonths = map[GroupBy]int{
+	GroupMonth:   1,
+	GroupQuarter: 3,
+	GroupYear:    12,
+}
+
+// months — длина окна в месяцах для календарных единиц m, q и y
+func (w Window) months() (int, bool) {
+	switch w.Unit {
+	case 'm':
+		return w.N, true
+	case 'q':
+		return 3 * w.N, true
+	case 'y':
+		return 12 * w.N, true
+	}
+	return 0, false
+}
+
+// FrameOffset — насколько раньше текущего периода начинается самый ранний период окна, как интервал Postgres
+// в одной единице: месяцы для month, quarter и year, часы для остальных группировок. Смешанный интервал вроде
+// '30 days' - '1 month' в 31-дневных месяцах отрицателен и дает пустое окно, поэтому для календарных группировок
+// окно должно быть целым числом периодов, а окна в месяцах не сочетаются с часами, днями и неделями
+func (w Window) FrameOffset(g GroupBy) (string, error) {
+	if g == "" {
+		g = GroupDay
+	}
+	m, calendar := w.months()
+	if step, ok := groupingMonths[g]; ok {
+		if !calendar || m%step != 0 {
+			return "", fmt.Errorf("%w: rolling window %s must be a whole number of %ss", ErrInvalidQuery, w, g)
+		}
+		return fmt.Sprintf("%d months", m-step), nil
+	}
+	if calendar {
+		return "", fmt.Errorf("%w: rolling window %s requires a month, quarter or year grouping, use h, d or w with %s", ErrInvalidQuery, w, g)
+	}
+	hours := w.N * windowUnits[w.Unit].hours
+	if hours < groupingHours[g] {
+		return "", fmt.Errorf("%w: rolling window %s is shorter than one %s", ErrInvalidQuery, w, g)
+	}
+	return fmt.Sprintf("%d hours", hours-groupingHours[g]), nil
+}
+
+// RollingValue — скользящие агрегаты на конец периода: сумма и число транзакций за окно,
+// Avg — сумма, деленная на число периодов группировки в окне (скользящее среднее ряда)
+type RollingValue struct {
+	Sum   float64 `json:"Sum"`
+	Avg   float64 `json:"Avg"`
+	Count int     `json:"Count"`
+}
+
+// RollingByType — скользящие агрегаты по типам; All — по значениям в режиме Mode, как у AnalyticByType
+type RollingByType struct {
+	Income  RollingValue `json:"Income"`
+	Expense RollingValue `json:"Expense"`
+	All     RollingValue `json:"All"`
+}
+
+// ByType возвращает агрегаты для типа в именовании CSV: Income, Expense или All
+func (r *RollingByType) ByType(typ string) RollingValue {
+	switch typ {
+	case "Income":
+		return r.Income
+	case "Expense":
+		return r.Expense
+	}
+	return r.All
+}
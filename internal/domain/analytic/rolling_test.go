@@ -0,0 +1,79 @@
+package analytic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseWindows(t *testing.T) {
+	ws, err := ParseWindows(" 7d, 30D,7d,2q,12h")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"7d", "30d", "2q", "12h"}
+	if len(ws) != len(want) {
+		t.Fatalf("got %v, want %v", ws, want)
+	}
+	for i, w := range ws {
+		if w.String() != want[i] {
+			t.Errorf("window %d: got %s, want %s", i, w, want[i])
+		}
+	}
+	if ws[2].Interval() != "6 months" || ws[0].Interval() != "7 days" {
+		t.Errorf("unexpected intervals %s, %s", ws[2].Interval(), ws[0].Interval())
+	}
+
+	for _, s := range []string{"7", "d", "0d", "7x", "-1d", "1.5d"} {
+		if _, err := ParseWindows(s); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%q: expected ErrInvalidQuery, got %v", s, err)
+		}
+	}
+}
+
+func TestQueryValidate_Rolling(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	day := []Window{{7, 'd'}, {30, 'd'}}
+
+	if err := (Query{From: from, To: to, Rolling: day}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := (Query{From: from, To: to, GroupBy: GroupMonth, Rolling: []Window{{1, 'm'}, {1, 'q'}, {1, 'y'}}}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, q := range []Query{
+		{From: from, To: to, GroupBy: GroupCategory, Rolling: day},
+		{From: from, To: to, Rolling: []Window{{12, 'h'}}},
+		{From: from, To: to, GroupBy: GroupMonth, Rolling: []Window{{2, 'w'}}},
+		{From: from, To: to, GroupBy: GroupMonth, Rolling: []Window{{30, 'd'}}},
+		{From: from, To: to, GroupBy: GroupQuarter, Rolling: []Window{{2, 'm'}}},
+		{From: from, To: to, GroupBy: GroupDay, Rolling: []Window{{1, 'm'}}},
+		{From: from, To: to, Rolling: []Window{{1, 'd'}, {2, 'd'}, {3, 'd'}, {4, 'd'}, {5, 'd'}}},
+	} {
+		if err := q.Validate(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", q.Rolling, err)
+		}
+	}
+}
+
+func TestWindowFrameOffset(t *testing.T) {
+	cases := []struct {
+		w    Window
+		g    GroupBy
+		want string
+	}{
+		{Window{7, 'd'}, GroupDay, "144 hours"},
+		{Window{30, 'd'}, GroupWeek, "552 hours"},
+		{Window{12, 'h'}, GroupHour, "11 hours"},
+		{Window{3, 'm'}, GroupMonth, "2 months"},
+		{Window{1, 'y'}, GroupQuarter, "9 months"},
+		{Window{1, 'y'}, GroupYear, "0 months"},
+	}
+	for _, c := range cases {
+		got, err := c.w.FrameOffset(c.g)
+		if err != nil || got != c.want {
+			t.Errorf("%s by %s: got %q, %v; want %q", c.w, c.g, got, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package analytic
+
+import (
+	"fmt"
+	"salestracker/internal/domain/transaction"
+	"slices"
+	"time"
+)
+
+// SeasonalityLayout — оси матрицы сезонности: строки и колонки
+type SeasonalityLayout string
+
+const (
+	LayoutWeekdayHour  SeasonalityLayout = "weekday_hour"  // строки — дни недели с понедельника, колонки — часы 00–23
+	LayoutMonthWeekday SeasonalityLayout = "month_weekday" // строки — месяцы года, колонки — дни недели
+)
+
+var (
+	weekdayLabels = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+	monthLabels   = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	hourLabels    = func() []string {
+		labels := make([]string, 24)
+		for i := range labels {
+			labels[i] = fmt.Sprintf("%02d", i)
+		}
+		return labels
+	}()
+)
+
+// SeasonalityMeasures — что может показывать матрица: число транзакций, их сумма или средняя сумма
+var SeasonalityMeasures = []Metric{MetricCount, MetricSum, MetricAvg}
+
+// SeasonalityQuery — параметры матрицы сезонности за период [From, To]. Час, день недели и месяц транзакции
+// определяются в часовом поясе Location
+type SeasonalityQuery struct {
+	From     time.Time
+	To       time.Time
+	Layout   SeasonalityLayout
+	Measure  Metric
+	Filter   transaction.Filter
+	Location *time.Location
+}
+
+// Seasonality — матрица сезонности: Values[i][j] — мера транзакций строки Rows[i] и колонки Columns[j].
+// Пустые ячейки — нули. Итоги строк, колонок и общий итог считаются по транзакциям, поэтому avg в них
+// не среднее средних
+type Seasonality struct {
+	Layout       SeasonalityLayout `json:"Layout"`
+	Measure      Metric            `json:"Measure"`
+	Rows         []string          `json:"Rows"`
+	Columns      []string          `json:"Columns"`
+	Values       [][]float64       `json:"Values"`
+	RowTotals    []float64         `json:"RowTotals"`
+	ColumnTotals []float64         `json:"ColumnTotals"`
+	Total        float64           `json:"Total"`
+
+	counts [][]int
+	sums   [][]float64
+}
+
+// Normalize проверяет запрос и подставляет значения по умолчанию: дни недели × часы, мера count, UTC
+func (q SeasonalityQuery) Normalize() (SeasonalityQuery, error) {
+	if q.From.After(q.To) {
+		return q, fmt.Errorf("%w: 'from' date cannot be after 'to'", ErrInvalidQuery)
+	}
+	switch q.Layout {
+	case "":
+		q.Layout = LayoutWeekdayHour
+	case LayoutWeekdayHour, LayoutMonthWeekday:
+	default:
+		return q, fmt.Errorf("%w: unknown layout %q, expected weekday_hour or month_weekday", ErrInvalidQuery, q.Layout)
+	}
+	if q.Measure == "" {
+		q.Measure = MetricCount
+	}
+	if !slices.Contains(SeasonalityMeasures, q.Measure) {
+		return q, fmt.Errorf("%w: unknown measure %q, expected count, sum or avg", ErrInvalidQuery, q.Measure)
+	}
+	if err := q.Filter.Validate(); err != nil {
+		return q, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	return q, nil
+}
+
+// Axes — подписи строк и колонок раскладки
+func (l SeasonalityLayout) Axes() (rows, columns []string) {
+	if l == LayoutMonthWeekday {
+		return monthLabels, weekdayLabels
+	}
+	return weekdayLabels, hourLabels
+}
+
+// NewSeasonality — пустая матрица для запроса
+func NewSeasonality(q SeasonalityQuery) *Seasonality {
+	rows, cols := q.Layout.Axes()
+	s := &Seasonality{Layout: q.Layout, Measure: q.Measure, Rows: rows, Columns: cols}
+	s.counts = make([][]int, len(rows))
+	s.sums = make([][]float64, len(rows))
+	for i := range rows {
+		s.counts[i] = make([]int, len(cols))
+		s.sums[i] = make([]float64, len(cols))
+	}
+	return s
+}
+
+// Add записывает число и сумму транзакций ячейки. row и col — значения EXTRACT: день недели ISODOW (1 — понедельник),
+// месяц с 1, час с 0; значения вне осей пропускаются
+func (s *Seasonality) Add(row, col, count int, sum float64) {
+	if s.Layout == LayoutWeekdayHour {
+		row--
+	} else {
+		row, col = row-1, col-1
+	}
+	if row < 0 || row >= len(s.Rows) || col < 0 || col >= len(s.Columns) {
+		return
+	}
+	s.counts[row][col] += count
+	s.sums[row][col] += sum
+}
+
+// Finish считает значения меры в ячейках и итогах по накопленным числам и суммам
+func (s *Seasonality) Finish() {
+	s.Values = make([][]float64, len(s.Rows))
+	s.RowTotals = make([]float64, len(s.Rows))
+	s.ColumnTotals = make([]float64, len(s.Columns))
+	colCounts, colSums := make([]int, len(s.Columns)), make([]float64, len(s.Columns))
+	var totalCount int
+	var totalSum float64
+	for i := range s.Rows {
+		s.Values[i] = make([]float64, len(s.Columns))
+		var rowCount int
+		var rowSum float64
+		for j := range s.Columns {
+			c, v := s.counts[i][j], s.sums[i][j]
+			s.Values[i][j] = s.measure(c, v)
+			rowCount, rowSum = rowCount+c, rowSum+v
+			colCounts[j], colSums[j] = colCounts[j]+c, colSums[j]+v
+		}
+		s.RowTotals[i] = s.measure(rowCount, rowSum)
+		totalCount, totalSum = totalCount+rowCount, totalSum+rowSum
+	}
+	for j := range s.Columns {
+		s.ColumnTotals[j] = s.measure(colCounts[j], colSums[j])
+	}
+	s.Total = s.measure(totalCount, totalSum)
+}
+
+func (s *Seasonality) measure(count int, sum float64) float64 {
+	switch s.Measure {
+	case MetricSum:
+		return sum
+	case MetricAvg:
+		if count == 0 {
+			return 0
+		}
+		return sum / float64(count)
+	}
+	return float64(count)
+}
@@ -0,0 +1,58 @@
+package analytic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSeasonalityQueryNormalize(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	q, err := SeasonalityQuery{From: from, To: to}.Normalize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Layout != LayoutWeekdayHour || q.Measure != MetricCount || q.Location != time.UTC {
+		t.Errorf("unexpected defaults %+v", q)
+	}
+	for _, bad := range []SeasonalityQuery{
+		{From: to, To: from},
+		{From: from, To: to, Layout: "hour_weekday"},
+		{From: from, To: to, Measure: MetricMedian},
+	} {
+		if _, err := bad.Normalize(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", bad, err)
+		}
+	}
+}
+
+func TestSeasonality_Avg(t *testing.T) {
+	s := NewSeasonality(SeasonalityQuery{Layout: LayoutWeekdayHour, Measure: MetricAvg})
+	s.Add(1, 9, 2, 100) // понедельник 09:00
+	s.Add(1, 18, 1, 40) // понедельник 18:00
+	s.Add(7, 9, 1, 10)  // воскресенье 09:00
+	s.Add(8, 0, 5, 500) // вне осей
+	s.Finish()
+
+	if len(s.Values) != 7 || len(s.Values[0]) != 24 || s.Rows[6] != "Sun" || s.Columns[9] != "09" {
+		t.Fatalf("unexpected axes %v x %v", s.Rows, s.Columns)
+	}
+	if s.Values[0][9] != 50 || s.Values[6][9] != 10 || s.Values[3][3] != 0 {
+		t.Errorf("unexpected cells %v", s.Values)
+	}
+	// итоги — по транзакциям: (100+40)/3, а не среднее средних 50 и 40
+	if s.RowTotals[0] != 140.0/3 || s.ColumnTotals[9] != 110.0/3 || s.Total != 37.5 {
+		t.Errorf("unexpected totals %v %v %v", s.RowTotals, s.ColumnTotals, s.Total)
+	}
+}
+
+func TestSeasonality_MonthWeekday(t *testing.T) {
+	s := NewSeasonality(SeasonalityQuery{Layout: LayoutMonthWeekday, Measure: MetricSum})
+	s.Add(2, 3, 2, 100) // февраль, среда
+	s.Finish()
+	if len(s.Values) != 12 || len(s.Values[0]) != 7 || s.Values[1][2] != 100 || s.Total != 100 {
+		t.Errorf("unexpected matrix %v", s.Values)
+	}
+}
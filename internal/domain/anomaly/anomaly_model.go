@@ -0,0 +1,211 @@
+package anomaly
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"math"
+	"salestracker/internal/domain/transaction"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kind — что считается аномалией: отдельная транзакция или итог дня
+type Kind string
+
+const (
+	KindAll         Kind = ""
+	KindTransaction Kind = "transaction" // сумма транзакции нетипична для ее типа и категории
+	KindDay         Kind = "day"         // итог дня по типу выбивается из ожидаемого диапазона
+)
+
+const (
+	// DefaultThreshold — порог модифицированного z-score (Iglewicz и Hoaglin)
+	DefaultThreshold = 3.5
+	// IQRFence — множитель IQR для внешних границ Тьюки (far out)
+	IQRFence = 3.0
+	// MinSamples — минимальный размер выборки, по которой можно судить о выбросах
+	MinSamples = 8
+	// TransactionLookback — сколько истории до from учитывается в статистике категорий
+	TransactionLookback = 90 * 24 * time.Hour
+	// DayBaseline — за сколько предыдущих дней считается ожидаемый диапазон итога дня
+	DayBaseline = 28
+	// DefaultPeriod — период проверки по умолчанию
+	DefaultPeriod = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidQuery — некорректные параметры поиска аномалий
+	ErrInvalidQuery = errors.New("invalid anomaly query")
+	// ErrInvalidID — ID не похож на ID аномалии
+	ErrInvalidID = errors.New("invalid anomaly id")
+)
+
+// Anomaly — найденная аномалия. ID стабилен между запросами: tx-<ID транзакции> или day-<тип>-<YYYY-MM-DD>,
+// по нему аномалию можно подтвердить. Expected — медиана выборки, Lower и Upper — границы, за которыми значение
+// считается выбросом, Score — модуль модифицированного z-score
+type Anomaly struct {
+	ID             string                      `json:"ID"`
+	Kind           Kind                        `json:"Kind"`
+	Type           transaction.TransactionType `json:"Type"`
+	Category       string                      `json:"Category,omitempty"`
+	TransactionID  *uuid.UUID                  `json:"TransactionID,omitempty"`
+	Date           time.Time                   `json:"Date"`
+	Value          float64                     `json:"Value"`
+	Expected       float64                     `json:"Expected"`
+	Lower          float64                     `json:"Lower"`
+	Upper          float64                     `json:"Upper"`
+	Score          float64                     `json:"Score"`
+	Reason         string                      `json:"Reason"`
+	Acknowledged   bool                        `json:"Acknowledged"`
+	AcknowledgedAt *time.Time                  `json:"AcknowledgedAt,omitempty"`
+	Note           string                      `json:"Note,omitempty"`
+}
+
+// Ack — подтверждение аномалии пользователем: подтвержденные аномалии по умолчанию не возвращаются
+type Ack struct {
+	ID             string    `json:"ID"`
+	Note           string    `json:"Note"`
+	AcknowledgedAt time.Time `json:"AcknowledgedAt"`
+}
+
+// Query — параметры поиска аномалий за период [From, To]
+type Query struct {
+	From                time.Time
+	To                  time.Time
+	Kind                Kind
+	Threshold           float64
+	Filter              transaction.Filter
+	IncludeAcknowledged bool
+	Location            *time.Location // часовой пояс границ дней, по умолчанию UTC
+}
+
+// Normalize проверяет запрос и подставляет значения по умолчанию: последние 30 дней и порог 3.5
+func (q Query) Normalize(now time.Time) (Query, error) {
+	if q.Kind != KindAll && q.Kind != KindTransaction && q.Kind != KindDay {
+		return q, fmt.Errorf("%w: unknown kind %q, expected transaction or day", ErrInvalidQuery, q.Kind)
+	}
+	if q.Threshold < 0 || math.IsNaN(q.Threshold) || math.IsInf(q.Threshold, 0) {
+		return q, fmt.Errorf("%w: threshold must be positive", ErrInvalidQuery)
+	}
+	if err := q.Filter.Validate(); err != nil {
+		return q, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	if q.Threshold == 0 {
+		q.Threshold = DefaultThreshold
+	}
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	if q.To.IsZero() {
+		q.To = now
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-DefaultPeriod)
+	}
+	if q.From.After(q.To) {
+		return q, fmt.Errorf("%w: 'from' date cannot be after 'to'", ErrInvalidQuery)
+	}
+	return q, nil
+}
+
+// TransactionAnomalyID — ID аномалии транзакции
+func TransactionAnomalyID(id uuid.UUID) string {
+	return "tx-" + id.String()
+}
+
+// DayAnomalyID — ID аномалии итога дня по типу
+func DayAnomalyID(typ transaction.TransactionType, day time.Time) string {
+	return fmt.Sprintf("day-%s-%s", typ, day.Format("2006-01-02"))
+}
+
+// ValidateID проверяет, что id построен TransactionAnomalyID или DayAnomalyID
+func ValidateID(id string) error {
+	if rest, ok := strings.CutPrefix(id, "tx-"); ok {
+		if _, err := uuid.Parse(rest); err == nil {
+			return nil
+		}
+	}
+	if rest, ok := strings.CutPrefix(id, "day-"); ok {
+		typ, day, _ := strings.Cut(rest, "-")
+		if _, err := time.Parse("2006-01-02", day); err == nil &&
+			(transaction.TransactionType(typ) == transaction.Income || transaction.TransactionType(typ) == transaction.Expense) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q", ErrInvalidID, id)
+}
+
+// Stats — робастная статистика выборки. Scale — MAD, приведенное к СКО нормального распределения (MAD / 0.6745);
+// если больше половины значений совпадают и MAD нулевое — среднее абсолютное отклонение от медианы × 1.2533
+type Stats struct {
+	N      int
+	Median float64
+	Scale  float64
+	Q1     float64
+	Q3     float64
+}
+
+// NewStats считает статистику по выборке; values не изменяется
+func NewStats(values []float64) Stats {
+	s := Stats{N: len(values)}
+	if s.N == 0 {
+		return s
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	s.Median = quantile(sorted, 0.5)
+	s.Q1 = quantile(sorted, 0.25)
+	s.Q3 = quantile(sorted, 0.75)
+
+	dev := make([]float64, len(sorted))
+	var sum float64
+	for i, v := range sorted {
+		dev[i] = math.Abs(v - s.Median)
+		sum += dev[i]
+	}
+	sort.Float64s(dev)
+	if mad := quantile(dev, 0.5); mad > 0 {
+		s.Scale = mad / 0.6745
+	} else {
+		s.Scale = 1.2533 * sum / float64(len(dev))
+	}
+	return s
+}
+
+// Score — модифицированный z-score значения: на сколько Scale оно отстоит от медианы
+func (s Stats) Score(x float64) float64 {
+	if s.Scale == 0 {
+		return 0
+	}
+	return (x - s.Median) / s.Scale
+}
+
+// Range — ожидаемый диапазон. Значение считается выбросом, только если оба метода согласны: |z| не меньше
+// threshold и значение за внешней границей Тьюки (Q1 − 3·IQR, Q3 + 3·IQR); поэтому границы — более широкие из двух
+func (s Stats) Range(threshold float64) (float64, float64) {
+	iqr := s.Q3 - s.Q1
+	lower := math.Min(s.Median-threshold*s.Scale, s.Q1-IQRFence*iqr)
+	upper := math.Max(s.Median+threshold*s.Scale, s.Q3+IQRFence*iqr)
+	return lower, upper
+}
+
+// Outlier сообщает, что x — выброс для выборки
+func (s Stats) Outlier(x, threshold float64) bool {
+	if s.Scale == 0 {
+		return false
+	}
+	lower, upper := s.Range(threshold)
+	return x < lower || x > upper
+}
+
+// quantile — квантиль отсортированной выборки с линейной интерполяцией, как percentile_cont в Postgres
+func quantile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
@@ -0,0 +1,74 @@
+package anomaly
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStats_FatFinger(t *testing.T) {
+	values := []float64{480, 500, 510, 495, 520, 505, 490, 515, 5000}
+	st := NewStats(values)
+	if st.Median != 505 || st.N != 9 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+	if !st.Outlier(5000, DefaultThreshold) {
+		t.Error("amount with an extra zero must be an outlier")
+	}
+	if st.Outlier(530, DefaultThreshold) || st.Outlier(470, DefaultThreshold) {
+		t.Error("ordinary amounts must not be outliers")
+	}
+	lower, upper := st.Range(DefaultThreshold)
+	if lower >= 470 || upper <= 530 || upper >= 5000 {
+		t.Errorf("unexpected range [%.2f, %.2f]", lower, upper)
+	}
+}
+
+func TestStats_ZeroMAD(t *testing.T) {
+	// Больше половины значений совпадают: MAD = 0, масштаб — по среднему абсолютному отклонению
+	st := NewStats([]float64{0, 0, 0, 0, 0, 0, 100, 120, 90, 2000})
+	if st.Scale <= 0 || math.Abs(st.Scale-1.2533*231) > 1e-9 {
+		t.Fatalf("unexpected scale %v", st.Scale)
+	}
+	if !st.Outlier(2000, DefaultThreshold) || st.Outlier(120, DefaultThreshold) {
+		t.Errorf("unexpected outliers for %+v", st)
+	}
+	// Все значения равны — судить не о чем
+	if NewStats([]float64{5, 5, 5}).Outlier(5, DefaultThreshold) {
+		t.Error("constant sample has no outliers")
+	}
+}
+
+func TestValidateID(t *testing.T) {
+	for _, id := range []string{
+		TransactionAnomalyID(uuid.New()),
+		DayAnomalyID("expense", time.Date(2025, 2, 12, 0, 0, 0, 0, time.UTC)),
+	} {
+		if err := ValidateID(id); err != nil {
+			t.Errorf("%s: unexpected error %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "tx-1", "day-refund-2025-02-12", "day-income-2025-13-01", "budget-1"} {
+		if err := ValidateID(id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("%q: expected ErrInvalidID, got %v", id, err)
+		}
+	}
+}
+
+func TestQuery_Normalize(t *testing.T) {
+	now := time.Date(2025, 2, 12, 15, 0, 0, 0, time.UTC)
+	q, err := Query{}.Normalize(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.To.Equal(now) || !q.From.Equal(now.Add(-DefaultPeriod)) || q.Threshold != DefaultThreshold || q.Location != time.UTC {
+		t.Errorf("unexpected defaults %+v", q)
+	}
+	for _, bad := range []Query{{Kind: "week"}, {Threshold: -1}, {From: now, To: now.Add(-time.Hour)}} {
+		if _, err := bad.Normalize(now); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package budget
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Period string
+
+const (
+	Month   Period = "month"
+	Quarter Period = "quarter"
+	Year    Period = "year"
+)
+
+// Budget — плановый расход по категории на каждый месяц, квартал или год
+type Budget struct {
+	ID       uuid.UUID `json:"ID"`
+	Category string    `json:"Category"`
+	Period   Period    `json:"Period"`
+	Amount   float64   `json:"Amount"`
+}
+
+type ReportItem struct {
+	BudgetID          uuid.UUID `json:"BudgetID"`
+	Category          string    `json:"Category"`
+	Period            Period    `json:"Period"`
+	PeriodStart       time.Time `json:"PeriodStart"`
+	PeriodEnd         time.Time `json:"PeriodEnd"`
+	Planned           float64   `json:"Planned"`
+	Actual            float64   `json:"Actual"`
+	Variance          float64   `json:"Variance"`
+	PercentConsumed   float64   `json:"PercentConsumed"`
+	Projected         float64   `json:"Projected"`
+	ProjectedVariance float64   `json:"ProjectedVariance"`
+}
+
+type Report struct {
+	Date  time.Time    `json:"Date"`
+	Items []ReportItem `json:"Items"`
+}
+
+func NewBudget(category string, period Period, amount float64) (*Budget, error) {
+	if err := validate(category, period, amount); err != nil {
+		return nil, err
+	}
+	return &Budget{
+		ID:       uuid.New(),
+		Category: category,
+		Period:   period,
+		Amount:   amount,
+	}, nil
+}
+
+func (b *Budget) BudgetChange(category string, period Period, amount float64) error {
+	if err := validate(category, period, amount); err != nil {
+		return err
+	}
+	b.Category = category
+	b.Period = period
+	b.Amount = amount
+	return nil
+}
+
+// Bounds возвращает границы периода бюджета, в который попадает date: [start, end)
+func (p Period) Bounds(date time.Time) (time.Time, time.Time) {
+	y, m, _ := date.Date()
+	loc := date.Location()
+	switch p {
+	case Quarter:
+		start := time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 3, 0)
+	case Year:
+		start := time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0)
+	default:
+		start := time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+func validate(category string, period Period, amount float64) error {
+	if category == "" {
+		return errors.New("category cant be empty")
+	}
+	if period != Month && period != Quarter && period != Year {
+		return errors.New("invalid budget period")
+	}
+	if amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	return nil
+}
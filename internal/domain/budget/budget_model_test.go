@@ -0,0 +1,53 @@
+package budget
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewBudget_Valid(t *testing.T) {
+	b, err := NewBudget("marketing", Month, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Category != "marketing" || b.Period != Month || b.Amount != 1000 {
+		t.Fatal("budget fields mismatch")
+	}
+}
+
+func TestNewBudget_InvalidPeriod(t *testing.T) {
+	if _, err := NewBudget("marketing", "week", 1000); err == nil {
+		t.Fatal("expected error for invalid period")
+	}
+}
+
+func TestNewBudget_NonPositiveAmount(t *testing.T) {
+	if _, err := NewBudget("marketing", Month, 0); err == nil {
+		t.Fatal("expected error for non-positive amount")
+	}
+}
+
+func TestBudgetChange_EmptyCategory(t *testing.T) {
+	b, _ := NewBudget("marketing", Month, 1000)
+	if err := b.BudgetChange("", Year, 10); err == nil {
+		t.Fatal("expected error for empty category")
+	}
+}
+
+func TestPeriodBounds(t *testing.T) {
+	date := time.Date(2025, 8, 17, 15, 0, 0, 0, time.UTC)
+	cases := []struct {
+		period     Period
+		start, end time.Time
+	}{
+		{Month, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{Quarter, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{Year, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		start, end := c.period.Bounds(date)
+		if !start.Equal(c.start) || !end.Equal(c.end) {
+			t.Fatalf("%s: got [%v, %v)", c.period, start, end)
+		}
+	}
+}
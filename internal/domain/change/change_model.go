@@ -0,0 +1,49 @@
+package change
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"salestracker/internal/domain/transaction"
+	"strconv"
+)
+
+type Op string
+
+const (
+	// Upsert — транзакция создана или изменена, в Transaction ее текущее состояние
+	Upsert Op = "upsert"
+	// Delete — транзакция удалена (tombstone), передается только ID
+	Delete Op = "delete"
+)
+
+// Change — последнее изменение транзакции с номером в сквозной последовательности изменений
+type Change struct {
+	Seq         int64                    `json:"Seq"`
+	Op          Op                       `json:"Op"`
+	ID          uuid.UUID                `json:"ID"`
+	Transaction *transaction.Transaction `json:"Transaction,omitempty"`
+}
+
+// Feed — страница ленты изменений. NextCursor передается в since следующего запроса;
+// HasMore сообщает, что за курсором уже есть изменения и можно сразу запросить следующую страницу
+type Feed struct {
+	Changes    []Change `json:"Changes"`
+	NextCursor string   `json:"NextCursor"`
+	HasMore    bool     `json:"HasMore"`
+}
+
+// ParseCursor разбирает курсор ленты; пустой курсор означает начало ленты
+func ParseCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return seq, nil
+}
+
+func FormatCursor(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
@@ -0,0 +1,33 @@
+package change
+
+import "testing"
+
+func TestParseCursor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"12345", 12345, false},
+		{"-1", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCursor(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseCursor(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseCursor(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	seq, err := ParseCursor(FormatCursor(987))
+	if err != nil || seq != 987 {
+		t.Fatalf("round trip failed: %d %v", seq, err)
+	}
+}
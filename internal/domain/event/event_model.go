@@ -0,0 +1,63 @@
+package event
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"salestracker/internal/domain/transaction"
+	"time"
+)
+
+type Type string
+
+const (
+	TransactionCreated Type = "transaction.created"
+	TransactionUpdated Type = "transaction.updated"
+	TransactionDeleted Type = "transaction.deleted"
+)
+
+// Event — запись outbox, которая пишется в одной транзакции БД с изменением транзакции
+type Event struct {
+	ID            int64           `json:"ID"`
+	Type          Type            `json:"Type"`
+	TransactionID uuid.UUID       `json:"TransactionID"`
+	Data          json.RawMessage `json:"Data"`
+	CreatedAt     time.Time       `json:"CreatedAt"`
+}
+
+// Update — данные события transaction.updated: новое состояние транзакции и Previous — состояние до изменения
+type Update struct {
+	transaction.Transaction
+	Previous *transaction.Transaction `json:"Previous,omitempty"`
+}
+
+// Stream — подписка клиента на поток событий: события для догонки, признак сброса и канал новых событий
+type Stream struct {
+	Replay []Event
+	Reset  bool
+	C      <-chan Event
+}
+
+// Matches проверяет транзакцию события (для удаления — последнее ее состояние) тем же фильтром, что и список
+// транзакций; пустой фильтр пропускает все. Обновление подходит, если под фильтр попадает новое или прежнее
+// состояние: подписчик должен узнать и о том, что транзакция из его выборки ушла
+func (e Event) Matches(f transaction.Filter) bool {
+	if f.Empty() {
+		return true
+	}
+	var data Update
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return false
+	}
+	if f.Match(&data.Transaction) {
+		return true
+	}
+	return e.Type == TransactionUpdated && data.Previous != nil && f.Match(data.Previous)
+}
+
+func (t Type) Valid() bool {
+	switch t {
+	case TransactionCreated, TransactionUpdated, TransactionDeleted:
+		return true
+	}
+	return false
+}
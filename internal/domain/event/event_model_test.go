@@ -0,0 +1,63 @@
+package event
+
+import (
+	"encoding/json"
+	"salestracker/internal/domain/transaction"
+	"testing"
+)
+
+func payload(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestEventMatches(t *testing.T) {
+	marketing := transaction.Transaction{Type: transaction.Expense, Category: "Marketing", Amount: 100}
+	rent := transaction.Transaction{Type: transaction.Expense, Category: "Rent", Amount: 100}
+	filter := transaction.Filter{Categories: []string{"Marketing"}}
+
+	cases := []struct {
+		name   string
+		ev     Event
+		filter transaction.Filter
+		want   bool
+	}{
+		{"empty filter passes anything", Event{Type: TransactionCreated, Data: json.RawMessage(`garbage`)}, transaction.Filter{}, true},
+		{"created in filter", Event{Type: TransactionCreated, Data: payload(t, marketing)}, filter, true},
+		{"created outside filter", Event{Type: TransactionCreated, Data: payload(t, rent)}, filter, false},
+		{"deleted in filter", Event{Type: TransactionDeleted, Data: payload(t, marketing)}, filter, true},
+		{"update moves into filter", Event{Type: TransactionUpdated, Data: payload(t, Update{Transaction: marketing, Previous: &rent})}, filter, true},
+		{"update moves out of filter", Event{Type: TransactionUpdated, Data: payload(t, Update{Transaction: rent, Previous: &marketing})}, filter, true},
+		{"update outside filter", Event{Type: TransactionUpdated, Data: payload(t, Update{Transaction: rent, Previous: &rent})}, filter, false},
+		{"update without previous state", Event{Type: TransactionUpdated, Data: payload(t, rent)}, filter, false},
+		{"type filter", Event{Type: TransactionCreated, Data: payload(t, rent)}, transaction.Filter{Type: transaction.Income}, false},
+		{"broken payload", Event{Type: TransactionCreated, Data: json.RawMessage(`{`)}, filter, false},
+	}
+	for _, c := range cases {
+		if got := c.ev.Matches(c.filter); got != c.want {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestTypeValid(t *testing.T) {
+	cases := []struct {
+		typ  Type
+		want bool
+	}{
+		{TransactionCreated, true},
+		{TransactionUpdated, true},
+		{TransactionDeleted, true},
+		{"transaction.merged", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := c.typ.Valid(); got != c.want {
+			t.Errorf("%q: expected %v, got %v", c.typ, c.want, got)
+		}
+	}
+}
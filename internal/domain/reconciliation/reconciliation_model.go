@@ -0,0 +1,116 @@
+package reconciliation
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"time"
+)
+
+type Status string
+
+const (
+	Open   Status = "open"
+	Closed Status = "closed"
+)
+
+type MatchType string
+
+const (
+	AutoMatch   MatchType = "auto"
+	ManualMatch MatchType = "manual"
+)
+
+var (
+	// ErrSessionClosed возвращается при попытке изменить сопоставления в закрытой сессии
+	ErrSessionClosed = errors.New("reconciliation session is closed")
+	// ErrInvalidStatement — в выписке нет ни одной разобранной строки, сверять нечего
+	ErrInvalidStatement = errors.New("invalid statement")
+	// ErrInvalidID — ID сессии или транзакции не является UUID
+	ErrInvalidID = errors.New("invalid id")
+	// ErrSessionNotFound — сессии сверки с таким ID нет
+	ErrSessionNotFound = errors.New("reconciliation session not found")
+	// ErrLineNotFound — в сессии нет строки выписки с таким ID
+	ErrLineNotFound = errors.New("statement line not found in session")
+	// ErrTransactionReconciled — транзакция уже сверена в закрытой сессии
+	ErrTransactionReconciled = errors.New("transaction is already reconciled")
+	// ErrAlreadyMatched — транзакция уже сопоставлена с другой строкой этой или другой открытой сессии
+	ErrAlreadyMatched = errors.New("transaction is already matched")
+)
+
+// Session — сессия сверки одной банковской выписки с учетными транзакциями за период выписки
+type Session struct {
+	ID        uuid.UUID        `json:"ID"`
+	Name      string           `json:"Name"`
+	Format    statement.Format `json:"Format"`
+	From      time.Time        `json:"From"`
+	To        time.Time        `json:"To"`
+	Status    Status           `json:"Status"`
+	CreatedAt time.Time        `json:"CreatedAt"`
+	ClosedAt  *time.Time       `json:"ClosedAt,omitempty"`
+}
+
+// BankLine — строка выписки в сессии. TransactionID заполнен, если строка сопоставлена с транзакцией
+type BankLine struct {
+	ID            int64                       `json:"ID"`
+	SessionID     uuid.UUID                   `json:"SessionID"`
+	ExternalID    string                      `json:"ExternalID"`
+	Type          transaction.TransactionType `json:"Type"`
+	Amount        float64                     `json:"Amount"`
+	Date          time.Time                   `json:"Date"`
+	Description   string                      `json:"Description"`
+	TransactionID *uuid.UUID                  `json:"TransactionID,omitempty"`
+	MatchType     MatchType                   `json:"MatchType,omitempty"`
+	Score         float64                     `json:"Score,omitempty"`
+}
+
+// Match — пара «строка выписки — транзакция»
+type Match struct {
+	Line        *BankLine                `json:"Line"`
+	Transaction *transaction.Transaction `json:"Transaction"`
+}
+
+// Report — состояние сессии: сопоставленные пары и несопоставленные строки с обеих сторон
+type Report struct {
+	Session         *Session                   `json:"Session"`
+	Matched         []Match                    `json:"Matched"`
+	UnmatchedBank   []*BankLine                `json:"UnmatchedBank"`
+	UnmatchedLedger []*transaction.Transaction `json:"UnmatchedLedger"`
+	ParseErrors     []statement.LineError      `json:"ParseErrors,omitempty"`
+}
+
+// NewSession создает открытую сессию, период которой покрывает даты всех строк выписки
+func NewSession(name string, format statement.Format, lines []statement.Line) (*Session, []*BankLine, error) {
+	if len(lines) == 0 {
+		return nil, nil, fmt.Errorf("%w: statement has no lines to reconcile", ErrInvalidStatement)
+	}
+	s := &Session{
+		ID:        uuid.New(),
+		Name:      name,
+		Format:    format,
+		From:      lines[0].Date,
+		To:        lines[0].Date,
+		Status:    Open,
+		CreatedAt: time.Now(),
+	}
+	bankLines := make([]*BankLine, 0, len(lines))
+	for _, l := range lines {
+		if l.Date.Before(s.From) {
+			s.From = l.Date
+		}
+		if l.Date.After(s.To) {
+			s.To = l.Date
+		}
+		bankLines = append(bankLines, &BankLine{
+			SessionID:   s.ID,
+			ExternalID:  l.ExternalID,
+			Type:        l.Type,
+			Amount:      l.Amount,
+			Date:        l.Date,
+			Description: l.Description,
+		})
+	}
+	return s, bankLines, nil
+}
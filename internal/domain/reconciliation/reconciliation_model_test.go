@@ -0,0 +1,34 @@
+package reconciliation
+
+import (
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+func TestNewSession(t *testing.T) {
+	d1 := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	lines := []statement.Line{
+		{ExternalID: "a", Type: transaction.Expense, Amount: 10, Date: d1},
+		{ExternalID: "b", Type: transaction.Income, Amount: 20, Date: d2},
+	}
+
+	s, bankLines, err := NewSession("march.ofx", statement.OFX, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Status != Open || !s.From.Equal(d2) || !s.To.Equal(d1) {
+		t.Errorf("unexpected session: %+v", s)
+	}
+	if len(bankLines) != 2 || bankLines[0].SessionID != s.ID || bankLines[1].ExternalID != "b" {
+		t.Errorf("unexpected bank lines: %+v", bankLines)
+	}
+}
+
+func TestNewSession_Empty(t *testing.T) {
+	if _, _, err := NewSession("empty.qif", statement.QIF, nil); err == nil {
+		t.Fatal("expected error for empty statement")
+	}
+}
@@ -0,0 +1,65 @@
+package statement
+
+import (
+	"errors"
+	"salestracker/internal/domain/transaction"
+	"time"
+)
+
+type Format string
+
+const (
+	OFX  Format = "ofx"
+	QIF  Format = "qif"
+	OneC Format = "1c"
+	// WBReport — отчет о реализации Wildberries; это не банковская выписка, поэтому в Valid не входит
+	WBReport Format = "wb"
+	// Receipt — кассовый чек 54-ФЗ в JSON-выгрузке ФНС; тоже не выписка
+	Receipt Format = "fns"
+)
+
+// File — загруженный файл выписки
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Line — операция из выписки. ExternalID стабилен между повторными загрузками одной и той же выписки
+type Line struct {
+	Number       int
+	ExternalID   string
+	Type         transaction.TransactionType
+	Amount       float64
+	Date         time.Time
+	Description  string
+	Category     string
+	Counterparty string
+}
+
+// LineError — строка выписки, которую не удалось разобрать или сохранить
+type LineError struct {
+	Line  int    `json:"Line"`
+	Error string `json:"Error"`
+}
+
+// FileSummary — итог импорта одного файла: создано, пропущено как уже загруженное, с ошибками
+type FileSummary struct {
+	File    string      `json:"File"`
+	Format  Format      `json:"Format"`
+	Created int         `json:"Created"`
+	Skipped int         `json:"Skipped"`
+	Failed  int         `json:"Failed"`
+	Errors  []LineError `json:"Errors"`
+}
+
+// ErrUnknownFormat — формат выписки передан явно, но не поддерживается
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// Valid проверяет формат банковской выписки
+func (f Format) Valid() bool {
+	switch f {
+	case OFX, QIF, OneC:
+		return true
+	}
+	return false
+}
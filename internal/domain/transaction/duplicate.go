@@ -0,0 +1,140 @@
+package transaction
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type DuplicateAction string
+
+const (
+	MergeDuplicate   DuplicateAction = "merge"
+	DismissDuplicate DuplicateAction = "dismiss"
+)
+
+// ErrInvalidDuplicatePair возвращается для пары с неверным ID, из одной и той же транзакции или с неизвестным действием
+var ErrInvalidDuplicatePair = errors.New("invalid duplicate pair")
+
+type DuplicatePair struct {
+	First   *Transaction `json:"First"`
+	Second  *Transaction `json:"Second"`
+	Score   float64      `json:"Score"`
+	Reasons []string     `json:"Reasons"`
+}
+
+// PairKey возвращает ключ пары, не зависящий от порядка ID
+func PairKey(a, b uuid.UUID) string {
+	first, second := OrderedPair(a, b)
+	return first.String() + ":" + second.String()
+}
+
+// OrderedPair упорядочивает ID пары, чтобы (a, b) и (b, a) хранились одинаково
+func OrderedPair(a, b uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if a.String() > b.String() {
+		return b, a
+	}
+	return a, b
+}
+
+// Cents — сумма в копейках; суммы сравниваются по ним, а не как float
+func Cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// DaysApart — число календарных дней между датами, каждая берется в своем часовом поясе
+func DaysApart(a, b time.Time) float64 {
+	return math.Abs(dayOf(a).Sub(dayOf(b)).Hours() / 24)
+}
+
+// DateCloseness — близость дат, разнесенных на days дней, в окне window дней: 1 для одного дня, к краю окна
+// убывает, но остается больше нуля
+func DateCloseness(days, window float64) float64 {
+	return 1 - days/(window+1)
+}
+
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DescriptionSimilarity оценивает похожесть описаний от 0 до 1:
+// берется максимум из пересечения слов и нормированного расстояния Левенштейна
+func DescriptionSimilarity(a, b string) float64 {
+	na, nb := normalizeDescription(a), normalizeDescription(b)
+	if na == "" && nb == "" {
+		return 1
+	}
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+
+	jaccard := tokenJaccard(strings.Fields(na), strings.Fields(nb))
+
+	ra, rb := []rune(na), []rune(nb)
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	editSim := 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+
+	if jaccard > editSim {
+		return jaccard
+	}
+	return editSim
+}
+
+func normalizeDescription(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func tokenJaccard(a, b []string) float64 {
+	set := make(map[string]int, len(a)+len(b))
+	for _, t := range a {
+		set[t] |= 1
+	}
+	for _, t := range b {
+		set[t] |= 2
+	}
+	common := 0
+	for _, v := range set {
+		if v == 3 {
+			common++
+		}
+	}
+	return float64(common) / float64(len(set))
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
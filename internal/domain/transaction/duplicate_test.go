@@ -0,0 +1,43 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDescriptionSimilarity(t *testing.T) {
+	if DescriptionSimilarity("Оплата аренды, ноябрь", "оплата аренды ноябрь") != 1 {
+		t.Fatal("expected identical descriptions after normalization")
+	}
+	if DescriptionSimilarity("", "") != 1 {
+		t.Fatal("expected two empty descriptions to match")
+	}
+	if DescriptionSimilarity("taxi", "") != 0 {
+		t.Fatal("expected empty description not to match")
+	}
+	if s := DescriptionSimilarity("paper a4", "paper a4 x5"); s < 0.6 || s >= 1 {
+		t.Fatalf("unexpected similarity %v", s)
+	}
+}
+
+func TestPairKey_OrderIndependent(t *testing.T) {
+	a, _ := NewTransaction(Income, "cat", 10, "", time.Now())
+	b, _ := NewTransaction(Income, "cat", 10, "", time.Now())
+	if PairKey(a.ID, b.ID) != PairKey(b.ID, a.ID) {
+		t.Fatal("pair key must not depend on order")
+	}
+}
+
+func TestDaysApartAndCents(t *testing.T) {
+	a := time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC)
+	b := time.Date(2024, 3, 11, 0, 15, 0, 0, time.UTC)
+	if DaysApart(a, b) != 1 || DaysApart(b, a) != 1 || DaysApart(a, a.Add(-time.Hour)) != 0 {
+		t.Errorf("unexpected day distances")
+	}
+	if Cents(0.1+0.2) != Cents(0.3) || Cents(100.004) != 10000 {
+		t.Errorf("unexpected cents")
+	}
+	if DateCloseness(0, 3) != 1 || DateCloseness(3, 3) <= 0 {
+		t.Errorf("unexpected closeness")
+	}
+}
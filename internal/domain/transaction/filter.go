@@ -0,0 +1,54 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Filter — отбор транзакций, общий для списка, экспорта и аналитики. Пустые поля не ограничивают выборку
+type Filter struct {
+	Type         TransactionType
+	Categories   []string // любая из категорий
+	MinAmount    *float64 // включительно
+	MaxAmount    *float64 // включительно
+	Search       string   // подстрока описания без учета регистра
+	Counterparty string   // ИНН контрагента
+}
+
+// Validate проверяет тип и диапазон сумм
+func (f Filter) Validate() error {
+	if f.Type != "" && f.Type != Income && f.Type != Expense {
+		return fmt.Errorf("invalid transaction type %q", f.Type)
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		return errors.New("minAmount cannot be greater than maxAmount")
+	}
+	return nil
+}
+
+// Empty — фильтр ничего не ограничивает
+func (f Filter) Empty() bool {
+	return f.Type == "" && len(f.Categories) == 0 && f.MinAmount == nil && f.MaxAmount == nil && f.Search == "" && f.Counterparty == ""
+}
+
+// Match проверяет транзакцию в памяти так же, как фильтр применяется в SQL (для потока событий)
+func (f Filter) Match(tr *Transaction) bool {
+	if f.Type != "" && tr.Type != f.Type {
+		return false
+	}
+	if len(f.Categories) > 0 && !slices.Contains(f.Categories, tr.Category) {
+		return false
+	}
+	if f.MinAmount != nil && tr.Amount < *f.MinAmount {
+		return false
+	}
+	if f.MaxAmount != nil && tr.Amount > *f.MaxAmount {
+		return false
+	}
+	if f.Search != "" && !strings.Contains(strings.ToLower(tr.Description), strings.ToLower(f.Search)) {
+		return false
+	}
+	return f.Counterparty == "" || tr.Counterparty == f.Counterparty
+}
@@ -0,0 +1,49 @@
+package transaction
+
+import "testing"
+
+func TestFilterValidate(t *testing.T) {
+	lo, hi := 10.0, 100.0
+	if err := (Filter{Type: Expense, MinAmount: &lo, MaxAmount: &hi}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := (Filter{}).Validate(); err != nil {
+		t.Fatalf("empty filter: unexpected error: %v", err)
+	}
+	for _, f := range []Filter{
+		{Type: "refund"},
+		{MinAmount: &hi, MaxAmount: &lo},
+	} {
+		if err := f.Validate(); err == nil {
+			t.Errorf("%+v: expected error", f)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	tr := &Transaction{Type: Expense, Category: "rent", Amount: 50, Description: "Office RENT march", Counterparty: "7701"}
+	lo, hi := 50.0, 49.0
+	for _, f := range []Filter{
+		{},
+		{Type: Expense, Categories: []string{"food", "rent"}},
+		{MinAmount: &lo, Search: "rent", Counterparty: "7701"},
+	} {
+		if !f.Match(tr) {
+			t.Errorf("%+v: expected match", f)
+		}
+	}
+	for _, f := range []Filter{
+		{Type: Income},
+		{Categories: []string{"food"}},
+		{MaxAmount: &hi},
+		{Search: "april"},
+		{Counterparty: "7702"},
+	} {
+		if f.Match(tr) {
+			t.Errorf("%+v: expected no match", f)
+		}
+	}
+	if !(Filter{}).Empty() || (Filter{Search: "x"}).Empty() {
+		t.Error("unexpected Empty result")
+	}
+}
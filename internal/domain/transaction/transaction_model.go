@@ -14,14 +14,22 @@ const (
 )
 
 type Transaction struct {
-	ID          uuid.UUID       `json:"ID"`
-	Type        TransactionType `json:"Type"`
-	Category    string          `json:"Category"`
-	Amount      float64         `json:"Amount"`
-	Date        time.Time       `json:"Date"`
-	Description string          `json:"Description"`
+	ID           uuid.UUID       `json:"ID"`
+	Type         TransactionType `json:"Type"`
+	Category     string          `json:"Category"`
+	Amount       float64         `json:"Amount"`
+	Date         time.Time       `json:"Date"`
+	Description  string          `json:"Description"`
+	Counterparty string          `json:"Counterparty"`
+	Reconciled   bool            `json:"Reconciled"`
 }
 
+// ErrReconciled возвращается при попытке изменить или удалить сверенную транзакцию без override
+var ErrReconciled = errors.New("transaction is reconciled; pass override=true to change it")
+
+// ErrNotFound возвращается, когда транзакции с таким ID нет
+var ErrNotFound = errors.New("transaction not found")
+
 func NewTransaction(trType TransactionType, Category string, Amount float64, Description string, Date time.Time) (*Transaction, error) {
 	if trType != Income && trType != Expense {
 		return nil, errors.New("invalid transaction type")
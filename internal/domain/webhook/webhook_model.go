@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
+	"salestracker/internal/domain/event"
+	"time"
+)
+
+type DeliveryStatus string
+
+const (
+	Pending   DeliveryStatus = "pending"
+	Succeeded DeliveryStatus = "succeeded"
+	Failed    DeliveryStatus = "failed"
+	Dead      DeliveryStatus = "dead"
+)
+
+// Subscription — подписка на события транзакций; пустой Events означает все события
+type Subscription struct {
+	ID        uuid.UUID    `json:"ID"`
+	URL       string       `json:"URL"`
+	Secret    string       `json:"Secret,omitempty"`
+	Events    []event.Type `json:"Events"`
+	Active    bool         `json:"Active"`
+	CreatedAt time.Time    `json:"CreatedAt"`
+}
+
+type Delivery struct {
+	ID             int64          `json:"ID"`
+	SubscriptionID uuid.UUID      `json:"SubscriptionID"`
+	EventID        int64          `json:"EventID"`
+	EventType      event.Type     `json:"EventType"`
+	Status         DeliveryStatus `json:"Status"`
+	Attempts       int            `json:"Attempts"`
+	NextAttemptAt  time.Time      `json:"NextAttemptAt"`
+	LastError      string         `json:"LastError,omitempty"`
+	ResponseCode   int            `json:"ResponseCode,omitempty"`
+	CreatedAt      time.Time      `json:"CreatedAt"`
+	UpdatedAt      time.Time      `json:"UpdatedAt"`
+}
+
+// Job — доставка, готовая к отправке: адрес и секрет подписки вместе с событием
+type Job struct {
+	Delivery Delivery
+	URL      string
+	Secret   string
+	Event    event.Event
+}
+
+func NewSubscription(rawURL, secret string, events []event.Type) (*Subscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("webhook url must be an absolute http(s) url")
+	}
+	for _, e := range events {
+		if !e.Valid() {
+			return nil, fmt.Errorf("unknown event type %q", e)
+		}
+	}
+	if secret == "" {
+		secret, err = newSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if events == nil {
+		events = []event.Type{}
+	}
+	return &Subscription{
+		ID:        uuid.New(),
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    events,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Sign возвращает HMAC-SHA256 тела запроса в hex, которым подписывается каждая доставка
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff — экспоненциальная задержка перед попыткой attempt+1: base * 2^(attempt-1), не больше maxDelay
+func Backoff(base, maxDelay time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return delay
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"salestracker/internal/domain/event"
+	"testing"
+	"time"
+)
+
+func TestNewSubscription_Valid(t *testing.T) {
+	s, err := NewSubscription("https://erp.example.com/hooks", "", []event.Type{event.TransactionCreated})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Secret == "" || !s.Active {
+		t.Fatal("expected generated secret and active subscription")
+	}
+}
+
+func TestNewSubscription_InvalidURL(t *testing.T) {
+	if _, err := NewSubscription("ftp://erp", "", nil); err == nil {
+		t.Fatal("expected error for non-http url")
+	}
+}
+
+func TestNewSubscription_UnknownEvent(t *testing.T) {
+	if _, err := NewSubscription("https://erp.example.com", "", []event.Type{"transaction.archived"}); err == nil {
+		t.Fatal("expected error for unknown event")
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"a":1}' | openssl dgst -sha256 -hmac secret
+	want := "aa9e2e3575f5d7098b6caccd790888c36d5fdb63342a73bada2d6a51747a8494"
+	if got := Sign("secret", []byte(`{"a":1}`)); got != want {
+		t.Fatalf("unexpected signature: %s", got)
+	}
+	if Sign("secret", []byte("x")) == Sign("other", []byte("x")) {
+		t.Fatal("signature must depend on secret")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, maxDelay := 10*time.Second, time.Minute
+	if Backoff(base, maxDelay, 1) != 10*time.Second {
+		t.Fatal("first retry must wait base delay")
+	}
+	if Backoff(base, maxDelay, 3) != 40*time.Second {
+		t.Fatal("expected exponential growth")
+	}
+	if Backoff(base, maxDelay, 10) != time.Minute {
+		t.Fatal("expected delay to be capped")
+	}
+}
@@ -7,75 +7,168 @@ import (
 	"github.com/wb-go/wbf/retry"
 	wbzlog "github.com/wb-go/wbf/zlog"
 	"salestracker/internal/domain/analytic"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
-func (p *Postgres) GetAnalytics(from, to time.Time, groupBy, splitBy, sortBy, sortDir string) (*analytic.Analytics, error) {
-	ctx := context.Background()
+// aggregatesOver — общий набор агрегатов по значению col для аналитических запросов
+func aggregatesOver(col string) string {
+	return fmt.Sprintf(`SUM(%[1]s) AS sum,
+		AVG(%[1]s) AS avg,
+		COUNT(*) AS count,
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY %[1]s) AS median,
+		percentile_cont(0.9) WITHIN GROUP (ORDER BY %[1]s) AS percentile90`, col)
+}
 
-	var dateTrunc string
-	switch groupBy {
-	case "month":
-		dateTrunc = "month"
-	case "year":
-		dateTrunc = "year"
+// metricExpr — SQL-агрегат метрики по значению col; filter — необязательное условие FILTER.
+// Все, кроме count, обернуты в COALESCE: пустой набор и дисперсия одной транзакции дают 0, а не NULL
+func metricExpr(m analytic.Metric, col, filter string) string {
+	if filter != "" {
+		filter = " FILTER (WHERE " + filter + ")"
+	}
+	var expr string
+	switch m {
+	case analytic.MetricCount:
+		return "COUNT(*)" + filter
+	case analytic.MetricSum:
+		expr = "SUM(" + col + ")" + filter
+	case analytic.MetricAvg:
+		expr = "AVG(" + col + ")" + filter
+	case analytic.MetricMin:
+		expr = "MIN(" + col + ")" + filter
+	case analytic.MetricMax:
+		expr = "MAX(" + col + ")" + filter
+	case analytic.MetricStddev:
+		expr = "stddev_samp(" + col + ")" + filter
+	case analytic.MetricVariance:
+		expr = "var_samp(" + col + ")" + filter
 	default:
-		dateTrunc = "day"
+		p, _ := m.Percentile()
+		expr = fmt.Sprintf("percentile_cont(%s) WITHIN GROUP (ORDER BY %s)%s", strconv.FormatFloat(p, 'f', -1, 64), col, filter)
 	}
+	return "COALESCE(" + expr + ", 0)"
+}
 
-	var splitColumn string
-	if splitBy == "category" {
-		splitColumn = "category"
-	} else {
-		splitColumn = "transtype"
+// metricColumns — агрегаты всех метрик через запятую с псевдонимами <prefix>0, <prefix>1, ...:
+// имена вроде p99.9 не годятся в идентификаторы SQL
+func metricColumns(metrics []analytic.Metric, col, filter, prefix string) string {
+	cols := make([]string, len(metrics))
+	for i, m := range metrics {
+		cols[i] = fmt.Sprintf("%s AS %s%d", metricExpr(m, col, filter), prefix, i)
 	}
+	return strings.Join(cols, ",\n\t\t")
+}
+
+// columnList перечисляет псевдонимы <prefix>0 ... <prefix>n-1, которые дает metricColumns
+func columnList(prefix string, n int) string {
+	cols := make([]string, n)
+	for i := range cols {
+		cols[i] = fmt.Sprintf("%s.%s%d", prefix, prefix, i)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// valueExpr — вклад транзакции в комбинированные метрики: в signed расходы со знаком минус, в absolute — сумма как есть
+func valueExpr(mode analytic.Mode) string {
+	if mode == analytic.ModeAbsolute {
+		return "amount"
+	}
+	return "CASE WHEN transtype = 'expense' THEN -amount ELSE amount END"
+}
+
+func (p *Postgres) GetAnalytics(q analytic.Query) (*analytic.Analytics, error) {
+	ctx := context.Background()
+	if len(q.Nested) > 0 {
+		return p.nestedAnalytics(ctx, q)
+	}
+
+	groupKey, usesTZ := groupKeyExpr(q.GroupBy)
+	args := []any{q.From, q.To}
+	if usesTZ {
+		args = append(args, q.Location.String())
+	}
+	filter, args := filterConditions(q.Filter, args)
+
+	// Income/Expense — всегда по модулю, тип уже задан самой группой; категории смешивают доходы и расходы,
+	// поэтому считаются по тому же значению, что и All
+	splitColumn, splitValue := "transtype", "amount"
+	if q.SplitBy == "category" {
+		splitColumn, splitValue = "category", "value"
+	}
+
+	metrics := q.MetricList()
+	dynamic := len(q.Metrics) > 0
 
 	sortColumn := "group_key"
-	switch sortBy {
-	case "sum", "avg", "count", "median", "percentile90":
-		sortColumn = sortBy
+	for i, m := range metrics {
+		if string(m) == q.SortBy {
+			sortColumn = fmt.Sprintf("m%d", i)
+		}
 	}
 	sortDirection := "DESC"
-	if sortDir == "asc" {
+	if q.SortDir == "asc" {
 		sortDirection = "ASC"
 	}
 
+	// С top разбиения каждой группы ранжируются по модулю TopBy, а все за пределами top получают общий ключ OtherSplitKey.
+	// Метрики Other затем считаются по самим транзакциям, как и у остальных разбиений
+	base := `
+	base AS (
+	SELECT group_key, split_key, FALSE AS other, 0 AS rn, amount, value
+	FROM raw
+	)`
+	if q.Top > 0 {
+		base = fmt.Sprintf(`
+	ranked AS (
+	SELECT group_key, split_key, ROW_NUMBER() OVER (PARTITION BY group_key ORDER BY ABS(rank_value) DESC, split_key) AS rn
+	FROM (
+		SELECT group_key, split_key, %[1]s AS rank_value
+		FROM raw
+		GROUP BY group_key, split_key
+	) r
+	),
+	base AS (
+	SELECT
+		group_key,
+		CASE WHEN k.rn <= %[2]d THEN split_key ELSE '%[3]s' END AS split_key,
+		k.rn > %[2]d AS other,
+		k.rn,
+		amount,
+		value
+	FROM raw
+	JOIN ranked k USING (group_key, split_key)
+	)`, metricExpr(q.TopBy, splitValue, ""), q.Top, analytic.OtherSplitKey)
+	}
+
 	query := fmt.Sprintf(`
-	WITH grouped AS (
+	WITH raw AS (
 	SELECT
-		to_char(date_trunc('%s', transdate), 'YYYY-MM-DD') AS group_key,
+		%s AS group_key,
 		%s AS split_key,
-		SUM(amount) AS sum,
-		AVG(amount) AS avg,
-		COUNT(*) AS count,
-		percentile_cont(0.5) WITHIN GROUP (ORDER BY amount) AS median,
-		percentile_cont(0.9) WITHIN GROUP (ORDER BY amount) AS percentile90,
-		SUM(CASE WHEN transtype='income' THEN amount ELSE 0 END) 
-		- SUM(CASE WHEN transtype='expense' THEN amount ELSE 0 END) AS sum_signed
+		amount,
+		%s AS value
 	FROM transactions
-	WHERE transdate >= $1 AND transdate <= $2
-	GROUP BY group_key, split_key
+	WHERE transdate >= $1 AND transdate <= $2%s
+	),%s,
+	grouped AS (
+	SELECT group_key, split_key, other, MIN(rn) AS split_rank, %s
+	FROM base
+	GROUP BY group_key, split_key, other
 	),
 	all_grouped AS (
-	SELECT
-		group_key,
-		SUM(sum_signed) AS sum,  -- это и будет All = income - expense
-		SUM(count) AS count,
-		AVG(sum / NULLIF(count,0)) AS avg,
-		AVG(median) AS median,
-		AVG(percentile90) AS percentile90
-	FROM grouped
+	SELECT group_key, %s
+	FROM base
 	GROUP BY group_key
 	)
-	SELECT 
-		g.group_key, g.split_key, g.sum, g.avg, g.count, g.median, g.percentile90,
-		a.sum AS all_sum, a.count AS all_count, a.avg AS all_avg, a.median AS all_median, a.percentile90 AS all_perc90
+	SELECT g.*, %s
 	FROM grouped g
 	JOIN all_grouped a USING(group_key)
 	ORDER BY %s %s;
-	`, dateTrunc, splitColumn, sortColumn, sortDirection)
+	`, groupKey, splitColumn, valueExpr(q.Mode), filter, base, metricColumns(metrics, splitValue, "", "m"), metricColumns(metrics, "value", "", "a"), columnList("a", len(metrics)), sortColumn, sortDirection)
 
-	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, from, to)
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, args...)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("Error executing analytics query")
 		return nil, err
@@ -86,41 +179,49 @@ func (p *Postgres) GetAnalytics(from, to time.Time, groupBy, splitBy, sortBy, so
 
 	result := &analytic.Analytics{}
 	groupMap := map[string]*analytic.AnalyticByType{}
+	var groupOrder []string
 
+	splitRanks := map[string]map[string]int{}
+	values := make([]float64, 2*len(metrics))
 	for rows.Next() {
 		var groupKey, splitKey string
-		var sum, avg, median, perc90 float64
-		var count int
-		var allSum, allAvg, allMedian, allPerc90 float64
-		var allCount int
+		var other bool
+		var splitRank int
+		dest := []any{&groupKey, &splitKey, &other, &splitRank}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
 
-		if err := rows.Scan(&groupKey, &splitKey, &sum, &avg, &count, &median, &perc90,
-			&allSum, &allCount, &allAvg, &allMedian, &allPerc90); err != nil {
+		if err := rows.Scan(dest...); err != nil {
 			wbzlog.Logger.Error().Err(err).Msg("Error scanning analytics row")
 			return nil, err
 		}
 
 		if _, ok := groupMap[groupKey]; !ok {
+			groupOrder = append(groupOrder, groupKey)
 			groupMap[groupKey] = &analytic.AnalyticByType{
 				AllMap: map[string]analytic.Analytic{},
 			}
 		}
 
-		a := analytic.Analytic{
-			Sum: sum, Avg: avg, Count: count, Median: median, Percentile90: perc90,
-		}
+		a := analytic.AnalyticFromValues(metrics, values[:len(metrics)], dynamic)
 
-		groupMap[groupKey].AllMap[splitKey] = a
-		groupMap[groupKey].All = analytic.Analytic{
-			Sum:          allSum,
-			Count:        allCount,
-			Avg:          allAvg,
-			Median:       allMedian,
-			Percentile90: allPerc90,
+		if !other {
+			groupMap[groupKey].AllMap[splitKey] = a
+		}
+		if q.Top > 0 {
+			groupMap[groupKey].Splits = append(groupMap[groupKey].Splits, analytic.AnalyticSplit{Key: splitKey, Other: other, Data: a})
+			if splitRanks[groupKey] == nil {
+				splitRanks[groupKey] = map[string]int{}
+			}
+			if !other {
+				splitRanks[groupKey][splitKey] = splitRank
+			}
 		}
+		groupMap[groupKey].All = analytic.AnalyticFromValues(metrics, values[len(metrics):], dynamic)
 
 		// Если splitBy=transtype, присвоим Income/Expense
-		if splitBy == "type" || splitBy == "transtype" {
+		if q.SplitBy == "type" || q.SplitBy == "transtype" {
 			switch splitKey {
 			case "income":
 				groupMap[groupKey].Income = a
@@ -130,42 +231,93 @@ func (p *Postgres) GetAnalytics(from, to time.Time, groupBy, splitBy, sortBy, so
 		}
 	}
 
-	for k, v := range groupMap {
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Группы идут в порядке первого появления, то есть в порядке сортировки запроса; разбиения — по рангу, Other последним
+	for _, k := range groupOrder {
+		slices.SortStableFunc(groupMap[k].Splits, func(a, b analytic.AnalyticSplit) int {
+			if a.Other != b.Other {
+				if a.Other {
+					return 1
+				}
+				return -1
+			}
+			return splitRanks[k][a.Key] - splitRanks[k][b.Key]
+		})
 		result.Groups = append(result.Groups, analytic.AnalyticGroup{
 			GroupKey: k,
-			Data:     *v,
+			Data:     groupMap[k],
 		})
 	}
 
-	summaryQuery := `
+	// С заполнением пропусков ряд всегда хронологический: сортировка по метрикам теряет смысл
+	if q.Fill != analytic.FillNone {
+		keys, err := p.timeBuckets(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		result.Groups = analytic.FillGaps(result.Groups, keys, q.Fill, q.Metrics)
+	}
+
+	if q.Cumulative {
+		opening, running, err := p.runningTotals(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		result.Opening = &opening
+		for i := range result.Groups {
+			if r, ok := running[result.Groups[i].GroupKey]; ok {
+				result.Groups[i].Running = &r
+			}
+		}
+	}
+
+	for _, w := range q.Rolling {
+		rolling, err := p.rollingWindow(ctx, q, w)
+		if err != nil {
+			return nil, err
+		}
+		for i := range result.Groups {
+			r, ok := rolling[result.Groups[i].GroupKey]
+			if !ok {
+				continue
+			}
+			if result.Groups[i].Rolling == nil {
+				result.Groups[i].Rolling = map[string]*analytic.RollingByType{}
+			}
+			result.Groups[i].Rolling[w.String()] = r
+		}
+	}
+
+	// Тот же фильтр, но параметры нумеруются заново: в сводке нет часового пояса
+	summaryFilter, summaryArgs := filterConditions(q.Filter, []any{q.From, q.To})
+	summaryQuery := fmt.Sprintf(`
 	SELECT
-		COALESCE(SUM(CASE WHEN transtype='income' THEN amount END),0) AS income_sum,
-		COUNT(CASE WHEN transtype='income' THEN 1 END) AS income_count,
-		COALESCE(AVG(CASE WHEN transtype='income' THEN amount END),0) AS income_avg,
-		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY CASE WHEN transtype='income' THEN amount END),0) AS income_median,
-		COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY CASE WHEN transtype='income' THEN amount END),0) AS income_perc90,
-		COALESCE(SUM(CASE WHEN transtype='expense' THEN amount END),0) AS expense_sum,
-		COUNT(CASE WHEN transtype='expense' THEN 1 END) AS expense_count,
-		COALESCE(AVG(CASE WHEN transtype='expense' THEN amount END),0) AS expense_avg,
-		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY CASE WHEN transtype='expense' THEN amount END),0) AS expense_median,
-		COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY CASE WHEN transtype='expense' THEN amount END),0) AS expense_perc90
-	FROM transactions
-	WHERE transdate >= $1 AND transdate <= $2;
-	`
+		%s,
+		%s,
+		%s
+	FROM (
+		SELECT transtype, amount, %s AS value
+		FROM transactions
+		WHERE transdate >= $1 AND transdate <= $2%s
+	) t;
+	`, metricColumns(metrics, "amount", "transtype = 'income'", "i"), metricColumns(metrics, "amount", "transtype = 'expense'", "e"),
+		metricColumns(metrics, "value", "", "a"), valueExpr(q.Mode), summaryFilter)
 
-	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, summaryQuery, from, to)
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, summaryQuery, summaryArgs...)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("Error executing analytics summary query")
 		return nil, err
 	}
 
-	var incomeSum, incomeAvg, incomeMedian, incomePerc90 float64
-	var incomeCount int
-	var expenseSum, expenseAvg, expenseMedian, expensePerc90 float64
-	var expenseCount int
-
-	if err := row.Scan(&incomeSum, &incomeCount, &incomeAvg, &incomeMedian, &incomePerc90,
-		&expenseSum, &expenseCount, &expenseAvg, &expenseMedian, &expensePerc90); err != nil {
+	summary := make([]float64, 3*len(metrics))
+	dest := make([]any, len(summary))
+	for i := range summary {
+		dest[i] = &summary[i]
+	}
+	if err := row.Scan(dest...); err != nil {
 		if err == sql.ErrNoRows {
 			wbzlog.Logger.Info().Msg("No rows returned for analytics summary")
 			return nil, nil
@@ -174,31 +326,381 @@ func (p *Postgres) GetAnalytics(from, to time.Time, groupBy, splitBy, sortBy, so
 		return nil, err
 	}
 
-	result.Summary = analytic.AnalyticByType{
-		Income: analytic.Analytic{
-			Sum: incomeSum, Count: incomeCount, Avg: incomeAvg, Median: incomeMedian, Percentile90: incomePerc90,
-		},
-		Expense: analytic.Analytic{
-			Sum: expenseSum, Count: expenseCount, Avg: expenseAvg, Median: expenseMedian, Percentile90: expensePerc90,
-		},
-		All: analytic.Analytic{
-			Sum:   incomeSum - expenseSum,
-			Count: incomeCount + expenseCount,
-			Avg: func() float64 {
-				if incomeCount+expenseCount == 0 {
-					return 0
-				}
-				return (incomeSum - expenseSum) / float64(incomeCount+expenseCount)
-			}(),
-			Median: func() float64 {
-				if incomeCount == 0 && expenseCount == 0 {
-					return 0
-				}
-				return (incomeMedian - expenseMedian) / 2
-			}(),
-			Percentile90: (incomePerc90 + expensePerc90) / 2,
-		},
+	n := len(metrics)
+	income := analytic.AnalyticFromValues(metrics, summary[:n], dynamic)
+	expense := analytic.AnalyticFromValues(metrics, summary[n:2*n], dynamic)
+	all := analytic.AnalyticFromValues(metrics, summary[2*n:], dynamic)
+	result.Summary = analytic.AnalyticByType{Income: income, Expense: expense, All: all}
+
+	return result, nil
+}
+
+// nestedAnalytics строит дерево групп по уровням GroupBy и Nested одним запросом: ROLLUP дает агрегаты
+// каждого уровня, в том числе общий итог (он есть и без транзакций), который становится Summary. Income и Expense считаются по суммам
+// своего типа, All — по значению в режиме Mode, как в сводке
+func (p *Postgres) nestedAnalytics(ctx context.Context, q analytic.Query) (*analytic.Analytics, error) {
+	levels := append([]analytic.GroupBy{q.GroupBy}, q.Nested...)
+	args := []any{q.From, q.To}
+	selects := make([]string, len(levels))
+	names := make([]string, len(levels))
+	tzAdded := false
+	for i, g := range levels {
+		expr, usesTZ := groupKeyExpr(g)
+		if usesTZ && !tzAdded {
+			args = append(args, q.Location.String())
+			tzAdded = true
+		}
+		names[i] = fmt.Sprintf("d%d", i)
+		selects[i] = fmt.Sprintf("%s AS d%d", expr, i)
+	}
+	filter, args := filterConditions(q.Filter, args)
+
+	metrics := q.MetricList()
+	dynamic := len(q.Metrics) > 0
+	query := fmt.Sprintf(`
+	WITH base AS (
+	SELECT %s, transtype, amount, %s AS value
+	FROM transactions
+	WHERE transdate >= $1 AND transdate <= $2%s
+	)
+	SELECT %s, GROUPING(%s) AS grp,
+		%s,
+		%s,
+		%s
+	FROM base
+	GROUP BY ROLLUP(%s);
+	`, strings.Join(selects, ", "), valueExpr(q.Mode), filter, strings.Join(names, ", "), strings.Join(names, ", "),
+		metricColumns(metrics, "amount", "transtype = 'income'", "i"), metricColumns(metrics, "amount", "transtype = 'expense'", "e"),
+		metricColumns(metrics, "value", "", "a"), strings.Join(names, ", "))
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error executing nested analytics query")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := &analytic.Analytics{}
+	root := analytic.NewGroupTree(levels)
+	keys := make([]*string, len(levels))
+	values := make([]float64, 3*len(metrics))
+	n := len(metrics)
+	for rows.Next() {
+		var grp int
+		dest := make([]any, 0, len(keys)+1+len(values))
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		dest = append(dest, &grp)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Error scanning nested analytics row")
+			return nil, err
+		}
+
+		data := &analytic.AnalyticByType{
+			Income:  analytic.AnalyticFromValues(metrics, values[:n], dynamic),
+			Expense: analytic.AnalyticFromValues(metrics, values[n:2*n], dynamic),
+			All:     analytic.AnalyticFromValues(metrics, values[2*n:], dynamic),
+		}
+		// ROLLUP сворачивает измерения с конца: число несвернутых уровней — глубина строки
+		depth := 0
+		for i := range levels {
+			if grp&(1<<(len(levels)-1-i)) == 0 {
+				depth++
+			}
+		}
+		if depth == 0 {
+			result.Summary = *data
+			continue
+		}
+		path := make([]string, depth)
+		for i := range path {
+			if keys[i] != nil {
+				path[i] = *keys[i]
+			}
+		}
+		root.Add(path, data)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result.Groups = root.Groups(metrics, q.SortBy, q.SortDir == "asc")
 
+	// Заполняется только верхний уровень: у пустых периодов подгрупп нет
+	if q.Fill != analytic.FillNone {
+		keys, err := p.timeBuckets(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		result.Groups = analytic.FillGaps(result.Groups, keys, q.Fill, q.Metrics)
+		for i := range result.Groups {
+			result.Groups[i].Dimension = q.GroupBy
+		}
+	}
 	return result, nil
 }
+
+// timeGroupings — формат to_char ключа группы, единица date_trunc и шаг generate_series для временных группировок
+var timeGroupings = map[analytic.GroupBy]struct {
+	format, unit, step string
+}{
+	analytic.GroupHour:    {`YYYY-MM-DD"T"HH24":00"`, "hour", "1 hour"},
+	analytic.GroupDay:     {`YYYY-MM-DD`, "day", "1 day"},
+	analytic.GroupWeek:    {`IYYY-"W"IW`, "week", "1 week"},
+	analytic.GroupMonth:   {`YYYY-MM`, "month", "1 month"},
+	analytic.GroupQuarter: {`YYYY-"Q"Q`, "quarter", "3 months"},
+	analytic.GroupYear:    {`YYYY`, "year", "1 year"},
+}
+
+// groupKeyExpr возвращает SQL-выражение ключа группы. Временные ключи считаются по transdate в часовом поясе
+// запроса ($3), usesTZ сообщает, что этот параметр нужно передать
+func groupKeyExpr(groupBy analytic.GroupBy) (expr string, usesTZ bool) {
+	switch groupBy {
+	case analytic.GroupCategory:
+		return "category", false
+	case analytic.GroupType:
+		return "transtype", false
+	case analytic.GroupCounterparty:
+		return "counterparty", false
+	case analytic.GroupNone:
+		return "'" + analytic.AllGroupKey + "'::text", false
+	}
+	g, ok := timeGroupings[groupBy]
+	if !ok {
+		g = timeGroupings[analytic.GroupDay]
+	}
+	return fmt.Sprintf("to_char(transdate AT TIME ZONE $3::text, '%s')", g.format), true
+}
+
+// timeBuckets возвращает ключи всех периодов группировки между from и to по порядку, в том же формате, что и groupKeyExpr
+func (p *Postgres) timeBuckets(ctx context.Context, q analytic.Query) ([]string, error) {
+	g, ok := timeGroupings[q.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: fill requires a time grouping", analytic.ErrInvalidQuery)
+	}
+	query := fmt.Sprintf(`
+	SELECT to_char(b, '%s')
+	FROM generate_series(
+		date_trunc('%s', $1::timestamptz AT TIME ZONE $3::text),
+		$2::timestamptz AT TIME ZONE $3::text,
+		interval '%s'
+	) AS b
+	ORDER BY b;
+	`, g.format, g.unit, g.step)
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, q.From, q.To, q.Location.String())
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error executing time buckets query")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// runningTotals считает входящий остаток до from и нарастающие итоги на конец каждого периода группировки.
+// Периоды берутся из generate_series, поэтому итог есть и у периодов без транзакций
+func (p *Postgres) runningTotals(ctx context.Context, q analytic.Query) (analytic.RunningTotals, map[string]analytic.RunningTotals, error) {
+	g, ok := timeGroupings[q.GroupBy]
+	if !ok {
+		return analytic.RunningTotals{}, nil, fmt.Errorf("%w: cumulative requires a time grouping", analytic.ErrInvalidQuery)
+	}
+	filter, args := filterConditions(q.Filter, []any{q.From, q.To, q.Location.String()})
+
+	query := fmt.Sprintf(`
+	WITH buckets AS (
+	SELECT b, to_char(b, '%[1]s') AS group_key
+	FROM generate_series(
+		date_trunc('%[2]s', $1::timestamptz AT TIME ZONE $3::text),
+		$2::timestamptz AT TIME ZONE $3::text,
+		interval '%[3]s'
+	) AS b
+	),
+	opening AS (
+	SELECT
+		COALESCE(SUM(amount) FILTER (WHERE transtype = 'income'), 0) AS income,
+		COALESCE(SUM(amount) FILTER (WHERE transtype = 'expense'), 0) AS expense
+	FROM transactions
+	WHERE transdate < $1%[4]s
+	),
+	per_bucket AS (
+	SELECT
+		to_char(transdate AT TIME ZONE $3::text, '%[1]s') AS group_key,
+		SUM(amount) FILTER (WHERE transtype = 'income') AS income,
+		SUM(amount) FILTER (WHERE transtype = 'expense') AS expense
+	FROM transactions
+	WHERE transdate >= $1 AND transdate <= $2%[4]s
+	GROUP BY 1
+	)
+	SELECT
+		b.group_key,
+		o.income,
+		o.expense,
+		o.income + SUM(COALESCE(pb.income, 0)) OVER (ORDER BY b.b) AS running_income,
+		o.expense + SUM(COALESCE(pb.expense, 0)) OVER (ORDER BY b.b) AS running_expense
+	FROM buckets b
+	CROSS JOIN opening o
+	LEFT JOIN per_bucket pb USING (group_key)
+	ORDER BY b.b;
+	`, g.format, g.unit, g.step, filter)
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error executing running totals query")
+		return analytic.RunningTotals{}, nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var opening analytic.RunningTotals
+	running := map[string]analytic.RunningTotals{}
+	for rows.Next() {
+		var key string
+		var r analytic.RunningTotals
+		if err := rows.Scan(&key, &opening.Income, &opening.Expense, &r.Income, &r.Expense); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Error scanning running totals row")
+			return analytic.RunningTotals{}, nil, err
+		}
+		r.Net = r.Income - r.Expense
+		running[key] = r
+	}
+	opening.Net = opening.Income - opening.Expense
+	return opening, running, rows.Err()
+}
+
+// rollingWindow считает скользящие агрегаты окна w на конец каждого периода группировки. Ряд периодов
+// начинается раньше from на длину окна, поэтому первые окна тоже полные. Окно периода — все периоды,
+// начавшиеся не раньше чем за (w - шаг) до него: для 7d по дням это сам день и 6 предыдущих, для 3m по месяцам —
+// месяц и 2 предыдущих. Смещение считает Window.FrameOffset в одной единице, без смешения дней и месяцев
+func (p *Postgres) rollingWindow(ctx context.Context, q analytic.Query, w analytic.Window) (map[string]*analytic.RollingByType, error) {
+	g, ok := timeGroupings[q.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: rolling requires a time grouping", analytic.ErrInvalidQuery)
+	}
+	offset, err := w.FrameOffset(q.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	filter, args := filterConditions(q.Filter, []any{q.From, q.To, q.Location.String()})
+
+	query := fmt.Sprintf(`
+	WITH buckets AS (
+	SELECT b, to_char(b, '%[1]s') AS group_key
+	FROM generate_series(
+		date_trunc('%[2]s', date_trunc('%[2]s', $1::timestamptz AT TIME ZONE $3::text) - interval '%[4]s'),
+		$2::timestamptz AT TIME ZONE $3::text,
+		interval '%[3]s'
+	) AS b
+	),
+	per_bucket AS (
+	SELECT
+		date_trunc('%[2]s', transdate AT TIME ZONE $3::text) AS b,
+		COALESCE(SUM(amount) FILTER (WHERE transtype = 'income'), 0) AS income_sum,
+		COUNT(*) FILTER (WHERE transtype = 'income') AS income_count,
+		COALESCE(SUM(amount) FILTER (WHERE transtype = 'expense'), 0) AS expense_sum,
+		COUNT(*) FILTER (WHERE transtype = 'expense') AS expense_count,
+		SUM(%[5]s) AS all_sum,
+		COUNT(*) AS all_count
+	FROM transactions
+	WHERE transdate >= (SELECT MIN(b) FROM buckets) AT TIME ZONE $3::text AND transdate <= $2%[6]s
+	GROUP BY 1
+	)
+	SELECT
+		bk.group_key,
+		SUM(COALESCE(pb.income_sum, 0)) OVER w,
+		SUM(COALESCE(pb.income_count, 0)) OVER w,
+		SUM(COALESCE(pb.expense_sum, 0)) OVER w,
+		SUM(COALESCE(pb.expense_count, 0)) OVER w,
+		SUM(COALESCE(pb.all_sum, 0)) OVER w,
+		SUM(COALESCE(pb.all_count, 0)) OVER w,
+		COUNT(*) OVER w AS periods,
+		bk.b >= date_trunc('%[2]s', $1::timestamptz AT TIME ZONE $3::text) AS in_range
+	FROM buckets bk
+	LEFT JOIN per_bucket pb USING (b)
+	WINDOW w AS (ORDER BY bk.b RANGE BETWEEN interval '%[7]s' PRECEDING AND CURRENT ROW)
+	ORDER BY bk.b;
+	`, g.format, g.unit, g.step, w.Interval(), valueExpr(q.Mode), filter, offset)
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error executing rolling window query")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := map[string]*analytic.RollingByType{}
+	for rows.Next() {
+		var key string
+		var r analytic.RollingByType
+		var periods int
+		var inRange bool
+		if err := rows.Scan(&key, &r.Income.Sum, &r.Income.Count, &r.Expense.Sum, &r.Expense.Count,
+			&r.All.Sum, &r.All.Count, &periods, &inRange); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Error scanning rolling window row")
+			return nil, err
+		}
+		// Периоды разгона до from нужны только как история окна; пустое окно не делится на ноль
+		if !inRange || periods == 0 {
+			continue
+		}
+		for _, v := range []*analytic.RollingValue{&r.Income, &r.Expense, &r.All} {
+			v.Avg = v.Sum / float64(periods)
+		}
+		result[key] = &r
+	}
+	return result, rows.Err()
+}
+
+// GetCategoryTotals возвращает агрегаты по категориям за период [from, to) для заданного типа транзакций
+func (p *Postgres) GetCategoryTotals(from, to time.Time, trType string) (map[string]analytic.Analytic, error) {
+	ctx := context.Background()
+
+	query := fmt.Sprintf(`
+	SELECT
+		category,
+		%s
+	FROM transactions
+	WHERE transdate >= $1 AND transdate < $2 AND transtype = $3
+	GROUP BY category;
+	`, aggregatesOver("amount"))
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, from, to, trType)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error executing category totals query")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := map[string]analytic.Analytic{}
+	for rows.Next() {
+		var category string
+		var a analytic.Analytic
+		if err := rows.Scan(&category, &a.Sum, &a.Avg, &a.Count, &a.Median, &a.Percentile90); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Error scanning category totals row")
+			return nil, err
+		}
+		result[category] = a
+	}
+	return result, rows.Err()
+}
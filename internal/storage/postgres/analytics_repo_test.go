@@ -0,0 +1,529 @@
+package postgres
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"math"
+	"salestracker/internal/domain/analytic"
+	"salestracker/internal/domain/transaction"
+	"testing"
+	"time"
+)
+
+// seedAnalytics вставляет транзакции с датами на границах часов, недель, кварталов и лет (в UTC)
+func seedAnalytics(t *testing.T, p *Postgres) {
+	t.Helper()
+	rows := []struct {
+		trType   transaction.TransactionType
+		category string
+		amount   float64
+		date     time.Time
+	}{
+		{transaction.Income, "sales", 100, time.Date(2024, 12, 31, 22, 30, 0, 0, time.UTC)},
+		{transaction.Expense, "food", 40, time.Date(2025, 2, 12, 10, 0, 0, 0, time.UTC)},
+		{transaction.Income, "sales", 60, time.Date(2025, 2, 12, 10, 20, 0, 0, time.UTC)},
+		{transaction.Expense, "food", 10, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{transaction.Expense, "food", 5, time.Date(2024, 12, 30, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, r := range rows {
+		tr := &transaction.Transaction{ID: uuid.New(), Type: r.trType, Category: r.category, Amount: r.amount, Date: r.date}
+		if err := p.SaveTransaction(tr); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+}
+
+func groupCounts(a *analytic.Analytics) map[string]int {
+	out := map[string]int{}
+	for _, g := range a.Groups {
+		out[g.GroupKey] = g.Data.All.Count
+	}
+	return out
+}
+
+func TestGetAnalytics_Groupings(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, moscow)
+	to := time.Date(2025, 4, 30, 23, 59, 59, 0, moscow)
+
+	cases := []struct {
+		groupBy analytic.GroupBy
+		loc     *time.Location
+		want    map[string]int
+	}{
+		{analytic.GroupHour, moscow, map[string]int{
+			"2024-12-30T15:00": 1, "2025-01-01T01:00": 1, "2025-02-12T13:00": 2, "2025-04-01T03:00": 1,
+		}},
+		{analytic.GroupDay, moscow, map[string]int{
+			"2024-12-30": 1, "2025-01-01": 1, "2025-02-12": 2, "2025-04-01": 1,
+		}},
+		{analytic.GroupDay, time.UTC, map[string]int{
+			"2024-12-30": 1, "2024-12-31": 1, "2025-02-12": 2, "2025-04-01": 1,
+		}},
+		// 30.12.2024 — понедельник первой ISO-недели 2025 года
+		{analytic.GroupWeek, moscow, map[string]int{
+			"2025-W01": 2, "2025-W07": 2, "2025-W14": 1,
+		}},
+		{analytic.GroupMonth, moscow, map[string]int{
+			"2024-12": 1, "2025-01": 1, "2025-02": 2, "2025-04": 1,
+		}},
+		{analytic.GroupQuarter, moscow, map[string]int{
+			"2024-Q4": 1, "2025-Q1": 3, "2025-Q2": 1,
+		}},
+		{analytic.GroupYear, moscow, map[string]int{
+			"2024": 1, "2025": 4,
+		}},
+		{analytic.GroupCategory, moscow, map[string]int{
+			"food": 3, "sales": 2,
+		}},
+		{analytic.GroupNone, moscow, map[string]int{
+			analytic.AllGroupKey: 5,
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.groupBy)+"/"+c.loc.String(), func(t *testing.T) {
+			res, err := p.GetAnalytics(analytic.Query{
+				From: from, To: to, GroupBy: c.groupBy, SplitBy: "transtype", SortDir: "asc", Location: c.loc,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := groupCounts(res)
+			if len(got) != len(c.want) {
+				t.Fatalf("got groups %v, want %v", got, c.want)
+			}
+			for k, n := range c.want {
+				if got[k] != n {
+					t.Errorf("group %s: got %d transactions, want %d (all groups: %v)", k, got[k], n, got)
+				}
+			}
+			for i := 1; i < len(res.Groups); i++ {
+				if res.Groups[i-1].GroupKey > res.Groups[i].GroupKey {
+					t.Errorf("groups are not ordered: %s before %s", res.Groups[i-1].GroupKey, res.Groups[i].GroupKey)
+				}
+			}
+		})
+	}
+
+	res, err := p.GetAnalytics(analytic.Query{From: from, To: to, GroupBy: analytic.GroupNone, SplitBy: "transtype", Location: moscow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := res.Groups[0].Data
+	if all.All.Sum != 105 || all.Income.Sum != 160 || all.Expense.Sum != 55 {
+		t.Errorf("unexpected totals: all %v, income %v, expense %v", all.All.Sum, all.Income.Sum, all.Expense.Sum)
+	}
+}
+
+func TestGetAnalytics_Fill(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := analytic.Query{
+		From:     time.Date(2024, 11, 15, 0, 0, 0, 0, moscow),
+		To:       time.Date(2025, 5, 10, 0, 0, 0, 0, moscow),
+		GroupBy:  analytic.GroupMonth,
+		SplitBy:  "transtype",
+		SortBy:   "sum",
+		SortDir:  "desc",
+		Fill:     analytic.FillZero,
+		Location: moscow,
+	}
+	res, err := p.GetAnalytics(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"2024-11", "2024-12", "2025-01", "2025-02", "2025-03", "2025-04", "2025-05"}
+	if len(res.Groups) != len(want) {
+		t.Fatalf("got %d groups, want %d: %+v", len(res.Groups), len(want), res.Groups)
+	}
+	for i, g := range res.Groups {
+		if g.GroupKey != want[i] {
+			t.Fatalf("group %d is %s, want %s", i, g.GroupKey, want[i])
+		}
+		empty := g.GroupKey == "2024-11" || g.GroupKey == "2025-03" || g.GroupKey == "2025-05"
+		if g.Filled != empty || g.Data == nil {
+			t.Errorf("%s: Filled=%v Data=%v", g.GroupKey, g.Filled, g.Data)
+		}
+	}
+
+	q.GroupBy, q.Fill = analytic.GroupWeek, analytic.FillNull
+	q.From = time.Date(2024, 12, 25, 0, 0, 0, 0, moscow)
+	q.To = time.Date(2025, 1, 20, 0, 0, 0, 0, moscow)
+	res, err = p.GetAnalytics(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	weeks := []string{"2024-W52", "2025-W01", "2025-W02", "2025-W03", "2025-W04"}
+	if len(res.Groups) != len(weeks) {
+		t.Fatalf("got %d weeks, want %d: %+v", len(res.Groups), len(weeks), res.Groups)
+	}
+	for i, g := range res.Groups {
+		if g.GroupKey != weeks[i] || (g.Data == nil) != (g.GroupKey != "2025-W01") {
+			t.Errorf("week %d: %s data=%v", i, g.GroupKey, g.Data)
+		}
+	}
+}
+
+// TestGetAnalytics_AllMetrics проверяет, что All считается по самим транзакциям, а не по агрегатам групп.
+// Доходы 100 и 60, расходы 40, 10 и 5: в signed это {-40, -10, -5, 60, 100}, в absolute — {5, 10, 40, 60, 100}
+func TestGetAnalytics_AllMetrics(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		mode analytic.Mode
+		want analytic.Analytic
+	}{
+		// percentile_cont(0.9) интерполирует между 4-м и 5-м значением: 60 + 0.6 * (100 - 60)
+		{analytic.ModeSigned, analytic.Analytic{Sum: 105, Avg: 21, Count: 5, Median: -5, Percentile90: 84}},
+		{analytic.ModeAbsolute, analytic.Analytic{Sum: 215, Avg: 43, Count: 5, Median: 40, Percentile90: 84}},
+	}
+	for _, c := range cases {
+		t.Run(string(c.mode), func(t *testing.T) {
+			res, err := p.GetAnalytics(analytic.Query{
+				From: from, To: to, GroupBy: analytic.GroupNone, SplitBy: "transtype", Mode: c.mode, Location: time.UTC,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := res.Groups[0].Data.All; !closeAnalytic(got, c.want) {
+				t.Errorf("group All: got %+v, want %+v", got, c.want)
+			}
+			if got := res.Summary.All; !closeAnalytic(got, c.want) {
+				t.Errorf("summary All: got %+v, want %+v", got, c.want)
+			}
+			// Income и Expense от режима не зависят
+			if res.Summary.Expense.Median != 10 || res.Summary.Income.Median != 80 {
+				t.Errorf("unexpected split medians: income %v, expense %v", res.Summary.Income.Median, res.Summary.Expense.Median)
+			}
+		})
+	}
+}
+
+func closeAnalytic(a, b analytic.Analytic) bool {
+	near := func(x, y float64) bool { return math.Abs(x-y) < 1e-9 }
+	return a.Count == b.Count && near(a.Sum, b.Sum) && near(a.Avg, b.Avg) && near(a.Median, b.Median) && near(a.Percentile90, b.Percentile90)
+}
+
+func TestGetAnalytics_Metrics(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	metrics := []analytic.Metric{"p25", analytic.MetricMin, analytic.MetricMax, analytic.MetricVariance, analytic.MetricStddev, "p99.9"}
+	res, err := p.GetAnalytics(analytic.Query{
+		From: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+		GroupBy: analytic.GroupCategory, SplitBy: "transtype", Mode: analytic.ModeAbsolute, Metrics: metrics,
+		SortBy: "max", SortDir: "desc", Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Groups) != 2 || res.Groups[0].GroupKey != "sales" {
+		t.Fatalf("expected sales first by max, got %+v", res.Groups)
+	}
+
+	// Расходы 40, 10, 5: p25 = 5 + 0.5 * 5, выборочная дисперсия = ((40-55/3)^2 + (10-55/3)^2 + (5-55/3)^2) / 2
+	want := map[analytic.Metric]float64{"p25": 7.5, "min": 5, "max": 40, "variance": 358.3333333333333, "stddev": 18.929694486000912, "p99.9": 39.94}
+	got := res.Summary.Expense.Values
+	if len(got) != len(metrics) {
+		t.Fatalf("expected only requested metrics, got %v", got)
+	}
+	for m, v := range want {
+		if math.Abs(got[m]-v) > 1e-6 {
+			t.Errorf("%s: got %v, want %v", m, got[m], v)
+		}
+	}
+	// Доходы 100 и 60: (20^2 + 20^2) / (2 - 1)
+	if v := res.Summary.Income.Values["variance"]; v != 800 {
+		t.Errorf("income variance: got %v, want 800", v)
+	}
+}
+
+func TestGetAnalytics_Filter(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	min := 8.0
+	f := transaction.Filter{Type: transaction.Expense, Categories: []string{"food", "rent"}, MinAmount: &min}
+	// Часовой пояс занимает $3 только в сгруппированном запросе: параметры фильтра должны нумероваться в обоих верно
+	for _, g := range []analytic.GroupBy{analytic.GroupMonth, analytic.GroupCategory} {
+		res, err := p.GetAnalytics(analytic.Query{From: from, To: to, GroupBy: g, SplitBy: "transtype", Filter: f, Location: time.UTC})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", g, err)
+		}
+		if res.Summary.All.Count != 2 || res.Summary.Expense.Sum != 50 || res.Summary.Income.Count != 0 {
+			t.Errorf("%s: unexpected summary %+v", g, res.Summary)
+		}
+		total := 0
+		for _, gr := range res.Groups {
+			total += gr.Data.All.Count
+		}
+		if total != 2 {
+			t.Errorf("%s: grouped query ignores filter: %d transactions", g, total)
+		}
+	}
+
+	trs, err := p.GetAllTransactions(from, to, transaction.Filter{Search: "%"}, "date", "asc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trs) != 0 {
+		t.Errorf("LIKE wildcards must be escaped, got %d transactions", len(trs))
+	}
+}
+
+func TestGetAnalytics_Cumulative(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	res, err := p.GetAnalytics(analytic.Query{
+		From: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond),
+		GroupBy: analytic.GroupMonth, SplitBy: "transtype", Fill: analytic.FillZero, Cumulative: true, Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// До 2025 года: доход 100 и расход 5
+	if res.Opening == nil || *res.Opening != (analytic.RunningTotals{Income: 100, Expense: 5, Net: 95}) {
+		t.Fatalf("unexpected opening balance %+v", res.Opening)
+	}
+	want := map[string]analytic.RunningTotals{
+		"2025-01": {Income: 100, Expense: 5, Net: 95},
+		"2025-02": {Income: 160, Expense: 45, Net: 115},
+		"2025-03": {Income: 160, Expense: 45, Net: 115},
+		"2025-04": {Income: 160, Expense: 55, Net: 105},
+	}
+	if len(res.Groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(res.Groups), len(want))
+	}
+	for _, g := range res.Groups {
+		if g.Running == nil || *g.Running != want[g.GroupKey] {
+			t.Errorf("%s: got running %+v, want %+v", g.GroupKey, g.Running, want[g.GroupKey])
+		}
+	}
+}
+
+func TestGetAnalytics_Rolling(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	res, err := p.GetAnalytics(analytic.Query{
+		From: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond),
+		GroupBy: analytic.GroupMonth, SplitBy: "transtype", Mode: analytic.ModeSigned, Fill: analytic.FillZero,
+		Rolling: []analytic.Window{{N: 2, Unit: 'm'}}, Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Окно 2m — месяц и предыдущий; для января это декабрь 2024 года, до from
+	want := map[string]analytic.RollingByType{
+		"2025-01": {Income: analytic.RollingValue{Sum: 100, Avg: 50, Count: 1}, Expense: analytic.RollingValue{Sum: 5, Avg: 2.5, Count: 1}, All: analytic.RollingValue{Sum: 95, Avg: 47.5, Count: 2}},
+		"2025-02": {Income: analytic.RollingValue{Sum: 60, Avg: 30, Count: 1}, Expense: analytic.RollingValue{Sum: 40, Avg: 20, Count: 1}, All: analytic.RollingValue{Sum: 20, Avg: 10, Count: 2}},
+		"2025-03": {Income: analytic.RollingValue{Sum: 60, Avg: 30, Count: 1}, Expense: analytic.RollingValue{Sum: 40, Avg: 20, Count: 1}, All: analytic.RollingValue{Sum: 20, Avg: 10, Count: 2}},
+		"2025-04": {Expense: analytic.RollingValue{Sum: 10, Avg: 5, Count: 1}, All: analytic.RollingValue{Sum: -10, Avg: -5, Count: 1}},
+	}
+	if len(res.Groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(res.Groups), len(want))
+	}
+	for _, g := range res.Groups {
+		r := g.Rolling["2m"]
+		if r == nil || *r != want[g.GroupKey] {
+			t.Errorf("%s: got %+v, want %+v", g.GroupKey, r, want[g.GroupKey])
+		}
+	}
+}
+
+func TestGetAnalytics_RollingCalendar(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	q := analytic.Query{
+		From: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond),
+		GroupBy: analytic.GroupMonth, SplitBy: "transtype", Mode: analytic.ModeSigned, Fill: analytic.FillZero,
+		Rolling: []analytic.Window{{N: 30, Unit: 'd'}}, Location: time.UTC,
+	}
+	// '30 days' - '1 month' в 31-дневных месяцах давал пустое окно и NaN в Avg
+	if _, err := p.GetAnalytics(q); !errors.Is(err, analytic.ErrInvalidQuery) {
+		t.Fatalf("month grouping with 30d window: expected ErrInvalidQuery, got %v", err)
+	}
+
+	q.Rolling = []analytic.Window{{N: 1, Unit: 'm'}}
+	res, err := p.GetAnalytics(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := json.Marshal(res); err != nil {
+		t.Fatalf("response must be encodable: %v", err)
+	}
+	for _, g := range res.Groups {
+		r := g.Rolling["1m"]
+		if r == nil || math.IsNaN(r.All.Avg) || r.All.Avg != r.All.Sum {
+			t.Errorf("%s: one-month window must equal the month itself, got %+v", g.GroupKey, r)
+		}
+	}
+	if r := res.Groups[2].Rolling["1m"]; res.Groups[2].GroupKey != "2025-03" || r.All.Count != 0 {
+		t.Errorf("March has no transactions, got %s %+v", res.Groups[2].GroupKey, r)
+	}
+}
+
+func TestGetPivot_Totals(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	res, err := p.GetPivot(analytic.PivotQuery{
+		From: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 30, 23, 59, 59, 0, time.UTC),
+		Rows: []analytic.Dimension{analytic.DimCategory}, Columns: []analytic.Dimension{analytic.DimPeriod},
+		Period: analytic.GroupQuarter, Metrics: []analytic.Metric{analytic.MetricSum, analytic.MetricMedian},
+		Mode: analytic.ModeAbsolute, Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("GetPivot: %v", err)
+	}
+	if len(res.RowKeys) != 2 || res.RowKeys[0][0] != "food" || len(res.ColumnKeys) != 3 || res.ColumnKeys[2][0] != "2025-Q2" {
+		t.Fatalf("unexpected keys %v %v", res.RowKeys, res.ColumnKeys)
+	}
+	if len(res.Cells) != 5 || res.Cells[0].Column[0] != "2024-Q4" || res.Cells[0].Values[analytic.MetricSum] != 5 {
+		t.Errorf("unexpected cells %+v", res.Cells)
+	}
+	if len(res.RowTotals) != 2 || res.RowTotals[1].Values[analytic.MetricSum] != 160 {
+		t.Errorf("unexpected row totals %+v", res.RowTotals)
+	}
+	if len(res.ColumnTotals) != 3 || res.ColumnTotals[0].Values[analytic.MetricSum] != 105 {
+		t.Errorf("unexpected column totals %+v", res.ColumnTotals)
+	}
+	// Медиана общего итога — по всем транзакциям, а не по медианам ячеек
+	if res.GrandTotal[analytic.MetricSum] != 215 || res.GrandTotal[analytic.MetricMedian] != 40 {
+		t.Errorf("unexpected grand total %+v", res.GrandTotal)
+	}
+}
+
+func TestGetAnalytics_Nested(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	res, err := p.GetAnalytics(analytic.Query{
+		From: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 30, 23, 59, 59, 0, time.UTC),
+		GroupBy: analytic.GroupQuarter, Nested: []analytic.GroupBy{analytic.GroupType, analytic.GroupCategory},
+		Mode: analytic.ModeSigned, SortDir: "asc", Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("GetAnalytics: %v", err)
+	}
+	if res.Summary.All.Count != 5 || res.Summary.Income.Sum != 160 || res.Summary.Expense.Sum != 55 || res.Summary.All.Sum != 105 {
+		t.Fatalf("unexpected summary %+v", res.Summary)
+	}
+	if len(res.Groups) != 3 || res.Groups[1].GroupKey != "2025-Q1" || res.Groups[1].Data.All.Count != 2 {
+		t.Fatalf("unexpected quarters %+v", res.Groups)
+	}
+	types := res.Groups[1].Children
+	if len(types) != 2 || types[0].GroupKey != "expense" || types[0].Dimension != analytic.GroupType || types[0].Data.Expense.Sum != 40 {
+		t.Fatalf("unexpected types %+v", types)
+	}
+	if cats := types[1].Children; len(cats) != 1 || cats[0].GroupKey != "sales" || cats[0].Data.Income.Sum != 60 || cats[0].Children != nil {
+		t.Errorf("unexpected categories %+v", cats)
+	}
+}
+
+func TestGetAnalytics_Top(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	res, err := p.GetAnalytics(analytic.Query{
+		From: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 30, 23, 59, 59, 0, time.UTC),
+		GroupBy: analytic.GroupNone, SplitBy: "category", Top: 1, TopBy: analytic.MetricCount,
+		Metrics: []analytic.Metric{analytic.MetricSum, analytic.MetricCount, analytic.MetricMedian},
+		Mode:    analytic.ModeAbsolute, Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("GetAnalytics: %v", err)
+	}
+	if len(res.Groups) != 1 {
+		t.Fatalf("expected one group, got %+v", res.Groups)
+	}
+	splits := res.Groups[0].Data.Splits
+	if len(splits) != 2 || splits[0].Key != "food" || splits[0].Other || splits[1].Key != analytic.OtherSplitKey || !splits[1].Other {
+		t.Fatalf("unexpected splits %+v", splits)
+	}
+	if _, ok := res.Groups[0].Data.AllMap[analytic.OtherSplitKey]; ok || len(res.Groups[0].Data.AllMap) != 1 {
+		t.Errorf("folded Other must not reach AllMap: %v", res.Groups[0].Data.AllMap)
+	}
+	// food — 3 транзакции против 2 у sales; Other — сами транзакции sales, а не их агрегаты
+	if v := splits[1].Data.Values; v[analytic.MetricSum] != 160 || v[analytic.MetricCount] != 2 || v[analytic.MetricMedian] != 80 {
+		t.Errorf("unexpected Other values %v", v)
+	}
+}
+
+func TestGetHistogram(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+	from, to := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 4, 30, 23, 59, 59, 0, time.UTC)
+
+	cases := []struct {
+		q      analytic.HistogramQuery
+		counts []int
+		sums   []float64
+	}{
+		// 5, 10, 40 | 60, 100: граница 52.5, максимум попадает в последнюю корзину
+		{analytic.HistogramQuery{Buckets: 2, Bucketing: analytic.BucketFixed}, []int{3, 2}, []float64{55, 160}},
+		// квантили 0 и 0.5 — 5 и 40
+		{analytic.HistogramQuery{Buckets: 2, Bucketing: analytic.BucketQuantile}, []int{2, 3}, []float64{15, 200}},
+		// расходы 5, 10, 40: граница √(5·40) ≈ 14.14
+		{analytic.HistogramQuery{Buckets: 2, Bucketing: analytic.BucketLog, Filter: transaction.Filter{Type: transaction.Expense}}, []int{2, 1}, []float64{15, 40}},
+	}
+	for _, c := range cases {
+		c.q.From, c.q.To = from, to
+		h, err := p.GetHistogram(c.q)
+		if err != nil {
+			t.Fatalf("%s: GetHistogram: %v", c.q.Bucketing, err)
+		}
+		if len(h.Buckets) != len(c.counts) {
+			t.Fatalf("%s: unexpected buckets %+v", c.q.Bucketing, h.Buckets)
+		}
+		for i, b := range h.Buckets {
+			if b.Count != c.counts[i] || b.Sum != c.sums[i] {
+				t.Errorf("%s: bucket %d: got %+v, want count %d sum %v", c.q.Bucketing, i, b, c.counts[i], c.sums[i])
+			}
+		}
+	}
+}
+
+func TestGetSeasonality(t *testing.T) {
+	p := newTestPostgres(t)
+	seedAnalytics(t, p)
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	s, err := p.GetSeasonality(analytic.SeasonalityQuery{
+		From: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 30, 23, 59, 59, 0, time.UTC),
+		Layout: analytic.LayoutWeekdayHour, Measure: analytic.MetricSum, Location: moscow,
+	})
+	if err != nil {
+		t.Fatalf("GetSeasonality: %v", err)
+	}
+	// 2025-02-12 10:00 и 10:20 UTC — среда, 13 часов по Москве; 2024-12-31 22:30 UTC — уже среда 01:30
+	if s.Values[2][13] != 100 || s.Values[2][1] != 100 || s.Values[1][1] != 0 {
+		t.Errorf("unexpected Wednesday row %v", s.Values[2])
+	}
+	if s.Total != 215 {
+		t.Errorf("unexpected total %v", s.Total)
+	}
+}
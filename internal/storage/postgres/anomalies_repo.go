@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/anomaly"
+)
+
+func (p *Postgres) GetAnomalyAcks() (map[string]*anomaly.Ack, error) {
+	query := `SELECT anomaly_id, note, acknowledged_at FROM anomaly_acks`
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query anomaly acks")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := map[string]*anomaly.Ack{}
+	for rows.Next() {
+		var a anomaly.Ack
+		if err := rows.Scan(&a.ID, &a.Note, &a.AcknowledgedAt); err != nil {
+			return nil, err
+		}
+		result[a.ID] = &a
+	}
+	return result, rows.Err()
+}
+
+// AcknowledgeAnomaly подтверждает аномалию; повторное подтверждение обновляет заметку и время
+func (p *Postgres) AcknowledgeAnomaly(a *anomaly.Ack) error {
+	query := `
+		INSERT INTO anomaly_acks (anomaly_id, note, acknowledged_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (anomaly_id) DO UPDATE SET note = EXCLUDED.note, acknowledged_at = EXCLUDED.acknowledged_at
+	`
+	ctx := context.Background()
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, a.ID, a.Note, a.AcknowledgedAt)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to acknowledge anomaly")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) UnacknowledgeAnomaly(id string) error {
+	query := `DELETE FROM anomaly_acks WHERE anomaly_id = $1`
+	ctx := context.Background()
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to unacknowledge anomaly")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/budget"
+)
+
+func (p *Postgres) SaveBudget(b *budget.Budget) error {
+	query := `
+		INSERT INTO budgets (id, category, period, amount)
+		VALUES ($1, $2, $3, $4)
+	`
+	ctx := context.Background()
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, b.ID, b.Category, b.Period, b.Amount)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert budget")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetBudget(id string) (*budget.Budget, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
+		return nil, err
+	}
+
+	query := `SELECT id, category, period, amount FROM budgets WHERE id = $1`
+	ctx := context.Background()
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, uid)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query budget by id")
+		return nil, err
+	}
+	var b budget.Budget
+	if err := row.Scan(&b.ID, &b.Category, &b.Period, &b.Amount); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wbzlog.Logger.Error().Err(err).Msg("failed to get budget by id")
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (p *Postgres) GetBudgets() ([]*budget.Budget, error) {
+	query := `SELECT id, category, period, amount FROM budgets ORDER BY category, period`
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query budgets")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*budget.Budget
+	for rows.Next() {
+		var b budget.Budget
+		if err := rows.Scan(&b.ID, &b.Category, &b.Period, &b.Amount); err != nil {
+			return nil, err
+		}
+		result = append(result, &b)
+	}
+	return result, rows.Err()
+}
+
+func (p *Postgres) UpdateBudget(b *budget.Budget) error {
+	query := `
+		UPDATE budgets
+		SET category = $1, period = $2, amount = $3
+		WHERE id = $4
+	`
+	ctx := context.Background()
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, b.Category, b.Period, b.Amount, b.ID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to update budget")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) DeleteBudget(id string) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
+		return err
+	}
+	ctx := context.Background()
+	query := `DELETE FROM budgets WHERE id = $1`
+	_, err = p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, uid)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to delete budget")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/change"
+	"salestracker/internal/domain/transaction"
+)
+
+// nextChangeSeq выдает следующий номер изменения. Строка счетчика остается заблокированной до конца tx,
+// поэтому конкурентные записи получают номера в порядке своих коммитов и клиент с курсором не пропустит строку
+func nextChangeSeq(ctx context.Context, tx *sql.Tx) (int64, error) {
+	var seq int64
+	err := tx.QueryRowContext(ctx, `UPDATE change_sequence SET value = value + 1 RETURNING value`).Scan(&seq)
+	return seq, err
+}
+
+// GetChanges возвращает актуальные версии транзакций и tombstones с номером больше since в порядке номеров
+func (p *Postgres) GetChanges(since int64, limit int) ([]change.Change, error) {
+	query := `
+		SELECT change_seq, FALSE AS deleted, id, transtype, category, amount, transdate, description, counterparty, reconciled
+		FROM transactions
+		WHERE change_seq > $1
+		UNION ALL
+		SELECT change_seq, TRUE AS deleted, id, NULL, NULL, NULL, NULL, NULL, NULL, NULL
+		FROM transaction_tombstones
+		WHERE change_seq > $1
+		ORDER BY change_seq
+		LIMIT $2
+	`
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, since, limit)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query changes")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []change.Change
+	for rows.Next() {
+		var (
+			c            change.Change
+			deleted      bool
+			trType       sql.NullString
+			category     sql.NullString
+			amount       sql.NullFloat64
+			date         sql.NullTime
+			description  sql.NullString
+			counterparty sql.NullString
+			reconciled   sql.NullBool
+		)
+		if err := rows.Scan(&c.Seq, &deleted, &c.ID, &trType, &category, &amount, &date, &description, &counterparty, &reconciled); err != nil {
+			return nil, err
+		}
+		if deleted {
+			c.Op = change.Delete
+		} else {
+			c.Op = change.Upsert
+			c.Transaction = &transaction.Transaction{
+				ID:           c.ID,
+				Type:         transaction.TransactionType(trType.String),
+				Category:     category.String,
+				Amount:       amount.Float64,
+				Date:         date.Time,
+				Description:  description.String,
+				Counterparty: counterparty.String,
+				Reconciled:   reconciled.Bool,
+			}
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+func insertTombstoneTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, seq int64) error {
+	query := `
+		INSERT INTO transaction_tombstones (id, change_seq)
+		VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET change_seq = EXCLUDED.change_seq, deleted_at = now()
+	`
+	_, err := tx.ExecContext(ctx, query, id, seq)
+	return err
+}
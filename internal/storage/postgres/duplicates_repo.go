@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/transaction"
+)
+
+func (p *Postgres) GetDismissedDuplicates() (map[string]bool, error) {
+	query := `SELECT first_id, second_id FROM dismissed_duplicates`
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query dismissed duplicates")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := map[string]bool{}
+	for rows.Next() {
+		var first, second uuid.UUID
+		if err := rows.Scan(&first, &second); err != nil {
+			return nil, err
+		}
+		result[transaction.PairKey(first, second)] = true
+	}
+	return result, rows.Err()
+}
+
+func (p *Postgres) DismissDuplicate(firstID, secondID uuid.UUID) error {
+	first, second := transaction.OrderedPair(firstID, secondID)
+	query := `
+		INSERT INTO dismissed_duplicates (first_id, second_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	ctx := context.Background()
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, first, second)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to dismiss duplicate")
+		return err
+	}
+	return nil
+}
+
+// MergeDuplicate обновляет оставляемую транзакцию и удаляет дубль в одной транзакции БД
+func (p *Postgres) MergeDuplicate(keep *transaction.Transaction, dropID uuid.UUID) error {
+	ctx := context.Background()
+	err := p.inTx(ctx, func(tx *sql.Tx) error {
+		if err := updateTransactionTx(ctx, tx, keep); err != nil {
+			return err
+		}
+		return deleteTransactionTx(ctx, tx, dropID)
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to merge duplicate")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/analytic"
+)
+
+// GetHistogram строит гистограмму сумм транзакций. Первый запрос дает итоги, минимум, максимум и для quantile —
+// квантили-границы; второй раскладывает транзакции по корзинам через width_bucket. Для log берутся только
+// положительные суммы: у нуля нет логарифма
+func (p *Postgres) GetHistogram(q analytic.HistogramQuery) (*analytic.Histogram, error) {
+	ctx := context.Background()
+
+	filter, args := filterConditions(q.Filter, []any{q.From, q.To})
+	if q.Bucketing == analytic.BucketLog {
+		filter += " AND amount > 0"
+	}
+
+	thresholdsExpr := "NULL::float8[]"
+	statsArgs := args
+	if q.Bucketing == analytic.BucketQuantile {
+		statsArgs = append(append([]any{}, args...), pq.Array(q.Quantiles()))
+		thresholdsExpr = fmt.Sprintf("percentile_cont($%d::float8[]) WITHIN GROUP (ORDER BY amount::float8)", len(statsArgs))
+	}
+	statsQuery := fmt.Sprintf(`
+	SELECT
+		COUNT(*),
+		COALESCE(SUM(amount), 0),
+		COALESCE(MIN(amount), 0),
+		COALESCE(MAX(amount), 0),
+		%s
+	FROM transactions
+	WHERE transdate >= $1 AND transdate <= $2%s;
+	`, thresholdsExpr, filter)
+
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, statsQuery, statsArgs...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error executing histogram stats query")
+		return nil, err
+	}
+	result := &analytic.Histogram{Bucketing: q.Bucketing, Buckets: []analytic.HistogramBucket{}}
+	var thresholds []float64
+	if err := row.Scan(&result.Count, &result.Sum, &result.Min, &result.Max, pq.Array(&thresholds)); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error scanning histogram stats row")
+		return nil, err
+	}
+	if result.Count == 0 {
+		return result, nil
+	}
+
+	var edges []float64
+	if q.Bucketing == analytic.BucketQuantile {
+		edges = analytic.QuantileEdges(thresholds, result.Max)
+	} else {
+		edges = analytic.HistogramEdges(q.Bucketing, q.Buckets, result.Min, result.Max)
+	}
+	result.Buckets = analytic.NewHistogramBuckets(edges)
+
+	// Границы передаются параметрами, те же, что в ответе: корзина в SQL и в JSON совпадает
+	var bucketExpr string
+	switch {
+	case result.Min == result.Max:
+		bucketExpr = "1"
+	case q.Bucketing == analytic.BucketQuantile:
+		args = append(args, pq.Array(edges[:len(edges)-1]))
+		bucketExpr = fmt.Sprintf("width_bucket(amount::float8, $%d::float8[])", len(args))
+	case q.Bucketing == analytic.BucketLog:
+		args = append(args, result.Min, result.Max)
+		bucketExpr = fmt.Sprintf("width_bucket(ln(amount::float8), ln($%d::float8), ln($%d::float8), %d)", len(args)-1, len(args), q.Buckets)
+	default:
+		args = append(args, result.Min, result.Max)
+		bucketExpr = fmt.Sprintf("width_bucket(amount::float8, $%d::float8, $%d::float8, %d)", len(args)-1, len(args), q.Buckets)
+	}
+	query := fmt.Sprintf(`
+	SELECT %s AS bucket, COUNT(*), SUM(amount)
+	FROM transactions
+	WHERE transdate >= $1 AND transdate <= $2%s
+	GROUP BY bucket
+	ORDER BY bucket;
+	`, bucketExpr, filter)
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error executing histogram query")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var bucket, count int
+		var sum float64
+		if err := rows.Scan(&bucket, &count, &sum); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Error scanning histogram row")
+			return nil, err
+		}
+		result.Add(bucket, count, sum)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/transaction"
+)
+
+// SaveImportedTransaction вставляет транзакцию из выписки; повторная загрузка той же операции пропускается по external_id
+func (p *Postgres) SaveImportedTransaction(tr *transaction.Transaction, externalID string) (bool, error) {
+	ctx := context.Background()
+	var created bool
+	err := p.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		created, err = insertTransactionTx(ctx, tx, tr, externalID)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Str("external_id", externalID).Msg("failed to insert imported transaction")
+		return false, err
+	}
+	return created, nil
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/event"
+	"strconv"
+	"time"
+)
+
+// eventsChannel — канал LISTEN/NOTIFY, в который при коммите приходят ID новых событий outbox
+const eventsChannel = "transaction_events"
+
+// insertOutboxEvent пишет событие в outbox внутри транзакции, изменяющей данные.
+// pg_notify доставляется слушателям только после коммита и в порядке коммитов.
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, evType event.Type, trID uuid.UUID, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO outbox_events (event_type, transaction_id, payload)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	var id int64
+	if err := tx.QueryRowContext(ctx, query, evType, trID, payload).Scan(&id); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, eventsChannel, strconv.FormatInt(id, 10))
+	return err
+}
+
+// GetRecentEvents возвращает последние limit событий outbox в порядке возрастания ID
+func (p *Postgres) GetRecentEvents(limit int) ([]event.Event, error) {
+	query := `
+		SELECT id, event_type, transaction_id, payload, created_at
+		FROM (
+			SELECT id, event_type, transaction_id, payload, created_at
+			FROM outbox_events
+			ORDER BY id DESC
+			LIMIT $1
+		) recent
+		ORDER BY id
+	`
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, limit)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query recent outbox events")
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+// ListenEvents подписывается на NOTIFY и отдает события outbox по мере коммита.
+// После переподключения слушателя догружает события, пришедшие за время разрыва.
+func (p *Postgres) ListenEvents(ctx context.Context) (<-chan event.Event, error) {
+	listener := pq.NewListener(p.dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			wbzlog.Logger.Warn().Err(err).Msg("outbox listener connection event")
+		}
+	})
+	if err := listener.Listen(eventsChannel); err != nil {
+		_ = listener.Close()
+		wbzlog.Logger.Error().Err(err).Msg("failed to listen for outbox events")
+		return nil, err
+	}
+
+	out := make(chan event.Event, 64)
+	go func() {
+		defer close(out)
+		defer func() {
+			_ = listener.Close()
+		}()
+
+		var lastID int64
+		emit := func(evs []event.Event) bool {
+			for _, ev := range evs {
+				if ev.ID > lastID {
+					lastID = ev.ID
+				}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-listener.Notify:
+				var evs []event.Event
+				var err error
+				if n == nil {
+					// nil означает переподключение: уведомления за время разрыва потеряны
+					evs, err = p.getEventsAfter(ctx, lastID)
+				} else {
+					var id int64
+					id, err = strconv.ParseInt(n.Extra, 10, 64)
+					if err == nil {
+						evs, err = p.getEventsByID(ctx, id)
+					}
+				}
+				if err != nil {
+					wbzlog.Logger.Error().Err(err).Msg("failed to load notified outbox events")
+					continue
+				}
+				if !emit(evs) {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (p *Postgres) getEventsByID(ctx context.Context, id int64) ([]event.Event, error) {
+	query := `
+		SELECT id, event_type, transaction_id, payload, created_at
+		FROM outbox_events
+		WHERE id = $1
+	`
+	// Читаем с мастера: реплика может еще не получить только что закоммиченное событие
+	rows, err := p.db.Master.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+func (p *Postgres) getEventsAfter(ctx context.Context, afterID int64) ([]event.Event, error) {
+	if afterID == 0 {
+		return nil, nil
+	}
+	query := `
+		SELECT id, event_type, transaction_id, payload, created_at
+		FROM outbox_events
+		WHERE id > $1
+		ORDER BY id
+	`
+	rows, err := p.db.Master.QueryContext(ctx, query, afterID)
+	if err != nil {
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+func scanEvents(rows *sql.Rows) ([]event.Event, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+	var result []event.Event
+	for rows.Next() {
+		var ev event.Event
+		if err := rows.Scan(&ev.ID, &ev.Type, &ev.TransactionID, &ev.Data, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, ev)
+	}
+	return result, rows.Err()
+}
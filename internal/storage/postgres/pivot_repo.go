@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/analytic"
+	"slices"
+	"strings"
+)
+
+// dimensionExpr возвращает SQL-выражение измерения сводной таблицы; период считается в часовом поясе запроса ($3)
+func dimensionExpr(d analytic.Dimension, period analytic.GroupBy) string {
+	switch d {
+	case analytic.DimCategory:
+		return "category"
+	case analytic.DimType:
+		return "transtype"
+	case analytic.DimCounterparty:
+		return "counterparty"
+	}
+	return fmt.Sprintf("to_char(transdate AT TIME ZONE $3::text, '%s')", timeGroupings[period].format)
+}
+
+// GetPivot строит сводную таблицу одним запросом: GROUPING SETS дает ячейки, итоги строк, итоги колонок
+// и общий итог, а GROUPING(...) отличает свернутое измерение от пустого значения
+func (p *Postgres) GetPivot(q analytic.PivotQuery) (*analytic.Pivot, error) {
+	ctx := context.Background()
+
+	dims := q.Dimensions()
+	args := []any{q.From, q.To}
+	if slices.Contains(dims, analytic.DimPeriod) {
+		args = append(args, q.Location.String())
+	}
+	filter, args := filterConditions(q.Filter, args)
+
+	selects := make([]string, len(dims))
+	names := make([]string, len(dims))
+	for i, d := range dims {
+		names[i] = fmt.Sprintf("d%d", i)
+		selects[i] = fmt.Sprintf("%s AS d%d", dimensionExpr(d, q.Period), i)
+	}
+	rowNames, colNames := names[:len(q.Rows)], names[len(q.Rows):]
+
+	var sets []string
+	seen := map[string]bool{}
+	for _, set := range [][]string{names, rowNames, colNames, nil} {
+		s := "(" + strings.Join(set, ", ") + ")"
+		if !seen[s] {
+			seen[s] = true
+			sets = append(sets, s)
+		}
+	}
+
+	query := fmt.Sprintf(`
+	WITH base AS (
+	SELECT %s, %s AS value
+	FROM transactions
+	WHERE transdate >= $1 AND transdate <= $2%s
+	)
+	SELECT %s, GROUPING(%s) AS grp, %s
+	FROM base
+	GROUP BY GROUPING SETS (%s);
+	`, strings.Join(selects, ", "), valueExpr(q.Mode), filter, strings.Join(names, ", "), strings.Join(names, ", "),
+		metricColumns(q.Metrics, "value", "", "m"), strings.Join(sets, ", "))
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error executing pivot query")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := &analytic.Pivot{
+		Rows: q.Rows, Columns: q.Columns, Metrics: q.Metrics,
+		Cells: []analytic.PivotCell{}, RowTotals: []analytic.PivotCell{}, ColumnTotals: []analytic.PivotCell{},
+		GrandTotal: map[analytic.Metric]float64{},
+	}
+	keys := make([]*string, len(dims))
+	values := make([]float64, len(q.Metrics))
+	for rows.Next() {
+		var grp int
+		dest := make([]any, 0, len(dims)+1+len(values))
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		dest = append(dest, &grp)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Error scanning pivot row")
+			return nil, err
+		}
+
+		// Бит GROUPING старшего измерения — самый старший
+		aggregated := make([]bool, len(dims))
+		cellKeys := make([]string, len(dims))
+		for i := range dims {
+			aggregated[i] = grp&(1<<(len(dims)-1-i)) != 0
+			if keys[i] != nil {
+				cellKeys[i] = *keys[i]
+			}
+		}
+		cellValues := make(map[analytic.Metric]float64, len(values))
+		for i, m := range q.Metrics {
+			cellValues[m] = values[i]
+		}
+		for _, level := range result.Levels(aggregated) {
+			result.Add(level, cellKeys, cellValues)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result.Sort()
+	return result, nil
+}
@@ -1,8 +1,11 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	wbdb "github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/retry"
 	wbzlog "github.com/wb-go/wbf/zlog"
 	"salestracker/internal/config"
 )
@@ -10,6 +13,7 @@ import (
 type Postgres struct {
 	db  *wbdb.DB
 	cfg *config.RetrysConfig
+	dsn string
 }
 
 func NewPostgres(cfg *config.AppConfig) (*Postgres, error) {
@@ -44,7 +48,7 @@ func NewPostgres(cfg *config.AppConfig) (*Postgres, error) {
 		return nil, err
 	}
 	wbzlog.Logger.Info().Msg("Connected to Postgres")
-	return &Postgres{db: db, cfg: &cfg.RetrysConfig}, nil
+	return &Postgres{db: db, cfg: &cfg.RetrysConfig, dsn: masterDSN}, nil
 }
 
 func (p *Postgres) Close() error {
@@ -64,3 +68,18 @@ func (p *Postgres) Close() error {
 	}
 	return nil
 }
+
+// inTx выполняет fn в транзакции на мастере; при ошибке транзакция целиком повторяется по стратегии ретраев
+func (p *Postgres) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return retry.Do(func() error {
+		tx, err := p.db.Master.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	}, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs})
+}
@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/event"
+	"salestracker/internal/domain/reconciliation"
+	"salestracker/internal/domain/transaction"
+)
+
+// SaveSession сохраняет сессию вместе со строками выписки и проставляет строкам ID
+func (p *Postgres) SaveSession(s *reconciliation.Session, lines []*reconciliation.BankLine) error {
+	sessionQuery := `
+		INSERT INTO reconciliation_sessions (id, name, format, period_from, period_to, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	lineQuery := `
+		INSERT INTO reconciliation_lines (session_id, external_id, transtype, amount, transdate, description)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	ctx := context.Background()
+	err := p.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, sessionQuery, s.ID, s.Name, s.Format, s.From, s.To, s.Status, s.CreatedAt); err != nil {
+			return err
+		}
+		for _, l := range lines {
+			if err := tx.QueryRowContext(ctx, lineQuery, s.ID, l.ExternalID, l.Type, l.Amount, l.Date, l.Description).Scan(&l.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert reconciliation session")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetSession(id string) (*reconciliation.Session, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
+		return nil, err
+	}
+	query := `
+		SELECT id, name, format, period_from, period_to, status, created_at, closed_at
+		FROM reconciliation_sessions
+		WHERE id = $1
+	`
+	ctx := context.Background()
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, uid)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query reconciliation session by id")
+		return nil, err
+	}
+	var s reconciliation.Session
+	if err := row.Scan(&s.ID, &s.Name, &s.Format, &s.From, &s.To, &s.Status, &s.CreatedAt, &s.ClosedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wbzlog.Logger.Error().Err(err).Msg("failed to get reconciliation session by id")
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (p *Postgres) GetSessions() ([]*reconciliation.Session, error) {
+	query := `
+		SELECT id, name, format, period_from, period_to, status, created_at, closed_at
+		FROM reconciliation_sessions
+		ORDER BY created_at DESC
+	`
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query reconciliation sessions")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*reconciliation.Session
+	for rows.Next() {
+		var s reconciliation.Session
+		if err := rows.Scan(&s.ID, &s.Name, &s.Format, &s.From, &s.To, &s.Status, &s.CreatedAt, &s.ClosedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, &s)
+	}
+	return result, rows.Err()
+}
+
+func (p *Postgres) GetBankLines(sessionID uuid.UUID) ([]*reconciliation.BankLine, error) {
+	query := `
+		SELECT id, session_id, external_id, transtype, amount, transdate, description, transaction_id, COALESCE(match_type, ''), score
+		FROM reconciliation_lines
+		WHERE session_id = $1
+		ORDER BY transdate, id
+	`
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, sessionID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query reconciliation lines")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*reconciliation.BankLine
+	for rows.Next() {
+		var l reconciliation.BankLine
+		if err := rows.Scan(&l.ID, &l.SessionID, &l.ExternalID, &l.Type, &l.Amount, &l.Date, &l.Description, &l.TransactionID, &l.MatchType, &l.Score); err != nil {
+			return nil, err
+		}
+		result = append(result, &l)
+	}
+	return result, rows.Err()
+}
+
+// SaveMatches сохраняет сопоставления строк выписки (пустой TransactionID снимает сопоставление)
+// GetMatchedElsewhere возвращает транзакции, уже сопоставленные строкам других открытых сессий сверки
+func (p *Postgres) GetMatchedElsewhere(sessionID uuid.UUID) (map[uuid.UUID]bool, error) {
+	query := `
+		SELECT DISTINCT l.transaction_id
+		FROM reconciliation_lines l
+		JOIN reconciliation_sessions s ON s.id = l.session_id
+		WHERE l.session_id <> $1 AND s.status = $2 AND l.transaction_id IS NOT NULL
+	`
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, sessionID, reconciliation.Open)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query transactions matched in other sessions")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		result[id] = true
+	}
+	return result, rows.Err()
+}
+
+func (p *Postgres) SaveMatches(lines []*reconciliation.BankLine) error {
+	query := `
+		UPDATE reconciliation_lines
+		SET transaction_id = $1, match_type = NULLIF($2, ''), score = $3
+		WHERE id = $4
+	`
+	ctx := context.Background()
+	err := p.inTx(ctx, func(tx *sql.Tx) error {
+		// Сначала снимаем старые сопоставления, чтобы перестановка пар не упиралась в уникальность (session_id, transaction_id)
+		for _, l := range lines {
+			if _, err := tx.ExecContext(ctx, `UPDATE reconciliation_lines SET transaction_id = NULL WHERE id = $1`, l.ID); err != nil {
+				return err
+			}
+		}
+		for _, l := range lines {
+			if _, err := tx.ExecContext(ctx, query, l.TransactionID, l.MatchType, l.Score, l.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to save reconciliation matches")
+		return err
+	}
+	return nil
+}
+
+// CloseSession закрывает сессию и в той же транзакции помечает сопоставленные транзакции сверенными
+func (p *Postgres) CloseSession(s *reconciliation.Session, transactionIDs []uuid.UUID) error {
+	ctx := context.Background()
+	err := p.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE reconciliation_sessions
+			SET status = $1, closed_at = now()
+			WHERE id = $2 AND status = $3
+		`, reconciliation.Closed, s.ID, reconciliation.Open)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			if err != nil {
+				return err
+			}
+			return reconciliation.ErrSessionClosed
+		}
+		for _, id := range transactionIDs {
+			if err := markReconciledTx(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to close reconciliation session")
+		return err
+	}
+	return nil
+}
+
+// markReconciledTx ставит флаг сверки как обычное изменение транзакции: с новым номером в ленте изменений и событием в outbox
+func markReconciledTx(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	seq, err := nextChangeSeq(ctx, tx)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE transactions
+		SET reconciled = TRUE, change_seq = $1
+		WHERE id = $2 AND NOT reconciled
+		RETURNING id, transtype, category, amount, transdate, description, counterparty, reconciled
+	`
+	var tr transaction.Transaction
+	err = tx.QueryRowContext(ctx, query, seq, id).Scan(&tr.ID, &tr.Type, &tr.Category, &tr.Amount, &tr.Date, &tr.Description, &tr.Counterparty, &tr.Reconciled)
+	if err == sql.ErrNoRows {
+		// Транзакция удалена или уже сверена в другой сессии
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	prev := tr
+	prev.Reconciled = false
+	return insertOutboxEvent(ctx, tx, event.TransactionUpdated, tr.ID, event.Update{Transaction: tr, Previous: &prev})
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/analytic"
+)
+
+// seasonalityAxes — поля EXTRACT строк и колонок матрицы сезонности
+var seasonalityAxes = map[analytic.SeasonalityLayout][2]string{
+	analytic.LayoutWeekdayHour:  {"ISODOW", "HOUR"},
+	analytic.LayoutMonthWeekday: {"MONTH", "ISODOW"},
+}
+
+// GetSeasonality считает число и сумму транзакций в каждой ячейке матрицы; час, день недели и месяц берутся
+// в часовом поясе запроса ($3). Мера ячеек и итогов считается из них в Seasonality.Finish
+func (p *Postgres) GetSeasonality(q analytic.SeasonalityQuery) (*analytic.Seasonality, error) {
+	ctx := context.Background()
+
+	axes := seasonalityAxes[q.Layout]
+	filter, args := filterConditions(q.Filter, []any{q.From, q.To, q.Location.String()})
+	query := fmt.Sprintf(`
+	SELECT
+		EXTRACT(%s FROM transdate AT TIME ZONE $3::text)::int AS r,
+		EXTRACT(%s FROM transdate AT TIME ZONE $3::text)::int AS c,
+		COUNT(*),
+		SUM(amount)
+	FROM transactions
+	WHERE transdate >= $1 AND transdate <= $2%s
+	GROUP BY r, c;
+	`, axes[0], axes[1], filter)
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Error executing seasonality query")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := analytic.NewSeasonality(q)
+	for rows.Next() {
+		var r, c, count int
+		var sum float64
+		if err := rows.Scan(&r, &c, &count, &sum); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Error scanning seasonality row")
+			return nil, err
+		}
+		result.Add(r, c, count, sum)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result.Finish()
+	return result, nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"fmt"
+	wbdb "github.com/wb-go/wbf/dbpg"
+	"os"
+	"path/filepath"
+	"salestracker/internal/config"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDSNEnv — переменная окружения с DSN локального Postgres для интеграционных тестов репозитория
+const testDSNEnv = "SALESTRACKER_TEST_DSN"
+
+// newTestPostgres поднимает все миграции в отдельной схеме и возвращает Postgres, который работает только с ней.
+// Без SALESTRACKER_TEST_DSN тест пропускается
+func newTestPostgres(t *testing.T) *Postgres {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set, skipping Postgres integration test", testDSNEnv)
+	}
+
+	admin, err := wbdb.New(dsn, nil, nil)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Master.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Master.Exec("DROP SCHEMA " + schema + " CASCADE")
+		_ = admin.Master.Close()
+	})
+
+	db, err := wbdb.New(withSearchPath(dsn, schema), nil, &wbdb.Options{MaxOpenConns: 4})
+	if err != nil {
+		t.Fatalf("connect to schema: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Master.Close() })
+
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("migrations not found: %v", err)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		sqlText, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Master.Exec(string(sqlText)); err != nil {
+			t.Fatalf("apply %s: %v", filepath.Base(f), err)
+		}
+	}
+
+	return &Postgres{db: db, cfg: &config.RetrysConfig{Attempts: 1}}
+}
+
+// withSearchPath добавляет search_path к DSN в любом из форматов lib/pq: URL или key=value
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		return dsn + sep + "search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
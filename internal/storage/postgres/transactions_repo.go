@@ -5,19 +5,21 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/wb-go/wbf/retry"
 	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/event"
 	"salestracker/internal/domain/transaction"
+	"strings"
 	"time"
 )
 
 func (p *Postgres) SaveTransaction(tr *transaction.Transaction) error {
-	query := `
-		INSERT INTO transactions (id, transtype, category, amount, transdate, description)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
 	ctx := context.Background()
-	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, tr.ID, tr.Type, tr.Category, tr.Amount, tr.Date, tr.Description)
+	err := p.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := insertTransactionTx(ctx, tx, tr, "")
+		return err
+	})
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("failed to insert transaction")
 		return err
@@ -33,7 +35,7 @@ func (p *Postgres) GetTransaction(id string) (*transaction.Transaction, error) {
 	}
 
 	query := `
-		SELECT id, transtype, category, amount, transdate, description
+		SELECT id, transtype, category, amount, transdate, description, counterparty, reconciled
 		FROM transactions
 		WHERE id = $1
 	`
@@ -44,7 +46,7 @@ func (p *Postgres) GetTransaction(id string) (*transaction.Transaction, error) {
 		return nil, err
 	}
 	var tr transaction.Transaction
-	err = row.Scan(&tr.ID, &tr.Type, &tr.Category, &tr.Amount, &tr.Date, &tr.Description)
+	err = row.Scan(&tr.ID, &tr.Type, &tr.Category, &tr.Amount, &tr.Date, &tr.Description, &tr.Counterparty, &tr.Reconciled)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -58,11 +60,11 @@ func (p *Postgres) GetTransaction(id string) (*transaction.Transaction, error) {
 
 func (p *Postgres) GetAllTransactions(
 	from, to time.Time,
-	trtype, category, sortBy, sortDir string,
+	f transaction.Filter, sortBy, sortDir string,
 ) ([]*transaction.Transaction, error) {
 
 	query := `
-		SELECT id, transtype, category, amount, transdate, description
+		SELECT id, transtype, category, amount, transdate, description, counterparty, reconciled
 		FROM transactions
 		WHERE 1=1
 	`
@@ -78,19 +80,11 @@ func (p *Postgres) GetAllTransactions(
 	if !to.IsZero() {
 		query += fmt.Sprintf(" AND transdate <= $%d", argIndex)
 		args = append(args, to)
-		argIndex++
 	}
 
-	if trtype != "" {
-		query += fmt.Sprintf(" AND transtype = $%d", argIndex)
-		args = append(args, trtype)
-		argIndex++
-	}
-
-	if category != "" {
-		query += fmt.Sprintf(" AND category = $%d", argIndex)
-		args = append(args, category)
-	}
+	var cond string
+	cond, args = filterConditions(f, args)
+	query += cond
 
 	if sortBy != "" {
 		if sortBy == "type" {
@@ -134,7 +128,7 @@ func (p *Postgres) GetAllTransactions(
 	var result []*transaction.Transaction
 	for rows.Next() {
 		var tr transaction.Transaction
-		if err := rows.Scan(&tr.ID, &tr.Type, &tr.Category, &tr.Amount, &tr.Date, &tr.Description); err != nil {
+		if err := rows.Scan(&tr.ID, &tr.Type, &tr.Category, &tr.Amount, &tr.Date, &tr.Description, &tr.Counterparty, &tr.Reconciled); err != nil {
 			return nil, err
 		}
 		result = append(result, &tr)
@@ -144,13 +138,10 @@ func (p *Postgres) GetAllTransactions(
 }
 
 func (p *Postgres) UpdateTransaction(tr *transaction.Transaction) error {
-	query := `
-		UPDATE transactions
-		SET transtype = $1, category = $2, amount = $3, transdate = $4, description = $5
-		WHERE id = $6
-	`
 	ctx := context.Background()
-	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, tr.Type, tr.Category, tr.Amount, tr.Date, tr.Description, tr.ID)
+	err := p.inTx(ctx, func(tx *sql.Tx) error {
+		return updateTransactionTx(ctx, tx, tr)
+	})
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("failed to update transaction")
 		return err
@@ -165,11 +156,128 @@ func (p *Postgres) DeleteTransaction(id string) error {
 		return err
 	}
 	ctx := context.Background()
-	query := `DELETE FROM transactions WHERE id = $1`
-	_, err = p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, uid)
+	err = p.inTx(ctx, func(tx *sql.Tx) error {
+		return deleteTransactionTx(ctx, tx, uid)
+	})
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("failed to delete transaction")
 		return err
 	}
 	return nil
 }
+
+// insertTransactionTx вставляет транзакцию с новым номером изменения и пишет событие в outbox.
+// Непустой externalID делает вставку идемпотентной: если такая операция уже есть, возвращается false
+func insertTransactionTx(ctx context.Context, tx *sql.Tx, tr *transaction.Transaction, externalID string) (bool, error) {
+	seq, err := nextChangeSeq(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	query := `
+		INSERT INTO transactions (id, transtype, category, amount, transdate, description, counterparty, change_seq, external_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (external_id) DO NOTHING
+	`
+	extID := sql.NullString{String: externalID, Valid: externalID != ""}
+	res, err := tx.ExecContext(ctx, query, tr.ID, tr.Type, tr.Category, tr.Amount, tr.Date, tr.Description, tr.Counterparty, seq, extID)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	return true, insertOutboxEvent(ctx, tx, event.TransactionCreated, tr.ID, tr)
+}
+
+// updateTransactionTx сохраняет транзакцию; событие outbox несет и ее прежнее состояние, чтобы подписчики
+// с фильтром узнали, что транзакция из него вышла
+func updateTransactionTx(ctx context.Context, tx *sql.Tx, tr *transaction.Transaction) error {
+	prevQuery := `
+		SELECT id, transtype, category, amount, transdate, description, counterparty, reconciled
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE
+	`
+	var prev transaction.Transaction
+	err := tx.QueryRowContext(ctx, prevQuery, tr.ID).Scan(&prev.ID, &prev.Type, &prev.Category, &prev.Amount, &prev.Date, &prev.Description, &prev.Counterparty, &prev.Reconciled)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	seq, err := nextChangeSeq(ctx, tx)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE transactions
+		SET transtype = $1, category = $2, amount = $3, transdate = $4, description = $5, counterparty = $6, reconciled = $7, change_seq = $8
+		WHERE id = $9
+	`
+	res, err := tx.ExecContext(ctx, query, tr.Type, tr.Category, tr.Amount, tr.Date, tr.Description, tr.Counterparty, tr.Reconciled, seq, tr.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	return insertOutboxEvent(ctx, tx, event.TransactionUpdated, tr.ID, event.Update{Transaction: *tr, Previous: &prev})
+}
+
+func deleteTransactionTx(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	query := `
+		DELETE FROM transactions
+		WHERE id = $1
+		RETURNING id, transtype, category, amount, transdate, description, counterparty, reconciled
+	`
+	var tr transaction.Transaction
+	err := tx.QueryRowContext(ctx, query, id).Scan(&tr.ID, &tr.Type, &tr.Category, &tr.Amount, &tr.Date, &tr.Description, &tr.Counterparty, &tr.Reconciled)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	seq, err := nextChangeSeq(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if err := insertTombstoneTx(ctx, tx, id, seq); err != nil {
+		return err
+	}
+	return insertOutboxEvent(ctx, tx, event.TransactionDeleted, id, &tr)
+}
+
+// filterConditions дописывает к запросу условия фильтра вида " AND ...", нумеруя параметры после уже занятых args.
+// Один и тот же фильтр так одинаково применяется к списку, экспорту и обоим запросам аналитики
+func filterConditions(f transaction.Filter, args []any) (string, []any) {
+	var b strings.Builder
+	add := func(format string, v any) {
+		args = append(args, v)
+		fmt.Fprintf(&b, " AND "+format, len(args))
+	}
+
+	if f.Type != "" {
+		add("transtype = $%d", string(f.Type))
+	}
+	if len(f.Categories) > 0 {
+		add("category = ANY($%d)", pq.Array(f.Categories))
+	}
+	if f.MinAmount != nil {
+		add("amount >= $%d", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		add("amount <= $%d", *f.MaxAmount)
+	}
+	if f.Search != "" {
+		add("description ILIKE '%%' || $%d || '%%'", likeEscaper.Replace(f.Search))
+	}
+	if f.Counterparty != "" {
+		add("counterparty = $%d", f.Counterparty)
+	}
+	return b.String(), args
+}
+
+// likeEscaper экранирует спецсимволы LIKE, чтобы поиск шел по подстроке буквально
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,220 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"salestracker/internal/domain/event"
+	"salestracker/internal/domain/webhook"
+	"time"
+)
+
+func (p *Postgres) SaveSubscription(s *webhook.Subscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (id, url, secret, events, active, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	ctx := context.Background()
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		s.ID, s.URL, s.Secret, pq.Array(s.Events), s.Active, s.CreatedAt)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert webhook subscription")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetSubscriptions() ([]*webhook.Subscription, error) {
+	query := `
+		SELECT id, url, secret, events, active, created_at
+		FROM webhook_subscriptions
+		ORDER BY created_at
+	`
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query webhook subscriptions")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*webhook.Subscription
+	for rows.Next() {
+		var s webhook.Subscription
+		var events []string
+		if err := rows.Scan(&s.ID, &s.URL, &s.Secret, pq.Array(&events), &s.Active, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.Events = make([]event.Type, 0, len(events))
+		for _, e := range events {
+			s.Events = append(s.Events, event.Type(e))
+		}
+		result = append(result, &s)
+	}
+	return result, rows.Err()
+}
+
+func (p *Postgres) DeleteSubscription(id string) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		wbzlog.Logger.Warn().Str("id", id).Msg("invalid uuid")
+		return err
+	}
+	ctx := context.Background()
+	_, err = p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, `DELETE FROM webhook_subscriptions WHERE id = $1`, uid)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to delete webhook subscription")
+		return err
+	}
+	return nil
+}
+
+// FanOutEvents создает доставки по активным подпискам для еще не разосланных событий outbox
+// и помечает эти события разосланными — одним атомарным запросом
+func (p *Postgres) FanOutEvents(limit int) (int64, error) {
+	query := `
+	WITH ev AS (
+		SELECT id, event_type
+		FROM outbox_events
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	),
+	ins AS (
+		INSERT INTO webhook_deliveries (subscription_id, event_id, status, next_attempt_at)
+		SELECT s.id, ev.id, $2, now()
+		FROM ev
+		JOIN webhook_subscriptions s
+		  ON s.active AND (cardinality(s.events) = 0 OR ev.event_type = ANY(s.events))
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	)
+	UPDATE outbox_events o
+	SET dispatched_at = now()
+	FROM ev
+	WHERE o.id = ev.id
+	`
+	ctx := context.Background()
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, limit, webhook.Pending)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to fan out outbox events")
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ClaimDueDeliveries забирает доставки, время которых пришло, и сдвигает их next_attempt_at на lease,
+// чтобы параллельный диспетчер не отправил их повторно, пока идет попытка
+func (p *Postgres) ClaimDueDeliveries(limit int, lease time.Duration) ([]*webhook.Job, error) {
+	query := `
+	WITH due AS (
+		SELECT id
+		FROM webhook_deliveries
+		WHERE status IN ($1, $2) AND next_attempt_at <= now()
+		ORDER BY next_attempt_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE webhook_deliveries d
+	SET next_attempt_at = now() + make_interval(secs => $4)
+	FROM due, webhook_subscriptions s, outbox_events e
+	WHERE d.id = due.id AND s.id = d.subscription_id AND e.id = d.event_id
+	RETURNING d.id, d.subscription_id, d.event_id, d.status, d.attempts, d.created_at,
+		s.url, s.secret,
+		e.event_type, e.transaction_id, e.payload, e.created_at
+	`
+	ctx := context.Background()
+	var jobs []*webhook.Job
+	err := p.inTx(ctx, func(tx *sql.Tx) error {
+		jobs = nil
+		rows, err := tx.QueryContext(ctx, query, webhook.Pending, webhook.Failed, limit, lease.Seconds())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = rows.Close()
+		}()
+		for rows.Next() {
+			var j webhook.Job
+			if err := rows.Scan(&j.Delivery.ID, &j.Delivery.SubscriptionID, &j.Delivery.EventID, &j.Delivery.Status, &j.Delivery.Attempts, &j.Delivery.CreatedAt,
+				&j.URL, &j.Secret,
+				&j.Event.Type, &j.Event.TransactionID, &j.Event.Data, &j.Event.CreatedAt); err != nil {
+				return err
+			}
+			j.Event.ID = j.Delivery.EventID
+			j.Delivery.EventType = j.Event.Type
+			jobs = append(jobs, &j)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim webhook deliveries")
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (p *Postgres) RecordDeliveryAttempt(d *webhook.Delivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, response_code = $5, updated_at = now()
+		WHERE id = $6
+	`
+	ctx := context.Background()
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseCode, d.ID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to record webhook delivery attempt")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetDeliveries(subscriptionID, status string, limit int) ([]*webhook.Delivery, error) {
+	uid, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		wbzlog.Logger.Warn().Str("id", subscriptionID).Msg("invalid uuid")
+		return nil, err
+	}
+
+	query := `
+		SELECT d.id, d.subscription_id, d.event_id, e.event_type, d.status, d.attempts, d.next_attempt_at,
+			COALESCE(d.last_error, ''), COALESCE(d.response_code, 0), d.created_at, d.updated_at
+		FROM webhook_deliveries d
+		JOIN outbox_events e ON e.id = d.event_id
+		WHERE d.subscription_id = $1
+	`
+	args := []any{uid}
+	if status != "" {
+		query += " AND d.status = $2"
+		args = append(args, status)
+	}
+	query += fmt.Sprintf(" ORDER BY d.id DESC LIMIT %d", limit)
+
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to query webhook deliveries")
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*webhook.Delivery
+	for rows.Next() {
+		var d webhook.Delivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastError, &d.ResponseCode, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, &d)
+	}
+	return result, rows.Err()
+}
@@ -1,27 +1,115 @@
 package dto
 
+import "salestracker/internal/domain/transaction"
+
 type AnalyticsReq struct {
 	From    string `json:"from"`
 	To      string `json:"to"`
-	GroupBy string `json:"groupBy"` // day|week|month|category|none
+	GroupBy string `json:"groupBy"` // hour|day|week|month|quarter|year|category|none
 	SplitBy string `json:"splitBy"` // type|category|none
-	SortBy  string `json:"sortBy"`  // sum|avg|count|median|percentile90
+	SortBy  string `json:"sortBy"`  // одна из метрик
 	SortDir string `json:"sortDir"` // asc|desc
+	Fill    string `json:"fill"`    // zero|null|previous, пусто — без заполнения
+	Mode    string `json:"mode"`    // signed|absolute
+	Metrics string `json:"metrics"` // sum,avg,count,min,max,stddev,variance,median,percentile90,pNN
+
+	Cumulative  string `json:"cumulative"` // true|false
+	Rolling     string `json:"rolling"`    // 7d,30d
+	Compare     string `json:"compare"`    // previous|yoy|custom
+	CompareFrom string `json:"compareFrom"`
+	CompareTo   string `json:"compareTo"`
+	Top         string `json:"top"`
+	TopBy       string `json:"topBy"` // одна из метрик, по умолчанию sum
+}
+
+type ForecastReq struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	GroupBy string `json:"groupBy"` // day|week|month
+	Horizon string `json:"horizon"`
+	Level   string `json:"level"` // 0..1
+}
+
+type PivotReq struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Rows    string `json:"rows"`    // period,category,type,counterparty
+	Columns string `json:"columns"` // period,category,type,counterparty
+	Period  string `json:"period"`  // hour|day|week|month|quarter|year
+	Metrics string `json:"metrics"` // sum,avg,count,min,max,stddev,variance,median,percentile90,pNN
+	Mode    string `json:"mode"`    // signed|absolute
+}
+
+type HistogramReq struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Buckets   string `json:"buckets"`
+	Bucketing string `json:"bucketing"` // fixed|log|quantile
+}
+
+type SeasonalityReq struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Layout  string `json:"layout"`  // weekday_hour|month_weekday
+	Measure string `json:"measure"` // count|sum|avg
+}
+
+type AnomaliesReq struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Kind         string `json:"kind"` // transaction|day, пусто — обе
+	Threshold    string `json:"threshold"`
+	Acknowledged string `json:"acknowledged"` // true — вместе с подтвержденными
 }
 
+type AckAnomalyReq struct {
+	Note string `json:"note"`
+}
+
+// GetTransactionReq — период и сортировка списка; фильтры разбирает parseTransactionFilter
 type GetTransactionReq struct {
-	From     string `json:"from"`
-	To       string `json:"to"`
-	Type     string `json:"type"` // income|expense|all
-	Category string `json:"category"`
-	SortBy   string `json:"sortBy"`  // id|type|category|amount|date
-	SortDir  string `json:"sortDir"` // asc|desc
+	From    string `json:"from"`
+	To      string `json:"to"`
+	SortBy  string `json:"sortBy"`  // id|type|category|amount|date
+	SortDir string `json:"sortDir"` // asc|desc
 }
 
 type SaveTransactionReq struct {
 	Type        string  `json:"type"` // income|expense
 	Category    string  `json:"category"`
 	Amount      float64 `json:"amount"`
-	Date        string  `json:"date"`
+	Date        string  `json:"date"` // YYYY-MM-DD или RFC 3339
 	Description string  `json:"description"`
 }
+
+type CreateTransactionResp struct {
+	*transaction.Transaction
+	PossibleDuplicates []*transaction.DuplicatePair `json:"PossibleDuplicates,omitempty"`
+}
+
+type ResolveDuplicateReq struct {
+	KeepID string `json:"keepId"`
+	DropID string `json:"dropId"`
+	Action string `json:"action"` // merge|dismiss
+}
+
+type SaveBudgetReq struct {
+	Category string  `json:"category"`
+	Period   string  `json:"period"` // month|quarter|year
+	Amount   float64 `json:"amount"`
+}
+
+type SaveWebhookReq struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"` // если пусто — будет сгенерирован
+	Events []string `json:"events"` // transaction.created|transaction.updated|transaction.deleted, пусто — все
+}
+
+type MatchLineReq struct {
+	LineID        int64  `json:"lineId"`
+	TransactionID string `json:"transactionId"`
+}
+
+type UnmatchLineReq struct {
+	LineID int64 `json:"lineId"`
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	wbgin "github.com/wb-go/wbf/ginext"
 	"io"
 	"net/http"
 	"salestracker/internal/domain/analytic"
 	"salestracker/internal/web/dto"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,8 +19,14 @@ type AnalyticsHandler struct {
 
 // AnalyticsIFace описывает интерфейс сервиса аналитики
 type AnalyticsIFace interface {
-	GetAnalytics(from, to time.Time, groupBy, splitBy, sortBy, sortDir string) (*analytic.Analytics, error)
-	GetCSV(from, to time.Time, groupBy, splitBy, sortBy, sortDir string, output io.Writer) error
+	GetAnalytics(q analytic.Query) (*analytic.Analytics, error)
+	GetCSV(q analytic.Query, output io.Writer) error
+	Forecast(q analytic.ForecastQuery) (*analytic.Forecast, error)
+	GetPivot(q analytic.PivotQuery) (*analytic.Pivot, error)
+	GetPivotCSV(q analytic.PivotQuery, output io.Writer) error
+	GetHistogram(q analytic.HistogramQuery) (*analytic.Histogram, error)
+	GetSeasonality(q analytic.SeasonalityQuery) (*analytic.Seasonality, error)
+	GetSeasonalityCSV(q analytic.SeasonalityQuery, output io.Writer) error
 }
 
 // NewAnalyticHandler создает новый AnalyticsHandler
@@ -32,46 +41,300 @@ func NewAnalyticHandler(service AnalyticsIFace) *AnalyticsHandler {
 // @Description Возвращает агрегированные данные транзакций за указанный период с возможностью группировки, разделения и сортировки
 // @Tags Analytics
 // @Produce json
-// @Param from query string true "Дата начала (YYYY-MM-DD)"
-// @Param to query string true "Дата конца (YYYY-MM-DD)"
-// @Param groupby query string false "Группировка (day/week/month/category)"
+// @Param from query string true "Начало периода (YYYY-MM-DD или RFC 3339)"
+// @Param to query string true "Конец периода включительно (YYYY-MM-DD или RFC 3339)"
+// @Param groupby query string false "Группировка: hour (2025-02-12T13:00), day (2025-02-12), week (2025-W07, ISO), month (2025-02), quarter (2025-Q1), year (2025), category, type, counterparty, none (одна группа all); по умолчанию day. Список через запятую (month,category) — дерево групп с агрегатами на каждом уровне в Children"
 // @Param splitby query string false "Разделение данных (например по типу транзакции)"
-// @Param sortby query string false "Поле для сортировки"
+// @Param sortby query string false "Метрика для сортировки групп (одна из metrics), иначе по ключу группы"
 // @Param sortdir query string false "Направление сортировки (asc/desc)"
+// @Param fill query string false "Заполнение периодов без транзакций: zero, null или previous; группы идут по порядку от from до to"
+// @Param metrics query string false "Метрики через запятую: sum, avg, count, min, max, stddev, variance, median, percentile90, pNN (p25, p99.9). С этим параметром в ответе только выбранные метрики под их именами"
+// @Param type query string false "Только транзакции типа income или expense"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param minAmount query number false "Сумма от, включительно"
+// @Param maxAmount query number false "Сумма до, включительно"
+// @Param search query string false "Подстрока описания без учета регистра"
+// @Param counterparty query string false "ИНН контрагента"
+// @Param compare query string false "Сравнение: previous (предыдущий период), yoy (год назад) или custom; у групп появляются CompareKey и Compare с Current, Previous, Delta и Percent по каждой метрике"
+// @Param compareFrom query string false "Начало периода сравнения для compare=custom (YYYY-MM-DD или RFC 3339)"
+// @Param compareTo query string false "Конец периода сравнения включительно для compare=custom"
+// @Param cumulative query bool false "Нарастающие итоги: у каждого периода Running (Income, Expense, Net) от входящего остатка Opening; только для временных группировок, по умолчанию включает fill=zero"
+// @Param rolling query string false "Скользящие окна через запятую: <N><h|d|w|m|q|y>, например 7d,30d; у каждого периода Rolling с Sum, Avg и Count за окно, окно захватывает данные до from"
+// @Param top query int false "Только с splitby=category: N крупнейших категорий в каждой группе, остальные сворачиваются в Other; разбиения отдаются в Data.Splits"
+// @Param topby query string false "Метрика ранжирования для top (по модулю значения), по умолчанию sum"
+// @Param mode query string false "Как считаются метрики All и группы по категориям: signed (расход со знаком минус, по умолчанию) или absolute (по модулю)"
+// @Param tz query string false "Часовой пояс IANA для дат и группировки, по умолчанию — рабочего пространства"
 // @Success 200 {object} analytic.Analytics
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/analytics [get]
 func (h *AnalyticsHandler) GetAnalys(ctx *wbgin.Context) {
-	var AnalyticsReq dto.AnalyticsReq
-	AnalyticsReq.From = ctx.Query("from")
-	if AnalyticsReq.From == "" {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "missing from date"})
+	q, ok := parseAnalyticsQuery(ctx)
+	if !ok {
 		return
 	}
-	AnalyticsReq.To = ctx.Query("to")
-	if AnalyticsReq.To == "" {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "missing to date"})
+
+	res, err := h.Service.GetAnalytics(q)
+	if errors.Is(err, analytic.ErrInvalidQuery) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
 		return
 	}
-	AnalyticsReq.GroupBy = ctx.Query("groupby")
-	AnalyticsReq.SplitBy = ctx.Query("splitby")
-	AnalyticsReq.SortBy = ctx.Query("sortby")
-	AnalyticsReq.SortDir = ctx.Query("sortdir")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
 
-	layout := "2006-01-02"
-	from, err := time.ParseInLocation(layout, AnalyticsReq.From, time.Local)
+// GetCSV godoc
+// @Summary Экспорт аналитики в CSV
+// @Description Экспортирует агрегированные данные транзакций за указанный период в CSV-файл
+// @Tags Analytics
+// @Param from query string true "Начало периода (YYYY-MM-DD или RFC 3339)"
+// @Param to query string true "Конец периода включительно (YYYY-MM-DD или RFC 3339)"
+// @Param groupby query string false "Группировка: hour (2025-02-12T13:00), day (2025-02-12), week (2025-W07, ISO), month (2025-02), quarter (2025-Q1), year (2025), category, type, counterparty, none (одна группа all); по умолчанию day. Список через запятую (month,category) — дерево групп с агрегатами на каждом уровне в Children"
+// @Param splitby query string false "Разделение данных (например по типу транзакции)"
+// @Param sortby query string false "Метрика для сортировки групп (одна из metrics), иначе по ключу группы"
+// @Param sortdir query string false "Направление сортировки (asc/desc)"
+// @Param fill query string false "Заполнение периодов без транзакций: zero, null или previous; группы идут по порядку от from до to"
+// @Param metrics query string false "Метрики через запятую: sum, avg, count, min, max, stddev, variance, median, percentile90, pNN (p25, p99.9). С этим параметром в ответе только выбранные метрики под их именами"
+// @Param type query string false "Только транзакции типа income или expense"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param minAmount query number false "Сумма от, включительно"
+// @Param maxAmount query number false "Сумма до, включительно"
+// @Param search query string false "Подстрока описания без учета регистра"
+// @Param counterparty query string false "ИНН контрагента"
+// @Param compare query string false "Сравнение: previous (предыдущий период), yoy (год назад) или custom; у групп появляются CompareKey и Compare с Current, Previous, Delta и Percent по каждой метрике"
+// @Param compareFrom query string false "Начало периода сравнения для compare=custom (YYYY-MM-DD или RFC 3339)"
+// @Param compareTo query string false "Конец периода сравнения включительно для compare=custom"
+// @Param cumulative query bool false "Нарастающие итоги: у каждого периода Running (Income, Expense, Net) от входящего остатка Opening; только для временных группировок, по умолчанию включает fill=zero"
+// @Param rolling query string false "Скользящие окна через запятую: <N><h|d|w|m|q|y>, например 7d,30d; у каждого периода Rolling с Sum, Avg и Count за окно, окно захватывает данные до from"
+// @Param top query int false "Только с splitby=category: N крупнейших категорий в каждой группе, остальные сворачиваются в Other; разбиения отдаются в Data.Splits"
+// @Param topby query string false "Метрика ранжирования для top (по модулю значения), по умолчанию sum"
+// @Param mode query string false "Как считаются метрики All и группы по категориям: signed (расход со знаком минус, по умолчанию) или absolute (по модулю)"
+// @Param tz query string false "Часовой пояс IANA для дат и группировки, по умолчанию — рабочего пространства"
+// @Success 200 {file} file "CSV файл"
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/analytics/export [get]
+func (h *AnalyticsHandler) GetCSV(ctx *wbgin.Context) {
+	q, ok := parseAnalyticsQuery(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Writer.Header().Set("Content-Disposition", "attachment; filename=transactions.csv")
+	ctx.Writer.Header().Set("Content-Type", "text/csv")
+	err := h.Service.GetCSV(q, ctx.Writer)
+	if errors.Is(err, analytic.ErrInvalidQuery) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+}
+
+// Forecast godoc
+// @Summary Прогноз доходов и расходов
+// @Description Прогнозирует доходы и расходы по категориям и их итоги по типам на horizon периодов вперед моделью Хольта — Винтерса (при короткой истории — трендом Хольта или средним); возвращает точечные прогнозы, доверительные интервалы и ошибки на отложенном хвосте истории
+// @Tags Analytics
+// @Produce json
+// @Param from query string false "Начало истории (YYYY-MM-DD или RFC 3339); по умолчанию 1 год для day, 2 года для week, 3 года для month"
+// @Param to query string false "Конец истории включительно; по умолчанию конец последнего завершенного периода"
+// @Param groupby query string false "Период: day (сезон — неделя), week или month (сезон — год); по умолчанию month"
+// @Param horizon query int false "Сколько периодов прогнозировать; по умолчанию квартал: 91 день, 13 недель или 3 месяца"
+// @Param level query number false "Уровень доверия интервалов, по умолчанию 0.95"
+// @Param type query string false "Только income или expense"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param tz query string false "Часовой пояс IANA для дат и периодов, по умолчанию — рабочего пространства"
+// @Success 200 {object} analytic.Forecast
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/analytics/forecast [get]
+func (h *AnalyticsHandler) Forecast(ctx *wbgin.Context) {
+	var req dto.ForecastReq
+	req.From = ctx.Query("from")
+	req.To = ctx.Query("to")
+	req.GroupBy = ctx.Query("groupby")
+	req.Horizon = ctx.Query("horizon")
+	req.Level = ctx.Query("level")
+
+	filter, ok := parseTransactionFilter(ctx)
+	if !ok {
+		return
+	}
+	q := analytic.ForecastQuery{GroupBy: analytic.GroupBy(req.GroupBy), Filter: filter, Location: requestLocation(ctx)}
+
+	var err error
+	if req.From != "" {
+		if q.From, err = parseTime(req.From, q.Location, false); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
+			return
+		}
+	}
+	if req.To != "" {
+		if q.To, err = parseTime(req.To, q.Location, true); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid to date format"})
+			return
+		}
+	}
+	if req.Horizon != "" {
+		if q.Horizon, err = strconv.Atoi(req.Horizon); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid horizon"})
+			return
+		}
+	}
+	if req.Level != "" {
+		if q.Level, err = strconv.ParseFloat(req.Level, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid level"})
+			return
+		}
+	}
+
+	res, err := h.Service.Forecast(q)
+	if errors.Is(err, analytic.ErrInvalidQuery) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetPivot godoc
+// @Summary Сводная таблица
+// @Description Сводная таблица по произвольным измерениям строк и колонок (period, category, type, counterparty) с выбранными метриками: ячейки, итоги строк и колонок и общий итог, все посчитаны по самим транзакциям
+// @Tags Analytics
+// @Produce json
+// @Param from query string true "Начало периода (YYYY-MM-DD или RFC 3339)"
+// @Param to query string true "Конец периода включительно (YYYY-MM-DD или RFC 3339)"
+// @Param rows query string false "Измерения строк через запятую: period, category, type, counterparty (tag не поддерживается)"
+// @Param columns query string false "Измерения колонок через запятую; вместе со строками не больше 4 и без повторов"
+// @Param period query string false "Шаг измерения period: hour, day, week, month, quarter, year; по умолчанию month"
+// @Param metrics query string false "Метрики через запятую: sum, avg, count, min, max, stddev, variance, median, percentile90, pNN; по умолчанию sum"
+// @Param mode query string false "signed (расход со знаком минус, по умолчанию) или absolute"
+// @Param type query string false "Только транзакции типа income или expense"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param tz query string false "Часовой пояс IANA для дат и периодов, по умолчанию — рабочего пространства"
+// @Success 200 {object} analytic.Pivot
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/analytics/pivot [get]
+func (h *AnalyticsHandler) GetPivot(ctx *wbgin.Context) {
+	q, ok := parsePivotQuery(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.Service.GetPivot(q)
+	if errors.Is(err, analytic.ErrInvalidQuery) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
 		return
 	}
-	to, err := time.ParseInLocation(layout, AnalyticsReq.To, time.Local)
 	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetPivotCSV godoc
+// @Summary Экспорт сводной таблицы в CSV
+// @Description Плоская выгрузка сводной таблицы: колонка Level (cell, row_total, column_total, grand_total), по колонке на измерение и на метрику
+// @Tags Analytics
+// @Param from query string true "Начало периода (YYYY-MM-DD или RFC 3339)"
+// @Param to query string true "Конец периода включительно (YYYY-MM-DD или RFC 3339)"
+// @Param rows query string false "Измерения строк через запятую: period, category, type, counterparty"
+// @Param columns query string false "Измерения колонок через запятую"
+// @Param period query string false "Шаг измерения period, по умолчанию month"
+// @Param metrics query string false "Метрики через запятую, по умолчанию sum"
+// @Param mode query string false "signed или absolute"
+// @Param tz query string false "Часовой пояс IANA для дат и периодов, по умолчанию — рабочего пространства"
+// @Success 200 {file} file "CSV файл"
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/analytics/pivot/export [get]
+func (h *AnalyticsHandler) GetPivotCSV(ctx *wbgin.Context) {
+	q, ok := parsePivotQuery(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Writer.Header().Set("Content-Disposition", "attachment; filename=pivot.csv")
+	ctx.Writer.Header().Set("Content-Type", "text/csv")
+	err := h.Service.GetPivotCSV(q, ctx.Writer)
+	if errors.Is(err, analytic.ErrInvalidQuery) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+}
+
+// GetHistogram godoc
+// @Summary Распределение сумм транзакций
+// @Description Гистограмма сумм транзакций за период: границы корзин, число и сумма транзакций в каждой; корзины считаются в базе через width_bucket. Пустые корзины тоже возвращаются, последняя включает максимум
+// @Tags Analytics
+// @Produce json
+// @Param from query string true "Начало периода (YYYY-MM-DD или RFC 3339)"
+// @Param to query string true "Конец периода включительно (YYYY-MM-DD или RFC 3339)"
+// @Param buckets query int false "Число корзин от 1 до 100, по умолчанию 10; для quantile при повторяющихся суммах корзин может быть меньше"
+// @Param bucketing query string false "Деление: fixed (одинаковая ширина от минимума до максимума, по умолчанию), log (одинаковая ширина по логарифму, только положительные суммы) или quantile (поровну транзакций)"
+// @Param type query string false "Только транзакции типа income или expense"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param minAmount query number false "Сумма от, включительно"
+// @Param maxAmount query number false "Сумма до, включительно"
+// @Param search query string false "Подстрока описания без учета регистра"
+// @Param counterparty query string false "ИНН контрагента"
+// @Param tz query string false "Часовой пояс IANA для дат, по умолчанию — рабочего пространства"
+// @Success 200 {object} analytic.Histogram
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/analytics/histogram [get]
+func (h *AnalyticsHandler) GetHistogram(ctx *wbgin.Context) {
+	var req dto.HistogramReq
+	req.From = ctx.Query("from")
+	req.To = ctx.Query("to")
+	req.Buckets = ctx.Query("buckets")
+	req.Bucketing = ctx.Query("bucketing")
+	if req.From == "" || req.To == "" {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "missing from or to date"})
+		return
+	}
+
+	filter, ok := parseTransactionFilter(ctx)
+	if !ok {
+		return
+	}
+	loc := requestLocation(ctx)
+	q := analytic.HistogramQuery{Bucketing: analytic.Bucketing(strings.ToLower(req.Bucketing)), Filter: filter}
+	var err error
+	if q.From, err = parseTime(req.From, loc, false); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
+		return
+	}
+	if q.To, err = parseTime(req.To, loc, true); err != nil {
 		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid to date format"})
 		return
 	}
+	if req.Buckets != "" {
+		if q.Buckets, err = strconv.Atoi(req.Buckets); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid buckets value"})
+			return
+		}
+	}
 
-	res, err := h.Service.GetAnalytics(from, to, AnalyticsReq.GroupBy, AnalyticsReq.SplitBy, AnalyticsReq.SortBy, AnalyticsReq.SortDir)
+	res, err := h.Service.GetHistogram(q)
+	if errors.Is(err, analytic.ErrInvalidQuery) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
 		return
@@ -79,54 +342,264 @@ func (h *AnalyticsHandler) GetAnalys(ctx *wbgin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
-// GetCSV godoc
-// @Summary Экспорт аналитики в CSV
-// @Description Экспортирует агрегированные данные транзакций за указанный период в CSV-файл
+// GetSeasonality godoc
+// @Summary Матрица сезонности
+// @Description Число, сумма или средняя сумма транзакций по дням недели и часам либо по месяцам и дням недели, с итогами строк, колонок и общим итогом
 // @Tags Analytics
-// @Param from query string true "Дата начала (YYYY-MM-DD)"
-// @Param to query string true "Дата конца (YYYY-MM-DD)"
-// @Param groupby query string false "Группировка (day/week/month/category)"
-// @Param splitby query string false "Разделение данных (например по типу транзакции)"
-// @Param sortby query string false "Поле для сортировки"
-// @Param sortdir query string false "Направление сортировки (asc/desc)"
+// @Produce json
+// @Param from query string true "Начало периода (YYYY-MM-DD или RFC 3339)"
+// @Param to query string true "Конец периода включительно (YYYY-MM-DD или RFC 3339)"
+// @Param layout query string false "Оси: weekday_hour (дни недели с понедельника × часы 00–23, по умолчанию) или month_weekday (месяцы × дни недели)"
+// @Param measure query string false "Мера ячеек: count (по умолчанию), sum или avg"
+// @Param type query string false "Только транзакции типа income или expense"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param minAmount query number false "Сумма от, включительно"
+// @Param maxAmount query number false "Сумма до, включительно"
+// @Param search query string false "Подстрока описания без учета регистра"
+// @Param counterparty query string false "ИНН контрагента"
+// @Param tz query string false "Часовой пояс IANA: в нем определяются час, день недели и месяц транзакции; по умолчанию — рабочего пространства"
+// @Success 200 {object} analytic.Seasonality
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/analytics/seasonality [get]
+func (h *AnalyticsHandler) GetSeasonality(ctx *wbgin.Context) {
+	q, ok := parseSeasonalityQuery(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.Service.GetSeasonality(q)
+	if errors.Is(err, analytic.ErrInvalidQuery) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetSeasonalityCSV godoc
+// @Summary Экспорт матрицы сезонности в CSV
+// @Description Матрица как есть: подписи строк, колонки матрицы и Total; последняя строка — итоги колонок
+// @Tags Analytics
+// @Param from query string true "Начало периода (YYYY-MM-DD или RFC 3339)"
+// @Param to query string true "Конец периода включительно (YYYY-MM-DD или RFC 3339)"
+// @Param layout query string false "Оси: weekday_hour (дни недели с понедельника × часы 00–23, по умолчанию) или month_weekday (месяцы × дни недели)"
+// @Param measure query string false "Мера ячеек: count (по умолчанию), sum или avg"
+// @Param type query string false "Только транзакции типа income или expense"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param minAmount query number false "Сумма от, включительно"
+// @Param maxAmount query number false "Сумма до, включительно"
+// @Param search query string false "Подстрока описания без учета регистра"
+// @Param counterparty query string false "ИНН контрагента"
+// @Param tz query string false "Часовой пояс IANA: в нем определяются час, день недели и месяц транзакции; по умолчанию — рабочего пространства"
 // @Success 200 {file} file "CSV файл"
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /api/analytics/export [get]
-func (h *AnalyticsHandler) GetCSV(ctx *wbgin.Context) {
+// @Router /api/analytics/seasonality/export [get]
+func (h *AnalyticsHandler) GetSeasonalityCSV(ctx *wbgin.Context) {
+	q, ok := parseSeasonalityQuery(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Writer.Header().Set("Content-Disposition", "attachment; filename=seasonality.csv")
+	ctx.Writer.Header().Set("Content-Type", "text/csv")
+	err := h.Service.GetSeasonalityCSV(q, ctx.Writer)
+	if errors.Is(err, analytic.ErrInvalidQuery) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+}
+
+// parseSeasonalityQuery читает параметры матрицы сезонности; при ошибке отвечает 400
+func parseSeasonalityQuery(ctx *wbgin.Context) (analytic.SeasonalityQuery, bool) {
+	var req dto.SeasonalityReq
+	req.From = ctx.Query("from")
+	req.To = ctx.Query("to")
+	req.Layout = ctx.Query("layout")
+	req.Measure = ctx.Query("measure")
+	if req.From == "" || req.To == "" {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "missing from or to date"})
+		return analytic.SeasonalityQuery{}, false
+	}
+
+	filter, ok := parseTransactionFilter(ctx)
+	if !ok {
+		return analytic.SeasonalityQuery{}, false
+	}
+	q := analytic.SeasonalityQuery{
+		Layout:   analytic.SeasonalityLayout(strings.ToLower(req.Layout)),
+		Measure:  analytic.Metric(strings.ToLower(req.Measure)),
+		Filter:   filter,
+		Location: requestLocation(ctx),
+	}
+	var err error
+	if q.From, err = parseTime(req.From, q.Location, false); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
+		return analytic.SeasonalityQuery{}, false
+	}
+	if q.To, err = parseTime(req.To, q.Location, true); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid to date format"})
+		return analytic.SeasonalityQuery{}, false
+	}
+	return q, true
+}
+
+// parsePivotQuery читает параметры сводной таблицы; при ошибке отвечает 400
+func parsePivotQuery(ctx *wbgin.Context) (analytic.PivotQuery, bool) {
+	var req dto.PivotReq
+	req.From = ctx.Query("from")
+	req.To = ctx.Query("to")
+	req.Rows = ctx.Query("rows")
+	req.Columns = ctx.Query("columns")
+	req.Period = ctx.Query("period")
+	req.Metrics = ctx.Query("metrics")
+	req.Mode = ctx.Query("mode")
+	if req.From == "" || req.To == "" {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "missing from or to date"})
+		return analytic.PivotQuery{}, false
+	}
+
+	filter, ok := parseTransactionFilter(ctx)
+	if !ok {
+		return analytic.PivotQuery{}, false
+	}
+	q := analytic.PivotQuery{
+		Period:   analytic.GroupBy(req.Period),
+		Metrics:  analytic.ParseMetrics(req.Metrics),
+		Mode:     analytic.Mode(req.Mode),
+		Filter:   filter,
+		Location: requestLocation(ctx),
+	}
+
+	var err error
+	if q.From, err = parseTime(req.From, q.Location, false); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
+		return analytic.PivotQuery{}, false
+	}
+	if q.To, err = parseTime(req.To, q.Location, true); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid to date format"})
+		return analytic.PivotQuery{}, false
+	}
+	if q.Rows, err = analytic.ParseDimensions(req.Rows); err == nil {
+		q.Columns, err = analytic.ParseDimensions(req.Columns)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return analytic.PivotQuery{}, false
+	}
+	return q, true
+}
+
+// parseAnalyticsQuery читает параметры аналитики из query; при ошибке отвечает 400.
+// Дата без времени в to включает весь день, даты считаются в часовом поясе запроса
+func parseAnalyticsQuery(ctx *wbgin.Context) (analytic.Query, bool) {
 	var AnalyticsReq dto.AnalyticsReq
 	AnalyticsReq.From = ctx.Query("from")
 	if AnalyticsReq.From == "" {
 		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "missing from date"})
-		return
+		return analytic.Query{}, false
 	}
 	AnalyticsReq.To = ctx.Query("to")
 	if AnalyticsReq.To == "" {
 		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "missing to date"})
-		return
+		return analytic.Query{}, false
 	}
 	AnalyticsReq.GroupBy = ctx.Query("groupby")
 	AnalyticsReq.SplitBy = ctx.Query("splitby")
 	AnalyticsReq.SortBy = ctx.Query("sortby")
 	AnalyticsReq.SortDir = ctx.Query("sortdir")
+	AnalyticsReq.Fill = ctx.Query("fill")
+	AnalyticsReq.Mode = ctx.Query("mode")
+	AnalyticsReq.Metrics = ctx.Query("metrics")
+	AnalyticsReq.Cumulative = ctx.Query("cumulative")
+	AnalyticsReq.Rolling = ctx.Query("rolling")
+	AnalyticsReq.Compare = ctx.Query("compare")
+	AnalyticsReq.CompareFrom = ctx.Query("compareFrom")
+	AnalyticsReq.CompareTo = ctx.Query("compareTo")
+	AnalyticsReq.Top = ctx.Query("top")
+	AnalyticsReq.TopBy = ctx.Query("topby")
+
+	filter, ok := parseTransactionFilter(ctx)
+	if !ok {
+		return analytic.Query{}, false
+	}
 
-	layout := "2006-01-02"
-	from, err := time.ParseInLocation(layout, AnalyticsReq.From, time.Local)
+	loc := requestLocation(ctx)
+	from, err := parseTime(AnalyticsReq.From, loc, false)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
-		return
+		return analytic.Query{}, false
 	}
-	to, err := time.ParseInLocation(layout, AnalyticsReq.To, time.Local)
+	to, err := parseTime(AnalyticsReq.To, loc, true)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid to date format"})
-		return
+		return analytic.Query{}, false
 	}
 
-	ctx.Writer.Header().Set("Content-Disposition", "attachment; filename=transactions.csv")
-	ctx.Writer.Header().Set("Content-Type", "text/csv")
-	err = h.Service.GetCSV(from, to, AnalyticsReq.GroupBy, AnalyticsReq.SplitBy, AnalyticsReq.SortBy, AnalyticsReq.SortDir, ctx.Writer)
+	var cumulative bool
+	if AnalyticsReq.Cumulative != "" {
+		if cumulative, err = strconv.ParseBool(AnalyticsReq.Cumulative); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid cumulative value"})
+			return analytic.Query{}, false
+		}
+	}
+
+	rolling, err := analytic.ParseWindows(AnalyticsReq.Rolling)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
-		return
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return analytic.Query{}, false
 	}
+
+	var compareFrom, compareTo time.Time
+	if AnalyticsReq.CompareFrom != "" {
+		if compareFrom, err = parseTime(AnalyticsReq.CompareFrom, loc, false); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid compareFrom date format"})
+			return analytic.Query{}, false
+		}
+	}
+	if AnalyticsReq.CompareTo != "" {
+		if compareTo, err = parseTime(AnalyticsReq.CompareTo, loc, true); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid compareTo date format"})
+			return analytic.Query{}, false
+		}
+	}
+
+	var top int
+	if AnalyticsReq.Top != "" {
+		if top, err = strconv.Atoi(AnalyticsReq.Top); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid top value"})
+			return analytic.Query{}, false
+		}
+	}
+
+	groupBy, nested := analytic.ParseGroupBy(AnalyticsReq.GroupBy)
+	return analytic.Query{
+		From:        from,
+		To:          to,
+		GroupBy:     groupBy,
+		Nested:      nested,
+		SplitBy:     AnalyticsReq.SplitBy,
+		SortBy:      AnalyticsReq.SortBy,
+		SortDir:     AnalyticsReq.SortDir,
+		Fill:        analytic.Fill(AnalyticsReq.Fill),
+		Mode:        analytic.Mode(AnalyticsReq.Mode),
+		Metrics:     analytic.ParseMetrics(AnalyticsReq.Metrics),
+		Filter:      filter,
+		Location:    loc,
+		Cumulative:  cumulative,
+		Rolling:     rolling,
+		Compare:     analytic.CompareMode(AnalyticsReq.Compare),
+		CompareFrom: compareFrom,
+		CompareTo:   compareTo,
+		Top:         top,
+		TopBy:       analytic.Metric(strings.ToLower(AnalyticsReq.TopBy)),
+	}, true
 }
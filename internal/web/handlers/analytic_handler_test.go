@@ -2,11 +2,13 @@ package handlers_test
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"salestracker/internal/domain/analytic"
+	"salestracker/internal/domain/transaction"
 	"salestracker/internal/web/handlers"
 	"testing"
 	"time"
@@ -15,16 +17,47 @@ import (
 // ---------------- MOCK --------------------
 
 type MockAnalyticsService struct {
-	GetAnalyticsFn func(from, to time.Time, groupBy, splitBy, sortBy, sortDir string) (*analytic.Analytics, error)
-	GetCSVFn       func(from, to time.Time, groupBy, splitBy, sortBy, sortDir string, output io.Writer) error
+	GetAnalyticsFn func(q analytic.Query) (*analytic.Analytics, error)
+	GetCSVFn       func(q analytic.Query, output io.Writer) error
+	ForecastFn     func(q analytic.ForecastQuery) (*analytic.Forecast, error)
+	GetPivotFn     func(q analytic.PivotQuery) (*analytic.Pivot, error)
+	GetPivotCSVFn  func(q analytic.PivotQuery, output io.Writer) error
+	GetHistogramFn func(q analytic.HistogramQuery) (*analytic.Histogram, error)
+
+	GetSeasonalityFn    func(q analytic.SeasonalityQuery) (*analytic.Seasonality, error)
+	GetSeasonalityCSVFn func(q analytic.SeasonalityQuery, output io.Writer) error
+}
+
+func (m *MockAnalyticsService) GetAnalytics(q analytic.Query) (*analytic.Analytics, error) {
+	return m.GetAnalyticsFn(q)
+}
+
+func (m *MockAnalyticsService) GetCSV(q analytic.Query, output io.Writer) error {
+	return m.GetCSVFn(q, output)
+}
+
+func (m *MockAnalyticsService) Forecast(q analytic.ForecastQuery) (*analytic.Forecast, error) {
+	return m.ForecastFn(q)
+}
+
+func (m *MockAnalyticsService) GetPivot(q analytic.PivotQuery) (*analytic.Pivot, error) {
+	return m.GetPivotFn(q)
+}
+
+func (m *MockAnalyticsService) GetPivotCSV(q analytic.PivotQuery, output io.Writer) error {
+	return m.GetPivotCSVFn(q, output)
+}
+
+func (m *MockAnalyticsService) GetHistogram(q analytic.HistogramQuery) (*analytic.Histogram, error) {
+	return m.GetHistogramFn(q)
 }
 
-func (m *MockAnalyticsService) GetAnalytics(from, to time.Time, groupBy, splitBy, sortBy, sortDir string) (*analytic.Analytics, error) {
-	return m.GetAnalyticsFn(from, to, groupBy, splitBy, sortBy, sortDir)
+func (m *MockAnalyticsService) GetSeasonality(q analytic.SeasonalityQuery) (*analytic.Seasonality, error) {
+	return m.GetSeasonalityFn(q)
 }
 
-func (m *MockAnalyticsService) GetCSV(from, to time.Time, groupBy, splitBy, sortBy, sortDir string, output io.Writer) error {
-	return m.GetCSVFn(from, to, groupBy, splitBy, sortBy, sortDir, output)
+func (m *MockAnalyticsService) GetSeasonalityCSV(q analytic.SeasonalityQuery, output io.Writer) error {
+	return m.GetSeasonalityCSVFn(q, output)
 }
 
 // ---------------- UTILS --------------------
@@ -50,12 +83,12 @@ func performRequest(hf func(*gin.Context), method, path string, query map[string
 
 func TestGetAnalys_Success(t *testing.T) {
 	mockSvc := &MockAnalyticsService{
-		GetAnalyticsFn: func(from, to time.Time, groupBy, splitBy, sortBy, sortDir string) (*analytic.Analytics, error) {
+		GetAnalyticsFn: func(q analytic.Query) (*analytic.Analytics, error) {
 			return &analytic.Analytics{
 				Groups: []analytic.AnalyticGroup{
 					{
 						GroupKey: "group1",
-						Data: analytic.AnalyticByType{
+						Data: &analytic.AnalyticByType{
 							Income:  analytic.Analytic{Sum: 100},
 							Expense: analytic.Analytic{Sum: 50},
 							All:     analytic.Analytic{Sum: 150},
@@ -101,7 +134,7 @@ func TestGetAnalys_InvalidDate(t *testing.T) {
 
 func TestGetAnalys_ServiceError(t *testing.T) {
 	mockSvc := &MockAnalyticsService{
-		GetAnalyticsFn: func(from, to time.Time, groupBy, splitBy, sortBy, sortDir string) (*analytic.Analytics, error) {
+		GetAnalyticsFn: func(q analytic.Query) (*analytic.Analytics, error) {
 			return nil, errors.New("service failed")
 		},
 	}
@@ -117,7 +150,7 @@ func TestGetAnalys_ServiceError(t *testing.T) {
 
 func TestGetCSV_Success(t *testing.T) {
 	mockSvc := &MockAnalyticsService{
-		GetCSVFn: func(from, to time.Time, groupBy, splitBy, sortBy, sortDir string, output io.Writer) error {
+		GetCSVFn: func(q analytic.Query, output io.Writer) error {
 			// просто пишем что-то в writer
 			_, err := output.Write([]byte("csv data"))
 			return err
@@ -158,3 +191,393 @@ func TestGetCSV_InvalidFrom(t *testing.T) {
 		t.Fatalf("expected 400, got %d", w.Code)
 	}
 }
+
+func TestGetAnalys_Timezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	var got analytic.Query
+	mockSvc := &MockAnalyticsService{
+		GetAnalyticsFn: func(q analytic.Query) (*analytic.Analytics, error) {
+			got = q
+			return &analytic.Analytics{}, nil
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+
+	engine := gin.New()
+	engine.Use(handlers.Timezone(time.UTC))
+	engine.GET("/analytics", h.GetAnalys)
+
+	req, _ := http.NewRequest("GET", "/analytics?from=2025-11-01&to=2025-11-27&tz=Asia/Tokyo", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got.Location.String() != tokyo.String() {
+		t.Fatalf("unexpected location %v", got.Location)
+	}
+	if want := time.Date(2025, 11, 1, 0, 0, 0, 0, tokyo); !got.From.Equal(want) {
+		t.Errorf("unexpected from %v", got.From)
+	}
+	if want := time.Date(2025, 11, 28, 0, 0, 0, 0, tokyo).Add(-time.Microsecond); !got.To.Equal(want) {
+		t.Errorf("plain to date should cover the whole day, got %v", got.To)
+	}
+
+	req, _ = http.NewRequest("GET", "/analytics?from=2025-11-01T10:30:00%2B03:00&to=2025-11-27", nil)
+	req.Header.Set(handlers.TimezoneHeader, "Asia/Tokyo")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if want := time.Date(2025, 11, 1, 7, 30, 0, 0, time.UTC); !got.From.Equal(want) {
+		t.Errorf("RFC 3339 from should keep its offset, got %v", got.From)
+	}
+
+	for _, tz := range []string{"Mars/Olympus", "Local"} {
+		req, _ = http.NewRequest("GET", "/analytics?from=2025-11-01&to=2025-11-27&tz="+tz, nil)
+		w = httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for timezone %q, got %d", tz, w.Code)
+		}
+	}
+}
+
+func TestGetAnalys_InvalidQuery(t *testing.T) {
+	mockSvc := &MockAnalyticsService{
+		GetAnalyticsFn: func(q analytic.Query) (*analytic.Analytics, error) {
+			if q.GroupBy != "fortnight" {
+				t.Errorf("unexpected groupBy %q", q.GroupBy)
+			}
+			return nil, fmt.Errorf("%w: unknown groupBy", analytic.ErrInvalidQuery)
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from":    "2025-11-01",
+		"to":      "2025-11-27",
+		"groupby": "fortnight",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetAnalys_Fill(t *testing.T) {
+	var got analytic.Query
+	mockSvc := &MockAnalyticsService{
+		GetAnalyticsFn: func(q analytic.Query) (*analytic.Analytics, error) {
+			got = q
+			return &analytic.Analytics{}, nil
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from":       "2025-11-01",
+		"to":         "2025-11-27",
+		"groupby":    "week",
+		"fill":       "previous",
+		"mode":       "absolute",
+		"metrics":    "P95, max,p95",
+		"cumulative": "true",
+		"rolling":    "7d,30d",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got.Fill != analytic.FillPrevious || got.GroupBy != analytic.GroupWeek || got.Mode != analytic.ModeAbsolute || !got.Cumulative || len(got.Rolling) != 2 ||
+		len(got.Metrics) != 2 || got.Metrics[0] != "p95" || got.Metrics[1] != analytic.MetricMax {
+		t.Fatalf("unexpected query %+v", got)
+	}
+}
+
+func TestGetAnalys_Filter(t *testing.T) {
+	var got analytic.Query
+	mockSvc := &MockAnalyticsService{
+		GetAnalyticsFn: func(q analytic.Query) (*analytic.Analytics, error) {
+			got = q
+			return &analytic.Analytics{}, nil
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from":      "2025-11-01",
+		"to":        "2025-11-27",
+		"type":      "expense",
+		"category":  "Marketing",
+		"maxAmount": "500.5",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	f := got.Filter
+	if f.Type != transaction.Expense || len(f.Categories) != 1 || f.Categories[0] != "Marketing" || f.MaxAmount == nil || *f.MaxAmount != 500.5 {
+		t.Fatalf("unexpected filter %+v", f)
+	}
+
+	w = performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from": "2025-11-01", "to": "2025-11-27", "maxAmount": "lots",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetAnalys_Compare(t *testing.T) {
+	var got analytic.Query
+	mockSvc := &MockAnalyticsService{
+		GetAnalyticsFn: func(q analytic.Query) (*analytic.Analytics, error) {
+			got = q
+			return &analytic.Analytics{}, nil
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from":        "2025-03-01",
+		"to":          "2025-03-31",
+		"compare":     "custom",
+		"compareFrom": "2024-12-01",
+		"compareTo":   "2024-12-31",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	wantTo := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond)
+	if got.Compare != analytic.CompareCustom || !got.CompareFrom.Equal(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)) || !got.CompareTo.Equal(wantTo) {
+		t.Fatalf("unexpected query %+v", got)
+	}
+
+	w = performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from": "2025-03-01", "to": "2025-03-31", "compare": "custom", "compareFrom": "last year",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetAnalys_InvalidRolling(t *testing.T) {
+	h := handlers.NewAnalyticHandler(&MockAnalyticsService{})
+	w := performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from": "2025-11-01", "to": "2025-11-27", "rolling": "week",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestForecast_Params(t *testing.T) {
+	var got analytic.ForecastQuery
+	mockSvc := &MockAnalyticsService{
+		ForecastFn: func(q analytic.ForecastQuery) (*analytic.Forecast, error) {
+			got = q
+			return &analytic.Forecast{}, nil
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.Forecast, "GET", "/analytics/forecast", map[string]string{
+		"groupby":  "week",
+		"horizon":  "13",
+		"level":    "0.8",
+		"category": "Marketing",
+		"to":       "2025-06-30",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got.GroupBy != analytic.GroupWeek || got.Horizon != 13 || got.Level != 0.8 || len(got.Filter.Categories) != 1 || got.To.IsZero() || !got.From.IsZero() {
+		t.Fatalf("unexpected query %+v", got)
+	}
+
+	for _, params := range []map[string]string{{"horizon": "soon"}, {"level": "high"}, {"from": "yesterday"}} {
+		if w := performRequest(h.Forecast, "GET", "/analytics/forecast", params); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", params, w.Code)
+		}
+	}
+}
+
+func TestForecast_InvalidQuery(t *testing.T) {
+	mockSvc := &MockAnalyticsService{
+		ForecastFn: func(q analytic.ForecastQuery) (*analytic.Forecast, error) {
+			return nil, fmt.Errorf("%w: forecast supports day, week and month", analytic.ErrInvalidQuery)
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.Forecast, "GET", "/analytics/forecast", map[string]string{"groupby": "year"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetPivot_Params(t *testing.T) {
+	mockSvc := &MockAnalyticsService{
+		GetPivotFn: func(q analytic.PivotQuery) (*analytic.Pivot, error) {
+			if len(q.Rows) != 2 || q.Rows[1] != analytic.DimType || len(q.Columns) != 1 || q.Columns[0] != analytic.DimPeriod ||
+				q.Period != analytic.GroupQuarter || len(q.Metrics) != 2 || q.Metrics[1] != analytic.MetricMedian || q.Filter.Type != transaction.Expense {
+				t.Fatalf("unexpected query %+v", q)
+			}
+			return &analytic.Pivot{}, nil
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.GetPivot, "GET", "/analytics/pivot", map[string]string{
+		"from": "2025-01-01", "to": "2025-12-31", "rows": "category,type", "columns": "period", "period": "quarter",
+		"metrics": "sum,median", "type": "expense",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetPivot_BadRequest(t *testing.T) {
+	mockSvc := &MockAnalyticsService{
+		GetPivotFn: func(q analytic.PivotQuery) (*analytic.Pivot, error) {
+			return nil, fmt.Errorf("%w: dimension category is used twice", analytic.ErrInvalidQuery)
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	for _, query := range []map[string]string{
+		{"to": "2025-12-31", "rows": "category"},
+		{"from": "2025-01-01", "to": "2025-12-31", "rows": "tag"},
+		{"from": "2025-01-01", "to": "2025-12-31", "rows": "category", "columns": "category"},
+	} {
+		if w := performRequest(h.GetPivot, "GET", "/analytics/pivot", query); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestGetAnalys_NestedGroupBy(t *testing.T) {
+	mockSvc := &MockAnalyticsService{
+		GetAnalyticsFn: func(q analytic.Query) (*analytic.Analytics, error) {
+			if q.GroupBy != analytic.GroupWeek || len(q.Nested) != 2 || q.Nested[0] != analytic.GroupType || q.Nested[1] != analytic.GroupCategory {
+				t.Fatalf("unexpected groupBy %q %v", q.GroupBy, q.Nested)
+			}
+			return &analytic.Analytics{}, nil
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from": "2025-01-01", "to": "2025-03-31", "groupby": "week,type,category",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetAnalys_Top(t *testing.T) {
+	mockSvc := &MockAnalyticsService{
+		GetAnalyticsFn: func(q analytic.Query) (*analytic.Analytics, error) {
+			if q.Top != 5 || q.TopBy != analytic.MetricCount || q.SplitBy != "category" {
+				t.Fatalf("unexpected top %d by %q, splitBy %q", q.Top, q.TopBy, q.SplitBy)
+			}
+			return &analytic.Analytics{}, nil
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from": "2025-01-01", "to": "2025-03-31", "splitby": "category", "top": "5", "topby": "Count",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = performRequest(h.GetAnalys, "GET", "/analytics", map[string]string{
+		"from": "2025-01-01", "to": "2025-03-31", "splitby": "category", "top": "five",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetHistogram_Params(t *testing.T) {
+	mockSvc := &MockAnalyticsService{
+		GetHistogramFn: func(q analytic.HistogramQuery) (*analytic.Histogram, error) {
+			if q.Buckets != 20 || q.Bucketing != analytic.BucketLog || q.Filter.Type != "expense" || len(q.Filter.Categories) != 1 {
+				t.Fatalf("unexpected query %+v", q)
+			}
+			if !q.To.Equal(time.Date(2025, 3, 31, 23, 59, 59, 999999000, time.UTC)) {
+				t.Fatalf("to must include the whole day, got %v", q.To)
+			}
+			return &analytic.Histogram{Bucketing: q.Bucketing}, nil
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+	w := performRequest(h.GetHistogram, "GET", "/analytics/histogram", map[string]string{
+		"from": "2025-01-01", "to": "2025-03-31", "buckets": "20", "bucketing": "log", "type": "expense", "category": "food",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetHistogram_BadRequest(t *testing.T) {
+	h := handlers.NewAnalyticHandler(&MockAnalyticsService{
+		GetHistogramFn: func(q analytic.HistogramQuery) (*analytic.Histogram, error) {
+			return nil, analytic.ErrInvalidQuery
+		},
+	})
+	for _, query := range []map[string]string{
+		{"to": "2025-03-31"},
+		{"from": "2025-01-01", "to": "2025-03-31", "buckets": "ten"},
+		{"from": "2025-01-01", "to": "2025-03-31", "bucketing": "sqrt"},
+	} {
+		if w := performRequest(h.GetHistogram, "GET", "/analytics/histogram", query); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestGetSeasonality_Timezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	var got analytic.SeasonalityQuery
+	mockSvc := &MockAnalyticsService{
+		GetSeasonalityFn: func(q analytic.SeasonalityQuery) (*analytic.Seasonality, error) {
+			got = q
+			return &analytic.Seasonality{}, nil
+		},
+		GetSeasonalityCSVFn: func(q analytic.SeasonalityQuery, output io.Writer) error {
+			_, err := io.WriteString(output, "weekday,00,Total\n")
+			return err
+		},
+	}
+	h := handlers.NewAnalyticHandler(mockSvc)
+
+	engine := gin.New()
+	engine.Use(handlers.Timezone(time.UTC))
+	engine.GET("/analytics/seasonality", h.GetSeasonality)
+	engine.GET("/analytics/seasonality/export", h.GetSeasonalityCSV)
+
+	req, _ := http.NewRequest("GET", "/analytics/seasonality?from=2025-01-01&to=2025-03-31&layout=MONTH_WEEKDAY&measure=avg&type=income&tz=Asia/Tokyo", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got.Layout != analytic.LayoutMonthWeekday || got.Measure != analytic.MetricAvg || got.Filter.Type != "income" {
+		t.Fatalf("unexpected query %+v", got)
+	}
+	if got.Location.String() != tokyo.String() || !got.From.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, tokyo)) {
+		t.Errorf("dates and buckets must use the request timezone, got %v from %v", got.Location, got.From)
+	}
+
+	req, _ = http.NewRequest("GET", "/analytics/seasonality/export?from=2025-01-01&to=2025-03-31", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" || w.Body.String() != "weekday,00,Total\n" {
+		t.Errorf("unexpected export %d %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/analytics/seasonality?to=2025-03-31", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without from, got %d", w.Code)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"salestracker/internal/domain/anomaly"
+	"salestracker/internal/web/dto"
+	"strconv"
+)
+
+// AnomalyHandler отдает нетипичные транзакции и дни и принимает их подтверждения
+type AnomalyHandler struct {
+	Service AnomalyIFace
+}
+
+// AnomalyIFace описывает интерфейс сервиса аномалий
+type AnomalyIFace interface {
+	GetAnomalies(q anomaly.Query) ([]*anomaly.Anomaly, error)
+	Acknowledge(id, note string) (*anomaly.Ack, error)
+	Unacknowledge(id string) error
+}
+
+// NewAnomalyHandler создает новый AnomalyHandler
+func NewAnomalyHandler(service AnomalyIFace) *AnomalyHandler {
+	return &AnomalyHandler{
+		Service: service,
+	}
+}
+
+// GetAnomalies godoc
+// @Summary Аномалии транзакций и дневных итогов
+// @Description Находит транзакции, сумма которых — выброс для их типа и категории, и дни, итог которых выходит из ожидаемого диапазона по 28 предыдущим дням. Выброс — когда модифицированный z-score (медиана и MAD) не меньше threshold и значение за внешней границей Тьюки (3·IQR). Подтвержденные аномалии по умолчанию скрыты
+// @Tags Anomalies
+// @Produce json
+// @Param from query string false "Начало периода (YYYY-MM-DD или RFC 3339), по умолчанию 30 дней до to"
+// @Param to query string false "Конец периода включительно, по умолчанию сейчас"
+// @Param kind query string false "transaction или day, по умолчанию обе"
+// @Param threshold query number false "Порог модифицированного z-score, по умолчанию 3.5"
+// @Param acknowledged query bool false "Вернуть и подтвержденные аномалии"
+// @Param type query string false "Только income или expense"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param tz query string false "Часовой пояс IANA границ дней, по умолчанию — рабочего пространства"
+// @Success 200 {array} anomaly.Anomaly
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/anomalies [get]
+func (h *AnomalyHandler) GetAnomalies(ctx *wbgin.Context) {
+	var req dto.AnomaliesReq
+	req.From = ctx.Query("from")
+	req.To = ctx.Query("to")
+	req.Kind = ctx.Query("kind")
+	req.Threshold = ctx.Query("threshold")
+	req.Acknowledged = ctx.Query("acknowledged")
+
+	filter, ok := parseTransactionFilter(ctx)
+	if !ok {
+		return
+	}
+	q := anomaly.Query{Kind: anomaly.Kind(req.Kind), Filter: filter, Location: requestLocation(ctx)}
+
+	var err error
+	if req.From != "" {
+		if q.From, err = parseTime(req.From, q.Location, false); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
+			return
+		}
+	}
+	if req.To != "" {
+		if q.To, err = parseTime(req.To, q.Location, true); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid to date format"})
+			return
+		}
+	}
+	if req.Threshold != "" {
+		if q.Threshold, err = strconv.ParseFloat(req.Threshold, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid threshold"})
+			return
+		}
+	}
+	if req.Acknowledged != "" {
+		if q.IncludeAcknowledged, err = strconv.ParseBool(req.Acknowledged); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid acknowledged"})
+			return
+		}
+	}
+
+	res, err := h.Service.GetAnomalies(q)
+	if errors.Is(err, anomaly.ErrInvalidQuery) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Acknowledge godoc
+// @Summary Подтвердить аномалию
+// @Description Помечает аномалию как просмотренную, необязательная заметка сохраняется вместе с подтверждением
+// @Tags Anomalies
+// @Accept json
+// @Produce json
+// @Param id path string true "ID аномалии: tx-<ID транзакции> или day-<income|expense>-<YYYY-MM-DD>"
+// @Param request body dto.AckAnomalyReq false "Заметка"
+// @Success 200 {object} anomaly.Ack
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/anomalies/{id}/ack [post]
+func (h *AnomalyHandler) Acknowledge(ctx *wbgin.Context) {
+	var req dto.AckAnomalyReq
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+			return
+		}
+	}
+	res, err := h.Service.Acknowledge(ctx.Param("id"), req.Note)
+	if errors.Is(err, anomaly.ErrInvalidID) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Unacknowledge godoc
+// @Summary Снять подтверждение аномалии
+// @Description Удаляет подтверждение, аномалия снова возвращается в списке
+// @Tags Anomalies
+// @Param id path string true "ID аномалии"
+// @Success 204 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/anomalies/{id}/ack [delete]
+func (h *AnomalyHandler) Unacknowledge(ctx *wbgin.Context) {
+	err := h.Service.Unacknowledge(ctx.Param("id"))
+	if errors.Is(err, anomaly.ErrInvalidID) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusNoContent, wbgin.H{"status": "deleted"})
+}
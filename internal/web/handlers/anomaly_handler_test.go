@@ -0,0 +1,90 @@
+package handlers_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"salestracker/internal/domain/anomaly"
+	"salestracker/internal/web/dto"
+	"salestracker/internal/web/handlers"
+	"testing"
+)
+
+// --------- MOCK SERVICE ---------
+
+type MockAnomalyService struct {
+	GetAnomaliesFn  func(q anomaly.Query) ([]*anomaly.Anomaly, error)
+	AcknowledgeFn   func(id, note string) (*anomaly.Ack, error)
+	UnacknowledgeFn func(id string) error
+}
+
+func (m *MockAnomalyService) GetAnomalies(q anomaly.Query) ([]*anomaly.Anomaly, error) {
+	return m.GetAnomaliesFn(q)
+}
+func (m *MockAnomalyService) Acknowledge(id, note string) (*anomaly.Ack, error) {
+	return m.AcknowledgeFn(id, note)
+}
+func (m *MockAnomalyService) Unacknowledge(id string) error {
+	return m.UnacknowledgeFn(id)
+}
+
+// --------- TESTS ---------
+
+func TestGetAnomalies_Params(t *testing.T) {
+	mock := &MockAnomalyService{
+		GetAnomaliesFn: func(q anomaly.Query) ([]*anomaly.Anomaly, error) {
+			if q.Kind != anomaly.KindTransaction || q.Threshold != 5 || !q.IncludeAcknowledged ||
+				q.From.Format("2006-01-02") != "2025-01-01" || q.Filter.Type != "expense" || len(q.Filter.Categories) != 1 {
+				t.Fatalf("unexpected query %+v", q)
+			}
+			return []*anomaly.Anomaly{}, nil
+		},
+	}
+	h := handlers.NewAnomalyHandler(mock)
+	w := performRequest(h.GetAnomalies, "GET", "/anomalies", map[string]string{
+		"from": "2025-01-01", "kind": "transaction", "threshold": "5", "acknowledged": "true", "type": "expense", "category": "food",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetAnomalies_BadRequest(t *testing.T) {
+	mock := &MockAnomalyService{
+		GetAnomaliesFn: func(q anomaly.Query) ([]*anomaly.Anomaly, error) {
+			return nil, fmt.Errorf("%w: unknown kind", anomaly.ErrInvalidQuery)
+		},
+	}
+	h := handlers.NewAnomalyHandler(mock)
+	for _, query := range []map[string]string{{"threshold": "high"}, {"acknowledged": "maybe"}, {"kind": "week"}} {
+		if w := performRequest(h.GetAnomalies, "GET", "/anomalies", query); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestAcknowledgeAnomaly(t *testing.T) {
+	mock := &MockAnomalyService{
+		AcknowledgeFn: func(id, note string) (*anomaly.Ack, error) {
+			if id == "bad" {
+				return nil, anomaly.ErrInvalidID
+			}
+			if note != "bulk purchase" {
+				t.Fatalf("unexpected note %q", note)
+			}
+			return &anomaly.Ack{ID: id, Note: note}, nil
+		},
+		UnacknowledgeFn: func(id string) error { return errors.New("db down") },
+	}
+	h := handlers.NewAnomalyHandler(mock)
+	w := trperformRequest(h.Acknowledge, "POST", "/anomalies/x/ack", dto.AckAnomalyReq{Note: "bulk purchase"}, map[string]string{"id": "day-expense-2025-01-31"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w = trperformRequest(h.Acknowledge, "POST", "/anomalies/bad/ack", nil, map[string]string{"id": "bad"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	if w = trperformRequest(h.Unacknowledge, "DELETE", "/anomalies/x/ack", nil, map[string]string{"id": "tx-1"}); w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+}
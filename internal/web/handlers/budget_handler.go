@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"salestracker/internal/domain/budget"
+	"salestracker/internal/web/dto"
+	"time"
+)
+
+// BudgetHandler управляет бюджетами и отчетом план/факт
+type BudgetHandler struct {
+	Service BudgetIFace
+}
+
+// BudgetIFace описывает интерфейс сервиса бюджетов
+type BudgetIFace interface {
+	CreateBudget(category, period string, amount float64) (*budget.Budget, error)
+	GetBudgets() ([]*budget.Budget, error)
+	PutBudget(id, category, period string, amount float64) (*budget.Budget, error)
+	DeleteBudget(id string) error
+	GetReport(date time.Time, period string) (*budget.Report, error)
+}
+
+// NewBudgetHandler создает новый BudgetHandler
+func NewBudgetHandler(service BudgetIFace) *BudgetHandler {
+	return &BudgetHandler{
+		Service: service,
+	}
+}
+
+// CreateBudget godoc
+// @Summary Создать бюджет
+// @Description Создает плановый расход по категории на период (month/quarter/year)
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param request body dto.SaveBudgetReq true "Данные бюджета"
+// @Success 200 {object} budget.Budget
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/budgets [post]
+func (h *BudgetHandler) CreateBudget(ctx *wbgin.Context) {
+	var req dto.SaveBudgetReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.Service.CreateBudget(req.Category, req.Period, req.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetBudgets godoc
+// @Summary Получить все бюджеты
+// @Description Возвращает список бюджетов
+// @Tags Budgets
+// @Produce json
+// @Success 200 {array} budget.Budget
+// @Failure 500 {object} map[string]string
+// @Router /api/budgets [get]
+func (h *BudgetHandler) GetBudgets(ctx *wbgin.Context) {
+	res, err := h.Service.GetBudgets()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// PutBudget godoc
+// @Summary Обновить бюджет
+// @Description Обновляет категорию, период и сумму бюджета по ID
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "ID бюджета"
+// @Param request body dto.SaveBudgetReq true "Новые данные бюджета"
+// @Success 200 {object} budget.Budget
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/budgets/{id} [put]
+func (h *BudgetHandler) PutBudget(ctx *wbgin.Context) {
+	var req dto.SaveBudgetReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	id, ok := ctx.Params.Get("id")
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "missing budget id"})
+		return
+	}
+	res, err := h.Service.PutBudget(id, req.Category, req.Period, req.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// DeleteBudget godoc
+// @Summary Удалить бюджет
+// @Description Удаляет бюджет по ID
+// @Tags Budgets
+// @Param id path string true "ID бюджета"
+// @Success 204 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/budgets/{id} [delete]
+func (h *BudgetHandler) DeleteBudget(ctx *wbgin.Context) {
+	if err := h.Service.DeleteBudget(ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusNoContent, wbgin.H{"status": "deleted"})
+}
+
+// GetReport godoc
+// @Summary Отчет план/факт по бюджетам
+// @Description Для каждого бюджета возвращает план, факт, отклонение, процент исполнения и прогноз на конец периода по текущему темпу расходов
+// @Tags Budgets
+// @Produce json
+// @Param date query string false "Дата, определяющая отчетный период (YYYY-MM-DD), по умолчанию сегодня"
+// @Param period query string false "Только бюджеты периода (month/quarter/year)"
+// @Param tz query string false "Часовой пояс IANA границ периода, по умолчанию — рабочего пространства"
+// @Success 200 {object} budget.Report
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/budgets/report [get]
+func (h *BudgetHandler) GetReport(ctx *wbgin.Context) {
+	date := time.Now().In(requestLocation(ctx))
+	if v := ctx.Query("date"); v != "" {
+		var err error
+		date, err = time.ParseInLocation(dateLayout, v, requestLocation(ctx))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid date format"})
+			return
+		}
+	}
+	res, err := h.Service.GetReport(date, ctx.Query("period"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,94 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"salestracker/internal/domain/budget"
+	"salestracker/internal/web/dto"
+	"salestracker/internal/web/handlers"
+	"testing"
+	"time"
+)
+
+// --------- MOCK SERVICE ---------
+
+type MockBudgetService struct {
+	CreateBudgetFn func(category, period string, amount float64) (*budget.Budget, error)
+	GetBudgetsFn   func() ([]*budget.Budget, error)
+	PutBudgetFn    func(id, category, period string, amount float64) (*budget.Budget, error)
+	DeleteBudgetFn func(id string) error
+	GetReportFn    func(date time.Time, period string) (*budget.Report, error)
+}
+
+func (m *MockBudgetService) CreateBudget(category, period string, amount float64) (*budget.Budget, error) {
+	return m.CreateBudgetFn(category, period, amount)
+}
+func (m *MockBudgetService) GetBudgets() ([]*budget.Budget, error) {
+	return m.GetBudgetsFn()
+}
+func (m *MockBudgetService) PutBudget(id, category, period string, amount float64) (*budget.Budget, error) {
+	return m.PutBudgetFn(id, category, period, amount)
+}
+func (m *MockBudgetService) DeleteBudget(id string) error {
+	return m.DeleteBudgetFn(id)
+}
+func (m *MockBudgetService) GetReport(date time.Time, period string) (*budget.Report, error) {
+	return m.GetReportFn(date, period)
+}
+
+// --------- TESTS ---------
+
+func TestCreateBudget_Success(t *testing.T) {
+	mock := &MockBudgetService{
+		CreateBudgetFn: func(category, period string, amount float64) (*budget.Budget, error) {
+			return budget.NewBudget(category, budget.Period(period), amount)
+		},
+	}
+	h := handlers.NewBudgetHandler(mock)
+	req := dto.SaveBudgetReq{Category: "marketing", Period: "month", Amount: 1000}
+	w := trperformRequest(h.CreateBudget, "POST", "/budgets", req, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestCreateBudget_ServiceError(t *testing.T) {
+	mock := &MockBudgetService{
+		CreateBudgetFn: func(category, period string, amount float64) (*budget.Budget, error) {
+			return nil, errors.New("invalid budget period")
+		},
+	}
+	h := handlers.NewBudgetHandler(mock)
+	req := dto.SaveBudgetReq{Category: "marketing", Period: "week", Amount: 1000}
+	w := trperformRequest(h.CreateBudget, "POST", "/budgets", req, nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestGetBudgetReport_Success(t *testing.T) {
+	mock := &MockBudgetService{
+		GetReportFn: func(date time.Time, period string) (*budget.Report, error) {
+			if date.Format("2006-01-02") != "2025-11-10" || period != "month" {
+				t.Fatalf("unexpected params %v %s", date, period)
+			}
+			return &budget.Report{Date: date}, nil
+		},
+	}
+	h := handlers.NewBudgetHandler(mock)
+	w := performRequest(h.GetReport, "GET", "/budgets/report", map[string]string{
+		"date":   "2025-11-10",
+		"period": "month",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestGetBudgetReport_InvalidDate(t *testing.T) {
+	h := handlers.NewBudgetHandler(&MockBudgetService{})
+	w := performRequest(h.GetReport, "GET", "/budgets/report", map[string]string{"date": "10.11.2025"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"salestracker/internal/domain/change"
+	"strconv"
+)
+
+// ChangeHandler отдает ленту изменений транзакций для инкрементальной синхронизации
+type ChangeHandler struct {
+	Service ChangeIFace
+}
+
+// ChangeIFace описывает интерфейс сервиса ленты изменений
+type ChangeIFace interface {
+	GetChanges(since int64, limit int) (*change.Feed, error)
+}
+
+// NewChangeHandler создает новый ChangeHandler
+func NewChangeHandler(service ChangeIFace) *ChangeHandler {
+	return &ChangeHandler{
+		Service: service,
+	}
+}
+
+// GetChanges godoc
+// @Summary Лента изменений транзакций
+// @Description Возвращает созданные/измененные транзакции (upsert) и удаленные (delete, tombstone) в порядке коммитов. Для первой синхронизации since не передается; дальше в since передается NextCursor из предыдущего ответа. Курсор хранится в БД и не устаревает, строки, закоммиченные позже, но начатые раньше, не пропускаются
+// @Tags Changes
+// @Produce json
+// @Param since query string false "Курсор из NextCursor предыдущего ответа"
+// @Param limit query int false "Размер страницы (по умолчанию 500, максимум 5000)"
+// @Success 200 {object} change.Feed
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/changes [get]
+func (h *ChangeHandler) GetChanges(ctx *wbgin.Context) {
+	since, err := change.ParseCursor(ctx.Query("since"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	var limit int
+	if v := ctx.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid limit"})
+			return
+		}
+	}
+	res, err := h.Service.GetChanges(since, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
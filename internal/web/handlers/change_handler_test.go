@@ -0,0 +1,69 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"salestracker/internal/domain/change"
+	"salestracker/internal/web/handlers"
+	"testing"
+)
+
+// --------- MOCK SERVICE ---------
+
+type MockChangeService struct {
+	GetChangesFn func(since int64, limit int) (*change.Feed, error)
+}
+
+func (m *MockChangeService) GetChanges(since int64, limit int) (*change.Feed, error) {
+	return m.GetChangesFn(since, limit)
+}
+
+// --------- TESTS ---------
+
+func TestGetChanges_Success(t *testing.T) {
+	var gotSince int64
+	var gotLimit int
+	mock := &MockChangeService{
+		GetChangesFn: func(since int64, limit int) (*change.Feed, error) {
+			gotSince, gotLimit = since, limit
+			return &change.Feed{Changes: []change.Change{}, NextCursor: "15"}, nil
+		},
+	}
+	h := handlers.NewChangeHandler(mock)
+	w := performRequest(h.GetChanges, "GET", "/changes", map[string]string{"since": "15", "limit": "100"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if gotSince != 15 || gotLimit != 100 {
+		t.Fatalf("unexpected args: since=%d limit=%d", gotSince, gotLimit)
+	}
+}
+
+func TestGetChanges_InvalidCursor(t *testing.T) {
+	h := handlers.NewChangeHandler(&MockChangeService{})
+	w := performRequest(h.GetChanges, "GET", "/changes", map[string]string{"since": "yesterday"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetChanges_InvalidLimit(t *testing.T) {
+	h := handlers.NewChangeHandler(&MockChangeService{})
+	w := performRequest(h.GetChanges, "GET", "/changes", map[string]string{"limit": "-5"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetChanges_ServiceError(t *testing.T) {
+	mock := &MockChangeService{
+		GetChangesFn: func(since int64, limit int) (*change.Feed, error) {
+			return nil, errors.New("db error")
+		},
+	}
+	h := handlers.NewChangeHandler(mock)
+	w := performRequest(h.GetChanges, "GET", "/changes", nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"salestracker/internal/domain/transaction"
+	"strconv"
+	"strings"
+)
+
+// parseTransactionFilter читает общие фильтры транзакций из query: type (income, expense, all),
+// category (повторяющийся параметр или список через запятую), minAmount, maxAmount, search и counterparty.
+// При ошибке отвечает 400
+func parseTransactionFilter(ctx *wbgin.Context) (transaction.Filter, bool) {
+	var f transaction.Filter
+	if t := ctx.Query("type"); t != "" && t != "all" {
+		f.Type = transaction.TransactionType(t)
+	}
+	for _, v := range ctx.QueryArray("category") {
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				f.Categories = append(f.Categories, c)
+			}
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{{"minAmount", &f.MinAmount}, {"maxAmount", &f.MaxAmount}} {
+		v := ctx.Query(p.name)
+		if v == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid " + p.name})
+			return transaction.Filter{}, false
+		}
+		*p.dst = &amount
+	}
+	f.Search = strings.TrimSpace(ctx.Query("search"))
+	f.Counterparty = strings.TrimSpace(ctx.Query("counterparty"))
+
+	if err := f.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return transaction.Filter{}, false
+	}
+	return f, true
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"io"
+	"net/http"
+	"salestracker/internal/domain/statement"
+	"time"
+)
+
+// ImportHandler принимает банковские выписки для импорта транзакций
+type ImportHandler struct {
+	Service ImportIFace
+}
+
+// ImportIFace описывает интерфейс сервиса импорта
+type ImportIFace interface {
+	ImportStatements(files []statement.File, format, category string, loc *time.Location) ([]*statement.FileSummary, error)
+	ImportWBReports(files []statement.File, reportID string, loc *time.Location) ([]*statement.FileSummary, error)
+	ImportReceipts(files []statement.File, category string, loc *time.Location) ([]*statement.FileSummary, error)
+}
+
+// NewImportHandler создает новый ImportHandler
+func NewImportHandler(service ImportIFace) *ImportHandler {
+	return &ImportHandler{
+		Service: service,
+	}
+}
+
+// ImportStatements godoc
+// @Summary Импорт банковских выписок
+// @Description Принимает один или несколько файлов выписок в форматах OFX 1.x/2.x, QIF и 1CClientBankExchange. Тип операции (income/expense) определяется по стороне дебета/кредита. Повторная загрузка той же выписки безопасна: уже импортированные операции пропускаются по внешнему ID. Возвращает сводку по каждому файлу: создано, пропущено, с ошибками
+// @Tags Imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param files formData file true "Файлы выписок (можно несколько)"
+// @Param format formData string false "Формат (ofx/qif/1c), по умолчанию определяется по содержимому"
+// @Param category formData string false "Категория для операций без категории (по умолчанию uncategorized)"
+// @Success 200 {array} statement.FileSummary
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/imports/statements [post]
+func (h *ImportHandler) ImportStatements(ctx *wbgin.Context) {
+	files, ok := readUploadedFiles(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.Service.ImportStatements(files, ctx.PostForm("format"), ctx.PostForm("category"), requestLocation(ctx))
+	if errors.Is(err, statement.ErrUnknownFormat) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ImportWBReports godoc
+// @Summary Импорт отчета о реализации Wildberries
+// @Description Принимает еженедельные отчеты о реализации Wildberries (XLSX или CSV, как выгружает кабинет продавца или API). Каждая строка раскладывается на отдельные транзакции: выручка, комиссия, логистика, хранение, штрафы и прочие удержания, каждая в свою настраиваемую категорию. Возвраты учитываются как расход. Повторная загрузка того же отчета безопасна: строки идентифицируются по rrd_id (или номеру отчета и номеру строки)
+// @Tags Imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param files formData file true "Файлы отчетов (можно несколько)"
+// @Param reportId formData string false "Номер отчета, нужен если в файле нет колонки rrd_id"
+// @Success 200 {array} statement.FileSummary
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/imports/wb [post]
+func (h *ImportHandler) ImportWBReports(ctx *wbgin.Context) {
+	files, ok := readUploadedFiles(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.Service.ImportWBReports(files, ctx.PostForm("reportId"), requestLocation(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ImportReceipts godoc
+// @Summary Импорт кассовых чеков
+// @Description Принимает JSON-выгрузки кассовых чеков 54-ФЗ из приложения ФНС (один чек или массив чеков в файле). Каждый чек становится расходной транзакцией на totalSum (возврат прихода — доходной), ИНН продавца записывается в Counterparty, в описание попадают продавец и позиции чека. Повторно загруженные чеки пропускаются по фискальному признаку
+// @Tags Imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param files formData file true "JSON-файлы чеков (можно несколько)"
+// @Param category formData string false "Категория транзакций (по умолчанию uncategorized)"
+// @Success 200 {array} statement.FileSummary
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/imports/receipts [post]
+func (h *ImportHandler) ImportReceipts(ctx *wbgin.Context) {
+	files, ok := readUploadedFiles(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.Service.ImportReceipts(files, ctx.PostForm("category"), requestLocation(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// readUploadedFiles читает все файлы из поля files; при ошибке отвечает 400
+func readUploadedFiles(ctx *wbgin.Context) ([]statement.File, bool) {
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return nil, false
+	}
+	headers := form.File["files"]
+	if len(headers) == 0 {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "no files uploaded"})
+		return nil, false
+	}
+
+	files := make([]statement.File, 0, len(headers))
+	for _, fh := range headers {
+		f, err := fh.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+			return nil, false
+		}
+		data, err := io.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+			return nil, false
+		}
+		files = append(files, statement.File{Name: fh.Filename, Data: data})
+	}
+	return files, true
+}
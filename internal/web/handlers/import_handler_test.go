@@ -0,0 +1,173 @@
+package handlers_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/web/handlers"
+	"testing"
+	"time"
+)
+
+// --------- MOCK SERVICE ---------
+
+type MockImportService struct {
+	ImportStatementsFn func(files []statement.File, format, category string, loc *time.Location) ([]*statement.FileSummary, error)
+	ImportWBReportsFn  func(files []statement.File, reportID string, loc *time.Location) ([]*statement.FileSummary, error)
+	ImportReceiptsFn   func(files []statement.File, category string, loc *time.Location) ([]*statement.FileSummary, error)
+}
+
+func (m *MockImportService) ImportStatements(files []statement.File, format, category string, loc *time.Location) ([]*statement.FileSummary, error) {
+	return m.ImportStatementsFn(files, format, category, loc)
+}
+
+func (m *MockImportService) ImportWBReports(files []statement.File, reportID string, loc *time.Location) ([]*statement.FileSummary, error) {
+	return m.ImportWBReportsFn(files, reportID, loc)
+}
+
+func (m *MockImportService) ImportReceipts(files []statement.File, category string, loc *time.Location) ([]*statement.FileSummary, error) {
+	return m.ImportReceiptsFn(files, category, loc)
+}
+
+// --------- HELPERS ---------
+
+func performMultipart(hf func(*gin.Context), fileField string, files map[string]string, fields map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for name, content := range files {
+		fw, _ := mw.CreateFormFile(fileField, name)
+		_, _ = fw.Write([]byte(content))
+	}
+	for k, v := range fields {
+		_ = mw.WriteField(k, v)
+	}
+	_ = mw.Close()
+
+	req, _ := http.NewRequest("POST", "/imports/statements", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	hf(c)
+	return w
+}
+
+// --------- TESTS ---------
+
+func TestImportStatements_Success(t *testing.T) {
+	var got []statement.File
+	var gotFormat, gotCategory string
+	mock := &MockImportService{
+		ImportStatementsFn: func(files []statement.File, format, category string, loc *time.Location) ([]*statement.FileSummary, error) {
+			got, gotFormat, gotCategory = files, format, category
+			return []*statement.FileSummary{{File: files[0].Name, Created: 1}}, nil
+		},
+	}
+	h := handlers.NewImportHandler(mock)
+	w := performMultipart(h.ImportStatements, "files", map[string]string{"jan.qif": "!Type:Bank\n"}, map[string]string{"format": "qif", "category": "bank"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(got) != 1 || got[0].Name != "jan.qif" || string(got[0].Data) != "!Type:Bank\n" {
+		t.Fatalf("unexpected files: %+v", got)
+	}
+	if gotFormat != "qif" || gotCategory != "bank" {
+		t.Fatalf("unexpected form values: %q %q", gotFormat, gotCategory)
+	}
+}
+
+func TestImportStatements_NoFiles(t *testing.T) {
+	h := handlers.NewImportHandler(&MockImportService{})
+	w := performMultipart(h.ImportStatements, "files", nil, map[string]string{"format": "ofx"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestImportStatements_UnknownFormat(t *testing.T) {
+	mock := &MockImportService{
+		ImportStatementsFn: func(files []statement.File, format, category string, loc *time.Location) ([]*statement.FileSummary, error) {
+			return nil, fmt.Errorf("%w %q", statement.ErrUnknownFormat, format)
+		},
+	}
+	h := handlers.NewImportHandler(mock)
+	w := performMultipart(h.ImportStatements, "files", map[string]string{"a.csv": "x"}, map[string]string{"format": "csv"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestImportStatements_ServiceError(t *testing.T) {
+	mock := &MockImportService{
+		ImportStatementsFn: func(files []statement.File, format, category string, loc *time.Location) ([]*statement.FileSummary, error) {
+			return nil, errors.New("db is down")
+		},
+	}
+	h := handlers.NewImportHandler(mock)
+	w := performMultipart(h.ImportStatements, "files", map[string]string{"a.qif": "x"}, nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestImportWBReports_Success(t *testing.T) {
+	var gotReport string
+	mock := &MockImportService{
+		ImportWBReportsFn: func(files []statement.File, reportID string, loc *time.Location) ([]*statement.FileSummary, error) {
+			gotReport = reportID
+			return []*statement.FileSummary{{File: files[0].Name, Format: statement.WBReport, Created: 3}}, nil
+		},
+	}
+	h := handlers.NewImportHandler(mock)
+	w := performMultipart(h.ImportWBReports, "files", map[string]string{"week.xlsx": "PK"}, map[string]string{"reportId": "123"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotReport != "123" {
+		t.Fatalf("unexpected report id %q", gotReport)
+	}
+}
+
+func TestImportWBReports_NoFiles(t *testing.T) {
+	h := handlers.NewImportHandler(&MockImportService{})
+	w := performMultipart(h.ImportWBReports, "files", nil, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestImportReceipts_Success(t *testing.T) {
+	var gotCategory string
+	mock := &MockImportService{
+		ImportReceiptsFn: func(files []statement.File, category string, loc *time.Location) ([]*statement.FileSummary, error) {
+			gotCategory = category
+			return []*statement.FileSummary{{File: files[0].Name, Format: statement.Receipt, Created: 1}}, nil
+		},
+	}
+	h := handlers.NewImportHandler(mock)
+	w := performMultipart(h.ImportReceipts, "files", map[string]string{"check.json": "{}"}, map[string]string{"category": "travel"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotCategory != "travel" {
+		t.Fatalf("unexpected category %q", gotCategory)
+	}
+}
+
+func TestImportReceipts_ServiceError(t *testing.T) {
+	mock := &MockImportService{
+		ImportReceiptsFn: func(files []statement.File, category string, loc *time.Location) ([]*statement.FileSummary, error) {
+			return nil, errors.New("boom")
+		},
+	}
+	h := handlers.NewImportHandler(mock)
+	w := performMultipart(h.ImportReceipts, "files", map[string]string{"check.json": "{}"}, nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
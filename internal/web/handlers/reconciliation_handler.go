@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"io"
+	"net/http"
+	"salestracker/internal/domain/reconciliation"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"salestracker/internal/web/dto"
+	"time"
+)
+
+// ReconciliationHandler управляет сессиями сверки банковских выписок с учетом
+type ReconciliationHandler struct {
+	Service ReconciliationIFace
+}
+
+// ReconciliationIFace описывает интерфейс сервиса сверки
+type ReconciliationIFace interface {
+	CreateSession(f statement.File, format string, loc *time.Location) (*reconciliation.Report, error)
+	GetSessions() ([]*reconciliation.Session, error)
+	GetReport(id string) (*reconciliation.Report, error)
+	AutoMatch(id string) (*reconciliation.Report, error)
+	Match(id string, lineID int64, transactionID string) (*reconciliation.Report, error)
+	Unmatch(id string, lineID int64) (*reconciliation.Report, error)
+	Close(id string) (*reconciliation.Report, error)
+}
+
+// NewReconciliationHandler создает новый ReconciliationHandler
+func NewReconciliationHandler(service ReconciliationIFace) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		Service: service,
+	}
+}
+
+// CreateSession godoc
+// @Summary Начать сверку выписки
+// @Description Загружает выписку (OFX, QIF, 1C), создает сессию сверки за ее период и автоматически сопоставляет строки с транзакциями по сумме, окну дат и похожести описания. Строки выписки в транзакции не импортируются
+// @Tags Reconciliations
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Файл выписки"
+// @Param format formData string false "Формат (ofx/qif/1c), по умолчанию определяется по содержимому"
+// @Success 200 {object} reconciliation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations [post]
+func (h *ReconciliationHandler) CreateSession(ctx *wbgin.Context) {
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	data, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.Service.CreateSession(statement.File{Name: fh.Filename, Data: data}, ctx.PostForm("format"), requestLocation(ctx))
+	respondReconciliation(ctx, res, err)
+}
+
+// GetSessions godoc
+// @Summary Список сессий сверки
+// @Tags Reconciliations
+// @Produce json
+// @Success 200 {array} reconciliation.Session
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations [get]
+func (h *ReconciliationHandler) GetSessions(ctx *wbgin.Context) {
+	res, err := h.Service.GetSessions()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetReport godoc
+// @Summary Состояние сессии сверки
+// @Description Возвращает сопоставленные пары и несопоставленные строки выписки и транзакции
+// @Tags Reconciliations
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Success 200 {object} reconciliation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations/{id} [get]
+func (h *ReconciliationHandler) GetReport(ctx *wbgin.Context) {
+	res, err := h.Service.GetReport(ctx.Param("id"))
+	respondReconciliation(ctx, res, err)
+}
+
+// AutoMatch godoc
+// @Summary Повторить автосопоставление
+// @Description Сопоставляет оставшиеся строки (например, после ручного ввода недостающих транзакций); существующие пары не меняются
+// @Tags Reconciliations
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Success 200 {object} reconciliation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations/{id}/auto-match [post]
+func (h *ReconciliationHandler) AutoMatch(ctx *wbgin.Context) {
+	res, err := h.Service.AutoMatch(ctx.Param("id"))
+	respondReconciliation(ctx, res, err)
+}
+
+// Match godoc
+// @Summary Сопоставить строку вручную
+// @Tags Reconciliations
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param request body dto.MatchLineReq true "Строка выписки и транзакция"
+// @Success 200 {object} reconciliation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations/{id}/match [post]
+func (h *ReconciliationHandler) Match(ctx *wbgin.Context) {
+	var req dto.MatchLineReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.Service.Match(ctx.Param("id"), req.LineID, req.TransactionID)
+	respondReconciliation(ctx, res, err)
+}
+
+// Unmatch godoc
+// @Summary Снять сопоставление строки
+// @Tags Reconciliations
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param request body dto.UnmatchLineReq true "Строка выписки"
+// @Success 200 {object} reconciliation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations/{id}/unmatch [post]
+func (h *ReconciliationHandler) Unmatch(ctx *wbgin.Context) {
+	var req dto.UnmatchLineReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.Service.Unmatch(ctx.Param("id"), req.LineID)
+	respondReconciliation(ctx, res, err)
+}
+
+// Close godoc
+// @Summary Закрыть сессию сверки
+// @Description Закрывает сессию и помечает сопоставленные транзакции сверенными: после этого их можно изменить или удалить только с override=true
+// @Tags Reconciliations
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Success 200 {object} reconciliation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations/{id}/close [post]
+func (h *ReconciliationHandler) Close(ctx *wbgin.Context) {
+	res, err := h.Service.Close(ctx.Param("id"))
+	respondReconciliation(ctx, res, err)
+}
+
+// respondReconciliation отдает отчет сессии или переводит ошибку сервиса в HTTP-статус
+func respondReconciliation(ctx *wbgin.Context, res *reconciliation.Report, err error) {
+	if errors.Is(err, statement.ErrUnknownFormat) || errors.Is(err, reconciliation.ErrInvalidStatement) || errors.Is(err, reconciliation.ErrInvalidID) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, reconciliation.ErrSessionNotFound) || errors.Is(err, reconciliation.ErrLineNotFound) || errors.Is(err, transaction.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, reconciliation.ErrSessionClosed) || errors.Is(err, reconciliation.ErrTransactionReconciled) || errors.Is(err, reconciliation.ErrAlreadyMatched) {
+		ctx.JSON(http.StatusConflict, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,192 @@
+package handlers_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"salestracker/internal/domain/reconciliation"
+	"salestracker/internal/domain/statement"
+	"salestracker/internal/domain/transaction"
+	"salestracker/internal/web/dto"
+	"salestracker/internal/web/handlers"
+	"testing"
+	"time"
+)
+
+// --------- MOCK SERVICE ---------
+
+type MockReconciliationService struct {
+	CreateSessionFn func(f statement.File, format string, loc *time.Location) (*reconciliation.Report, error)
+	GetSessionsFn   func() ([]*reconciliation.Session, error)
+	GetReportFn     func(id string) (*reconciliation.Report, error)
+	AutoMatchFn     func(id string) (*reconciliation.Report, error)
+	MatchFn         func(id string, lineID int64, transactionID string) (*reconciliation.Report, error)
+	UnmatchFn       func(id string, lineID int64) (*reconciliation.Report, error)
+	CloseFn         func(id string) (*reconciliation.Report, error)
+}
+
+func (m *MockReconciliationService) CreateSession(f statement.File, format string, loc *time.Location) (*reconciliation.Report, error) {
+	return m.CreateSessionFn(f, format, loc)
+}
+func (m *MockReconciliationService) GetSessions() ([]*reconciliation.Session, error) {
+	return m.GetSessionsFn()
+}
+func (m *MockReconciliationService) GetReport(id string) (*reconciliation.Report, error) {
+	return m.GetReportFn(id)
+}
+func (m *MockReconciliationService) AutoMatch(id string) (*reconciliation.Report, error) {
+	return m.AutoMatchFn(id)
+}
+func (m *MockReconciliationService) Match(id string, lineID int64, transactionID string) (*reconciliation.Report, error) {
+	return m.MatchFn(id, lineID, transactionID)
+}
+func (m *MockReconciliationService) Unmatch(id string, lineID int64) (*reconciliation.Report, error) {
+	return m.UnmatchFn(id, lineID)
+}
+func (m *MockReconciliationService) Close(id string) (*reconciliation.Report, error) {
+	return m.CloseFn(id)
+}
+
+// --------- TESTS ---------
+
+func TestMatchLine_Success(t *testing.T) {
+	var gotLine int64
+	var gotTr string
+	mock := &MockReconciliationService{
+		MatchFn: func(id string, lineID int64, transactionID string) (*reconciliation.Report, error) {
+			gotLine, gotTr = lineID, transactionID
+			return &reconciliation.Report{}, nil
+		},
+	}
+	h := handlers.NewReconciliationHandler(mock)
+	req := dto.MatchLineReq{LineID: 7, TransactionID: "tr-1"}
+	w := trperformRequest(h.Match, "POST", "/reconciliations/s1/match", req, map[string]string{"id": "s1"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if gotLine != 7 || gotTr != "tr-1" {
+		t.Fatalf("unexpected args: %d %q", gotLine, gotTr)
+	}
+}
+
+func TestCloseSession_AlreadyClosed(t *testing.T) {
+	mock := &MockReconciliationService{
+		CloseFn: func(id string) (*reconciliation.Report, error) {
+			return nil, reconciliation.ErrSessionClosed
+		},
+	}
+	h := handlers.NewReconciliationHandler(mock)
+	w := trperformRequest(h.Close, "POST", "/reconciliations/s1/close", nil, map[string]string{"id": "s1"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+}
+
+func TestCreateReconciliation_Success(t *testing.T) {
+	var gotName string
+	mock := &MockReconciliationService{
+		CreateSessionFn: func(f statement.File, format string, loc *time.Location) (*reconciliation.Report, error) {
+			gotName = f.Name
+			return &reconciliation.Report{}, nil
+		},
+	}
+	h := handlers.NewReconciliationHandler(mock)
+	w := performMultipart(h.CreateSession, "file", map[string]string{"march.qif": "!Type:Bank\n"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if gotName != "march.qif" {
+		t.Fatalf("unexpected file name %q", gotName)
+	}
+}
+
+func TestCreateReconciliation_NoFile(t *testing.T) {
+	h := handlers.NewReconciliationHandler(&MockReconciliationService{})
+	w := performMultipart(h.CreateSession, "files", map[string]string{"march.qif": "!Type:Bank\n"}, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetReconciliationReport_ServiceError(t *testing.T) {
+	mock := &MockReconciliationService{
+		GetReportFn: func(id string) (*reconciliation.Report, error) {
+			return nil, errors.New("db is down")
+		},
+	}
+	h := handlers.NewReconciliationHandler(mock)
+	w := trperformRequest(h.GetReport, "GET", "/reconciliations/s1", nil, map[string]string{"id": "s1"})
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestGetReconciliationReport_NotFound(t *testing.T) {
+	mock := &MockReconciliationService{
+		GetReportFn: func(id string) (*reconciliation.Report, error) {
+			return nil, reconciliation.ErrSessionNotFound
+		},
+	}
+	h := handlers.NewReconciliationHandler(mock)
+	w := trperformRequest(h.GetReport, "GET", "/reconciliations/s1", nil, map[string]string{"id": "s1"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestCreateReconciliation_InvalidStatement(t *testing.T) {
+	mock := &MockReconciliationService{
+		CreateSessionFn: func(f statement.File, format string, loc *time.Location) (*reconciliation.Report, error) {
+			return nil, fmt.Errorf("%w: statement has no lines to reconcile", reconciliation.ErrInvalidStatement)
+		},
+	}
+	h := handlers.NewReconciliationHandler(mock)
+	w := performMultipart(h.CreateSession, "file", map[string]string{"march.qif": "!Type:Bank\n"}, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestCreateReconciliation_UnknownFormat(t *testing.T) {
+	mock := &MockReconciliationService{
+		CreateSessionFn: func(f statement.File, format string, loc *time.Location) (*reconciliation.Report, error) {
+			return nil, fmt.Errorf("%w %q", statement.ErrUnknownFormat, format)
+		},
+	}
+	h := handlers.NewReconciliationHandler(mock)
+	w := performMultipart(h.CreateSession, "file", map[string]string{"march.csv": "x"}, map[string]string{"format": "csv"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestMatchLine_ErrorStatuses(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: session %q", reconciliation.ErrInvalidID, "s1"), http.StatusBadRequest},
+		{fmt.Errorf("%w: transaction %q", reconciliation.ErrInvalidID, "tr-1"), http.StatusBadRequest},
+		{reconciliation.ErrSessionNotFound, http.StatusNotFound},
+		{fmt.Errorf("%w: line %d", reconciliation.ErrLineNotFound, 7), http.StatusNotFound},
+		{transaction.ErrNotFound, http.StatusNotFound},
+		{reconciliation.ErrSessionClosed, http.StatusConflict},
+		{reconciliation.ErrTransactionReconciled, http.StatusConflict},
+		{fmt.Errorf("%w to line %d", reconciliation.ErrAlreadyMatched, 3), http.StatusConflict},
+		{fmt.Errorf("%w in another open session", reconciliation.ErrAlreadyMatched), http.StatusConflict},
+		{errors.New("db is down"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		mock := &MockReconciliationService{
+			MatchFn: func(id string, lineID int64, transactionID string) (*reconciliation.Report, error) {
+				return nil, c.err
+			},
+		}
+		h := handlers.NewReconciliationHandler(mock)
+		req := dto.MatchLineReq{LineID: 7, TransactionID: "tr-1"}
+		w := trperformRequest(h.Match, "POST", "/reconciliations/s1/match", req, map[string]string{"id": "s1"})
+		if w.Code != c.want {
+			t.Errorf("%v: expected %d, got %d", c.err, c.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"io"
+	"net/http"
+	"salestracker/internal/domain/event"
+	"salestracker/internal/domain/transaction"
+	"time"
+)
+
+const (
+	// streamRetry — через сколько браузер переподключится после обрыва соединения
+	streamRetry = 3 * time.Second
+	// streamKeepAlive — период комментариев-пингов, чтобы прокси не закрывали простаивающее соединение
+	streamKeepAlive = 15 * time.Second
+)
+
+// StreamHandler отдает изменения транзакций в виде Server-Sent Events
+type StreamHandler struct {
+	Service StreamIFace
+}
+
+// StreamIFace описывает интерфейс сервиса потока событий
+type StreamIFace interface {
+	Subscribe(lastEventID string, f transaction.Filter) *event.Stream
+	Unsubscribe(stream *event.Stream)
+}
+
+// NewStreamHandler создает новый StreamHandler
+func NewStreamHandler(service StreamIFace) *StreamHandler {
+	return &StreamHandler{
+		Service: service,
+	}
+}
+
+// Stream godoc
+// @Summary Поток изменений транзакций (SSE)
+// @Description Отдает события transaction.created/updated/deleted в формате text/event-stream. Принимает те же фильтры, что и список транзакций. При переподключении передайте заголовок Last-Event-ID (или параметр lastEventId): пропущенные события будут досланы из буфера, а если их там уже нет — придет событие reset, после которого данные нужно перечитать
+// @Tags Transactions
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "ID последнего полученного события"
+// @Param lastEventId query string false "ID последнего полученного события (если нельзя передать заголовок)"
+// @Param type query string false "Тип транзакции (income/expense/all)"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param minAmount query number false "Сумма от, включительно"
+// @Param maxAmount query number false "Сумма до, включительно"
+// @Param search query string false "Подстрока описания без учета регистра"
+// @Param counterparty query string false "ИНН контрагента"
+// @Success 200 {string} string "Поток событий"
+// @Failure 400 {object} map[string]string
+// @Router /api/items/stream [get]
+func (h *StreamHandler) Stream(ctx *wbgin.Context) {
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("lastEventId")
+	}
+	filter, ok := parseTransactionFilter(ctx)
+	if !ok {
+		return
+	}
+	stream := h.Service.Subscribe(lastEventID, filter)
+	defer h.Service.Unsubscribe(stream)
+
+	w := ctx.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if stream.Reset {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, ev := range stream.Replay {
+		writeEvent(w, ev)
+	}
+	w.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case ev, ok := <-stream.C:
+			if !ok {
+				// Сервис отключил подписчика (не успевал читать) — клиент переподключится с Last-Event-ID
+				return
+			}
+			writeEvent(w, ev)
+			w.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
+
+func writeEvent(w io.Writer, ev event.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+}
@@ -0,0 +1,92 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"salestracker/internal/domain/event"
+	"salestracker/internal/domain/transaction"
+	"salestracker/internal/web/handlers"
+	"strings"
+	"testing"
+)
+
+// --------- MOCK SERVICE ---------
+
+type MockStreamService struct {
+	SubscribeFn   func(lastEventID string, f transaction.Filter) *event.Stream
+	UnsubscribeFn func(stream *event.Stream)
+}
+
+func (m *MockStreamService) Subscribe(lastEventID string, f transaction.Filter) *event.Stream {
+	return m.SubscribeFn(lastEventID, f)
+}
+func (m *MockStreamService) Unsubscribe(stream *event.Stream) {
+	if m.UnsubscribeFn != nil {
+		m.UnsubscribeFn(stream)
+	}
+}
+
+// closedStream возвращает подписку с уже закрытым каналом, чтобы обработчик завершился после догонки
+func closedStream(reset bool, replay ...event.Event) *event.Stream {
+	ch := make(chan event.Event)
+	close(ch)
+	return &event.Stream{Replay: replay, Reset: reset, C: ch}
+}
+
+// --------- TESTS ---------
+
+func TestStream_ReplaysEvents(t *testing.T) {
+	var gotLastID string
+	var gotFilter transaction.Filter
+	unsubscribed := false
+	mock := &MockStreamService{
+		SubscribeFn: func(lastEventID string, f transaction.Filter) *event.Stream {
+			gotLastID, gotFilter = lastEventID, f
+			return closedStream(false, event.Event{ID: 42, Type: event.TransactionCreated, Data: json.RawMessage(`{"Amount":10}`)})
+		},
+		UnsubscribeFn: func(stream *event.Stream) { unsubscribed = true },
+	}
+	h := handlers.NewStreamHandler(mock)
+	w := performRequest(h.Stream, "GET", "/items/stream", map[string]string{"lastEventId": "41", "type": "income", "category": "sales,rent"})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if gotLastID != "41" || gotFilter.Type != transaction.Income || len(gotFilter.Categories) != 2 {
+		t.Fatalf("unexpected subscribe args: %q %+v", gotLastID, gotFilter)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "retry: 3000") {
+		t.Errorf("missing retry directive: %q", body)
+	}
+	if !strings.Contains(body, "id: 42\nevent: transaction.created\ndata: {\"Amount\":10}\n\n") {
+		t.Errorf("missing replayed event: %q", body)
+	}
+	if !unsubscribed {
+		t.Error("expected unsubscribe on return")
+	}
+}
+
+func TestStream_Reset(t *testing.T) {
+	mock := &MockStreamService{
+		SubscribeFn: func(lastEventID string, f transaction.Filter) *event.Stream {
+			return closedStream(true)
+		},
+	}
+	h := handlers.NewStreamHandler(mock)
+	w := performRequest(h.Stream, "GET", "/items/stream", map[string]string{"lastEventId": "1"})
+	if !strings.Contains(w.Body.String(), "event: reset\n") {
+		t.Fatalf("expected reset event, got %q", w.Body.String())
+	}
+}
+
+func TestStream_InvalidFilter(t *testing.T) {
+	h := handlers.NewStreamHandler(&MockStreamService{})
+	w := performRequest(h.Stream, "GET", "/items/stream", map[string]string{"minAmount": "lots"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
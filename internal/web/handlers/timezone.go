@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"time"
+)
+
+const (
+	// dateLayout — дата без времени, как ее присылает форма
+	dateLayout = "2006-01-02"
+	// timezoneKey — ключ контекста запроса, под которым Timezone сохраняет часовой пояс
+	timezoneKey = "timezone"
+	// TimezoneHeader — заголовок с часовым поясом клиента, альтернатива параметру tz
+	TimezoneHeader = "X-Timezone"
+)
+
+// Timezone определяет часовой пояс запроса: параметр tz, затем заголовок X-Timezone, иначе часовой пояс
+// рабочего пространства. В нем разбираются даты без времени и группируется аналитика. Неизвестный пояс (и "Local") — 400
+func Timezone(workspace *time.Location) wbgin.HandlerFunc {
+	return func(ctx *wbgin.Context) {
+		name := ctx.Query("tz")
+		if name == "" {
+			name = ctx.GetHeader(TimezoneHeader)
+		}
+		loc := workspace
+		if name != "" {
+			var err error
+			loc, err = time.LoadLocation(name)
+			// "Local" — пояс сервера приложения: Postgres такого имени не знает, а запрос от него зависеть не должен
+			if err != nil || name == "Local" {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, wbgin.H{"error": fmt.Sprintf("unknown timezone %q", name)})
+				return
+			}
+		}
+		ctx.Set(timezoneKey, loc)
+		ctx.Next()
+	}
+}
+
+// requestLocation возвращает часовой пояс, выбранный Timezone; без middleware — UTC
+func requestLocation(ctx *wbgin.Context) *time.Location {
+	if v, ok := ctx.Get(timezoneKey); ok {
+		if loc, ok := v.(*time.Location); ok && loc != nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// parseTime принимает RFC 3339 (со смещением), время без смещения (как у datetime-local) или дату YYYY-MM-DD;
+// время без смещения и даты считаются в часовом поясе loc. endOfDay нужен для верхней границы фильтра:
+// дата без времени включает весь свой день
+func parseTime(v string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, nil
+		}
+	}
+	t, err := time.ParseInLocation(dateLayout, v, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		// Микросекунда — точность timestamptz; наносекунды Postgres округлил бы до начала следующего дня
+		t = t.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+	return t, nil
+}
@@ -1,12 +1,13 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	wbgin "github.com/wb-go/wbf/ginext"
 	"io"
 	"net/http"
 	"salestracker/internal/domain/transaction"
 	"salestracker/internal/web/dto"
+	"strconv"
 	"time"
 )
 
@@ -18,11 +19,14 @@ type TransactionHandler struct {
 // TransactionIFace описывает интерфейс сервиса транзакций
 type TransactionIFace interface {
 	CreateTransaction(trType, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error)
-	GetAllTransactions(from, to time.Time, trtype, category, sortBy, sortDir string) ([]*transaction.Transaction, error)
-	PutTransaction(id string, trType string, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error)
-	DeleteTransaction(id string) error
-	GetCSV(from, to time.Time, trtype, category, sortBy, sortDir string, output io.Writer) error
+	GetAllTransactions(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error)
+	PutTransaction(id string, trType string, category string, amount float64, date time.Time, descr string, override bool) (*transaction.Transaction, error)
+	DeleteTransaction(id string, override bool) error
+	GetCSV(from, to time.Time, f transaction.Filter, sortBy, sortDir string, output io.Writer) error
 	GetTransaction(id string) (*transaction.Transaction, error)
+	FindDuplicates(from, to time.Time, threshold float64) ([]*transaction.DuplicatePair, error)
+	FindLikelyDuplicates(tr *transaction.Transaction) ([]*transaction.DuplicatePair, error)
+	ResolveDuplicate(keepID, dropID, action string) (*transaction.Transaction, error)
 }
 
 // NewTransactionHandler создает новый TransactionHandler
@@ -34,12 +38,14 @@ func NewTransactionHandler(service TransactionIFace) *TransactionHandler {
 
 // CreateTransaction godoc
 // @Summary Создать новую транзакцию
-// @Description Создает транзакцию с типом (income/expense), категорией, суммой, датой и описанием
+// @Description Создает транзакцию с типом (income/expense), категорией, суммой, датой и описанием.
+// @Description Если транзакция похожа на уже существующую, в ответе возвращается список PossibleDuplicates
 // @Tags Transactions
 // @Accept json
 // @Produce json
 // @Param request body dto.SaveTransactionReq true "Данные транзакции"
-// @Success 200 {object} transaction.Transaction
+// @Param tz query string false "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства"
+// @Success 200 {object} dto.CreateTransactionResp
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/items [post]
@@ -49,10 +55,8 @@ func (h *TransactionHandler) CreateTransaction(ctx *wbgin.Context) {
 		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
 		return
 	}
-	layout := "2006-01-02"
-	trDate, err := time.ParseInLocation(layout, req.Date, time.Local)
+	trDate, err := parseTime(req.Date, requestLocation(ctx), false)
 	if err != nil {
-		fmt.Println(err)
 		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid date format"})
 		return
 	}
@@ -67,21 +71,40 @@ func (h *TransactionHandler) CreateTransaction(ctx *wbgin.Context) {
 		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
 		return
 	}
-	ctx.JSON(http.StatusOK, res)
+
+	resp := dto.CreateTransactionResp{Transaction: res}
+	// Поиск дублей — только предупреждение, ошибка не должна ломать создание
+	if dups, err := h.Service.FindLikelyDuplicates(res); err == nil {
+		resp.PossibleDuplicates = dups
+	}
+	ctx.JSON(http.StatusOK, resp)
 }
 
 // DeleteTransaction godoc
 // @Summary Удалить транзакцию
-// @Description Удаляет транзакцию по ID
+// @Description Удаляет транзакцию по ID. Сверенную с выпиской транзакцию можно удалить только с override=true
 // @Tags Transactions
 // @Param id path string true "ID транзакции"
+// @Param override query bool false "Разрешить удаление сверенной транзакции"
 // @Success 204 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/items/{id} [delete]
 func (h *TransactionHandler) DeleteTransaction(ctx *wbgin.Context) {
 	trxId := ctx.Param("id")
 
-	err := h.Service.DeleteTransaction(trxId)
+	override, err := parseOverride(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.DeleteTransaction(trxId, override)
+	if errors.Is(err, transaction.ErrReconciled) {
+		ctx.JSON(http.StatusConflict, wbgin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
 		return
@@ -92,14 +115,18 @@ func (h *TransactionHandler) DeleteTransaction(ctx *wbgin.Context) {
 
 // PutTransaction godoc
 // @Summary Обновить транзакцию
-// @Description Обновляет данные транзакции по ID
+// @Description Обновляет данные транзакции по ID. Сверенную с выпиской транзакцию можно изменить только с override=true
 // @Tags Transactions
 // @Accept json
 // @Produce json
 // @Param id path string true "ID транзакции"
+// @Param override query bool false "Разрешить изменение сверенной транзакции"
 // @Param request body dto.SaveTransactionReq true "Новые данные транзакции"
+// @Param tz query string false "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства"
 // @Success 200 {object} transaction.Transaction
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/items/{id} [put]
 func (h *TransactionHandler) PutTransaction(ctx *wbgin.Context) {
@@ -115,14 +142,18 @@ func (h *TransactionHandler) PutTransaction(ctx *wbgin.Context) {
 		return
 	}
 
-	layout := "2006-01-02"
-	trDate, err := time.ParseInLocation(layout, req.Date, time.Local)
+	trDate, err := parseTime(req.Date, requestLocation(ctx), false)
 	if err != nil {
-		fmt.Println(err)
 		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid date format"})
 		return
 	}
 
+	override, err := parseOverride(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+
 	res, err := h.Service.PutTransaction(
 		trxId,
 		req.Type,
@@ -130,7 +161,16 @@ func (h *TransactionHandler) PutTransaction(ctx *wbgin.Context) {
 		req.Amount,
 		trDate,
 		req.Description,
+		override,
 	)
+	if errors.Is(err, transaction.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, transaction.ErrReconciled) {
+		ctx.JSON(http.StatusConflict, wbgin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
 		return
@@ -173,12 +213,17 @@ func (h *TransactionHandler) GetTransaction(ctx *wbgin.Context) {
 // @Summary Получить все транзакции
 // @Description Возвращает список всех транзакций с фильтрами
 // @Tags Transactions
-// @Param from query string false "Дата от"
-// @Param to query string false "Дата до"
+// @Param from query string false "Дата от (YYYY-MM-DD или RFC 3339)"
+// @Param to query string false "Дата до включительно (YYYY-MM-DD или RFC 3339)"
 // @Param type query string false "Тип транзакции (income/expense)"
-// @Param category query string false "Категория"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param minAmount query number false "Сумма от, включительно"
+// @Param maxAmount query number false "Сумма до, включительно"
+// @Param search query string false "Подстрока описания без учета регистра"
+// @Param counterparty query string false "ИНН контрагента"
 // @Param sortBy query string false "Поле сортировки"
 // @Param sortDir query string false "Направление сортировки (asc/desc)"
+// @Param tz query string false "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства"
 // @Success 200 {array} transaction.Transaction
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -187,16 +232,17 @@ func (h *TransactionHandler) GetAllTransactions(ctx *wbgin.Context) {
 	var req dto.GetTransactionReq
 	req.From = ctx.Query("from")
 	req.To = ctx.Query("to")
-	req.Type = ctx.Query("type")
-	req.Category = ctx.Query("category")
 	req.SortBy = ctx.Query("sortBy")
 	req.SortDir = ctx.Query("sortDir")
+	filter, ok := parseTransactionFilter(ctx)
+	if !ok {
+		return
+	}
 
 	var from time.Time
 	var err error
-	layout := "2006-01-02"
 	if req.From != "" {
-		from, err = time.ParseInLocation(layout, req.From, time.Local)
+		from, err = parseTime(req.From, requestLocation(ctx), false)
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
 			return
@@ -204,14 +250,14 @@ func (h *TransactionHandler) GetAllTransactions(ctx *wbgin.Context) {
 	}
 	var to time.Time
 	if req.To != "" {
-		to, err = time.ParseInLocation(layout, req.To, time.Local)
+		to, err = parseTime(req.To, requestLocation(ctx), true)
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid to date format"})
 			return
 		}
 	}
 
-	res, err := h.Service.GetAllTransactions(from, to, req.Type, req.Category, req.SortBy, req.SortDir)
+	res, err := h.Service.GetAllTransactions(from, to, filter, req.SortBy, req.SortDir)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
 		return
@@ -223,12 +269,17 @@ func (h *TransactionHandler) GetAllTransactions(ctx *wbgin.Context) {
 // @Summary Экспорт транзакций в CSV
 // @Description Экспортирует все транзакции за период в CSV-файл
 // @Tags Transactions
-// @Param from query string false "Дата от"
-// @Param to query string false "Дата до"
+// @Param from query string false "Дата от (YYYY-MM-DD или RFC 3339)"
+// @Param to query string false "Дата до включительно (YYYY-MM-DD или RFC 3339)"
 // @Param type query string false "Тип транзакции (income/expense)"
-// @Param category query string false "Категория"
+// @Param category query []string false "Категории: параметр повторяется или перечисляет их через запятую" collectionFormat(multi)
+// @Param minAmount query number false "Сумма от, включительно"
+// @Param maxAmount query number false "Сумма до, включительно"
+// @Param search query string false "Подстрока описания без учета регистра"
+// @Param counterparty query string false "ИНН контрагента"
 // @Param sortBy query string false "Поле сортировки"
 // @Param sortDir query string false "Направление сортировки (asc/desc)"
+// @Param tz query string false "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства"
 // @Success 200 {file} file "CSV файл"
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -237,16 +288,17 @@ func (h *TransactionHandler) GetCSV(ctx *wbgin.Context) {
 	var req dto.GetTransactionReq
 	req.From = ctx.Query("from")
 	req.To = ctx.Query("to")
-	req.Type = ctx.Query("type")
-	req.Category = ctx.Query("category")
 	req.SortBy = ctx.Query("sortBy")
 	req.SortDir = ctx.Query("sortDir")
+	filter, ok := parseTransactionFilter(ctx)
+	if !ok {
+		return
+	}
 
 	var from time.Time
 	var err error
-	layout := "2006-01-02"
 	if req.From != "" {
-		from, err = time.ParseInLocation(layout, req.From, time.Local)
+		from, err = parseTime(req.From, requestLocation(ctx), false)
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
 			return
@@ -254,7 +306,7 @@ func (h *TransactionHandler) GetCSV(ctx *wbgin.Context) {
 	}
 	var to time.Time
 	if req.To != "" {
-		to, err = time.ParseInLocation(layout, req.To, time.Local)
+		to, err = parseTime(req.To, requestLocation(ctx), true)
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid to date format"})
 			return
@@ -264,9 +316,118 @@ func (h *TransactionHandler) GetCSV(ctx *wbgin.Context) {
 	ctx.Writer.Header().Set("Content-Disposition", "attachment; filename=transactions.csv")
 	ctx.Writer.Header().Set("Content-Type", "text/csv")
 
-	err = h.Service.GetCSV(from, to, req.Type, req.Category, req.SortBy, req.SortDir, ctx.Writer)
+	err = h.Service.GetCSV(from, to, filter, req.SortBy, req.SortDir, ctx.Writer)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+}
+
+// GetDuplicates godoc
+// @Summary Найти возможные дубли транзакций
+// @Description Возвращает пары транзакций с одинаковым типом и суммой, оцененные по близости дат, категории и похожести описания
+// @Tags Transactions
+// @Produce json
+// @Param from query string false "Дата от (YYYY-MM-DD или RFC 3339)"
+// @Param to query string false "Дата до включительно (YYYY-MM-DD или RFC 3339)"
+// @Param threshold query number false "Минимальный score пары (0..1), по умолчанию 0.7"
+// @Param tz query string false "Часовой пояс IANA для дат без смещения, по умолчанию — рабочего пространства"
+// @Success 200 {array} transaction.DuplicatePair
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/duplicates [get]
+func (h *TransactionHandler) GetDuplicates(ctx *wbgin.Context) {
+	var from time.Time
+	var err error
+	if v := ctx.Query("from"); v != "" {
+		from, err = parseTime(v, requestLocation(ctx), false)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid from date format"})
+			return
+		}
+	}
+	var to time.Time
+	if v := ctx.Query("to"); v != "" {
+		to, err = parseTime(v, requestLocation(ctx), true)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid to date format"})
+			return
+		}
+	}
+	var threshold float64
+	if v := ctx.Query("threshold"); v != "" {
+		threshold, err = strconv.ParseFloat(v, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid threshold"})
+			return
+		}
+	}
+
+	res, err := h.Service.FindDuplicates(from, to, threshold)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ResolveDuplicate godoc
+// @Summary Разрешить пару дублей
+// @Description merge — оставляет keepId и удаляет dropId (сверенные транзакции удалить или дополнить нельзя — 409), dismiss — помечает пару как не дубль, чтобы она больше не предлагалась
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Param request body dto.ResolveDuplicateReq true "Пара транзакций и действие"
+// @Success 200 {object} transaction.Transaction
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/duplicates/resolve [post]
+func (h *TransactionHandler) ResolveDuplicate(ctx *wbgin.Context) {
+	var req dto.ResolveDuplicateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if req.KeepID == "" || req.DropID == "" {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "missing transaction id"})
+		return
+	}
+	if req.Action != string(transaction.MergeDuplicate) && req.Action != string(transaction.DismissDuplicate) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "action must be merge or dismiss"})
+		return
+	}
+
+	res, err := h.Service.ResolveDuplicate(req.KeepID, req.DropID, req.Action)
+	if errors.Is(err, transaction.ErrInvalidDuplicatePair) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, transaction.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, transaction.ErrReconciled) {
+		ctx.JSON(http.StatusConflict, wbgin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
 		return
 	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// parseOverride читает флаг override, разрешающий менять сверенные транзакции
+func parseOverride(ctx *wbgin.Context) (bool, error) {
+	v := ctx.Query("override")
+	if v == "" {
+		return false, nil
+	}
+	override, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.New("invalid override flag")
+	}
+	return override, nil
 }
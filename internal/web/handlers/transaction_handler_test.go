@@ -3,6 +3,7 @@ package handlers_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"io"
@@ -19,31 +20,47 @@ import (
 
 type MockTransactionService struct {
 	CreateTransactionFn  func(trType, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error)
-	GetAllTransactionsFn func(from, to time.Time, trtype, category, sortBy, sortDir string) ([]*transaction.Transaction, error)
-	PutTransactionFn     func(id string, trType, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error)
-	DeleteTransactionFn  func(id string) error
-	GetCSVFn             func(from, to time.Time, trtype, category, sortBy, sortDir string, output io.Writer) error
+	GetAllTransactionsFn func(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error)
+	PutTransactionFn     func(id string, trType, category string, amount float64, date time.Time, descr string, override bool) (*transaction.Transaction, error)
+	DeleteTransactionFn  func(id string, override bool) error
+	GetCSVFn             func(from, to time.Time, f transaction.Filter, sortBy, sortDir string, output io.Writer) error
 	GetTransactionFn     func(id string) (*transaction.Transaction, error)
+
+	FindDuplicatesFn       func(from, to time.Time, threshold float64) ([]*transaction.DuplicatePair, error)
+	FindLikelyDuplicatesFn func(tr *transaction.Transaction) ([]*transaction.DuplicatePair, error)
+	ResolveDuplicateFn     func(keepID, dropID, action string) (*transaction.Transaction, error)
 }
 
 func (m *MockTransactionService) CreateTransaction(trType, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error) {
 	return m.CreateTransactionFn(trType, category, amount, date, descr)
 }
-func (m *MockTransactionService) GetAllTransactions(from, to time.Time, trtype, category, sortBy, sortDir string) ([]*transaction.Transaction, error) {
-	return m.GetAllTransactionsFn(from, to, trtype, category, sortBy, sortDir)
+func (m *MockTransactionService) GetAllTransactions(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error) {
+	return m.GetAllTransactionsFn(from, to, f, sortBy, sortDir)
 }
-func (m *MockTransactionService) PutTransaction(id string, trType, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error) {
-	return m.PutTransactionFn(id, trType, category, amount, date, descr)
+func (m *MockTransactionService) PutTransaction(id string, trType, category string, amount float64, date time.Time, descr string, override bool) (*transaction.Transaction, error) {
+	return m.PutTransactionFn(id, trType, category, amount, date, descr, override)
 }
-func (m *MockTransactionService) DeleteTransaction(id string) error {
-	return m.DeleteTransactionFn(id)
+func (m *MockTransactionService) DeleteTransaction(id string, override bool) error {
+	return m.DeleteTransactionFn(id, override)
 }
-func (m *MockTransactionService) GetCSV(from, to time.Time, trtype, category, sortBy, sortDir string, output io.Writer) error {
-	return m.GetCSVFn(from, to, trtype, category, sortBy, sortDir, output)
+func (m *MockTransactionService) GetCSV(from, to time.Time, f transaction.Filter, sortBy, sortDir string, output io.Writer) error {
+	return m.GetCSVFn(from, to, f, sortBy, sortDir, output)
 }
 func (m *MockTransactionService) GetTransaction(id string) (*transaction.Transaction, error) {
 	return m.GetTransactionFn(id)
 }
+func (m *MockTransactionService) FindDuplicates(from, to time.Time, threshold float64) ([]*transaction.DuplicatePair, error) {
+	return m.FindDuplicatesFn(from, to, threshold)
+}
+func (m *MockTransactionService) FindLikelyDuplicates(tr *transaction.Transaction) ([]*transaction.DuplicatePair, error) {
+	if m.FindLikelyDuplicatesFn == nil {
+		return nil, nil
+	}
+	return m.FindLikelyDuplicatesFn(tr)
+}
+func (m *MockTransactionService) ResolveDuplicate(keepID, dropID, action string) (*transaction.Transaction, error) {
+	return m.ResolveDuplicateFn(keepID, dropID, action)
+}
 
 // --------- UTILS ---------
 
@@ -89,6 +106,29 @@ func TestCreateTransaction_Success(t *testing.T) {
 	}
 }
 
+func TestCreateTransaction_RFC3339Date(t *testing.T) {
+	var got time.Time
+	mock := &MockTransactionService{
+		CreateTransactionFn: func(trType, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error) {
+			got = date
+			return &transaction.Transaction{Type: transaction.TransactionType(trType), Category: category, Amount: amount, Date: date}, nil
+		},
+		FindLikelyDuplicatesFn: func(tr *transaction.Transaction) ([]*transaction.DuplicatePair, error) {
+			return nil, nil
+		},
+	}
+	h := handlers.NewTransactionHandler(mock)
+	req := dto.SaveTransactionReq{Type: "expense", Category: "food", Amount: 10, Date: "2025-11-27T18:45:10+03:00"}
+
+	w := trperformRequest(h.CreateTransaction, "POST", "/transactions", req, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if want := time.Date(2025, 11, 27, 15, 45, 10, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("time of day lost: got %v, want %v", got, want)
+	}
+}
+
 func TestCreateTransaction_BadDate(t *testing.T) {
 	mock := &MockTransactionService{}
 	h := handlers.NewTransactionHandler(mock)
@@ -133,7 +173,7 @@ func TestGetTransaction_NotFound(t *testing.T) {
 
 func TestDeleteTransaction_Success(t *testing.T) {
 	mock := &MockTransactionService{
-		DeleteTransactionFn: func(id string) error { return nil },
+		DeleteTransactionFn: func(id string, override bool) error { return nil },
 	}
 	h := handlers.NewTransactionHandler(mock)
 	w := trperformRequest(h.DeleteTransaction, "DELETE", "/transactions/123", nil, map[string]string{"id": "123"})
@@ -142,9 +182,40 @@ func TestDeleteTransaction_Success(t *testing.T) {
 	}
 }
 
+func TestDeleteTransaction_Reconciled(t *testing.T) {
+	var gotOverride bool
+	mock := &MockTransactionService{
+		DeleteTransactionFn: func(id string, override bool) error {
+			gotOverride = override
+			if !override {
+				return transaction.ErrReconciled
+			}
+			return nil
+		},
+	}
+	h := handlers.NewTransactionHandler(mock)
+	w := trperformRequest(h.DeleteTransaction, "DELETE", "/transactions/123", nil, map[string]string{"id": "123"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+	w = trperformRequest(h.DeleteTransaction, "DELETE", "/transactions/123?override=true", nil, map[string]string{"id": "123"})
+	if w.Code != http.StatusNoContent || !gotOverride {
+		t.Fatalf("expected 204 with override, got %d (override=%v)", w.Code, gotOverride)
+	}
+}
+
+func TestPutTransaction_InvalidOverride(t *testing.T) {
+	h := handlers.NewTransactionHandler(&MockTransactionService{})
+	req := dto.SaveTransactionReq{Type: "expense", Category: "food", Amount: 50, Date: "2025-11-27"}
+	w := trperformRequest(h.PutTransaction, "PUT", "/transactions/123?override=maybe", req, map[string]string{"id": "123"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
 func TestPutTransaction_Success(t *testing.T) {
 	mock := &MockTransactionService{
-		PutTransactionFn: func(id string, trType, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error) {
+		PutTransactionFn: func(id string, trType, category string, amount float64, date time.Time, descr string, override bool) (*transaction.Transaction, error) {
 			return &transaction.Transaction{ID: uuid.New(), Type: transaction.TransactionType(trType)}, nil
 		},
 	}
@@ -162,9 +233,23 @@ func TestPutTransaction_Success(t *testing.T) {
 	}
 }
 
+func TestPutTransaction_NotFound(t *testing.T) {
+	mock := &MockTransactionService{
+		PutTransactionFn: func(id string, trType, category string, amount float64, date time.Time, descr string, override bool) (*transaction.Transaction, error) {
+			return nil, transaction.ErrNotFound
+		},
+	}
+	h := handlers.NewTransactionHandler(mock)
+	req := dto.SaveTransactionReq{Type: "expense", Category: "food", Amount: 50, Date: "2025-11-27"}
+	w := trperformRequest(h.PutTransaction, "PUT", "/transactions/123", req, map[string]string{"id": "123"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
 func TestGetAllTransactions_Success(t *testing.T) {
 	mock := &MockTransactionService{
-		GetAllTransactionsFn: func(from, to time.Time, trtype, category, sortBy, sortDir string) ([]*transaction.Transaction, error) {
+		GetAllTransactionsFn: func(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error) {
 			return []*transaction.Transaction{
 				{ID: uuid.New(), Type: transaction.Income},
 			}, nil
@@ -177,9 +262,35 @@ func TestGetAllTransactions_Success(t *testing.T) {
 	}
 }
 
+func TestGetAllTransactions_Filter(t *testing.T) {
+	var got transaction.Filter
+	mock := &MockTransactionService{
+		GetAllTransactionsFn: func(from, to time.Time, f transaction.Filter, sortBy, sortDir string) ([]*transaction.Transaction, error) {
+			got = f
+			return nil, nil
+		},
+	}
+	h := handlers.NewTransactionHandler(mock)
+	w := trperformRequest(h.GetAllTransactions, "GET",
+		"/transactions?type=expense&category=Marketing,%20Ads&category=Office&minAmount=10000&search=%D0%BA%D0%BE%D1%84%D0%B5&counterparty=7707083893", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got.Type != transaction.Expense || len(got.Categories) != 3 || got.Categories[1] != "Ads" ||
+		got.MinAmount == nil || *got.MinAmount != 10000 || got.MaxAmount != nil || got.Search != "кофе" || got.Counterparty != "7707083893" {
+		t.Fatalf("unexpected filter %+v", got)
+	}
+
+	for _, path := range []string{"/transactions?minAmount=abc", "/transactions?minAmount=10&maxAmount=5", "/transactions?type=refund"} {
+		if w := trperformRequest(h.GetAllTransactions, "GET", path, nil, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, w.Code)
+		}
+	}
+}
+
 func TestGetCSVTr_Success(t *testing.T) {
 	mock := &MockTransactionService{
-		GetCSVFn: func(from, to time.Time, trtype, category, sortBy, sortDir string, output io.Writer) error {
+		GetCSVFn: func(from, to time.Time, f transaction.Filter, sortBy, sortDir string, output io.Writer) error {
 			_, err := output.Write([]byte("csv data"))
 			return err
 		},
@@ -193,3 +304,93 @@ func TestGetCSVTr_Success(t *testing.T) {
 		t.Fatalf("expected csv data, got %s", w.Body.String())
 	}
 }
+
+func TestCreateTransaction_WarnsAboutDuplicates(t *testing.T) {
+	mock := &MockTransactionService{
+		CreateTransactionFn: func(trType, category string, amount float64, date time.Time, descr string) (*transaction.Transaction, error) {
+			return &transaction.Transaction{ID: uuid.New(), Type: transaction.TransactionType(trType), Amount: amount}, nil
+		},
+		FindLikelyDuplicatesFn: func(tr *transaction.Transaction) ([]*transaction.DuplicatePair, error) {
+			return []*transaction.DuplicatePair{{First: &transaction.Transaction{ID: uuid.New()}, Second: tr, Score: 0.9}}, nil
+		},
+	}
+	h := handlers.NewTransactionHandler(mock)
+	req := dto.SaveTransactionReq{Type: "income", Category: "food", Amount: 100, Date: "2025-11-27"}
+
+	w := trperformRequest(h.CreateTransaction, "POST", "/transactions", req, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if resp["ID"] == nil || resp["PossibleDuplicates"] == nil {
+		t.Fatalf("expected transaction fields and PossibleDuplicates, got %s", w.Body.String())
+	}
+}
+
+func TestGetDuplicates_Success(t *testing.T) {
+	mock := &MockTransactionService{
+		FindDuplicatesFn: func(from, to time.Time, threshold float64) ([]*transaction.DuplicatePair, error) {
+			if threshold != 0 {
+				t.Fatalf("unexpected threshold %v", threshold)
+			}
+			return []*transaction.DuplicatePair{}, nil
+		},
+	}
+	h := handlers.NewTransactionHandler(mock)
+	w := trperformRequest(h.GetDuplicates, "GET", "/transactions/duplicates", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestResolveDuplicate_BadAction(t *testing.T) {
+	h := handlers.NewTransactionHandler(&MockTransactionService{})
+	req := dto.ResolveDuplicateReq{KeepID: uuid.NewString(), DropID: uuid.NewString(), Action: "drop"}
+	w := trperformRequest(h.ResolveDuplicate, "POST", "/transactions/duplicates/resolve", req, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestResolveDuplicate_ErrorStatuses(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{transaction.ErrInvalidDuplicatePair, http.StatusBadRequest},
+		{transaction.ErrNotFound, http.StatusNotFound},
+		{transaction.ErrReconciled, http.StatusConflict},
+		{errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		mock := &MockTransactionService{
+			ResolveDuplicateFn: func(keep, drop, action string) (*transaction.Transaction, error) {
+				return nil, c.err
+			},
+		}
+		h := handlers.NewTransactionHandler(mock)
+		req := dto.ResolveDuplicateReq{KeepID: uuid.NewString(), DropID: uuid.NewString(), Action: "merge"}
+		w := trperformRequest(h.ResolveDuplicate, "POST", "/transactions/duplicates/resolve", req, nil)
+		if w.Code != c.code {
+			t.Errorf("%v: expected %d, got %d", c.err, c.code, w.Code)
+		}
+	}
+}
+
+func TestResolveDuplicate_Success(t *testing.T) {
+	keepID := uuid.New()
+	mock := &MockTransactionService{
+		ResolveDuplicateFn: func(keep, drop, action string) (*transaction.Transaction, error) {
+			return &transaction.Transaction{ID: keepID}, nil
+		},
+	}
+	h := handlers.NewTransactionHandler(mock)
+	req := dto.ResolveDuplicateReq{KeepID: keepID.String(), DropID: uuid.NewString(), Action: "merge"}
+	w := trperformRequest(h.ResolveDuplicate, "POST", "/transactions/duplicates/resolve", req, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
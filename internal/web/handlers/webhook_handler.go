@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"salestracker/internal/domain/webhook"
+	"salestracker/internal/web/dto"
+	"strconv"
+)
+
+// WebhookHandler управляет подписками на события транзакций и журналом доставок
+type WebhookHandler struct {
+	Service WebhookIFace
+}
+
+// WebhookIFace описывает интерфейс сервиса вебхуков
+type WebhookIFace interface {
+	CreateSubscription(url, secret string, events []string) (*webhook.Subscription, error)
+	GetSubscriptions() ([]*webhook.Subscription, error)
+	DeleteSubscription(id string) error
+	GetDeliveries(subscriptionID, status string, limit int) ([]*webhook.Delivery, error)
+}
+
+// NewWebhookHandler создает новый WebhookHandler
+func NewWebhookHandler(service WebhookIFace) *WebhookHandler {
+	return &WebhookHandler{
+		Service: service,
+	}
+}
+
+// CreateSubscription godoc
+// @Summary Создать подписку на вебхуки
+// @Description Подписывает URL на события transaction.created/updated/deleted. Каждая доставка подписывается HMAC-SHA256 тела запроса секретом подписки (заголовок X-Webhook-Signature: sha256=...). Секрет возвращается только в ответе на создание
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param request body dto.SaveWebhookReq true "Данные подписки"
+// @Success 200 {object} webhook.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks [post]
+func (h *WebhookHandler) CreateSubscription(ctx *wbgin.Context) {
+	var req dto.SaveWebhookReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.Service.CreateSubscription(req.URL, req.Secret, req.Events)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetSubscriptions godoc
+// @Summary Получить подписки на вебхуки
+// @Description Возвращает список подписок (без секретов)
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} webhook.Subscription
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks [get]
+func (h *WebhookHandler) GetSubscriptions(ctx *wbgin.Context) {
+	res, err := h.Service.GetSubscriptions()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// DeleteSubscription godoc
+// @Summary Удалить подписку на вебхуки
+// @Description Удаляет подписку и ее журнал доставок
+// @Tags Webhooks
+// @Param id path string true "ID подписки"
+// @Success 204 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteSubscription(ctx *wbgin.Context) {
+	if err := h.Service.DeleteSubscription(ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusNoContent, wbgin.H{"status": "deleted"})
+}
+
+// GetDeliveries godoc
+// @Summary Журнал доставок вебхука
+// @Description Возвращает доставки подписки: статус (pending/succeeded/failed/dead), число попыток, время следующей попытки, последнюю ошибку и код ответа
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param status query string false "Фильтр по статусу (pending/succeeded/failed/dead)"
+// @Param limit query int false "Максимум записей (по умолчанию 100)"
+// @Success 200 {array} webhook.Delivery
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(ctx *wbgin.Context) {
+	var limit int
+	if v := ctx.Query("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid limit"})
+			return
+		}
+	}
+	res, err := h.Service.GetDeliveries(ctx.Param("id"), ctx.Query("status"), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
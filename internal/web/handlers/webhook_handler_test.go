@@ -0,0 +1,69 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"salestracker/internal/domain/webhook"
+	"salestracker/internal/web/dto"
+	"salestracker/internal/web/handlers"
+	"testing"
+)
+
+// --------- MOCK SERVICE ---------
+
+type MockWebhookService struct {
+	CreateSubscriptionFn func(url, secret string, events []string) (*webhook.Subscription, error)
+	GetSubscriptionsFn   func() ([]*webhook.Subscription, error)
+	DeleteSubscriptionFn func(id string) error
+	GetDeliveriesFn      func(subscriptionID, status string, limit int) ([]*webhook.Delivery, error)
+}
+
+func (m *MockWebhookService) CreateSubscription(url, secret string, events []string) (*webhook.Subscription, error) {
+	return m.CreateSubscriptionFn(url, secret, events)
+}
+func (m *MockWebhookService) GetSubscriptions() ([]*webhook.Subscription, error) {
+	return m.GetSubscriptionsFn()
+}
+func (m *MockWebhookService) DeleteSubscription(id string) error {
+	return m.DeleteSubscriptionFn(id)
+}
+func (m *MockWebhookService) GetDeliveries(subscriptionID, status string, limit int) ([]*webhook.Delivery, error) {
+	return m.GetDeliveriesFn(subscriptionID, status, limit)
+}
+
+// --------- TESTS ---------
+
+func TestCreateSubscription_Success(t *testing.T) {
+	mock := &MockWebhookService{
+		CreateSubscriptionFn: func(url, secret string, events []string) (*webhook.Subscription, error) {
+			return &webhook.Subscription{URL: url, Secret: "generated"}, nil
+		},
+	}
+	h := handlers.NewWebhookHandler(mock)
+	req := dto.SaveWebhookReq{URL: "https://erp.example.com/hooks", Events: []string{"transaction.created"}}
+	w := trperformRequest(h.CreateSubscription, "POST", "/webhooks", req, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestGetDeliveries_InvalidLimit(t *testing.T) {
+	h := handlers.NewWebhookHandler(&MockWebhookService{})
+	w := performRequest(h.GetDeliveries, "GET", "/webhooks/1/deliveries", map[string]string{"limit": "many"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetDeliveries_ServiceError(t *testing.T) {
+	mock := &MockWebhookService{
+		GetDeliveriesFn: func(subscriptionID, status string, limit int) ([]*webhook.Delivery, error) {
+			return nil, errors.New("invalid UUID length: 1")
+		},
+	}
+	h := handlers.NewWebhookHandler(mock)
+	w := performRequest(h.GetDeliveries, "GET", "/webhooks/1/deliveries", nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"salestracker/internal/web/handlers"
 )
 
-func RegisterRoutes(engine *wbgin.Engine, transactionHandler *handlers.TransactionHandler, analyticsHandler *handlers.AnalyticsHandler) {
+func RegisterRoutes(engine *wbgin.Engine, transactionHandler *handlers.TransactionHandler, analyticsHandler *handlers.AnalyticsHandler, budgetHandler *handlers.BudgetHandler, webhookHandler *handlers.WebhookHandler, streamHandler *handlers.StreamHandler, changeHandler *handlers.ChangeHandler, importHandler *handlers.ImportHandler, reconciliationHandler *handlers.ReconciliationHandler, anomalyHandler *handlers.AnomalyHandler) {
 	api := engine.Group("/api")
 	api.GET("/swagger/*any", func(c *wbgin.Context) {
 		httpSwagger.WrapHandler(c.Writer, c.Request)
@@ -19,8 +19,46 @@ func RegisterRoutes(engine *wbgin.Engine, transactionHandler *handlers.Transacti
 	api.PUT("/items/:id", transactionHandler.PutTransaction)
 	api.DELETE("/items/:id", transactionHandler.DeleteTransaction)
 	api.GET("/items/export", transactionHandler.GetCSV)
+	api.GET("/items/stream", streamHandler.Stream)
+	api.GET("/items/duplicates", transactionHandler.GetDuplicates)
+	api.POST("/items/duplicates/resolve", transactionHandler.ResolveDuplicate)
+
+	api.GET("/changes", changeHandler.GetChanges)
+
+	api.POST("/imports/statements", importHandler.ImportStatements)
+	api.POST("/imports/wb", importHandler.ImportWBReports)
+	api.POST("/imports/receipts", importHandler.ImportReceipts)
+
+	api.POST("/reconciliations", reconciliationHandler.CreateSession)
+	api.GET("/reconciliations", reconciliationHandler.GetSessions)
+	api.GET("/reconciliations/:id", reconciliationHandler.GetReport)
+	api.POST("/reconciliations/:id/auto-match", reconciliationHandler.AutoMatch)
+	api.POST("/reconciliations/:id/match", reconciliationHandler.Match)
+	api.POST("/reconciliations/:id/unmatch", reconciliationHandler.Unmatch)
+	api.POST("/reconciliations/:id/close", reconciliationHandler.Close)
 
 	api.GET("/analytics", analyticsHandler.GetAnalys)
 	api.GET("/analytics/export", analyticsHandler.GetCSV)
+	api.GET("/analytics/forecast", analyticsHandler.Forecast)
+	api.GET("/analytics/pivot", analyticsHandler.GetPivot)
+	api.GET("/analytics/pivot/export", analyticsHandler.GetPivotCSV)
+	api.GET("/analytics/histogram", analyticsHandler.GetHistogram)
+	api.GET("/analytics/seasonality", analyticsHandler.GetSeasonality)
+	api.GET("/analytics/seasonality/export", analyticsHandler.GetSeasonalityCSV)
+
+	api.GET("/anomalies", anomalyHandler.GetAnomalies)
+	api.POST("/anomalies/:id/ack", anomalyHandler.Acknowledge)
+	api.DELETE("/anomalies/:id/ack", anomalyHandler.Unacknowledge)
+
+	api.POST("/budgets", budgetHandler.CreateBudget)
+	api.GET("/budgets", budgetHandler.GetBudgets)
+	api.PUT("/budgets/:id", budgetHandler.PutBudget)
+	api.DELETE("/budgets/:id", budgetHandler.DeleteBudget)
+	api.GET("/budgets/report", budgetHandler.GetReport)
+
+	api.POST("/webhooks", webhookHandler.CreateSubscription)
+	api.GET("/webhooks", webhookHandler.GetSubscriptions)
+	api.DELETE("/webhooks/:id", webhookHandler.DeleteSubscription)
+	api.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
 
 }
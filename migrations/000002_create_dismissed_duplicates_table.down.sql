@@ -0,0 +1 @@
+DROP TABLE IF EXISTS dismissed_duplicates;
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS dismissed_duplicates (
+    First_ID UUID NOT NULL REFERENCES transactions(ID) ON DELETE CASCADE,
+    Second_ID UUID NOT NULL REFERENCES transactions(ID) ON DELETE CASCADE,
+    Dismissed_At TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (First_ID, Second_ID)
+)
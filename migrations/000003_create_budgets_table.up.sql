@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS budgets (
+    ID UUID PRIMARY KEY,
+    Category VARCHAR(100) NOT NULL,
+    Period VARCHAR(20) NOT NULL,
+    Amount DECIMAL(15, 2) NOT NULL,
+    UNIQUE (Category, Period)
+)
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    ID BIGSERIAL PRIMARY KEY,
+    Event_Type VARCHAR(50) NOT NULL,
+    Transaction_ID UUID NOT NULL,
+    Payload JSONB NOT NULL,
+    Created_At TIMESTAMPTZ NOT NULL DEFAULT now(),
+    Dispatched_At TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_undispatched_idx ON outbox_events (ID) WHERE Dispatched_At IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    ID UUID PRIMARY KEY,
+    URL TEXT NOT NULL,
+    Secret TEXT NOT NULL,
+    Events TEXT[] NOT NULL DEFAULT '{}',
+    Active BOOLEAN NOT NULL DEFAULT TRUE,
+    Created_At TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    ID BIGSERIAL PRIMARY KEY,
+    Subscription_ID UUID NOT NULL REFERENCES webhook_subscriptions(ID) ON DELETE CASCADE,
+    Event_ID BIGINT NOT NULL REFERENCES outbox_events(ID),
+    Status VARCHAR(20) NOT NULL,
+    Attempts INT NOT NULL DEFAULT 0,
+    Next_Attempt_At TIMESTAMPTZ NOT NULL,
+    Last_Error TEXT,
+    Response_Code INT,
+    Created_At TIMESTAMPTZ NOT NULL DEFAULT now(),
+    Updated_At TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (Subscription_ID, Event_ID)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (Next_Attempt_At) WHERE Status IN ('pending', 'failed');
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS transaction_tombstones;
+DROP INDEX IF EXISTS transactions_change_seq_idx;
+ALTER TABLE transactions DROP COLUMN IF EXISTS Change_Seq;
+DROP TABLE IF EXISTS change_sequence;
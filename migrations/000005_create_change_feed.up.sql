@@ -0,0 +1,26 @@
+-- Единственная строка-счетчик: UPDATE ... RETURNING блокирует ее до коммита,
+-- поэтому номера изменений выдаются строго в порядке коммитов
+CREATE TABLE IF NOT EXISTS change_sequence (
+    ID BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (ID),
+    Value BIGINT NOT NULL
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS Change_Seq BIGINT;
+
+UPDATE transactions t
+SET Change_Seq = s.rn
+FROM (SELECT ID, row_number() OVER (ORDER BY TransDate, ID) AS rn FROM transactions) s
+WHERE t.ID = s.ID;
+
+ALTER TABLE transactions ALTER COLUMN Change_Seq SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_change_seq_idx ON transactions (Change_Seq);
+
+INSERT INTO change_sequence (ID, Value)
+SELECT TRUE, COALESCE(MAX(Change_Seq), 0) FROM transactions
+ON CONFLICT (ID) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS transaction_tombstones (
+    ID UUID PRIMARY KEY,
+    Change_Seq BIGINT NOT NULL UNIQUE,
+    Deleted_At TIMESTAMP NOT NULL DEFAULT now()
+);
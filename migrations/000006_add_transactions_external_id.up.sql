@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS External_ID TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_external_id_idx ON transactions (External_ID);
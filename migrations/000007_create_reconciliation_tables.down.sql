@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reconciliation_lines;
+DROP TABLE IF EXISTS reconciliation_sessions;
+ALTER TABLE transactions DROP COLUMN IF EXISTS Reconciled;
@@ -0,0 +1,28 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS Reconciled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS reconciliation_sessions (
+    ID UUID PRIMARY KEY,
+    Name TEXT NOT NULL,
+    Format VARCHAR(10) NOT NULL,
+    Period_From TIMESTAMP NOT NULL,
+    Period_To TIMESTAMP NOT NULL,
+    Status VARCHAR(10) NOT NULL,
+    Created_At TIMESTAMP NOT NULL DEFAULT now(),
+    Closed_At TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_lines (
+    ID BIGSERIAL PRIMARY KEY,
+    Session_ID UUID NOT NULL REFERENCES reconciliation_sessions(ID) ON DELETE CASCADE,
+    External_ID TEXT NOT NULL,
+    TransType VARCHAR(50) NOT NULL,
+    Amount DECIMAL(15, 2) NOT NULL,
+    TransDate TIMESTAMP NOT NULL,
+    Description TEXT NOT NULL DEFAULT '',
+    Transaction_ID UUID,
+    Match_Type VARCHAR(10),
+    Score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    UNIQUE (Session_ID, Transaction_ID)
+);
+
+CREATE INDEX IF NOT EXISTS reconciliation_lines_session_idx ON reconciliation_lines (Session_ID);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS transactions_counterparty_idx;
+ALTER TABLE transactions DROP COLUMN IF EXISTS Counterparty;
@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS Counterparty VARCHAR(12) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS transactions_counterparty_idx ON transactions (Counterparty) WHERE Counterparty <> '';
@@ -0,0 +1,8 @@
+ALTER TABLE dismissed_duplicates ALTER COLUMN Dismissed_At TYPE TIMESTAMP;
+ALTER TABLE transaction_tombstones ALTER COLUMN Deleted_At TYPE TIMESTAMP;
+ALTER TABLE reconciliation_sessions ALTER COLUMN Closed_At TYPE TIMESTAMP;
+ALTER TABLE reconciliation_sessions ALTER COLUMN Created_At TYPE TIMESTAMP;
+ALTER TABLE reconciliation_sessions ALTER COLUMN Period_To TYPE TIMESTAMP;
+ALTER TABLE reconciliation_sessions ALTER COLUMN Period_From TYPE TIMESTAMP;
+ALTER TABLE reconciliation_lines ALTER COLUMN TransDate TYPE TIMESTAMP;
+ALTER TABLE transactions ALTER COLUMN TransDate TYPE TIMESTAMP;
@@ -0,0 +1,11 @@
+-- Старые значения TIMESTAMP хранят время в часовом поясе сервера приложения (а заполненные now() — в поясе сессии БД);
+-- при конвертации они читаются в часовом поясе сессии, поэтому миграцию нужно запускать с TimeZone (PGTZ),
+-- равным поясу сервера
+ALTER TABLE transactions ALTER COLUMN TransDate TYPE TIMESTAMPTZ;
+ALTER TABLE reconciliation_lines ALTER COLUMN TransDate TYPE TIMESTAMPTZ;
+ALTER TABLE reconciliation_sessions ALTER COLUMN Period_From TYPE TIMESTAMPTZ;
+ALTER TABLE reconciliation_sessions ALTER COLUMN Period_To TYPE TIMESTAMPTZ;
+ALTER TABLE reconciliation_sessions ALTER COLUMN Created_At TYPE TIMESTAMPTZ;
+ALTER TABLE reconciliation_sessions ALTER COLUMN Closed_At TYPE TIMESTAMPTZ;
+ALTER TABLE transaction_tombstones ALTER COLUMN Deleted_At TYPE TIMESTAMPTZ;
+ALTER TABLE dismissed_duplicates ALTER COLUMN Dismissed_At TYPE TIMESTAMPTZ;
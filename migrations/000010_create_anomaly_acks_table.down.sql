@@ -0,0 +1 @@
+DROP TABLE IF EXISTS anomaly_acks;
\ No newline at end of file
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS anomaly_acks (
+    Anomaly_ID TEXT PRIMARY KEY,
+    Note TEXT NOT NULL DEFAULT '',
+    Acknowledged_At TIMESTAMPTZ NOT NULL DEFAULT now()
+);